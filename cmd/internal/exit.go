@@ -6,10 +6,25 @@ import (
 	"strings"
 )
 
-// Fatal will Echo the message and os.Exit with code 1.
+// Exit codes shared by commands, so scripts can tell why a command failed.
+const (
+	// ExitOK is returned when the command succeeds.
+	ExitOK = 0
+	// ExitFailure is returned when the command fails while doing its work.
+	ExitFailure = 1
+	// ExitUsage is returned when flags, arguments, or configuration are invalid, so nothing was attempted.
+	ExitUsage = 2
+)
+
+// Fatal will Echo the message and os.Exit with ExitFailure.
 func Fatal(msg string, args ...any) {
+	Exit(ExitFailure, msg, args...)
+}
+
+// Exit will Echo the message and os.Exit with the given code.
+func Exit(code int, msg string, args ...any) {
 	Echo(msg, args...)
-	os.Exit(1)
+	os.Exit(code)
 }
 
 // Echo will emit the given message without any logging formatting.
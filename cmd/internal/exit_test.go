@@ -0,0 +1,68 @@
+package internal
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// exitEnv is set when the test binary is re-executed to call Exit or Fatal, since they end the process.
+const exitEnv = "GOCRYPTX_TEST_EXIT"
+
+func TestMain(m *testing.M) {
+	switch code := os.Getenv(exitEnv); code {
+	case "":
+		os.Exit(m.Run())
+	case "fatal":
+		Fatal("fatal: %s", "message")
+	default:
+		n, _ := strconv.Atoi(code)
+		Exit(n, "exit %d\n", n)
+	}
+}
+
+// runExit re-executes the test binary to call Exit with the given code, or Fatal if code is "fatal".
+func runExit(t *testing.T, code string) (int, string) {
+	cmd := exec.Command(os.Args[0])
+	cmd.Env = append(os.Environ(), exitEnv+"="+code)
+	var stderr strings.Builder
+	cmd.Stderr = &stderr
+	err := cmd.Run()
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return exitErr.ExitCode(), stderr.String()
+	}
+	require.NoError(t, err)
+	return 0, stderr.String()
+}
+
+func TestExitCodes(t *testing.T) {
+	tests := map[string]struct {
+		code     int
+		expected int
+	}{
+		"Success":          {code: ExitOK, expected: 0},
+		"Failure":          {code: ExitFailure, expected: 1},
+		"Usage":            {code: ExitUsage, expected: 2},
+		"Command specific": {code: 3, expected: 3},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, tc.code, "Documented exit codes shouldn't change, since scripts depend on them")
+			code, stderr := runExit(t, strconv.Itoa(tc.code))
+			assert.Equal(t, tc.expected, code)
+			assert.Equal(t, "exit "+strconv.Itoa(tc.code)+"\n", stderr, "A trailing newline shouldn't be doubled")
+		})
+	}
+}
+
+func TestFatal(t *testing.T) {
+	code, stderr := runExit(t, "fatal")
+	assert.Equal(t, ExitFailure, code)
+	assert.Equal(t, "fatal: message\n", stderr, "A newline should be added to the message")
+}
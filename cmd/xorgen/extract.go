@@ -0,0 +1,67 @@
+package main
+
+import (
+	"fmt"
+	"github.com/saylorsolutions/gocryptx/pkg/xorgen"
+	flag "github.com/spf13/pflag"
+	"os"
+	"path/filepath"
+)
+
+// runExtract recovers the original content embedded in a generated file, and writes it to --output or stdout.
+func runExtract(flags *flag.FlagSet) error {
+	args := flags.Args()[1:]
+	switch {
+	case len(args) != 1:
+		return usagef("extract requires exactly one GOFILE argument")
+	case len(dirFlag) > 0:
+		return usagef("--dir can't be used with extract")
+	case dryRunFlag:
+		return usagef("--dry-run can't be used with extract")
+	}
+	var opts []xorgen.ParamOpt
+	// A key file set in the configuration is where generated keys are written, so only an explicit flag is used to extract.
+	if flags.Changed("keyfile") {
+		opts = append(opts, xorgen.KeyFile(flagSettings.keyFile))
+	}
+	files, err := xorgen.ExtractFile(args[0], opts...)
+	if err != nil {
+		return fmt.Errorf("failed to extract '%s': %w", args[0], err)
+	}
+
+	toStdout := len(outputFlag) == 0 || outputFlag == "-"
+	switch {
+	case toStdout && len(files) > 1:
+		return usagef("'%s' embeds %d files, so --output must be given as the directory to write them to", args[0], len(files))
+	case toStdout && jsonFlag:
+		return usagef("--json can't be used when extracting to stdout")
+	case toStdout:
+		_, err := os.Stdout.Write(files[0].Data)
+		return err
+	case len(files) == 1:
+		return writeExtracted(outputFlag, files[0])
+	}
+	for _, file := range files {
+		if err := writeExtracted(filepath.Join(outputFlag, filepath.FromSlash(file.Name)), file); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeExtracted writes an extracted file to path, creating its parent directories.
+// The content is likely sensitive, so it's only readable by the current user.
+func writeExtracted(path string, file xorgen.ExtractedFile) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, file.Data, 0600); err != nil {
+		return fmt.Errorf("failed to write '%s': %w", file.Name, err)
+	}
+	results = append(results, fileResult{
+		Path:   path,
+		Status: "extracted",
+	})
+	say("Extracted '%s' to '%s'", file.Name, path)
+	return nil
+}
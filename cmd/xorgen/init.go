@@ -0,0 +1,163 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"github.com/saylorsolutions/gocryptx/cmd/xorgen/internal/directive"
+	"github.com/saylorsolutions/gocryptx/pkg/xorgen"
+	flag "github.com/spf13/pflag"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// pathFlags are flags with paths that need to be made relative to the directory of the Go file a directive is written to.
+var pathFlags = map[string]bool{
+	"dir":     true,
+	"keyfile": true,
+	"config":  true,
+	"output":  true,
+}
+
+// runInit writes a go:generate directive that runs xorgen with the given flags and arguments to the Go file given with --into.
+// The file is created if it doesn't exist.
+func runInit(flags *flag.FlagSet) error {
+	args := flags.Args()[1:]
+	switch {
+	case len(dirFlag) > 0 && len(args) > 0:
+		return usagef("FILE and KEY arguments can't be used with --dir")
+	case len(dirFlag) == 0 && len(args) == 0:
+		return usagef("missing required FILE argument for init, or use gen to generate the files in the configuration file")
+	case len(args) > 0 && args[0] == "-":
+		return usagef("FILE can't be '-' with init, since go generate doesn't read from stdin")
+	case !strings.HasSuffix(intoFlag, ".go") || strings.HasSuffix(intoFlag, "_test.go"):
+		return usagef("--into must name a non-test Go file, got '%s'", intoFlag)
+	}
+	// The FILE argument follows the subcommand with "xorgen init screen FILE".
+	fileArg := 0
+	if len(args) > 0 && args[0] == "screen" {
+		if len(args) == 1 {
+			return usagef("missing required FILE argument for screen")
+		}
+		fileArg = 1
+	}
+	if len(args) > 0 && args[0] != "gen" {
+		if _, err := os.Stat(args[fileArg]); err != nil {
+			return err
+		}
+	}
+	if len(dirFlag) > 0 {
+		if _, err := os.Stat(dirFlag); err != nil {
+			return err
+		}
+	}
+	target, err := filepath.Abs(intoFlag)
+	if err != nil {
+		return err
+	}
+	targetDir := filepath.Dir(target)
+
+	var (
+		flagArgs []string
+		relErr   error
+	)
+	flags.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "help", "version", "force", "dry-run", "json", "into":
+			return
+		}
+		if f.Value.Type() == "bool" {
+			if f.Value.String() == "true" {
+				flagArgs = append(flagArgs, "--"+f.Name)
+			} else {
+				flagArgs = append(flagArgs, "--"+f.Name+"=false")
+			}
+			return
+		}
+		val := f.Value.String()
+		var err error
+		if pathFlags[f.Name] {
+			val, err = relativeTo(targetDir, val)
+		} else if path, ok := strings.CutPrefix(val, "file:"); ok && f.Name == "encrypt" {
+			path, err = relativeTo(targetDir, path)
+			val = "file:" + path
+		}
+		if err != nil && relErr == nil {
+			relErr = err
+		}
+		flagArgs = append(flagArgs, "--"+f.Name, val)
+	})
+	if relErr != nil {
+		return relErr
+	}
+	if len(args) > 0 && args[0] != "gen" {
+		input, err := relativeTo(targetDir, args[fileArg])
+		if err != nil {
+			return err
+		}
+		if input == "gen" || input == "init" || input == "verify" || input == "screen" || input == "extract" {
+			input = "./" + input
+		}
+		args[fileArg] = input
+	}
+	line := directive.Format("xorgen", append(flagArgs, args...)...)
+
+	status := "updated"
+	src, err := os.ReadFile(target)
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+		status = "created"
+		pkg, err := initPackage(targetDir)
+		if err != nil {
+			return err
+		}
+		src = directive.NewFile(pkg, line)
+	case err != nil:
+		return err
+	default:
+		if src, err = directive.Insert(src, line); err != nil {
+			return fmt.Errorf("failed to add directive to '%s': %w", intoFlag, err)
+		}
+	}
+	if err := os.WriteFile(target, src, 0644); err != nil {
+		return err
+	}
+	say("Added to %s: %s", intoFlag, line)
+	results = append(results, fileResult{
+		Path:      intoFlag,
+		Status:    status,
+		Directive: line,
+	})
+	return nil
+}
+
+// initPackage returns the package of a new Go file in dir, which must match the package given with --package if there is one.
+func initPackage(dir string) (string, error) {
+	detected, err := xorgen.DetectPackage(dir)
+	if err != nil {
+		return "", err
+	}
+	switch {
+	case len(detected) > 0 && len(flagSettings.pkg) > 0 && detected != flagSettings.pkg:
+		return "", usagef("package name '%s' conflicts with package '%s' declared by existing files in '%s'", flagSettings.pkg, detected, dir)
+	case len(detected) > 0:
+		return detected, nil
+	case len(flagSettings.pkg) > 0:
+		return flagSettings.pkg, nil
+	default:
+		return filepath.Base(dir), nil
+	}
+}
+
+// relativeTo makes the path relative to dir, since go generate runs commands in the directory of the Go file.
+func relativeTo(dir, path string) (string, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return "", err
+	}
+	rel, err := filepath.Rel(dir, abs)
+	if err != nil {
+		return "", err
+	}
+	return filepath.ToSlash(rel), nil
+}
@@ -0,0 +1,517 @@
+package config
+
+import (
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+const (
+	// FileName is the name of the project-level configuration file.
+	FileName = "xorgen.toml"
+
+	KeyStrategyMatched = "matched"
+	KeyStrategyPayload = "payload"
+)
+
+// Config holds project-level defaults for xorgen, and an optional manifest of files to generate.
+// Fields that are not set in the configuration file are nil or empty, and should not override flag defaults.
+//
+// An example configuration file looks like this.
+//
+//	# Defaults applied to every generated file.
+//	compressed = true
+//	compress-level = 9
+//	exposed = false
+//	encoding = "base64"
+//	key-strategy = "matched"
+//	max-size = "16MiB"
+//	vary-shape = true
+//	tags = "release,!debug"
+//	with-test = true
+//	checksum = true
+//	http = false
+//	cached = false
+//	obfuscate-names = false
+//	keyfile = ""
+//	shared-key = false
+//	go-embed = false
+//
+//	# Package name overrides for directories relative to the configuration file.
+//	[packages]
+//	"internal/assets" = "assets"
+//
+//	# Files generated by "xorgen gen", with paths relative to the configuration file.
+//	[[generate]]
+//	input = "secrets/api-key.txt"
+//	output = "internal/assets"
+//	key = "deadbeef"
+//	offset = "random"
+//
+//	[[generate]]
+//	dir = "web/static"
+//	output = "internal/web"
+//	package = "web"
+//	compressed = false
+//
+//	# Encrypted with a pass phrase read from the environment, which is needed again to decrypt it at runtime.
+//	[[generate]]
+//	input = "secrets/db-password.txt"
+//	output = "internal/assets"
+//	encrypt = "env:XORGEN_PASS"
+//	checksum = false
+//	with-test = false
+//
+//	# The key is written to a key file to deploy separately, and loaded from it at runtime.
+//	[[generate]]
+//	input = "secrets/signing-key.pem"
+//	output = "internal/assets"
+//	keyfile = "deploy/signing-key.xkey"
+//	with-test = false
+//
+//	# Screened with the key declared in internal/config/keys.go, which is shared with every other entry there that sets shared-key.
+//	[[generate]]
+//	dir = "config/defaults"
+//	output = "internal/config"
+//	shared-key = true
+//
+//	# Embedded with go:embed after it's screened by "xorgen screen -c internal/assets/model.bin", which writes model.bin.xor.
+//	[[generate]]
+//	input = "internal/assets/model.bin.xor"
+//	output = "internal/assets"
+//	compressed = true
+//	go-embed = true
+type Config struct {
+	Settings
+	Packages map[string]string
+	Generate []Entry
+
+	dir string
+}
+
+// Settings are generation settings that may be given at the root of the configuration file, or for a single manifest Entry.
+type Settings struct {
+	Compressed     *bool
+	CompressLevel  *int
+	Exposed        *bool
+	Encoding       string
+	KeyStrategy    string
+	MaxSize        *int64
+	VaryShape      *bool
+	Tags           string
+	WithTest       *bool
+	Checksum       *bool
+	HTTP           *bool
+	Cached         *bool
+	Encrypt        string
+	ObfuscateNames *bool
+	KeyFile        string
+	SharedKey      *bool
+	GoEmbed        *bool
+}
+
+// Entry is a file or directory to generate with "xorgen gen", defined in a [[generate]] table.
+// Settings given in an Entry override those at the root of the configuration file.
+type Entry struct {
+	Settings
+	// Input is the file to embed. Exactly one of Input or Dir is set.
+	Input string
+	// Dir is the directory to embed as an fs.FS.
+	Dir string
+	// Output is the directory where the generated file is written, which is the configuration file's directory if empty.
+	Output string
+	// Package is the package name of the generated file.
+	// If empty, the Packages override for the output directory is used, or the package is detected from the output directory.
+	Package string
+	// Key is a fixed key to use instead of a random one, which may only be used with Input.
+	Key []byte
+	// Offset is the offset used with Key, which may be empty for 0, a number, or "random".
+	Offset string
+	// ScatterKey is the number of fragments the key is split into.
+	ScatterKey *int
+}
+
+// Find searches for a configuration file starting in the given directory, and continuing to parent directories.
+// The search stops at the first directory with a go.mod file, which is treated as the project root.
+// An empty path and no error is returned if no configuration file is found.
+func Find(start string) (string, error) {
+	dir, err := filepath.Abs(start)
+	if err != nil {
+		return "", err
+	}
+	for {
+		candidate := filepath.Join(dir, FileName)
+		if _, err := os.Stat(candidate); err == nil {
+			return candidate, nil
+		} else if !errors.Is(err, os.ErrNotExist) {
+			return "", err
+		}
+		if _, err := os.Stat(filepath.Join(dir, "go.mod")); err == nil {
+			return "", nil
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", nil
+		}
+		dir = parent
+	}
+}
+
+// Load reads a configuration file from the given path.
+func Load(path string) (*Config, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+	cfg, err := Parse(f)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	dir, err := filepath.Abs(filepath.Dir(path))
+	if err != nil {
+		return nil, err
+	}
+	cfg.dir = dir
+	cfg.resolvePaths(&cfg.Settings)
+	for i := range cfg.Generate {
+		cfg.resolvePaths(&cfg.Generate[i].Settings)
+	}
+	return cfg, nil
+}
+
+// Parse reads configuration from the io.Reader.
+// Package overrides and manifest paths will be relative to the current working directory, use Load to make them relative to the configuration file.
+func Parse(r io.Reader) (*Config, error) {
+	doc, err := parseTOML(r)
+	if err != nil {
+		return nil, err
+	}
+	cfg := new(Config)
+	for name, tbls := range doc.arrays {
+		if name != "generate" {
+			return nil, fmt.Errorf("unknown array of tables '%s'", name)
+		}
+		for i, tbl := range tbls {
+			entry, err := parseEntry(tbl)
+			if err != nil {
+				return nil, fmt.Errorf("generate entry %d: %w", i+1, err)
+			}
+			cfg.Generate = append(cfg.Generate, entry)
+		}
+	}
+	for name, tbl := range doc.tables {
+		switch name {
+		case "":
+			if err := cfg.applyRoot(tbl); err != nil {
+				return nil, err
+			}
+		case "packages":
+			cfg.Packages = map[string]string{}
+			for dir, val := range tbl {
+				pkg, ok := val.(string)
+				if !ok {
+					return nil, fmt.Errorf("package override for '%s' must be a string", dir)
+				}
+				cfg.Packages[filepath.Clean(filepath.FromSlash(dir))] = pkg
+			}
+		default:
+			return nil, fmt.Errorf("unknown table '%s'", name)
+		}
+	}
+	return cfg, nil
+}
+
+func (c *Config) applyRoot(tbl map[string]any) error {
+	for key, val := range tbl {
+		ok, err := c.apply(key, val)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return fmt.Errorf("unknown configuration key '%s'", key)
+		}
+	}
+	return nil
+}
+
+func parseEntry(tbl map[string]any) (Entry, error) {
+	var entry Entry
+	for key, val := range tbl {
+		switch key {
+		case "input", "dir", "output", "package", "key":
+			s, ok := val.(string)
+			if !ok {
+				return entry, fmt.Errorf("'%s' must be a string", key)
+			}
+			switch key {
+			case "input":
+				entry.Input = filepath.Clean(filepath.FromSlash(s))
+			case "dir":
+				entry.Dir = filepath.Clean(filepath.FromSlash(s))
+			case "output":
+				entry.Output = filepath.Clean(filepath.FromSlash(s))
+			case "package":
+				entry.Package = s
+			case "key":
+				decoded, err := ParseKey(s)
+				if err != nil {
+					return entry, fmt.Errorf("'key' is invalid: %w", err)
+				}
+				entry.Key = decoded
+			}
+		case "offset":
+			switch v := val.(type) {
+			case int64:
+				entry.Offset = strconv.FormatInt(v, 10)
+			case string:
+				entry.Offset = v
+			default:
+				return entry, fmt.Errorf("'%s' must be an integer or a string", key)
+			}
+		case "scatter-key":
+			n, ok := val.(int64)
+			if !ok {
+				return entry, fmt.Errorf("'%s' must be an integer", key)
+			}
+			if n < 0 {
+				return entry, fmt.Errorf("'%s' may not be negative", key)
+			}
+			fragments := int(n)
+			entry.ScatterKey = &fragments
+		default:
+			ok, err := entry.apply(key, val)
+			if err != nil {
+				return entry, err
+			}
+			if !ok {
+				return entry, fmt.Errorf("unknown generate key '%s'", key)
+			}
+		}
+	}
+	switch {
+	case len(entry.Input) == 0 && len(entry.Dir) == 0:
+		return entry, errors.New("one of 'input' or 'dir' is required")
+	case len(entry.Input) > 0 && len(entry.Dir) > 0:
+		return entry, errors.New("'input' and 'dir' can't both be given")
+	case len(entry.Dir) > 0 && len(entry.Key) > 0:
+		return entry, errors.New("'key' can't be used with 'dir', since each file is screened with its own key")
+	case len(entry.Offset) > 0 && len(entry.Key) == 0:
+		return entry, errors.New("'offset' may only be used with 'key'")
+	}
+	return entry, nil
+}
+
+// apply sets the setting with the given key, and returns false if the key isn't a known setting.
+func (st *Settings) apply(key string, val any) (bool, error) {
+	switch key {
+	case "compressed":
+		b, ok := val.(bool)
+		if !ok {
+			return false, fmt.Errorf("'%s' must be a boolean", key)
+		}
+		st.Compressed = &b
+	case "compress-level":
+		level, ok := val.(int64)
+		if !ok {
+			return false, fmt.Errorf("'%s' must be an integer", key)
+		}
+		if level < gzip.HuffmanOnly || level > gzip.BestCompression {
+			return false, fmt.Errorf("'%s' must be between %d and %d", key, gzip.HuffmanOnly, gzip.BestCompression)
+		}
+		l := int(level)
+		st.CompressLevel = &l
+	case "exposed":
+		b, ok := val.(bool)
+		if !ok {
+			return false, fmt.Errorf("'%s' must be a boolean", key)
+		}
+		st.Exposed = &b
+	case "vary-shape":
+		b, ok := val.(bool)
+		if !ok {
+			return false, fmt.Errorf("'%s' must be a boolean", key)
+		}
+		st.VaryShape = &b
+	case "encoding":
+		s, ok := val.(string)
+		if !ok {
+			return false, fmt.Errorf("'%s' must be a string", key)
+		}
+		st.Encoding = s
+	case "checksum":
+		b, ok := val.(bool)
+		if !ok {
+			return false, fmt.Errorf("'%s' must be a boolean", key)
+		}
+		st.Checksum = &b
+	case "http":
+		b, ok := val.(bool)
+		if !ok {
+			return false, fmt.Errorf("'%s' must be a boolean", key)
+		}
+		st.HTTP = &b
+	case "obfuscate-names":
+		b, ok := val.(bool)
+		if !ok {
+			return false, fmt.Errorf("'%s' must be a boolean", key)
+		}
+		st.ObfuscateNames = &b
+	case "shared-key":
+		b, ok := val.(bool)
+		if !ok {
+			return false, fmt.Errorf("'%s' must be a boolean", key)
+		}
+		st.SharedKey = &b
+	case "go-embed":
+		b, ok := val.(bool)
+		if !ok {
+			return false, fmt.Errorf("'%s' must be a boolean", key)
+		}
+		st.GoEmbed = &b
+	case "cached":
+		b, ok := val.(bool)
+		if !ok {
+			return false, fmt.Errorf("'%s' must be a boolean", key)
+		}
+		st.Cached = &b
+	case "with-test":
+		b, ok := val.(bool)
+		if !ok {
+			return false, fmt.Errorf("'%s' must be a boolean", key)
+		}
+		st.WithTest = &b
+	case "encrypt":
+		s, ok := val.(string)
+		if !ok {
+			return false, fmt.Errorf("'%s' must be a string", key)
+		}
+		if _, err := ParsePassSource(s); err != nil {
+			return false, err
+		}
+		st.Encrypt = s
+	case "keyfile":
+		s, ok := val.(string)
+		if !ok {
+			return false, fmt.Errorf("'%s' must be a string", key)
+		}
+		st.KeyFile = filepath.FromSlash(s)
+	case "tags":
+		s, ok := val.(string)
+		if !ok {
+			return false, fmt.Errorf("'%s' must be a string", key)
+		}
+		st.Tags = s
+	case "key-strategy":
+		s, ok := val.(string)
+		if !ok {
+			return false, fmt.Errorf("'%s' must be a string", key)
+		}
+		switch s {
+		case KeyStrategyMatched, KeyStrategyPayload:
+		default:
+			return false, fmt.Errorf("unknown key strategy '%s', must be '%s' or '%s'", s, KeyStrategyMatched, KeyStrategyPayload)
+		}
+		st.KeyStrategy = s
+	case "max-size":
+		var size int64
+		switch v := val.(type) {
+		case int64:
+			size = v
+		case string:
+			var err error
+			if size, err = ParseSize(v); err != nil {
+				return false, fmt.Errorf("'%s': %w", key, err)
+			}
+		default:
+			return false, fmt.Errorf("'%s' must be an integer or a string", key)
+		}
+		if size < 0 {
+			return false, fmt.Errorf("'%s' may not be negative", key)
+		}
+		st.MaxSize = &size
+	default:
+		return false, nil
+	}
+	return true, nil
+}
+
+// Path resolves a path from the configuration file, such as an Entry path, relative to the directory containing the configuration file.
+// An empty path resolves to the directory itself.
+func (c *Config) Path(path string) string {
+	if filepath.IsAbs(path) {
+		return path
+	}
+	base := c.dir
+	if len(base) == 0 {
+		base = "."
+	}
+	return filepath.Join(base, path)
+}
+
+// resolvePaths makes paths given in Settings relative to the configuration file.
+func (c *Config) resolvePaths(st *Settings) {
+	c.resolvePassFile(st)
+	if len(st.KeyFile) > 0 {
+		st.KeyFile = c.Path(st.KeyFile)
+	}
+}
+
+// PackageFor returns the package name override for the given directory, or an empty string if there is none.
+func (c *Config) PackageFor(dir string) (string, error) {
+	if len(c.Packages) == 0 {
+		return "", nil
+	}
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return "", err
+	}
+	base := c.dir
+	if len(base) == 0 {
+		base, err = os.Getwd()
+		if err != nil {
+			return "", err
+		}
+	}
+	rel, err := filepath.Rel(base, abs)
+	if err != nil {
+		return "", nil
+	}
+	return c.Packages[rel], nil
+}
+
+// ParseSize parses a size in bytes, with an optional binary unit suffix of K, M, or G.
+// A suffix may be followed by "iB" or "B", which are treated the same, so "16M", "16MB", and "16MiB" are all 16*1024*1024 bytes.
+func ParseSize(size string) (int64, error) {
+	s := strings.ToUpper(strings.TrimSpace(size))
+	s = strings.TrimSuffix(strings.TrimSuffix(s, "B"), "I")
+	var mult int64 = 1
+	if len(s) > 0 {
+		switch s[len(s)-1] {
+		case 'K':
+			mult = 1 << 10
+		case 'M':
+			mult = 1 << 20
+		case 'G':
+			mult = 1 << 30
+		}
+	}
+	if mult > 1 {
+		s = s[:len(s)-1]
+	}
+	n, err := strconv.ParseInt(strings.TrimSpace(s), 10, 64)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("invalid size '%s', must be a non-negative number of bytes with an optional K, M, or G suffix", size)
+	}
+	if n > (1<<63-1)/mult {
+		return 0, fmt.Errorf("size '%s' is too large", size)
+	}
+	return n * mult, nil
+}
@@ -0,0 +1,314 @@
+package config
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const testConfig = `
+# Defaults applied to every generated file.
+compressed = true
+compress-level = 1
+exposed = false # Trailing comment
+encoding = "base64"
+key-strategy = "payload"
+max-size = "32MiB"
+vary-shape = true
+tags = "release"
+with-test = true
+checksum = true
+http = true
+cached = true
+obfuscate-names = true
+encrypt = "file:secrets/pass.txt"
+keyfile = "deploy/test.xkey"
+shared-key = true
+go-embed = true
+
+[packages]
+"internal/assets" = "assets"
+"internal/#hash" = "hash"
+`
+
+func TestParse(t *testing.T) {
+	cfg, err := Parse(strings.NewReader(testConfig))
+	require.NoError(t, err)
+	require.NotNil(t, cfg.Compressed)
+	assert.True(t, *cfg.Compressed)
+	require.NotNil(t, cfg.CompressLevel)
+	assert.Equal(t, 1, *cfg.CompressLevel)
+	require.NotNil(t, cfg.Exposed)
+	assert.False(t, *cfg.Exposed)
+	assert.Equal(t, "base64", cfg.Encoding)
+	assert.Equal(t, KeyStrategyPayload, cfg.KeyStrategy)
+	require.NotNil(t, cfg.MaxSize)
+	assert.Equal(t, int64(32<<20), *cfg.MaxSize)
+	require.NotNil(t, cfg.VaryShape)
+	assert.True(t, *cfg.VaryShape)
+	assert.Equal(t, "release", cfg.Tags)
+	require.NotNil(t, cfg.WithTest)
+	assert.True(t, *cfg.WithTest)
+	require.NotNil(t, cfg.Checksum)
+	assert.True(t, *cfg.Checksum)
+	require.NotNil(t, cfg.HTTP)
+	assert.True(t, *cfg.HTTP)
+	require.NotNil(t, cfg.Cached)
+	assert.True(t, *cfg.Cached)
+	require.NotNil(t, cfg.ObfuscateNames)
+	assert.True(t, *cfg.ObfuscateNames)
+	assert.Equal(t, "file:secrets/pass.txt", cfg.Encrypt)
+	assert.Equal(t, filepath.FromSlash("deploy/test.xkey"), cfg.KeyFile)
+	require.NotNil(t, cfg.SharedKey)
+	assert.True(t, *cfg.SharedKey)
+	require.NotNil(t, cfg.GoEmbed)
+	assert.True(t, *cfg.GoEmbed)
+	assert.Equal(t, map[string]string{
+		filepath.FromSlash("internal/assets"): "assets",
+		filepath.FromSlash("internal/#hash"):  "hash",
+	}, cfg.Packages)
+}
+
+func TestParse_Empty(t *testing.T) {
+	cfg, err := Parse(strings.NewReader("# Nothing to see here"))
+	require.NoError(t, err)
+	assert.Nil(t, cfg.Compressed)
+	assert.Nil(t, cfg.CompressLevel)
+	assert.Nil(t, cfg.Exposed)
+	assert.Empty(t, cfg.Encoding)
+	assert.Nil(t, cfg.MaxSize)
+	assert.Nil(t, cfg.VaryShape)
+}
+
+func TestParse_Neg(t *testing.T) {
+	tests := map[string]string{
+		"Unknown key":          `unknown = true`,
+		"Unknown table":        "[other]\na = 1",
+		"Wrong type":           `compressed = "yes"`,
+		"Bad key strategy":     `key-strategy = "other"`,
+		"Missing value":        `compressed =`,
+		"Missing equals":       `compressed true`,
+		"Duplicate key":        "compressed = true\ncompressed = false",
+		"Duplicate table":      "[packages]\n[packages]",
+		"Unterminated header":  `[packages`,
+		"Unterminated string":  `encoding = "base64`,
+		"Trailing characters":  `encoding = "base64" extra`,
+		"Invalid bare key":     `com/pressed = true`,
+		"Non-string package":   "[packages]\ndir = 1",
+		"Unsupported value":    `compressed = [true]`,
+		"Unterminated key":     `"compressed = true`,
+		"Invalid quoted key":   "[\"\\q\"]",
+		"Invalid table header": "[com/pressed]",
+		"Invalid max size":     `max-size = "lots"`,
+		"Negative max size":    `max-size = -1`,
+		"Wrong max size type":  `max-size = true`,
+		"Compress level high":  `compress-level = 10`,
+		"Compress level low":   `compress-level = -3`,
+		"Wrong compress level": `compress-level = "best"`,
+		"Wrong tags type":      `tags = true`,
+		"Wrong with-test type": `with-test = "yes"`,
+		"Wrong checksum type":  `checksum = 1`,
+		"Wrong http type":      `http = "yes"`,
+		"Wrong cached type":    `cached = 0`,
+		"Bad pass source":      `encrypt = "hunter2"`,
+		"Wrong obfuscate type": `obfuscate-names = "yes"`,
+		"Wrong keyfile type":   `keyfile = true`,
+		"Wrong shared type":    `shared-key = "yes"`,
+		"Wrong go-embed type":  `go-embed = 1`,
+		"Unknown array":        "[[other]]\na = 1",
+		"Table and array":      "[[packages]]\n[packages]",
+		"Array and table":      "[packages]\n[[packages]]",
+		"Unterminated array":   "[[generate]\ninput = \"a.txt\"",
+		"No entry input":       "[[generate]]\ncompressed = true",
+		"Input and dir":        "[[generate]]\ninput = \"a.txt\"\ndir = \"assets\"",
+		"Key with dir":         "[[generate]]\ndir = \"assets\"\nkey = \"01\"",
+		"Offset without key":   "[[generate]]\ninput = \"a.txt\"\noffset = 1",
+		"Invalid entry key":    "[[generate]]\ninput = \"a.txt\"\nkey = \"xyz\"",
+		"Invalid base64 key":   "[[generate]]\ninput = \"a.txt\"\nkey = \"base64:!!\"",
+		"Unknown entry key":    "[[generate]]\ninput = \"a.txt\"\nother = 1",
+		"Negative scatter key": "[[generate]]\ninput = \"a.txt\"\nscatter-key = -1",
+		"Wrong entry type":     "[[generate]]\ninput = \"a.txt\"\ncompressed = 1",
+	}
+	for name, input := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := Parse(strings.NewReader(input))
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestParse_Generate(t *testing.T) {
+	cfg, err := Parse(strings.NewReader(testConfig + `
+[[generate]]
+input = "secrets/api-key.txt"
+output = "internal/assets"
+key = "deadbeef"
+offset = 2
+scatter-key = 3
+
+[[generate]]
+dir = "web/static" # Trailing comment
+package = "static"
+compressed = false
+encoding = "string"
+tags = "debug"
+
+[[generate]]
+input = "secrets/db-password.txt"
+key = "base64:3q2+7w=="
+`))
+	require.NoError(t, err)
+	require.NotNil(t, cfg.Compressed, "Root settings should still be parsed")
+	assert.True(t, *cfg.Compressed)
+	require.Len(t, cfg.Generate, 3)
+
+	first := cfg.Generate[0]
+	assert.Equal(t, filepath.FromSlash("secrets/api-key.txt"), first.Input)
+	assert.Empty(t, first.Dir)
+	assert.Equal(t, filepath.FromSlash("internal/assets"), first.Output)
+	assert.Equal(t, []byte{0xde, 0xad, 0xbe, 0xef}, first.Key)
+	assert.Equal(t, "2", first.Offset)
+	require.NotNil(t, first.ScatterKey)
+	assert.Equal(t, 3, *first.ScatterKey)
+	assert.Nil(t, first.Compressed, "Root settings should not be copied into entries")
+
+	second := cfg.Generate[1]
+	assert.Equal(t, filepath.FromSlash("web/static"), second.Dir)
+	assert.Empty(t, second.Output)
+	assert.Equal(t, "static", second.Package)
+	require.NotNil(t, second.Compressed)
+	assert.False(t, *second.Compressed)
+	assert.Equal(t, "string", second.Encoding)
+	assert.Equal(t, "debug", second.Tags)
+
+	assert.Equal(t, []byte{0xde, 0xad, 0xbe, 0xef}, cfg.Generate[2].Key, "Keys may be given as base64")
+}
+
+func TestParseKey(t *testing.T) {
+	tests := map[string]string{
+		"Hex":             "deadbeef",
+		"Upper case hex":  "DEADBEEF",
+		"Base64":          "base64:3q2+7w==",
+		"Unpadded base64": "base64:3q2+7w",
+		"URL-safe base64": "base64:3q2-7w==",
+	}
+	for name, key := range tests {
+		t.Run(name, func(t *testing.T) {
+			decoded, err := ParseKey(key)
+			require.NoError(t, err)
+			assert.Equal(t, []byte{0xde, 0xad, 0xbe, 0xef}, decoded)
+		})
+	}
+	for _, key := range []string{"", "xyz", "abc", "base64:", "base64:!!", "3q2+7w=="} {
+		_, err := ParseKey(key)
+		assert.ErrorIs(t, err, ErrInvalidKey, "Key '%s' should be invalid", key)
+	}
+}
+
+func TestCutKeyOffset(t *testing.T) {
+	type result struct {
+		key       string
+		offset    string
+		hasOffset bool
+	}
+	tests := map[string]result{
+		"deadbeef":             {"deadbeef", "", false},
+		"deadbeef:2":           {"deadbeef", "2", true},
+		"base64:3q2+7w==":      {"base64:3q2+7w==", "", false},
+		"base64:3q2+7w==:2":    {"base64:3q2+7w==", "2", true},
+		"base64:3q2+7w:random": {"base64:3q2+7w", "random", true},
+	}
+	for arg, expected := range tests {
+		t.Run(arg, func(t *testing.T) {
+			key, offset, hasOffset := CutKeyOffset(arg)
+			assert.Equal(t, expected, result{key, offset, hasOffset})
+		})
+	}
+}
+
+func TestParseSize(t *testing.T) {
+	tests := map[string]int64{
+		"0":          0,
+		"1024":       1024,
+		"16K":        16 << 10,
+		"16kb":       16 << 10,
+		"16MiB":      16 << 20,
+		" 2 GiB ":    2 << 30,
+		"100B":       100,
+		"8589934592": 8 << 30,
+	}
+	for input, expected := range tests {
+		t.Run(input, func(t *testing.T) {
+			size, err := ParseSize(input)
+			require.NoError(t, err)
+			assert.Equal(t, expected, size)
+		})
+	}
+
+	for _, input := range []string{"", "M", "-1", "1.5M", "16T", "99999999999G"} {
+		_, err := ParseSize(input)
+		assert.Error(t, err, "Size '%s' should be rejected", input)
+	}
+}
+
+func TestParsePassSource(t *testing.T) {
+	t.Setenv("XORGEN_TEST_PASS", "env pass")
+	src, err := ParsePassSource("env:XORGEN_TEST_PASS")
+	require.NoError(t, err)
+	pass, err := src.Passphrase()
+	require.NoError(t, err)
+	assert.Equal(t, "env pass", string(pass))
+
+	path := filepath.Join(t.TempDir(), "pass.txt")
+	require.NoError(t, os.WriteFile(path, []byte("file pass\n"), 0600))
+	src, err = ParsePassSource("file:" + path)
+	require.NoError(t, err)
+	pass, err = src.Passphrase()
+	require.NoError(t, err)
+	assert.Equal(t, "file pass", string(pass))
+
+	_, err = ParsePassSource("prompt")
+	assert.NoError(t, err)
+	assert.True(t, IsPassPrompt("prompt"))
+
+	for _, spec := range []string{"", "env:", "file:", "hunter2", "Prompt"} {
+		_, err := ParsePassSource(spec)
+		assert.Error(t, err, "Pass phrase source '%s' should be invalid", spec)
+	}
+}
+
+func TestFindLoad(t *testing.T) {
+	root := t.TempDir()
+	sub := filepath.Join(root, "internal", "assets")
+	require.NoError(t, os.MkdirAll(sub, 0700))
+
+	found, err := Find(sub)
+	require.NoError(t, err)
+	assert.Empty(t, found, "No config should be found without a config file")
+
+	require.NoError(t, os.WriteFile(filepath.Join(root, FileName), []byte(testConfig), 0600))
+	found, err = Find(sub)
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(root, FileName), found)
+
+	cfg, err := Load(found)
+	require.NoError(t, err)
+	pkg, err := cfg.PackageFor(sub)
+	assert.NoError(t, err)
+	assert.Equal(t, "assets", pkg)
+	pkg, err = cfg.PackageFor(root)
+	assert.NoError(t, err)
+	assert.Empty(t, pkg)
+	assert.Equal(t, filepath.Join(root, "web"), cfg.Path("web"))
+	assert.Equal(t, root, cfg.Path(""))
+	assert.Equal(t, "file:"+filepath.Join(root, "secrets", "pass.txt"), cfg.Encrypt, "Pass phrase files should be relative to the config file")
+	assert.Equal(t, filepath.Join(root, "deploy", "test.xkey"), cfg.KeyFile, "Key files should be relative to the config file")
+
+	require.NoError(t, os.WriteFile(filepath.Join(sub, "go.mod"), []byte("module test"), 0600))
+	found, err = Find(sub)
+	require.NoError(t, err)
+	assert.Empty(t, found, "Search should stop at the module root")
+}
@@ -0,0 +1,48 @@
+package config
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"strings"
+)
+
+const (
+	keyBase64Prefix = "base64:"
+)
+
+var (
+	ErrInvalidKey = errors.New("key must be a non-empty hex string, or base64 with a 'base64:' prefix")
+)
+
+// ParseKey decodes a key given as a hex string, or as base64 with a "base64:" prefix, like the output of "openssl rand -base64 32".
+// Both the standard and URL-safe base64 alphabets are accepted, with or without padding.
+func ParseKey(key string) ([]byte, error) {
+	var (
+		decoded []byte
+		err     error
+	)
+	if encoded, ok := strings.CutPrefix(key, keyBase64Prefix); ok {
+		encoded = strings.TrimRight(encoded, "=")
+		if strings.ContainsAny(encoded, "-_") {
+			decoded, err = base64.RawURLEncoding.DecodeString(encoded)
+		} else {
+			decoded, err = base64.RawStdEncoding.DecodeString(encoded)
+		}
+	} else {
+		decoded, err = hex.DecodeString(key)
+	}
+	if err != nil || len(decoded) == 0 {
+		return nil, ErrInvalidKey
+	}
+	return decoded, nil
+}
+
+// CutKeyOffset splits a KEY[:OFFSET] argument into the key and offset, keeping the prefix of a base64 key intact.
+func CutKeyOffset(arg string) (key, offset string, hasOffset bool) {
+	if encoded, ok := strings.CutPrefix(arg, keyBase64Prefix); ok {
+		key, offset, hasOffset = strings.Cut(encoded, ":")
+		return keyBase64Prefix + key, offset, hasOffset
+	}
+	return strings.Cut(arg, ":")
+}
@@ -0,0 +1,41 @@
+package config
+
+import (
+	"fmt"
+	"github.com/saylorsolutions/gocryptx/pkg/passlock"
+	"os"
+	"strings"
+)
+
+const (
+	passEnvPrefix  = "env:"
+	passFilePrefix = "file:"
+	passPrompt     = "prompt"
+)
+
+// ParsePassSource parses the source of the pass phrase used to encrypt files, which may be "env:NAME" to read the named environment variable,
+// "file:PATH" to read the first line of a file, or "prompt" to prompt for it on stderr and read it from stdin.
+func ParsePassSource(spec string) (passlock.PassSource, error) {
+	if name, ok := strings.CutPrefix(spec, passEnvPrefix); ok && len(name) > 0 {
+		return passlock.PassFromEnv(name), nil
+	}
+	if path, ok := strings.CutPrefix(spec, passFilePrefix); ok && len(path) > 0 {
+		return passlock.PassFromFile(path), nil
+	}
+	if spec == passPrompt {
+		return passlock.PassFromPrompt("Pass phrase: ", os.Stdin, os.Stderr), nil
+	}
+	return nil, fmt.Errorf("invalid pass phrase source '%s', must be 'env:NAME', 'file:PATH', or 'prompt'", spec)
+}
+
+// IsPassPrompt returns true if the pass phrase source reads from stdin.
+func IsPassPrompt(spec string) bool {
+	return spec == passPrompt
+}
+
+// resolvePassFile makes a "file:PATH" pass phrase source relative to the configuration file.
+func (c *Config) resolvePassFile(st *Settings) {
+	if path, ok := strings.CutPrefix(st.Encrypt, passFilePrefix); ok {
+		st.Encrypt = passFilePrefix + c.Path(path)
+	}
+}
@@ -0,0 +1,196 @@
+package config
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	bareKeyPattern = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+)
+
+// table is a set of key/value pairs, where a value may be a bool, int64, or string.
+type table = map[string]any
+
+// document is the result of parsing a TOML file.
+type document struct {
+	// tables are the named tables, where the root table has the name "".
+	tables map[string]table
+	// arrays are the arrays of tables, in the order they were defined.
+	arrays map[string][]table
+}
+
+// parseTOML parses a small subset of TOML that is sufficient for xorgen configuration.
+// Supported features are comments, bare or quoted keys, single-level tables, single-level arrays of tables, and boolean, integer, and basic string values.
+// Keys before the first table header are placed in the root table, which has the name "".
+func parseTOML(r io.Reader) (*document, error) {
+	var (
+		doc = &document{
+			tables: map[string]table{"": {}},
+			arrays: map[string][]table{},
+		}
+		current = doc.tables[""]
+		scanner = bufio.NewScanner(r)
+		lineNum = 0
+	)
+	for scanner.Scan() {
+		lineNum++
+		line := strings.TrimSpace(stripComment(scanner.Text()))
+		if len(line) == 0 {
+			continue
+		}
+		if strings.HasPrefix(line, "[[") {
+			if !strings.HasSuffix(line, "]]") {
+				return nil, fmt.Errorf("line %d: unterminated array of tables header", lineNum)
+			}
+			name, err := parseKey(strings.TrimSpace(line[2 : len(line)-2]))
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", lineNum, err)
+			}
+			if _, ok := doc.tables[name]; ok {
+				return nil, fmt.Errorf("line %d: '%s' is already defined as a table", lineNum, name)
+			}
+			current = table{}
+			doc.arrays[name] = append(doc.arrays[name], current)
+			continue
+		}
+		if strings.HasPrefix(line, "[") {
+			if !strings.HasSuffix(line, "]") {
+				return nil, fmt.Errorf("line %d: unterminated table header", lineNum)
+			}
+			name, err := parseKey(strings.TrimSpace(line[1 : len(line)-1]))
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", lineNum, err)
+			}
+			if _, ok := doc.tables[name]; ok {
+				return nil, fmt.Errorf("line %d: table '%s' is defined more than once", lineNum, name)
+			}
+			if _, ok := doc.arrays[name]; ok {
+				return nil, fmt.Errorf("line %d: '%s' is already defined as an array of tables", lineNum, name)
+			}
+			current = table{}
+			doc.tables[name] = current
+			continue
+		}
+		key, val, err := parseKeyValue(line)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", lineNum, err)
+		}
+		if _, ok := current[key]; ok {
+			return nil, fmt.Errorf("line %d: key '%s' is defined more than once", lineNum, key)
+		}
+		current[key] = val
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return doc, nil
+}
+
+// stripComment removes a trailing comment from the line, ignoring '#' characters within strings.
+func stripComment(line string) string {
+	inString := false
+	for i := 0; i < len(line); i++ {
+		switch line[i] {
+		case '\\':
+			if inString {
+				i++
+			}
+		case '"':
+			inString = !inString
+		case '#':
+			if !inString {
+				return line[:i]
+			}
+		}
+	}
+	return line
+}
+
+func parseKeyValue(line string) (string, any, error) {
+	var (
+		rawKey string
+		rest   string
+	)
+	if strings.HasPrefix(line, `"`) {
+		end := closingQuote(line)
+		if end < 0 {
+			return "", nil, fmt.Errorf("unterminated quoted key")
+		}
+		rawKey, rest = line[:end+1], line[end+1:]
+	} else {
+		idx := strings.Index(line, "=")
+		if idx < 0 {
+			return "", nil, fmt.Errorf("expected key = value")
+		}
+		rawKey, rest = line[:idx], line[idx:]
+	}
+	key, err := parseKey(strings.TrimSpace(rawKey))
+	if err != nil {
+		return "", nil, err
+	}
+	rest = strings.TrimSpace(rest)
+	if !strings.HasPrefix(rest, "=") {
+		return "", nil, fmt.Errorf("expected '=' after key '%s'", key)
+	}
+	val, err := parseValue(strings.TrimSpace(rest[1:]))
+	if err != nil {
+		return "", nil, fmt.Errorf("key '%s': %w", key, err)
+	}
+	return key, val, nil
+}
+
+func parseKey(key string) (string, error) {
+	if strings.HasPrefix(key, `"`) {
+		unquoted, err := strconv.Unquote(key)
+		if err != nil {
+			return "", fmt.Errorf("invalid quoted key %s", key)
+		}
+		return unquoted, nil
+	}
+	if !bareKeyPattern.MatchString(key) {
+		return "", fmt.Errorf("invalid key '%s', quote keys that contain characters other than A-Z, a-z, 0-9, '_', or '-'", key)
+	}
+	return key, nil
+}
+
+func parseValue(val string) (any, error) {
+	switch {
+	case val == "true":
+		return true, nil
+	case val == "false":
+		return false, nil
+	case strings.HasPrefix(val, `"`):
+		if closingQuote(val) != len(val)-1 {
+			return nil, fmt.Errorf("unexpected characters after string value")
+		}
+		s, err := strconv.Unquote(val)
+		if err != nil {
+			return nil, fmt.Errorf("invalid string value %s", val)
+		}
+		return s, nil
+	default:
+		i, err := strconv.ParseInt(strings.ReplaceAll(val, "_", ""), 0, 64)
+		if err != nil {
+			return nil, fmt.Errorf("unsupported value '%s', must be a boolean, integer, or string", val)
+		}
+		return i, nil
+	}
+}
+
+// closingQuote returns the index of the quote that terminates the string starting at s[0], or -1 if there is none.
+func closingQuote(s string) int {
+	for i := 1; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case '"':
+			return i
+		}
+	}
+	return -1
+}
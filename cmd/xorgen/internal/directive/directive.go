@@ -0,0 +1,79 @@
+// Package directive formats and inserts the go:generate directives written by "xorgen init".
+package directive
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"go/parser"
+	"go/token"
+	"strconv"
+	"strings"
+)
+
+const prefix = "//go:generate "
+
+// ErrExists is returned by Insert when the Go file already contains the directive.
+var ErrExists = errors.New("the directive already exists")
+
+// Format returns a go:generate directive that runs the command with the given arguments.
+// Arguments are quoted like Go strings if they contain spaces or quotes, which go generate unquotes before running the command.
+func Format(command string, args ...string) string {
+	var buf strings.Builder
+	buf.WriteString(prefix)
+	buf.WriteString(command)
+	for _, arg := range args {
+		buf.WriteByte(' ')
+		if len(arg) == 0 || strings.ContainsAny(arg, " \t\"\\") {
+			arg = strconv.Quote(arg)
+		}
+		buf.WriteString(arg)
+	}
+	return buf.String()
+}
+
+// NewFile returns the source of a Go file in the package that only contains the directive.
+func NewFile(pkg, directive string) []byte {
+	return []byte(fmt.Sprintf("package %s\n\n%s\n", pkg, directive))
+}
+
+// Insert adds the directive to the source of a Go file.
+// It's added after the last existing go:generate directive so they run in order, or after the package clause if there are none.
+// ErrExists is returned if the file already contains the same directive.
+func Insert(src []byte, directive string) ([]byte, error) {
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "", src, parser.PackageClauseOnly)
+	if err != nil {
+		return nil, err
+	}
+	lines := bytes.SplitAfter(src, []byte("\n"))
+	insertAt := fset.Position(f.Name.End()).Line
+	separate := true
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(string(line))
+		if trimmed == directive {
+			return nil, ErrExists
+		}
+		if strings.HasPrefix(trimmed, prefix) {
+			insertAt = i + 1
+			separate = false
+		}
+	}
+
+	var out bytes.Buffer
+	for _, line := range lines[:insertAt] {
+		out.Write(line)
+	}
+	if !bytes.HasSuffix(out.Bytes(), []byte("\n")) {
+		out.WriteByte('\n')
+	}
+	if separate {
+		out.WriteByte('\n')
+	}
+	out.WriteString(directive)
+	out.WriteByte('\n')
+	for _, line := range lines[insertAt:] {
+		out.Write(line)
+	}
+	return out.Bytes(), nil
+}
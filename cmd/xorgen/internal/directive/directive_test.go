@@ -0,0 +1,70 @@
+package directive
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestFormat(t *testing.T) {
+	tests := map[string][]string{
+		"//go:generate xorgen secret.txt":                         {"secret.txt"},
+		"//go:generate xorgen -c --encoding base64 secret.txt":    {"-c", "--encoding", "base64", "secret.txt"},
+		`//go:generate xorgen "my secret.txt"`:                    {"my secret.txt"},
+		`//go:generate xorgen --tags "" secret.txt`:               {"--tags", "", "secret.txt"},
+		`//go:generate xorgen --encrypt "file:a \"b\".txt" a.txt`: {"--encrypt", `file:a "b".txt`, "a.txt"},
+	}
+	for expected, args := range tests {
+		t.Run(expected, func(t *testing.T) {
+			assert.Equal(t, expected, Format("xorgen", args...))
+		})
+	}
+}
+
+func TestInsert(t *testing.T) {
+	const directive = "//go:generate xorgen secret.txt"
+	tests := map[string]struct {
+		src      string
+		expected string
+	}{
+		"After package": {
+			src:      "package assets\n\nvar x = 1\n",
+			expected: "package assets\n\n" + directive + "\n\nvar x = 1\n",
+		},
+		"Package doc comment": {
+			src:      "// Package assets has assets.\npackage assets\n",
+			expected: "// Package assets has assets.\npackage assets\n\n" + directive + "\n",
+		},
+		"No trailing newline": {
+			src:      "package assets",
+			expected: "package assets\n\n" + directive + "\n",
+		},
+		"After directives": {
+			src:      "package assets\n\n//go:generate xorgen other.txt\n\nvar x = 1\n",
+			expected: "package assets\n\n//go:generate xorgen other.txt\n" + directive + "\n\nvar x = 1\n",
+		},
+		"Directive before package": {
+			src:      "//go:generate xorgen other.txt\npackage assets\n",
+			expected: "//go:generate xorgen other.txt\n" + directive + "\npackage assets\n",
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			out, err := Insert([]byte(tc.src), directive)
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, string(out))
+		})
+	}
+}
+
+func TestInsert_Neg(t *testing.T) {
+	_, err := Insert([]byte("package assets\n\n//go:generate xorgen secret.txt\n"), "//go:generate xorgen secret.txt")
+	assert.ErrorIs(t, err, ErrExists)
+
+	_, err = Insert([]byte("not go"), "//go:generate xorgen secret.txt")
+	assert.Error(t, err)
+}
+
+func TestNewFile(t *testing.T) {
+	assert.Equal(t, "package assets\n\n//go:generate xorgen secret.txt\n", string(NewFile("assets", "//go:generate xorgen secret.txt")))
+}
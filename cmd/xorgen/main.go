@@ -2,33 +2,89 @@ package main
 
 import (
 	"bytes"
-	"encoding/hex"
-	"errors"
+	"compress/gzip"
 	"fmt"
 	. "github.com/saylorsolutions/gocryptx/cmd/internal"
-	"github.com/saylorsolutions/gocryptx/cmd/xorgen/internal/tmpl"
+	"github.com/saylorsolutions/gocryptx/cmd/xorgen/internal/config"
+	"github.com/saylorsolutions/gocryptx/pkg/passlock"
+	"github.com/saylorsolutions/gocryptx/pkg/xorgen"
 	flag "github.com/spf13/pflag"
 	"io"
 	"os"
-	"strings"
+	"strconv"
 )
 
 var (
 	version      = "unknown"
 	versionFlag  bool
 	helpFlag     bool
-	exposedFlag  bool
-	compressFlag bool
-	packageFlag  string
+	configFlag   string
+	dirFlag      string
+	offsetFlag   string
+	nameFlag     string
+	forceFlag    bool
+	dryRunFlag   bool
+	intoFlag     string
+	outputFlag   string
+	jsonFlag     bool
+	flagSettings settings
 )
 
+// settings are the values used to generate a file, which are bound to flags and may be overridden by configuration.
+type settings struct {
+	exposed       bool
+	compressed    bool
+	compressLevel int
+	varyShape     bool
+	pkg           string
+	encoding      string
+	keyStrategy   string
+	maxSize       string
+	scatterKey    int
+	tags          string
+	withTest      bool
+	checksum      bool
+	http          bool
+	cached        bool
+	encrypt       string
+	obfuscate     bool
+	keyFile       string
+	sharedKey     bool
+	goEmbed       bool
+}
+
 func main() {
 	flags := flag.NewFlagSet("xorgen", flag.ContinueOnError)
 	flags.BoolVar(&versionFlag, "version", false, "Prints the version of this executable")
 	flags.BoolVarP(&helpFlag, "help", "h", false, "Prints this usage information.")
-	flags.BoolVarP(&exposedFlag, "exposed", "E", false, "Make the unscreen function exposed from the file. It's recommended to only expose from within an internal package.")
-	flags.BoolVarP(&compressFlag, "compressed", "c", false, "Payload should be gzip compressed when embedded, which includes a checksum to help prevent tampering.")
-	flags.StringVarP(&packageFlag, "package", "p", "", "Specifies a package name that should be used for the generated file.")
+	flags.BoolVarP(&flagSettings.exposed, "exposed", "E", false, "Make the unscreen function exposed from the file. It's recommended to only expose from within an internal package.")
+	flags.BoolVarP(&flagSettings.compressed, "compressed", "c", false, "Payload should be gzip compressed when embedded, which includes a checksum to help prevent tampering.")
+	flags.IntVar(&flagSettings.compressLevel, "compress-level", gzip.BestCompression, "Specifies the gzip compression level used with --compressed, from -2 (Huffman only) to 9 (best compression). Lower levels are faster for large inputs, at the cost of larger generated files.")
+	flags.BoolVar(&flagSettings.varyShape, "vary-shape", false, "Select the structure of the generated decode routine from several equivalent shapes based on a hash of the input, so generated files can't all be matched by a single signature.")
+	flags.StringVarP(&flagSettings.pkg, "package", "p", "", "Specifies a package name that should be used for the generated file. By default, the package is detected from existing Go files in the current directory, or the directory name is used if there are none. This must match the detected package if there is one.")
+	flags.StringVar(&offsetFlag, "offset", "", "Specifies the offset used with a KEY argument. This may be a number less than the length of the key, or 'random' to securely choose a random offset. The offset may also be given as part of the KEY argument as KEY:OFFSET.")
+	flags.StringVar(&flagSettings.encoding, "encoding", xorgen.DefaultEncoding, "Specifies how the screened data and key are represented in the generated file. May be one of 'bytes', 'string', or 'base64'. The 'base64' and 'string' encodings compile more than 10 times faster than 'bytes' for multi-MB inputs, and use far less memory in gopls. A 'string' payload is read in place at runtime, while 'base64' produces the smallest files but is decoded at runtime. Files embedded with --dir always use string literals.")
+	flags.StringVar(&flagSettings.keyStrategy, "key-strategy", config.KeyStrategyMatched, "Specifies how random keys are sized. The 'matched' strategy uses a key length based on the payload length, and 'payload' uses a key as long as the payload.")
+	flags.StringVar(&flagSettings.maxSize, "max-size", "16MiB", "Specifies the maximum size of the input file, with an optional K, M, or G suffix. Very large inputs produce Go files that may not compile, so generation fails if the input is larger than this. A size of 0 disables the limit.")
+	flags.IntVar(&flagSettings.scatterKey, "scatter-key", 0, "Splits the key into this many fragments, declared as separately named variables away from the data and combined at runtime. A value less than 2 disables scattering. This raises the bar for trivial static extraction of the key.")
+	flags.StringVar(&flagSettings.tags, "tags", "", "Adds a //go:build constraint to the generated file with comma separated build tags that must all be satisfied, like 'release,!debug'. This allows embedded data to be included only in specific build configurations.")
+	flags.BoolVar(&flagSettings.checksum, "checksum", false, "Embeds a SHA-256 checksum of the input that the generated unscreen function verifies, so tampering or key drift is detected even without --compressed. With --dir, each file is verified when it's opened.")
+	flags.BoolVar(&flagSettings.http, "http", false, "Also generates a function returning the embedded data as an http.FileSystem, so it can be served with http.FileServer. The input's modification time is recorded so Last-Modified and conditional requests work, which means touching the input regenerates the file.")
+	flags.BoolVar(&flagSettings.cached, "cached", false, "Also generates a cached accessor that only unscreens the data once, and a wipe function that zeroes the cached data. This is useful when the data is only needed at startup, and shouldn't stay resident in memory afterward. This can't be used with --dir.")
+	flags.StringVar(&flagSettings.encrypt, "encrypt", "", "Encrypts the payload with AES-GCM using a key derived from a pass phrase, instead of screening it. The pass phrase source may be 'env:NAME' to read an environment variable, 'file:PATH' to read the first line of a file, or 'prompt' to read it from stdin. The generated function is called decryptFILE and takes a passlock.PassSource to read the pass phrase at runtime, and it returns an error if the pass phrase is incorrect. Encrypted files are regenerated every time, since recording a hash of the input would let anyone with the generated file check guesses of its content. This can't be used with --dir.")
+	flags.BoolVar(&flagSettings.obfuscate, "obfuscate-names", false, "Declares generated functions and variables with random names, so the unscreen function can't be located by its symbol name in a stripped binary. Variables with the usual function names are generated to call them by, which are removed when building with -ldflags=\"-s -w\".")
+	flags.StringVar(&flagSettings.keyFile, "keyfile", "", "Writes the key to a key file at PATH instead of embedding it, and the generated functions take the path of the key file to load at runtime. The key file may be deployed and protected separately from the binary, and the generated code returns an error if it doesn't match the embedded data. An existing key file is reused when regenerating. Rotating the key without recompiling isn't supported, since screened data can only be unscreened with the key it was screened with, so delete the key file and regenerate to use a new key. This can't be used with --dir, --scatter-key, --http, --cached, --with-test, --checksum, or --encrypt. With extract, it's the key file that GOFILE loads its key from.")
+	flags.BoolVar(&flagSettings.sharedKey, "shared-key", false, "Screens the input with a key shared by every file generated with --shared-key in the output directory, which is declared in a keys.go file there instead of the generated file. The key is generated the first time it's needed, or a KEY argument replaces it, and files using it are regenerated whenever it changes. Delete keys.go and regenerate to rotate the key. This can't be used with --scatter-key, --keyfile, --encrypt, or --key-strategy payload.")
+	flags.BoolVar(&flagSettings.goEmbed, "go-embed", false, "FILE is a file screened with screen, which is embedded with a //go:embed directive instead of a literal, so large payloads don't slow down compilation. The key is read from the key file written next to it by screen, and --compressed must match how it was screened. The .xor extension is removed to name generated functions, and FILE must be in the output directory or a subdirectory. This can't be used with --dir, --keyfile, --shared-key, --encrypt, or a KEY argument.")
+	flags.BoolVar(&flagSettings.withTest, "with-test", false, "Also generates a _test.go file that unscreens the embedded data and compares its SHA-256 checksum to the original, so template or key regressions are caught by 'go test'. This can't be used when FILE is '-'.")
+	flags.BoolVarP(&forceFlag, "force", "f", false, "Regenerates files even if they're up-to-date. By default, a generated file records a hash of its input and options, and isn't regenerated with new keys unless one of them changed.")
+	flags.BoolVar(&dryRunFlag, "dry-run", false, "Writes generated files to stdout instead of creating them, each preceded by a comment with the path it would be written to. This is useful to inspect the result of a combination of flags.")
+	flags.StringVar(&nameFlag, "name", "stdin", "Specifies the name used in place of a file name to name generated functions when FILE is '-'. For example, 'secret.bin' results in a function called unscreenSecret_bin.")
+	flags.StringVar(&dirFlag, "dir", "", "Embeds every file in the given directory instead of a single FILE, and generates a function returning them as an fs.FS. Each file is screened with its own key, and files or directories beginning with '.' or '_' are skipped like with go:embed.")
+	flags.StringVar(&configFlag, "config", "", fmt.Sprintf("Specifies a configuration file with default flag values. By default, a file called %s is used if found in the current directory or a parent directory, up to the directory with a go.mod file.", config.FileName))
+	flags.BoolVar(&jsonFlag, "json", false, "Writes the outcome as JSON to stdout instead of the usual messages, including the path, status, key fingerprint, and warnings of each file. The JSON is written even if the command fails, with the error and exit code. This can't be used with --dry-run, or when FILE is '-'.")
+	flags.StringVarP(&outputFlag, "output", "o", "", "Specifies the path of the screened file written by screen, which is FILE.xor by default. The key file is written next to it with a .xkey extension added. With extract, it's the path the original content is written to, or the directory to write each file to if GOFILE was generated with --dir. Extracted content is written to stdout by default.")
+	flags.StringVar(&intoFlag, "into", "doc.go", "Specifies the Go file that init adds a go:generate directive to, which is created if it doesn't exist.")
 	flags.Usage = func() {
 		fmt.Printf(`
 xorgen generates code to embed XOR obfuscated (and optionally compressed) data by generating a *.go file based on the input file. This pairs well with go:generate comments.
@@ -36,30 +92,103 @@ The name of the generated Go file will be based on the name of the input file, r
 For example, given a file called super-secret.txt, a Go file will be created in the current directory called super_secret_txt.go, containing a function called unscreenSuper_secret_txt.
 See the -E flag below to make it an exposed function, and make sure you review the SECURITY notes below if you're unfamiliar with XOR screening.
 
-USAGE:  xorgen FILE [KEY]
+USAGE:  xorgen FILE [KEY[:OFFSET]]
+        xorgen --dir DIR
+        xorgen gen [--config CONFIG]
+        xorgen init [--into GOFILE] FILE [KEY[:OFFSET]]
+        xorgen verify [DIR]
+        xorgen screen [-o OUTPUT] FILE [KEY[:OFFSET]]
+        xorgen extract [--keyfile KEYFILE] [-o OUTPUT] GOFILE
+        xorgen - [KEY[:OFFSET]] < FILE > OUTPUT.go
 
-Note: If a key argument is given, it will be used with offset 0.
+Note: If a key argument is given, it will be used with offset 0 unless an offset is given with --offset or KEY:OFFSET.
+With --dir, a file called DIR_fs.go is created instead, containing a function called fsDIR that returns an fs.FS of the embedded files.
+If FILE is '-', the input is read from stdin and the generated code is written to stdout, so unscreened data doesn't need to touch disk. Use --name to name the generated functions.
+With gen, every file and directory listed in the configuration file is generated, as described in CONFIGURATION below. Use ./gen, ./init, ./verify, ./screen, or ./extract to embed files with those names.
+With init, a go:generate directive that runs xorgen with the same flags and arguments is added to GOFILE instead, which is doc.go by default and is created if it doesn't exist.
+Paths are made relative to the directory of GOFILE, since that's where go generate runs. Use "xorgen init gen" or "xorgen init screen FILE" to add a directive that runs gen or screen.
+With verify, every file generated by xorgen in DIR and its subdirectories is checked against the checksum in its header, to detect hand edits in CI.
+If the source of a generated file is found, then it's also checked for changes since the file was generated. DIR is the current directory by default.
+With screen, FILE is screened (and compressed with -c) into FILE.xor and its key is written to FILE.xor.xkey, instead of generating code. The screened file is left alone if it's still current, so keys don't churn between builds.
+Then "xorgen --go-embed FILE.xor" generates the usual functions, with the screened data embedded by a //go:embed directive.
+With extract, the original content embedded in GOFILE is recovered from its key, offset, and data, and written to OUTPUT or stdout. This audits what a build actually ships, or recovers a lost input.
+Use --keyfile if GOFILE was generated with --keyfile, and give a directory as OUTPUT if it was generated with --dir. Encrypted files can't be extracted, since they can only be decrypted with their pass phrase.
 
 ARGS:
-    FILE is the input file to be embedded.
-    KEY is optional and may be specified to override secure random generation behavior.
+    FILE is the input file to be embedded, or '-' to read from stdin and write to stdout.
+    KEY is optional and may be specified to override secure random generation behavior. It's a hex string, or base64 with a 'base64:' prefix like 'base64:3q2+7w=='.
+It may be followed by a colon and an OFFSET, which is a number or 'random'.
 
 FLAGS:
 %s
+CONFIGURATION:
+    Default flag values for a project may be set in a %s file, so they don't need to be repeated in every go:generate comment.
+Flags given on the command line always override values from the configuration file.
+
+    # Defaults applied to every generated file.
+    compressed = true
+    compress-level = 9
+    exposed = false
+    encoding = "base64"
+    key-strategy = "matched"
+    max-size = "16MiB"
+    vary-shape = true
+    tags = "release,!debug"
+    with-test = true
+    checksum = true
+    http = false
+    cached = false
+    obfuscate-names = false
+    keyfile = ""
+    shared-key = false
+    go-embed = false
+
+    # Package name overrides for directories relative to the configuration file.
+    [packages]
+    "internal/assets" = "assets"
+
+    The configuration file may also be a manifest of files to generate with "xorgen gen", so a single go:generate comment regenerates an entire asset set.
+Each [[generate]] table has an 'input' file or a 'dir' to embed, and paths are relative to the configuration file.
+The generated file is written to the 'output' directory, which is the configuration file's directory by default.
+Each entry may set its own 'output' and 'package', so assets can land in different packages. If 'package' isn't set, the [packages] override for the output directory is used, or the package is detected from it.
+An entry may also set a hex or 'base64:' 'key' with an optional 'offset', 'scatter-key', and any of the defaults above, which override the root defaults for that entry.
+Entries with shared-key set share the keys.go file of their output directory, and a 'key' given by one of them is used for all of them, so they can't give different keys.
+
+    [[generate]]
+    input = "secrets/api-key.txt"
+    output = "internal/assets"
+    key = "deadbeef"
+    offset = "random"
+
+    [[generate]]
+    dir = "web/static"
+    output = "internal/web"
+    package = "web"
+    compressed = false
+
 SECURITY:
     This is not encryption, this is obfuscation, and they are very different things!
 XOR screening is intended to hide embedded data from passive binary analysis only, since XOR screening is easily reversible.
 It's noteworthy that using gzip compression could make part of the XOR key easier to recover, since the gzip header is somewhat predictable.
 This isn't really important to the threat model of this obfuscation method, since the plain text key is stored right next to the screened data.
-`, flags.FlagUsages())
+If embedded data needs to be kept confidential, use --encrypt instead, which requires a pass phrase to decrypt the data at runtime.
+
+EXIT CODES:
+    0 - Success.
+    1 - Generation failed, such as when an input can't be read or a file can't be written.
+    2 - Invalid flags, arguments, or configuration, so nothing was generated.
+    3 - One or more generated files failed verification with verify.
+`, flags.FlagUsages(), config.FileName)
 	}
 	if len(os.Args) == 1 {
 		flags.Usage()
 		return
 	}
 	if err := flags.Parse(os.Args[1:]); err != nil {
-		flags.Usage()
-		Fatal("Error parsing flags: %v", err)
+		if !jsonFlag {
+			flags.Usage()
+		}
+		finish("Error parsing flags", usageError{err})
 	}
 	if helpFlag {
 		flags.Usage()
@@ -69,43 +198,400 @@ This isn't really important to the threat model of this obfuscation method, sinc
 		Echo("xorgen version: %s", version)
 		return
 	}
-	if err := run(flags); err != nil {
-		Fatal("Error running xorgen: %v", err)
+	if jsonFlag && (dryRunFlag || flags.Arg(0) == "-") {
+		finish("Error parsing flags", usagef("--json can't be used with --dry-run, or when FILE is '-', since generated code is written to stdout"))
+	}
+	cfg, err := applyConfig(flags)
+	if err != nil {
+		finish("Error loading configuration", usageError{err})
+	}
+	switch {
+	case flags.NArg() > 0 && flags.Arg(0) == "init":
+		err = runInit(flags)
+	case flags.NArg() > 0 && flags.Arg(0) == "verify":
+		err = runVerify(flags)
+	case flags.NArg() > 0 && flags.Arg(0) == "gen":
+		err = runGen(flags, cfg)
+	case flags.NArg() > 0 && flags.Arg(0) == "screen":
+		err = runScreen(flags)
+	case flags.NArg() > 0 && flags.Arg(0) == "extract":
+		err = runExtract(flags)
+	default:
+		err = run(flags)
+	}
+	finish("Error running xorgen", err)
+}
+
+// applyConfig loads the project configuration file, if any, and uses it to populate flags that weren't explicitly set.
+// A nil Config is returned if there is no configuration file.
+func applyConfig(flags *flag.FlagSet) (*config.Config, error) {
+	path := configFlag
+	if len(path) == 0 {
+		var err error
+		path, err = config.Find(".")
+		if err != nil {
+			return nil, err
+		}
+		if len(path) == 0 {
+			return nil, nil
+		}
+	}
+	cfg, err := config.Load(path)
+	if err != nil {
+		return nil, err
+	}
+	flagSettings.apply(flags, cfg.Settings)
+	if !flags.Changed("package") {
+		pkg, err := cfg.PackageFor(".")
+		if err != nil {
+			return nil, err
+		}
+		if len(pkg) > 0 {
+			flagSettings.pkg = pkg
+		}
+	}
+	return cfg, nil
+}
+
+// apply overrides settings with values from the configuration file, unless the corresponding flag was given on the command line.
+func (s *settings) apply(flags *flag.FlagSet, cfg config.Settings) {
+	if cfg.Compressed != nil && !flags.Changed("compressed") {
+		s.compressed = *cfg.Compressed
+	}
+	if cfg.CompressLevel != nil && !flags.Changed("compress-level") {
+		s.compressLevel = *cfg.CompressLevel
+	}
+	if cfg.Exposed != nil && !flags.Changed("exposed") {
+		s.exposed = *cfg.Exposed
+	}
+	if len(cfg.Encoding) > 0 && !flags.Changed("encoding") {
+		s.encoding = cfg.Encoding
+	}
+	if len(cfg.KeyStrategy) > 0 && !flags.Changed("key-strategy") {
+		s.keyStrategy = cfg.KeyStrategy
+	}
+	if cfg.VaryShape != nil && !flags.Changed("vary-shape") {
+		s.varyShape = *cfg.VaryShape
+	}
+	if cfg.MaxSize != nil && !flags.Changed("max-size") {
+		s.maxSize = strconv.FormatInt(*cfg.MaxSize, 10)
+	}
+	if len(cfg.Tags) > 0 && !flags.Changed("tags") {
+		s.tags = cfg.Tags
+	}
+	if cfg.WithTest != nil && !flags.Changed("with-test") {
+		s.withTest = *cfg.WithTest
+	}
+	if cfg.Checksum != nil && !flags.Changed("checksum") {
+		s.checksum = *cfg.Checksum
+	}
+	if cfg.HTTP != nil && !flags.Changed("http") {
+		s.http = *cfg.HTTP
+	}
+	if cfg.Cached != nil && !flags.Changed("cached") {
+		s.cached = *cfg.Cached
+	}
+	if len(cfg.Encrypt) > 0 && !flags.Changed("encrypt") {
+		s.encrypt = cfg.Encrypt
+	}
+	if cfg.ObfuscateNames != nil && !flags.Changed("obfuscate-names") {
+		s.obfuscate = *cfg.ObfuscateNames
+	}
+	if len(cfg.KeyFile) > 0 && !flags.Changed("keyfile") {
+		s.keyFile = cfg.KeyFile
+	}
+	if cfg.SharedKey != nil && !flags.Changed("shared-key") {
+		s.sharedKey = *cfg.SharedKey
+	}
+	if cfg.GoEmbed != nil && !flags.Changed("go-embed") {
+		s.goEmbed = *cfg.GoEmbed
 	}
-	Echo("xorgen ran successfully")
 }
 
 func run(flags *flag.FlagSet) error {
-	switch flags.NArg() {
-	case 0:
-		return errors.New("missing required FILE argument")
-	case 1:
-		err := tmpl.GenerateFile(
-			flags.Arg(0),
-			tmpl.RandomKey(),
-			tmpl.CompressData(compressFlag),
-			tmpl.ExposeFunctions(exposedFlag),
-			tmpl.PackageName(packageFlag),
-		)
+	var keyOpt xorgen.ParamOpt
+	switch {
+	case len(dirFlag) > 0 && flags.NArg() > 0:
+		return usagef("FILE and KEY arguments can't be used with --dir")
+	case len(dirFlag) == 0 && flags.NArg() == 0:
+		return usagef("missing required FILE argument")
+	case flags.NArg() <= 1:
+		if len(offsetFlag) > 0 {
+			return usagef("--offset may only be used with a KEY argument")
+		}
+		var err error
+		keyOpt, err = flagSettings.randomKey()
 		if err != nil {
-			return fmt.Errorf("failed to generate file: %w", err)
+			return err
 		}
 	default:
-		var key bytes.Buffer
-		_, err := io.Copy(&key, hex.NewDecoder(strings.NewReader(flags.Arg(1))))
-		if err != nil {
-			return errors.New("failed to decode KEY, must be a hex string with only the characters a-f, A-F, or 0-9")
-		}
-		err = tmpl.GenerateFile(
-			flags.Arg(0),
-			tmpl.UseKeyOffset(key.Bytes(), 0),
-			tmpl.CompressData(compressFlag),
-			tmpl.ExposeFunctions(exposedFlag),
-			tmpl.PackageName(packageFlag),
-		)
+		if flagSettings.goEmbed {
+			return usagef("a KEY argument can't be used with --go-embed, since the key is read from the key file written by screen")
+		}
+		var err error
+		keyOpt, err = parseKeyArg(flags.Arg(1))
 		if err != nil {
-			return fmt.Errorf("failed to generate file: %w", err)
+			return err
+		}
+	}
+	if flags.Changed("name") && flags.Arg(0) != "-" {
+		return usagef("--name may only be used when FILE is '-'")
+	}
+	if len(outputFlag) > 0 {
+		return usagef("--output may only be used with screen or extract")
+	}
+	if flagSettings.goEmbed && (len(dirFlag) > 0 || flags.Arg(0) == "-") {
+		return usagef("--go-embed can't be used with --dir, or when FILE is '-', since it embeds a single screened file")
+	}
+	if len(dirFlag) > 0 {
+		if flagSettings.scatterKey > 1 {
+			return usagef("--scatter-key can't be used with --dir")
+		}
+		if flagSettings.cached {
+			return usagef("--cached can't be used with --dir")
+		}
+		if len(flagSettings.encrypt) > 0 {
+			return usagef("--encrypt can't be used with --dir")
+		}
+		if len(flagSettings.keyFile) > 0 {
+			return usagef("--keyfile can't be used with --dir")
+		}
+		return flagSettings.generateDir(dirFlag, keyOpt)
+	}
+	return flagSettings.generateFile(flags.Arg(0), keyOpt)
+}
+
+// runGen generates every file and directory listed in [[generate]] tables of the configuration file.
+func runGen(flags *flag.FlagSet, cfg *config.Config) error {
+	switch {
+	case flags.NArg() > 1 || len(dirFlag) > 0 || len(offsetFlag) > 0 || flags.Changed("package"):
+		return usagef("FILE, KEY, --dir, --offset, and --package can't be used with gen, since they're set for each entry in the configuration file")
+	case cfg == nil:
+		return usagef("no %s file found for gen, use --config to specify one", config.FileName)
+	case len(cfg.Generate) == 0:
+		return usagef("no [[generate]] entries found in the configuration file")
+	}
+	shared, err := sharedKeys(flags, cfg)
+	if err != nil {
+		return err
+	}
+	for i, entry := range cfg.Generate {
+		if err := generateEntry(flags, cfg, entry, shared); err != nil {
+			return fmt.Errorf("generate entry %d: %w", i+1, err)
 		}
 	}
 	return nil
 }
+
+// entrySettings returns the settings for a manifest entry.
+// Entry settings override the root configuration, but flags given on the command line still take precedence.
+func entrySettings(flags *flag.FlagSet, entry config.Entry) settings {
+	s := flagSettings
+	s.apply(flags, entry.Settings)
+	if entry.ScatterKey != nil && !flags.Changed("scatter-key") {
+		s.scatterKey = *entry.ScatterKey
+	}
+	return s
+}
+
+// sharedKeys returns the entries that give a key for each output directory with entries using a shared key, so the key is used for all of them.
+func sharedKeys(flags *flag.FlagSet, cfg *config.Config) (map[string]config.Entry, error) {
+	keys := map[string]config.Entry{}
+	for _, entry := range cfg.Generate {
+		if !entrySettings(flags, entry).sharedKey || len(entry.Key) == 0 {
+			continue
+		}
+		out := cfg.Path(entry.Output)
+		if other, ok := keys[out]; ok && (!bytes.Equal(other.Key, entry.Key) || other.Offset != entry.Offset) {
+			return nil, usagef("entries using a shared key in '%s' give different keys or offsets", out)
+		}
+		keys[out] = entry
+	}
+	return keys, nil
+}
+
+// generateEntry generates a single manifest entry, with paths relative to the configuration file.
+// If the entry uses a shared key, then a key given by another entry for the same output directory is used.
+func generateEntry(flags *flag.FlagSet, cfg *config.Config, entry config.Entry, shared map[string]config.Entry) error {
+	s := entrySettings(flags, entry)
+	out := cfg.Path(entry.Output)
+	s.pkg = entry.Package
+	if len(s.pkg) == 0 {
+		var err error
+		if s.pkg, err = cfg.PackageFor(out); err != nil {
+			return err
+		}
+	}
+	if keyed, ok := shared[out]; ok && s.sharedKey {
+		entry.Key, entry.Offset = keyed.Key, keyed.Offset
+	}
+
+	var (
+		keyOpt xorgen.ParamOpt
+		err    error
+	)
+	if len(entry.Key) > 0 {
+		keyOpt, err = keyWithOffset(entry.Key, entry.Offset)
+	} else {
+		keyOpt, err = s.randomKey()
+	}
+	if err != nil {
+		return err
+	}
+	if len(entry.Dir) > 0 {
+		if s.goEmbed {
+			return usagef("go-embed can't be used with 'dir', since it embeds a single screened file")
+		}
+		return s.generateDir(cfg.Path(entry.Dir), keyOpt, xorgen.OutputDir(out))
+	}
+	return s.generateFile(cfg.Path(entry.Input), keyOpt, xorgen.OutputDir(out))
+}
+
+// dryRunOutput returns stdout if --dry-run was given, so generated files are written there instead of being created.
+func dryRunOutput() io.Writer {
+	if dryRunFlag {
+		return os.Stdout
+	}
+	return nil
+}
+
+// passSource returns the source of the pass phrase used to encrypt files, or nil if files shouldn't be encrypted.
+func (s settings) passSource() (passlock.PassSource, error) {
+	if len(s.encrypt) == 0 {
+		return nil, nil
+	}
+	return config.ParsePassSource(s.encrypt)
+}
+
+// randomKey returns a ParamOpt that generates a random key with the selected key strategy.
+func (s settings) randomKey() (xorgen.ParamOpt, error) {
+	switch s.keyStrategy {
+	case config.KeyStrategyMatched:
+		return xorgen.RandomKey(), nil
+	case config.KeyStrategyPayload:
+		return xorgen.FullLengthKey(), nil
+	default:
+		return nil, usagef("unknown key strategy '%s'", s.keyStrategy)
+	}
+}
+
+func (s settings) generateFile(input string, opts ...xorgen.ParamOpt) error {
+	maxSize, err := config.ParseSize(s.maxSize)
+	if err != nil {
+		return err
+	}
+	passSource, err := s.passSource()
+	if err != nil {
+		return err
+	}
+	opts = append([]xorgen.ParamOpt{
+		xorgen.CompressData(s.compressed),
+		xorgen.CompressLevel(s.compressLevel),
+		xorgen.ExposeFunctions(s.exposed),
+		xorgen.PackageName(s.pkg),
+		xorgen.EncodeData(s.encoding),
+		xorgen.MaxInputSize(maxSize),
+		xorgen.VaryShape(s.varyShape),
+		xorgen.ScatterKey(s.scatterKey),
+		xorgen.BuildTags(s.tags),
+		xorgen.WithTest(s.withTest),
+		xorgen.EmbedChecksum(s.checksum),
+		xorgen.HTTPFileSystem(s.http),
+		xorgen.CacheData(s.cached),
+		xorgen.Encrypt(passSource),
+		xorgen.ObfuscateNames(s.obfuscate),
+		xorgen.KeyFile(s.keyFile),
+		xorgen.SharedKey(s.sharedKey),
+		xorgen.GoEmbed(s.goEmbed),
+		xorgen.GeneratorVersion(generatorVersion()),
+		xorgen.Report(report),
+		xorgen.ForceRegenerate(forceFlag),
+		xorgen.DryRun(dryRunOutput()),
+	}, opts...)
+	if input == "-" {
+		if s.withTest {
+			return usagef("--with-test can't be used when FILE is '-', since only one file can be written to stdout")
+		}
+		if config.IsPassPrompt(s.encrypt) {
+			return usagef("--encrypt can't prompt for a pass phrase when FILE is '-', since the input is read from stdin")
+		}
+		// Buffer the output so nothing is written to stdout if generation fails.
+		var buf bytes.Buffer
+		if err := xorgen.Generate(nameFlag, os.Stdin, &buf, opts...); err != nil {
+			return fmt.Errorf("failed to generate code from stdin: %w", err)
+		}
+		_, err := buf.WriteTo(os.Stdout)
+		return err
+	}
+	if err := xorgen.GenerateFile(input, opts...); err != nil {
+		return fmt.Errorf("failed to generate file for '%s': %w", input, err)
+	}
+	return nil
+}
+
+func (s settings) generateDir(dir string, opts ...xorgen.ParamOpt) error {
+	maxSize, err := config.ParseSize(s.maxSize)
+	if err != nil {
+		return err
+	}
+	passSource, err := s.passSource()
+	if err != nil {
+		return err
+	}
+	opts = append([]xorgen.ParamOpt{
+		xorgen.CompressData(s.compressed),
+		xorgen.CompressLevel(s.compressLevel),
+		xorgen.ExposeFunctions(s.exposed),
+		xorgen.PackageName(s.pkg),
+		xorgen.MaxInputSize(maxSize),
+		xorgen.ScatterKey(s.scatterKey),
+		xorgen.BuildTags(s.tags),
+		xorgen.WithTest(s.withTest),
+		xorgen.EmbedChecksum(s.checksum),
+		xorgen.HTTPFileSystem(s.http),
+		xorgen.CacheData(s.cached),
+		xorgen.Encrypt(passSource),
+		xorgen.ObfuscateNames(s.obfuscate),
+		xorgen.KeyFile(s.keyFile),
+		xorgen.SharedKey(s.sharedKey),
+		xorgen.GeneratorVersion(generatorVersion()),
+		xorgen.Report(report),
+		xorgen.ForceRegenerate(forceFlag),
+		xorgen.DryRun(dryRunOutput()),
+	}, opts...)
+	if err := xorgen.GenerateDir(dir, opts...); err != nil {
+		return fmt.Errorf("failed to generate directory file for '%s': %w", dir, err)
+	}
+	return nil
+}
+
+// parseKeyArg parses a KEY[:OFFSET] argument, which may use --offset instead of an OFFSET suffix.
+func parseKeyArg(arg string) (xorgen.ParamOpt, error) {
+	keyArg, offsetArg, hasOffset := config.CutKeyOffset(arg)
+	if hasOffset && len(offsetFlag) > 0 {
+		return nil, usagef("an offset may be given with KEY:OFFSET or --offset, but not both")
+	}
+	if !hasOffset {
+		offsetArg = offsetFlag
+	}
+	key, err := config.ParseKey(keyArg)
+	if err != nil {
+		return nil, usagef("failed to decode KEY: %w", err)
+	}
+	return keyWithOffset(key, offsetArg)
+}
+
+// keyWithOffset returns a ParamOpt that uses the key with the given offset, which may be empty for offset 0, a number, or "random".
+func keyWithOffset(key []byte, offset string) (xorgen.ParamOpt, error) {
+	switch offset {
+	case "":
+		return xorgen.UseKeyOffset(key, 0), nil
+	case "random":
+		return xorgen.UseKeyRandomOffset(key), nil
+	}
+	n, err := strconv.Atoi(offset)
+	if err != nil || n < 0 || n >= len(key) {
+		return nil, usagef("invalid offset '%s', must be 'random' or a number from 0 to %d", offset, len(key)-1)
+	}
+	return xorgen.UseKeyOffset(key, n), nil
+}
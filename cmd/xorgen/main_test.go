@@ -0,0 +1,272 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	. "github.com/saylorsolutions/gocryptx/cmd/internal"
+	"github.com/saylorsolutions/gocryptx/pkg/xorgen"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// mainEnv is set when the test binary is re-executed to run xorgen, since main exits the process.
+const mainEnv = "GOCRYPTX_TEST_XORGEN"
+
+const testMessage = "A test message that should be screened"
+
+func TestMain(m *testing.M) {
+	if os.Getenv(mainEnv) == "1" {
+		main()
+		os.Exit(ExitOK)
+	}
+	os.Exit(m.Run())
+}
+
+// xorgenRun is the outcome of running xorgen in a test.
+type xorgenRun struct {
+	code   int
+	stdout string
+	stderr string
+}
+
+// runXorgen runs xorgen with the given arguments in dir.
+func runXorgen(t *testing.T, dir string, args ...string) xorgenRun {
+	cmd := exec.Command(os.Args[0], args...)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), mainEnv+"=1")
+	var stdout, stderr strings.Builder
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	err := cmd.Run()
+	run := xorgenRun{stdout: stdout.String(), stderr: stderr.String()}
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		run.code = exitErr.ExitCode()
+		return run
+	}
+	require.NoError(t, err)
+	return run
+}
+
+// runJSON runs xorgen with --json, and decodes its output.
+func runJSON(t *testing.T, dir string, args ...string) (xorgenRun, jsonOutput) {
+	run := runXorgen(t, dir, append([]string{"--json"}, args...)...)
+	var out jsonOutput
+	require.NoError(t, json.Unmarshal([]byte(run.stdout), &out), "Output should be JSON: %s", run.stdout)
+	assert.Equal(t, run.code, out.ExitCode, "The reported exit code should match the process")
+	assert.Equal(t, run.code == ExitOK, out.OK)
+	return run, out
+}
+
+// testModule returns a directory with a go.mod file and an input file called secret.txt, so configuration isn't found outside it.
+func testModule(t *testing.T) string {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "go.mod"), []byte("module example.com/assets\n\ngo 1.23\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "secret.txt"), []byte(testMessage), 0644))
+	return dir
+}
+
+func TestMain_ExitCodes(t *testing.T) {
+	tests := map[string]struct {
+		args     []string
+		expected int
+	}{
+		"Generate":          {args: []string{"-p", "assets", "secret.txt"}, expected: ExitOK},
+		"Unknown flag":      {args: []string{"--unknown", "secret.txt"}, expected: ExitUsage},
+		"Missing FILE":      {args: []string{"-p", "assets"}, expected: ExitUsage},
+		"Invalid offset":    {args: []string{"--offset", "5", "secret.txt", "0102"}, expected: ExitUsage},
+		"Missing input":     {args: []string{"-p", "assets", "missing.txt"}, expected: ExitFailure},
+		"Conflicting flags": {args: []string{"--dir", ".", "--cached"}, expected: ExitUsage},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			run := runXorgen(t, testModule(t), tc.args...)
+			assert.Equal(t, tc.expected, run.code, "Unexpected exit code with stderr: %s", run.stderr)
+		})
+	}
+}
+
+func TestMain_JSON(t *testing.T) {
+	dir := testModule(t)
+	_, out := runJSON(t, dir, "-p", "assets", "--with-test", "secret.txt")
+	require.Len(t, out.Files, 1)
+	assert.Equal(t, "secret_txt.go", out.Files[0].Path)
+	assert.Equal(t, xorgen.StatusGenerated, out.Files[0].Status)
+	assert.Equal(t, "secret_txt_test.go", out.Files[0].TestPath)
+	assert.Len(t, out.Files[0].KeyFingerprint, 64)
+	assert.Empty(t, out.Error)
+
+	_, out = runJSON(t, dir, "-p", "assets", "--with-test", "secret.txt")
+	require.Len(t, out.Files, 1)
+	assert.Equal(t, xorgen.StatusUpToDate, out.Files[0].Status)
+
+	run, out := runJSON(t, dir, "-p", "assets", "missing.txt")
+	assert.Equal(t, ExitFailure, run.code)
+	assert.True(t, strings.HasPrefix(out.Error, "Error running xorgen: "), "The error should have its context, got '%s'", out.Error)
+	assert.NotNil(t, out.Files, "Files should be an empty array when nothing was generated")
+
+	run, out = runJSON(t, dir, "--unknown")
+	assert.Equal(t, ExitUsage, run.code)
+	assert.True(t, strings.HasPrefix(out.Error, "Error parsing flags: "), "The error should have its context, got '%s'", out.Error)
+	assert.Empty(t, run.stderr, "Usage shouldn't be shown with --json")
+}
+
+func TestMain_Init(t *testing.T) {
+	dir := testModule(t)
+	_, out := runJSON(t, dir, "init", "-c", "-p", "assets", "secret.txt")
+	require.Len(t, out.Files, 1)
+	assert.Equal(t, "doc.go", out.Files[0].Path)
+	assert.Equal(t, "created", out.Files[0].Status)
+	assert.Equal(t, "//go:generate xorgen --compressed --package assets secret.txt", out.Files[0].Directive)
+	data, err := os.ReadFile(filepath.Join(dir, "doc.go"))
+	require.NoError(t, err)
+	assert.Equal(t, "package assets\n\n"+out.Files[0].Directive+"\n", string(data))
+
+	require.NoError(t, os.Mkdir(filepath.Join(dir, "sub"), 0755))
+	_, out = runJSON(t, dir, "init", "--into", "sub/doc.go", "-p", "sub", "--keyfile", "keys/secret.xkey", "secret.txt")
+	require.Len(t, out.Files, 1)
+	assert.Equal(t, "//go:generate xorgen --keyfile ../keys/secret.xkey --package sub ../secret.txt", out.Files[0].Directive, "Paths should be relative to the Go file")
+
+	_, out = runJSON(t, dir, "init", "gen")
+	require.Len(t, out.Files, 1)
+	assert.Equal(t, "updated", out.Files[0].Status)
+	assert.Equal(t, "//go:generate xorgen gen", out.Files[0].Directive)
+
+	tests := map[string][]string{
+		"Missing FILE": {"init"},
+		"Stdin":        {"init", "-"},
+		"Test file":    {"init", "--into", "doc_test.go", "secret.txt"},
+		"Not Go":       {"init", "--into", "doc.txt", "secret.txt"},
+	}
+	for name, args := range tests {
+		t.Run(name, func(t *testing.T) {
+			run := runXorgen(t, dir, args...)
+			assert.Equal(t, ExitUsage, run.code, "Unexpected exit code with stderr: %s", run.stderr)
+		})
+	}
+	run := runXorgen(t, dir, "init", "missing.txt")
+	assert.Equal(t, ExitFailure, run.code, "A missing input should fail")
+}
+
+func TestMain_Verify(t *testing.T) {
+	dir := testModule(t)
+	run := runXorgen(t, dir, "-p", "assets", "secret.txt")
+	require.Equal(t, ExitOK, run.code, run.stderr)
+
+	_, out := runJSON(t, dir, "verify")
+	require.Len(t, out.Files, 1)
+	assert.Equal(t, "secret_txt.go", out.Files[0].Path)
+	assert.Equal(t, "ok", out.Files[0].Status)
+	assert.Equal(t, "secret.txt", out.Files[0].Source)
+	assert.True(t, out.Files[0].SourceChecked)
+
+	run = runXorgen(t, t.TempDir(), "verify", dir)
+	assert.Equal(t, ExitOK, run.code, "A DIR argument should be verified")
+	assert.Contains(t, run.stderr, "ok   "+filepath.Join(dir, "secret_txt.go"))
+
+	target := filepath.Join(dir, "secret_txt.go")
+	data, err := os.ReadFile(target)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(target, append(data, "// Hand edit\n"...), 0644))
+	run, out = runJSON(t, dir, "verify")
+	assert.Equal(t, exitVerify, run.code, "A failed verification should have its own exit code")
+	require.Len(t, out.Files, 1)
+	assert.Equal(t, "failed", out.Files[0].Status)
+	assert.NotEmpty(t, out.Files[0].Error)
+
+	run = runXorgen(t, dir, "verify", "a", "b")
+	assert.Equal(t, ExitUsage, run.code)
+	run = runXorgen(t, dir, "verify", "missing")
+	assert.Equal(t, ExitFailure, run.code)
+}
+
+func TestMain_Screen(t *testing.T) {
+	dir := testModule(t)
+	_, out := runJSON(t, dir, "screen", "-c", "secret.txt")
+	require.Len(t, out.Files, 1)
+	assert.Equal(t, "secret.txt.xor", out.Files[0].Path)
+	assert.Equal(t, xorgen.StatusGenerated, out.Files[0].Status)
+	assert.FileExists(t, filepath.Join(dir, "secret.txt.xor"))
+	assert.FileExists(t, filepath.Join(dir, "secret.txt.xor.xkey"))
+
+	run := runXorgen(t, dir, "screen", "-o", "screened.bin", "secret.txt", "0102:1")
+	assert.Equal(t, ExitOK, run.code, run.stderr)
+	assert.FileExists(t, filepath.Join(dir, "screened.bin"))
+	assert.FileExists(t, filepath.Join(dir, "screened.bin.xkey"))
+
+	run = runXorgen(t, dir, "-c", "-p", "assets", "--go-embed", "secret.txt.xor")
+	assert.Equal(t, ExitOK, run.code, "A screened file should be embedded with --go-embed: %s", run.stderr)
+	assert.FileExists(t, filepath.Join(dir, "secret_txt.go"))
+
+	tests := map[string][]string{
+		"Missing FILE":   {"screen"},
+		"Too many args":  {"screen", "secret.txt", "0102", "extra"},
+		"Stdin":          {"screen", "-"},
+		"Dir":            {"screen", "--dir", ".", "secret.txt"},
+		"Dry run":        {"screen", "--dry-run", "secret.txt"},
+		"Offset no key":  {"screen", "--offset", "1", "secret.txt"},
+		"Invalid offset": {"screen", "secret.txt", "0102:2"},
+	}
+	for name, args := range tests {
+		t.Run(name, func(t *testing.T) {
+			run := runXorgen(t, dir, args...)
+			assert.Equal(t, ExitUsage, run.code, "Unexpected exit code with stderr: %s", run.stderr)
+		})
+	}
+}
+
+func TestMain_Extract(t *testing.T) {
+	dir := testModule(t)
+	run := runXorgen(t, dir, "-c", "-p", "assets", "secret.txt")
+	require.Equal(t, ExitOK, run.code, run.stderr)
+
+	run = runXorgen(t, dir, "extract", "secret_txt.go")
+	assert.Equal(t, ExitOK, run.code, run.stderr)
+	assert.Equal(t, testMessage, run.stdout, "Content should be written to stdout by default")
+
+	_, out := runJSON(t, dir, "extract", "-o", "out/secret.txt", "secret_txt.go")
+	require.Len(t, out.Files, 1)
+	assert.Equal(t, filepath.Join("out", "secret.txt"), out.Files[0].Path)
+	assert.Equal(t, "extracted", out.Files[0].Status)
+	data, err := os.ReadFile(filepath.Join(dir, "out", "secret.txt"))
+	require.NoError(t, err)
+	assert.Equal(t, testMessage, string(data))
+
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "assets", "css"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "assets", "message.txt"), []byte(testMessage), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "assets", "css", "site.css"), []byte("body {}"), 0644))
+	run = runXorgen(t, dir, "-p", "assets", "--dir", "assets")
+	require.Equal(t, ExitOK, run.code, run.stderr)
+	run = runXorgen(t, dir, "extract", "-o", "extracted", "assets_fs.go")
+	assert.Equal(t, ExitOK, run.code, run.stderr)
+	data, err = os.ReadFile(filepath.Join(dir, "extracted", "css", "site.css"))
+	require.NoError(t, err)
+	assert.Equal(t, "body {}", string(data))
+
+	tests := map[string]struct {
+		args     []string
+		expected int
+	}{
+		"Missing GOFILE":   {args: []string{"extract"}, expected: ExitUsage},
+		"Too many args":    {args: []string{"extract", "secret_txt.go", "assets_fs.go"}, expected: ExitUsage},
+		"Dir":              {args: []string{"extract", "--dir", "assets", "secret_txt.go"}, expected: ExitUsage},
+		"Dry run":          {args: []string{"extract", "--dry-run", "secret_txt.go"}, expected: ExitUsage},
+		"Dir to stdout":    {args: []string{"extract", "assets_fs.go"}, expected: ExitUsage},
+		"JSON to stdout":   {args: []string{"--json", "extract", "secret_txt.go"}, expected: ExitUsage},
+		"Not generated":    {args: []string{"extract", "doc.go"}, expected: ExitFailure},
+		"Missing key file": {args: []string{"extract", "--keyfile", "missing.xkey", "secret_txt.go"}, expected: ExitFailure},
+		"Missing Go file":  {args: []string{"extract", "missing.go"}, expected: ExitFailure},
+	}
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "doc.go"), []byte("package assets\n"), 0644))
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			run := runXorgen(t, dir, tc.args...)
+			assert.Equal(t, tc.expected, run.code, "Unexpected exit code with stderr: %s", run.stderr)
+		})
+	}
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	. "github.com/saylorsolutions/gocryptx/cmd/internal"
+	"github.com/saylorsolutions/gocryptx/pkg/xorgen"
+	"os"
+)
+
+// exitVerify is returned by verify when a generated file fails verification, so CI can tell it apart from other failures.
+const exitVerify = 3
+
+var (
+	errVerifyFailed = errors.New("generated files failed verification")
+	results         = []fileResult{}
+)
+
+// usageError is an error caused by invalid flags, arguments, or configuration, which exits with ExitUsage.
+type usageError struct {
+	error
+}
+
+func (e usageError) Unwrap() error {
+	return e.error
+}
+
+// usagef returns a usageError with a formatted message.
+func usagef(format string, args ...any) error {
+	return usageError{fmt.Errorf(format, args...)}
+}
+
+// jsonOutput is written to stdout with --json, once the command has finished.
+type jsonOutput struct {
+	OK       bool         `json:"ok"`
+	ExitCode int          `json:"exitCode"`
+	Error    string       `json:"error,omitempty"`
+	Files    []fileResult `json:"files"`
+}
+
+// fileResult describes a file that was generated, verified, or updated by init.
+type fileResult struct {
+	Path           string   `json:"path"`
+	Status         string   `json:"status"`
+	TestPath       string   `json:"testPath,omitempty"`
+	KeyFile        string   `json:"keyFile,omitempty"`
+	KeyFingerprint string   `json:"keyFingerprint,omitempty"`
+	Directive      string   `json:"directive,omitempty"`
+	Version        string   `json:"version,omitempty"`
+	Source         string   `json:"source,omitempty"`
+	SourceChecked  bool     `json:"sourceChecked,omitempty"`
+	Warnings       []string `json:"warnings,omitempty"`
+	Error          string   `json:"error,omitempty"`
+}
+
+// report records the result of generating a file, and shows its warnings unless --json is used.
+func report(result xorgen.Result) {
+	for _, warning := range result.Warnings {
+		say("Warning: %s: %s", result.Path, warning)
+	}
+	results = append(results, fileResult{
+		Path:           result.Path,
+		Status:         result.Status,
+		TestPath:       result.TestPath,
+		KeyFile:        result.KeyFile,
+		KeyFingerprint: result.KeyFingerprint,
+		Warnings:       result.Warnings,
+	})
+}
+
+// say will Echo the message, unless --json is used so only JSON is written.
+func say(msg string, args ...any) {
+	if !jsonFlag {
+		Echo(msg, args...)
+	}
+}
+
+// exitCode returns the exit code for the outcome of the command.
+func exitCode(err error) int {
+	var usage usageError
+	switch {
+	case err == nil:
+		return ExitOK
+	case errors.As(err, &usage):
+		return ExitUsage
+	case errors.Is(err, errVerifyFailed):
+		return exitVerify
+	default:
+		return ExitFailure
+	}
+}
+
+// finish reports the outcome of the command and exits with a code that reflects it.
+// The context describes what failed, and prefixes the error message.
+func finish(context string, err error) {
+	code := exitCode(err)
+	if jsonFlag {
+		out := jsonOutput{
+			OK:       err == nil,
+			ExitCode: code,
+			Files:    results,
+		}
+		if err != nil {
+			out.Error = fmt.Sprintf("%s: %v", context, err)
+		}
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if encErr := enc.Encode(out); encErr != nil {
+			Exit(ExitFailure, "Error writing JSON output: %v", encErr)
+		}
+		os.Exit(code)
+	}
+	if err != nil {
+		Exit(code, "%s: %v", context, err)
+	}
+	Echo("xorgen ran successfully")
+	os.Exit(code)
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	. "github.com/saylorsolutions/gocryptx/cmd/internal"
+	"github.com/saylorsolutions/gocryptx/pkg/xorgen"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"os"
+	"testing"
+)
+
+func TestExitCode(t *testing.T) {
+	tests := map[string]struct {
+		err      error
+		expected int
+	}{
+		"Success":                {err: nil, expected: 0},
+		"Usage":                  {err: usagef("missing required FILE argument"), expected: 2},
+		"Wrapped usage":          {err: fmt.Errorf("generate entry 1: %w", usagef("unknown key strategy")), expected: 2},
+		"Verification":           {err: fmt.Errorf("%w: 1 of 2", errVerifyFailed), expected: 3},
+		"Generation":             {err: fmt.Errorf("failed to generate file for 'a.txt': %w", xorgen.ErrInputTooLarge), expected: 1},
+		"Not extractable":        {err: xorgen.ErrNotExtractable, expected: 1},
+		"Missing input":          {err: os.ErrNotExist, expected: 1},
+		"Unclassified":           {err: errors.New("something went wrong"), expected: 1},
+		"Usage takes precedence": {err: usageError{errVerifyFailed}, expected: 2},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, exitCode(tc.err))
+		})
+	}
+}
+
+func TestJSONOutput(t *testing.T) {
+	out := jsonOutput{
+		OK:       false,
+		ExitCode: exitVerify,
+		Error:    "Error running xorgen: generated files failed verification: 1 of 1",
+		Files: []fileResult{
+			{
+				Path:           "secret_txt.go",
+				Status:         xorgen.StatusGenerated,
+				TestPath:       "secret_txt_test.go",
+				KeyFile:        "secret.xkey",
+				KeyFingerprint: "abcd",
+				Directive:      "//go:generate xorgen secret.txt",
+				Version:        "v1.0.0",
+				Source:         "secret.txt",
+				SourceChecked:  true,
+				Warnings:       []string{"warning"},
+				Error:          "checksum mismatch",
+			},
+			{
+				Path:   "other_txt.go",
+				Status: xorgen.StatusUpToDate,
+			},
+		},
+	}
+	data, err := json.Marshal(out)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{
+		"ok": false,
+		"exitCode": 3,
+		"error": "Error running xorgen: generated files failed verification: 1 of 1",
+		"files": [
+			{
+				"path": "secret_txt.go",
+				"status": "generated",
+				"testPath": "secret_txt_test.go",
+				"keyFile": "secret.xkey",
+				"keyFingerprint": "abcd",
+				"directive": "//go:generate xorgen secret.txt",
+				"version": "v1.0.0",
+				"source": "secret.txt",
+				"sourceChecked": true,
+				"warnings": ["warning"],
+				"error": "checksum mismatch"
+			},
+			{
+				"path": "other_txt.go",
+				"status": "up-to-date"
+			}
+		]
+	}`, string(data))
+
+	data, err = json.Marshal(jsonOutput{OK: true, ExitCode: ExitOK, Files: []fileResult{}})
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"ok": true, "exitCode": 0, "files": []}`, string(data), "Files should be an empty array rather than null, and error should be omitted")
+}
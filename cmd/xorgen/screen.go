@@ -0,0 +1,50 @@
+package main
+
+import (
+	"fmt"
+	"github.com/saylorsolutions/gocryptx/pkg/xorgen"
+	flag "github.com/spf13/pflag"
+)
+
+// runScreen screens FILE into a file that can be embedded with go:embed, and writes its key file next to it.
+func runScreen(flags *flag.FlagSet) error {
+	args := flags.Args()[1:]
+	switch {
+	case len(args) == 0:
+		return usagef("missing required FILE argument for screen")
+	case len(args) > 2:
+		return usagef("screen accepts a FILE argument, and an optional KEY argument")
+	case args[0] == "-":
+		return usagef("FILE can't be '-' with screen, since the screened file and its key file are written next to each other")
+	case len(dirFlag) > 0:
+		return usagef("--dir can't be used with screen")
+	case dryRunFlag:
+		return usagef("--dry-run can't be used with screen, since screened files are binary")
+	}
+	var (
+		keyOpt xorgen.ParamOpt
+		err    error
+	)
+	if len(args) == 2 {
+		keyOpt, err = parseKeyArg(args[1])
+	} else {
+		if len(offsetFlag) > 0 {
+			return usagef("--offset may only be used with a KEY argument")
+		}
+		keyOpt, err = flagSettings.randomKey()
+	}
+	if err != nil {
+		return err
+	}
+	err = xorgen.ScreenFile(args[0], outputFlag,
+		keyOpt,
+		xorgen.CompressData(flagSettings.compressed),
+		xorgen.CompressLevel(flagSettings.compressLevel),
+		xorgen.Report(report),
+		xorgen.ForceRegenerate(forceFlag),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to screen '%s': %w", args[0], err)
+	}
+	return nil
+}
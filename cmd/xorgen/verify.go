@@ -0,0 +1,87 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"github.com/saylorsolutions/gocryptx/pkg/xorgen"
+	flag "github.com/spf13/pflag"
+	"io/fs"
+	"path/filepath"
+	"runtime/debug"
+	"strings"
+)
+
+// generatorVersion returns the version stamped in generated files, which falls back to the module version when installed with go install.
+func generatorVersion() string {
+	if version != "unknown" {
+		return version
+	}
+	if info, ok := debug.ReadBuildInfo(); ok && info.Main.Version != "(devel)" {
+		return info.Main.Version
+	}
+	return ""
+}
+
+// runVerify verifies every file generated by xorgen in the directory and its subdirectories.
+func runVerify(flags *flag.FlagSet) error {
+	if flags.NArg() > 2 {
+		return usagef("verify accepts at most one DIR argument")
+	}
+	dir := "."
+	if flags.NArg() == 2 {
+		dir = flags.Arg(1)
+	}
+	var verified, failed int
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		name := d.Name()
+		if d.IsDir() {
+			// Skip the same directories that the go command ignores.
+			if path != dir && (strings.HasPrefix(name, ".") || strings.HasPrefix(name, "_") || name == "testdata" || name == "vendor") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !strings.HasSuffix(name, ".go") {
+			return nil
+		}
+		result, err := xorgen.VerifyFile(path)
+		if errors.Is(err, xorgen.ErrNotGenerated) {
+			return nil
+		}
+		entry := fileResult{
+			Path:          path,
+			Status:        "ok",
+			Version:       result.Version,
+			Source:        result.Source,
+			SourceChecked: result.SourceChecked,
+		}
+		switch {
+		case err != nil:
+			failed++
+			entry.Status = "failed"
+			entry.Error = err.Error()
+			say("FAIL %s: %v", path, err)
+		case len(result.Source) > 0 && !result.SourceChecked:
+			verified++
+			say("ok   %s (source '%s' not found)", path, result.Source)
+		default:
+			verified++
+			say("ok   %s", path)
+		}
+		results = append(results, entry)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if failed > 0 {
+		return fmt.Errorf("%w: %d of %d", errVerifyFailed, failed, verified+failed)
+	}
+	if verified == 0 {
+		say("No generated files found in '%s'", dir)
+	}
+	return nil
+}
@@ -0,0 +1,251 @@
+package passlock
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"time"
+)
+
+const (
+	armorBlockType = "PASSLOCK MULTILOCKER"
+)
+
+var (
+	_ json.Marshaler   = (*MultiLocker)(nil)
+	_ json.Unmarshaler = (*MultiLocker)(nil)
+)
+
+// WriteArmored will write the MultiLocker as a PEM encoded block of base64 text to the io.Writer.
+// This is useful for storing a MultiLocker in text-based systems like git, tickets, or configuration files.
+func (l *MultiLocker) WriteArmored(w io.Writer) error {
+	var buf bytes.Buffer
+	if err := l.Write(&buf); err != nil {
+		return err
+	}
+	return pem.Encode(w, &pem.Block{
+		Type:  armorBlockType,
+		Bytes: buf.Bytes(),
+	})
+}
+
+// ReadArmored will read a MultiLocker written with MultiLocker.WriteArmored from the io.Reader.
+// Any text before or after the PEM block is ignored.
+func ReadArmored(r io.Reader) (*MultiLocker, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	for {
+		var block *pem.Block
+		block, data = pem.Decode(data)
+		if block == nil {
+			return nil, fmt.Errorf("%w: no %s block found", ErrInvalidHeader, armorBlockType)
+		}
+		if block.Type == armorBlockType {
+			return ReadMultiLocker(bytes.NewReader(block.Bytes))
+		}
+	}
+}
+
+type jsonGenerator struct {
+	KDF               string `json:"kdf,omitempty"`
+	KDFParams         []byte `json:"kdfParams,omitempty"`
+	Cipher            string `json:"cipher,omitempty"`
+	Iterations        uint64 `json:"iterations"`
+	RelativeBlockSize uint8  `json:"relativeBlockSize"`
+	CPUCost           uint8  `json:"cpuCost"`
+	KeySize           uint8  `json:"keySize"`
+}
+
+func (j *jsonGenerator) fromGenerator(gen *KeyGenerator) *jsonGenerator {
+	j.KDF = gen.kdf.String()
+	j.KDFParams = gen.kdfParams
+	j.Cipher = gen.cipher.String()
+	j.Iterations = gen.iterations
+	j.RelativeBlockSize = gen.relativeBlockSize
+	j.CPUCost = gen.cpuCost
+	j.KeySize = gen.aesKeySize
+	return j
+}
+
+func (j *jsonGenerator) toGenerator() (*KeyGenerator, error) {
+	if j == nil {
+		return nil, errors.New("missing generator")
+	}
+	switch j.KeySize {
+	case AES128KeySize, AES256KeySize:
+	default:
+		return nil, fmt.Errorf("invalid key size %d", j.KeySize)
+	}
+	kdf := KDFScrypt
+	if len(j.KDF) > 0 {
+		var err error
+		if kdf, err = ParseKDFID(j.KDF); err != nil {
+			return nil, err
+		}
+	}
+	cipherID := CipherAESGCM
+	if len(j.Cipher) > 0 {
+		var err error
+		if cipherID, err = ParseCipherID(j.Cipher); err != nil {
+			return nil, err
+		}
+	}
+	if kdf == KDFScrypt && len(j.KDFParams) > 0 {
+		return nil, fmt.Errorf("unexpected %s parameters", kdf)
+	}
+	if len(j.KDFParams) > math.MaxUint16 {
+		return nil, fmt.Errorf("%s parameters are longer than %d bytes", kdf, math.MaxUint16)
+	}
+	gen := &KeyGenerator{
+		kdf:               kdf,
+		kdfParams:         j.KDFParams,
+		cipher:            cipherID,
+		iterations:        j.Iterations,
+		relativeBlockSize: j.RelativeBlockSize,
+		cpuCost:           j.CPUCost,
+		aesKeySize:        j.KeySize,
+	}
+	if err := gen.checkCipher(); err != nil {
+		return nil, err
+	}
+	return gen, nil
+}
+
+type jsonSurrogateKey struct {
+	Generator     *jsonGenerator `json:"generator"`
+	EncryptedPass []byte         `json:"encryptedPass"`
+	Label         string         `json:"label,omitempty"`
+	Created       *time.Time     `json:"created,omitempty"`
+	Expires       *time.Time     `json:"expires,omitempty"`
+	ReadOnly      bool           `json:"readOnly,omitempty"`
+}
+
+func optionalTime(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &t
+}
+
+func fromOptionalTime(t *time.Time) time.Time {
+	if t == nil {
+		return time.Time{}
+	}
+	return t.Truncate(time.Second).UTC()
+}
+
+type jsonGeneration struct {
+	Generator *jsonGenerator `json:"generator"`
+	Replaced  *time.Time     `json:"replaced,omitempty"`
+	Payload   []byte         `json:"payload"`
+}
+
+type jsonMultiLocker struct {
+	Version       uint16                      `json:"version"`
+	Generator     *jsonGenerator              `json:"generator"`
+	SurrogateKeys map[string]jsonSurrogateKey `json:"surrogateKeys"`
+	Payload       []byte                      `json:"payload"`
+	HistoryLimit  uint8                       `json:"historyLimit,omitempty"`
+	History       []jsonGeneration            `json:"history,omitempty"`
+}
+
+// MarshalJSON will produce a JSON representation of the MultiLocker, including key generation settings.
+// Binary fields are encoded as base64 strings.
+func (l *MultiLocker) MarshalJSON() ([]byte, error) {
+	if err := l.validateHasGenerator(); err != nil {
+		return nil, err
+	}
+	j := jsonMultiLocker{
+		Version:       CurrentFormatVersion,
+		Generator:     new(jsonGenerator).fromGenerator(l.keyGen),
+		SurrogateKeys: make(map[string]jsonSurrogateKey, len(l.surKeys)),
+		Payload:       l.payload,
+		HistoryLimit:  l.historyLimit,
+	}
+	for _, g := range l.history {
+		j.History = append(j.History, jsonGeneration{
+			Generator: new(jsonGenerator).fromGenerator(g.keyGen),
+			Replaced:  optionalTime(g.replaced),
+			Payload:   g.payload,
+		})
+	}
+	for id, sur := range l.surKeys {
+		j.SurrogateKeys[id] = jsonSurrogateKey{
+			Generator:     new(jsonGenerator).fromGenerator(sur.keyGen),
+			EncryptedPass: sur.encryptedPass,
+			Label:         sur.info.label,
+			Created:       optionalTime(sur.info.created),
+			Expires:       optionalTime(sur.info.expires),
+			ReadOnly:      sur.info.readOnly,
+		}
+	}
+	return json.Marshal(j)
+}
+
+// UnmarshalJSON will populate the MultiLocker from a JSON representation produced by MarshalJSON.
+// As with ReadMultiLocker, update is not enabled for the resulting MultiLocker.
+func (l *MultiLocker) UnmarshalJSON(data []byte) error {
+	var j jsonMultiLocker
+	if err := json.Unmarshal(data, &j); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidHeader, err)
+	}
+	if j.Version > CurrentFormatVersion {
+		return &UnsupportedVersionError{Version: j.Version}
+	}
+	gen, err := j.Generator.toGenerator()
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidHeader, err)
+	}
+	surKeys := make(map[string]surrogateKey, len(j.SurrogateKeys))
+	for id, sur := range j.SurrogateKeys {
+		if len(id) > idFieldLen || len(id) == 0 {
+			return fmt.Errorf("%w: surrogate key id '%s' is not within the valid range of 1-%d bytes", ErrInvalidHeader, id, idFieldLen)
+		}
+		surGen, err := sur.Generator.toGenerator()
+		if err != nil {
+			return fmt.Errorf("%w: surrogate key '%s': %v", ErrInvalidHeader, id, err)
+		}
+		if len(sur.Label) > labelFieldLen {
+			return fmt.Errorf("%w: surrogate key '%s' label is longer than the maximum of %d bytes", ErrInvalidHeader, id, labelFieldLen)
+		}
+		surKeys[id] = surrogateKey{
+			encryptedPass: sur.EncryptedPass,
+			keyGen:        surGen,
+			info: surrogateInfo{
+				label:    sur.Label,
+				created:  fromOptionalTime(sur.Created),
+				expires:  fromOptionalTime(sur.Expires),
+				readOnly: sur.ReadOnly,
+			},
+		}
+	}
+	if len(j.History) > int(j.HistoryLimit) {
+		return fmt.Errorf("%w: %d payload generations exceeds the history limit of %d", ErrInvalidHeader, len(j.History), j.HistoryLimit)
+	}
+	var history []payloadGeneration
+	for i, g := range j.History {
+		histGen, err := g.Generator.toGenerator()
+		if err != nil {
+			return fmt.Errorf("%w: payload generation %d: %v", ErrInvalidHeader, i+1, err)
+		}
+		history = append(history, payloadGeneration{
+			payload:  g.Payload,
+			keyGen:   histGen,
+			replaced: fromOptionalTime(g.Replaced),
+		})
+	}
+	*l = MultiLocker{
+		surKeys:      surKeys,
+		payload:      j.Payload,
+		keyGen:       gen,
+		historyLimit: j.HistoryLimit,
+		history:      history,
+	}
+	return nil
+}
@@ -0,0 +1,87 @@
+package passlock
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"strings"
+	"testing"
+)
+
+func newTestMultiLocker(t testing.TB, basePass, surPass Passphrase, payload Plaintext) *MultiLocker {
+	t.Helper()
+	gen, err := NewKeyGenerator(SetShortDelayIterations())
+	require.NoError(t, err)
+	mk := NewMultiLocker(gen)
+	require.NoError(t, mk.Lock(basePass, payload))
+	require.NoError(t, mk.AddSurrogatePass("developer", surPass))
+	mk.DisableUpdate()
+	return mk
+}
+
+func TestMultiLocker_WriteArmored(t *testing.T) {
+	var (
+		basePass = Passphrase("base key pass")
+		surPass  = Passphrase("sur key pass")
+		payload  = Plaintext("test payload")
+		buf      bytes.Buffer
+	)
+	mk := newTestMultiLocker(t, basePass, surPass, payload)
+
+	buf.WriteString("Some leading text\n")
+	require.NoError(t, mk.WriteArmored(&buf))
+	buf.WriteString("Some trailing text\n")
+	assert.Contains(t, buf.String(), "-----BEGIN PASSLOCK MULTILOCKER-----")
+
+	read, err := ReadArmored(&buf)
+	require.NoError(t, err)
+	plaintext, err := read.SurrogateUnlock("developer", surPass)
+	assert.NoError(t, err)
+	assert.Equal(t, payload, plaintext)
+
+	_, err = ReadArmored(strings.NewReader("no armor here"))
+	assert.ErrorIs(t, err, ErrInvalidHeader)
+}
+
+func TestMultiLocker_MarshalJSON(t *testing.T) {
+	var (
+		basePass = Passphrase("base key pass")
+		surPass  = Passphrase("sur key pass")
+		payload  = Plaintext("test payload")
+	)
+	mk := newTestMultiLocker(t, basePass, surPass, payload)
+
+	data, err := json.Marshal(mk)
+	require.NoError(t, err)
+
+	read := new(MultiLocker)
+	require.NoError(t, json.Unmarshal(data, read))
+	assert.Equal(t, mk.ListKeyIDs(), read.ListKeyIDs())
+	plaintext, err := read.SurrogateUnlock("developer", surPass)
+	assert.NoError(t, err)
+	assert.Equal(t, payload, plaintext)
+	plaintext, err = read.Unlock(basePass)
+	assert.NoError(t, err)
+	assert.Equal(t, payload, plaintext)
+	assert.Error(t, read.AddSurrogatePass("other", surPass), "Update should not be enabled after unmarshalling")
+}
+
+func TestMultiLocker_UnmarshalJSON_Neg(t *testing.T) {
+	tests := map[string]struct {
+		input    string
+		expected error
+	}{
+		"Invalid JSON":      {`{`, ErrInvalidHeader},
+		"Missing generator": {`{"payload":"AAAA"}`, ErrInvalidHeader},
+		"Invalid key size":  {`{"generator":{"iterations":2,"relativeBlockSize":8,"cpuCost":1,"keySize":3}}`, ErrInvalidHeader},
+		"Unknown KDF":       {`{"generator":{"kdf":"argon2id","iterations":2,"relativeBlockSize":8,"cpuCost":1,"keySize":32}}`, ErrInvalidHeader},
+		"Future version":    {`{"version":65535}`, ErrUnsupportedVersion},
+		"Invalid ID":        {`{"generator":{"iterations":2,"relativeBlockSize":8,"cpuCost":1,"keySize":32},"surrogateKeys":{"":{}}}`, ErrInvalidHeader},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert.ErrorIs(t, new(MultiLocker).UnmarshalJSON([]byte(tc.input)), tc.expected)
+		})
+	}
+}
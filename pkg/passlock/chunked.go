@@ -0,0 +1,130 @@
+package passlock
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+)
+
+const (
+	// DefaultChunkSize is the size of each plaintext chunk used by LockLarge.
+	DefaultChunkSize uint32 = 1 << 26
+	// MaxPlaintextSize is the largest Plaintext that may be encrypted with Lock, which is the limit of a single AES-GCM operation.
+	// Use LockLarge for larger payloads.
+	MaxPlaintextSize uint64 = ((1 << 32) - 2) * 16
+
+	chunkHeaderSize = 4 + 8
+)
+
+var (
+	ErrPayloadTooLarge = errors.New("payload is too large to encrypt in a single operation")
+)
+
+func checkPlaintextSize(size uint64) error {
+	if size > MaxPlaintextSize {
+		return fmt.Errorf("%w: %d bytes exceeds the limit of %d, use LockLarge instead", ErrPayloadTooLarge, size, MaxPlaintextSize)
+	}
+	return nil
+}
+
+// LockLarge will split the payload into ordered chunks of DefaultChunkSize, encrypting each with the given Key, and append the Salt to the output.
+// This allows encrypting payloads larger than MaxPlaintextSize, which is the limit for Lock.
+// The chunk size and count are stored in a header, and each chunk is authenticated with the header and its position.
+// This prevents chunks from being reordered, truncated, or duplicated without detection.
+// A payload locked with LockLarge must be unlocked with UnlockLarge.
+func LockLarge(key Key, salt Salt, data Plaintext) (Encrypted, error) {
+	return lockChunked(rand.Reader, key, salt, data, DefaultChunkSize)
+}
+
+// LockLargeWithSource is the same as LockLarge, except that nonces are read from the given random source.
+func LockLargeWithSource(random io.Reader, key Key, salt Salt, data Plaintext) (Encrypted, error) {
+	return lockChunked(random, key, salt, data, DefaultChunkSize)
+}
+
+// UnlockLarge will decrypt and reassemble a payload created with LockLarge.
+func UnlockLarge(key Key, data Encrypted) (Plaintext, error) {
+	gcm, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < chunkHeaderSize+len(key) {
+		return nil, fmt.Errorf("%w: input data isn't long enough to contain a chunk header", ErrInvalidData)
+	}
+	header := data[:chunkHeaderSize]
+	chunkSize := binary.BigEndian.Uint32(header)
+	chunkCount := binary.BigEndian.Uint64(header[4:])
+	if chunkSize == 0 || chunkCount == 0 {
+		return nil, fmt.Errorf("%w: invalid chunk header", ErrInvalidData)
+	}
+	data = data[chunkHeaderSize : len(data)-len(key)]
+
+	sealOverhead := uint64(gcm.NonceSize() + gcm.Overhead())
+	sealedChunkSize := sealOverhead + uint64(chunkSize)
+	if chunkCount > math.MaxUint64/sealedChunkSize {
+		return nil, fmt.Errorf("%w: invalid chunk count", ErrInvalidData)
+	}
+	// Every chunk before the last is full, and the last holds at least a nonce and tag, which is all an empty payload seals to.
+	// This also ensures that sizing the output can't underflow.
+	if uint64(len(data)) > chunkCount*sealedChunkSize || uint64(len(data)) < (chunkCount-1)*sealedChunkSize+sealOverhead {
+		return nil, fmt.Errorf("%w: payload length doesn't match chunk header", ErrInvalidData)
+	}
+	out := make(Plaintext, 0, uint64(len(data))-chunkCount*sealOverhead)
+	for i := uint64(0); i < chunkCount; i++ {
+		sealed := data
+		if uint64(len(sealed)) > sealedChunkSize {
+			sealed = sealed[:sealedChunkSize]
+		}
+		data = data[len(sealed):]
+		nonceSize := gcm.NonceSize()
+		if len(sealed) < nonceSize+gcm.Overhead() {
+			return nil, fmt.Errorf("%w: chunk %d is truncated", ErrInvalidData, i)
+		}
+		out, err = gcm.Open(out, sealed[:nonceSize], sealed[nonceSize:], chunkAAD(header, i))
+		if err != nil {
+			return nil, fmt.Errorf("failed to decrypt chunk %d: %w", i, err)
+		}
+	}
+	return out, nil
+}
+
+func lockChunked(random io.Reader, key Key, salt Salt, data Plaintext, chunkSize uint32) (Encrypted, error) {
+	gcm, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+	chunkCount := (uint64(len(data)) + uint64(chunkSize) - 1) / uint64(chunkSize)
+	if chunkCount == 0 {
+		chunkCount = 1
+	}
+	header := make([]byte, chunkHeaderSize)
+	binary.BigEndian.PutUint32(header, chunkSize)
+	binary.BigEndian.PutUint64(header[4:], chunkCount)
+
+	out := make(Encrypted, 0, uint64(chunkHeaderSize)+uint64(len(data))+chunkCount*uint64(gcm.NonceSize()+gcm.Overhead())+uint64(len(salt)))
+	out = append(out, header...)
+	nonce := make([]byte, gcm.NonceSize())
+	for i := uint64(0); i < chunkCount; i++ {
+		chunk := data
+		if uint64(len(chunk)) > uint64(chunkSize) {
+			chunk = chunk[:chunkSize]
+		}
+		data = data[len(chunk):]
+		if _, err := io.ReadFull(random, nonce); err != nil {
+			return nil, err
+		}
+		out = append(out, nonce...)
+		out = gcm.Seal(out, nonce, chunk, chunkAAD(header, i))
+	}
+	return append(out, salt...), nil
+}
+
+// chunkAAD binds a chunk to the header and its position in the payload.
+func chunkAAD(header []byte, index uint64) []byte {
+	aad := make([]byte, len(header)+8)
+	copy(aad, header)
+	binary.BigEndian.PutUint64(aad[len(header):], index)
+	return aad
+}
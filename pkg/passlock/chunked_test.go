@@ -0,0 +1,114 @@
+package passlock
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"math"
+	"testing"
+)
+
+func TestLockUnlockLarge(t *testing.T) {
+	const password = "password"
+	const data = "How wonderful life is while you're in the world"
+
+	gen, err := NewKeyGenerator(SetShortDelayIterations())
+	require.NoError(t, err)
+	key, salt, err := gen.GenerateKey([]byte(password))
+	require.NoError(t, err)
+
+	encrypted, err := LockLarge(key, salt, []byte(data))
+	require.NoError(t, err)
+	key2, err := gen.DeriveKey([]byte(password), encrypted)
+	require.NoError(t, err)
+	unencrypted, err := UnlockLarge(key2, encrypted)
+	assert.NoError(t, err)
+	assert.Equal(t, data, string(unencrypted))
+}
+
+func TestLockChunked(t *testing.T) {
+	key := make(Key, AES256KeySize)
+	salt := make(Salt, AES256KeySize)
+	data := make(Plaintext, 100)
+	_, err := rand.Read(data)
+	require.NoError(t, err)
+
+	for _, size := range []int{0, 1, 15, 16, 17, 100} {
+		encrypted, err := lockChunked(rand.Reader, key, salt, data[:size], 16)
+		require.NoError(t, err)
+		unencrypted, err := UnlockLarge(key, encrypted)
+		assert.NoError(t, err)
+		assert.Equal(t, data[:size], unencrypted)
+	}
+}
+
+func TestUnlockLarge_Tampered(t *testing.T) {
+	const chunkSize = 16
+	key := make(Key, AES256KeySize)
+	salt := make(Salt, AES256KeySize)
+	data := make(Plaintext, 5*chunkSize)
+	encrypted, err := lockChunked(rand.Reader, key, salt, data, chunkSize)
+	require.NoError(t, err)
+	sealedSize := 12 + chunkSize + 16
+	chunks := func(e Encrypted) [][]byte {
+		var out [][]byte
+		body := e[chunkHeaderSize : len(e)-len(salt)]
+		for len(body) > 0 {
+			out = append(out, body[:sealedSize])
+			body = body[sealedSize:]
+		}
+		return out
+	}
+	assemble := func(header []byte, parts ...[]byte) Encrypted {
+		out := append(Encrypted{}, header...)
+		for _, p := range parts {
+			out = append(out, p...)
+		}
+		return append(out, salt...)
+	}
+	header := encrypted[:chunkHeaderSize]
+	parts := chunks(encrypted)
+	require.Len(t, parts, 5)
+
+	t.Run("Reordered", func(t *testing.T) {
+		_, err := UnlockLarge(key, assemble(header, parts[1], parts[0], parts[2], parts[3], parts[4]))
+		assert.Error(t, err)
+	})
+	t.Run("Truncated", func(t *testing.T) {
+		_, err := UnlockLarge(key, assemble(header, parts[:4]...))
+		assert.ErrorIs(t, err, ErrInvalidData)
+	})
+	t.Run("Truncated with header", func(t *testing.T) {
+		modified := append([]byte{}, header...)
+		modified[len(modified)-1] = 4
+		_, err := UnlockLarge(key, assemble(modified, parts[:4]...))
+		assert.Error(t, err)
+	})
+	t.Run("Duplicated", func(t *testing.T) {
+		_, err := UnlockLarge(key, assemble(header, parts[0], parts[0], parts[2], parts[3], parts[4]))
+		assert.Error(t, err)
+	})
+	t.Run("Short", func(t *testing.T) {
+		_, err := UnlockLarge(key, encrypted[:chunkHeaderSize])
+		assert.ErrorIs(t, err, ErrInvalidData)
+	})
+	t.Run("Short final chunk", func(t *testing.T) {
+		short := make([]byte, chunkHeaderSize+17+int(AES128KeySize))
+		binary.BigEndian.PutUint32(short, 1)
+		binary.BigEndian.PutUint64(short[4:], 1)
+		_, err := UnlockLarge(make(Key, AES128KeySize), short)
+		assert.ErrorIs(t, err, ErrInvalidData, "A final chunk shorter than a nonce and tag should be rejected")
+	})
+	t.Run("Overflowing count", func(t *testing.T) {
+		modified := append([]byte{}, header...)
+		binary.BigEndian.PutUint64(modified[4:], math.MaxUint64/uint64(sealedSize)+1)
+		_, err := UnlockLarge(key, assemble(modified, parts...))
+		assert.ErrorIs(t, err, ErrInvalidData)
+	})
+}
+
+func TestCheckPlaintextSize(t *testing.T) {
+	assert.NoError(t, checkPlaintextSize(MaxPlaintextSize))
+	assert.ErrorIs(t, checkPlaintextSize(MaxPlaintextSize+1), ErrPayloadTooLarge)
+}
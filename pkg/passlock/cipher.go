@@ -0,0 +1,153 @@
+package passlock
+
+import (
+	"crypto/cipher"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	bin "github.com/saylorsolutions/binmap"
+	"golang.org/x/crypto/chacha20poly1305"
+	"io"
+)
+
+var (
+	ErrUnknownCipher = errors.New("unknown cipher")
+)
+
+// CipherID identifies the AEAD cipher used to encrypt payloads with a KeyGenerator's keys, and is persisted with its settings.
+type CipherID uint8
+
+const (
+	// CipherAESGCM identifies [AES-GCM] with a 96-bit random nonce, which is the default and is used by Lock and Unlock.
+	//
+	// [AES-GCM]: https://en.wikipedia.org/wiki/Galois/Counter_Mode
+	CipherAESGCM CipherID = 1
+	// CipherXChaCha20Poly1305 identifies [XChaCha20-Poly1305] with a 192-bit random nonce.
+	// The larger nonce makes collisions negligible even when a very large number of payloads are encrypted with the same Key.
+	//
+	// [XChaCha20-Poly1305]: https://en.wikipedia.org/wiki/ChaCha20-Poly1305#XChaCha20-Poly1305_%E2%80%93_extended_nonce_variant
+	CipherXChaCha20Poly1305 CipherID = 2
+)
+
+func (id CipherID) String() string {
+	switch id {
+	case CipherAESGCM:
+		return "aes-gcm"
+	case CipherXChaCha20Poly1305:
+		return "xchacha20-poly1305"
+	default:
+		return fmt.Sprintf("unknown(%d)", uint8(id))
+	}
+}
+
+// ParseCipherID returns the CipherID for the given name, as returned by CipherID.String.
+func ParseCipherID(name string) (CipherID, error) {
+	switch name {
+	case "aes-gcm":
+		return CipherAESGCM, nil
+	case "xchacha20-poly1305":
+		return CipherXChaCha20Poly1305, nil
+	default:
+		return 0, fmt.Errorf("%w: '%s'", ErrUnknownCipher, name)
+	}
+}
+
+// SetXChaCha20Poly1305 uses XChaCha20-Poly1305 to encrypt payloads instead of AES-GCM.
+// This is recommended when a very large number of payloads will be encrypted with the same Key.
+// XChaCha20-Poly1305 requires a 256-bit key, so this can't be used with SetAES128KeySize.
+func SetXChaCha20Poly1305() GeneratorOpt {
+	return func(gen *KeyGenerator) error {
+		gen.cipher = CipherXChaCha20Poly1305
+		return nil
+	}
+}
+
+// Cipher returns the CipherID of the cipher used to encrypt payloads with this KeyGenerator's keys.
+func (g *KeyGenerator) Cipher() CipherID {
+	return g.cipher
+}
+
+// NewLocker creates a Locker for the given Key and Salt that uses this KeyGenerator's cipher.
+// Payloads encrypted with CipherXChaCha20Poly1305 can't be decrypted with Unlock, so a Locker created this way must be used instead.
+func (g *KeyGenerator) NewLocker(key Key, salt Salt) (*Locker, error) {
+	aead, err := g.newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+	return &Locker{
+		keyLen: len(key),
+		salt:   salt,
+		aead:   aead,
+	}, nil
+}
+
+func (g *KeyGenerator) newAEAD(key Key) (cipher.AEAD, error) {
+	switch g.cipher {
+	case CipherAESGCM:
+		return newAEAD(key)
+	case CipherXChaCha20Poly1305:
+		return chacha20poly1305.NewX(key)
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnknownCipher, g.cipher)
+	}
+}
+
+func (g *KeyGenerator) checkCipher() error {
+	switch g.cipher {
+	case CipherAESGCM:
+	case CipherXChaCha20Poly1305:
+		if int(g.aesKeySize) != chacha20poly1305.KeySize {
+			return fmt.Errorf("%s requires a %d byte key", g.cipher, chacha20poly1305.KeySize)
+		}
+	default:
+		return fmt.Errorf("%w: %s", ErrUnknownCipher, g.cipher)
+	}
+	return nil
+}
+
+// lock encrypts the payload with this KeyGenerator's cipher and random source.
+func (g *KeyGenerator) lock(key Key, salt Salt, data Plaintext) (Encrypted, error) {
+	l, err := g.NewLocker(key, salt)
+	if err != nil {
+		return nil, err
+	}
+	return l.SealWithSource(g.randomSource(), data)
+}
+
+// unlock decrypts the payload with this KeyGenerator's cipher.
+func (g *KeyGenerator) unlock(key Key, data Encrypted) (Plaintext, error) {
+	l, err := g.NewLocker(key, nil)
+	if err != nil {
+		return nil, err
+	}
+	return l.Open(data)
+}
+
+// cipherMapper maps the CipherID from cipherFormatVersion, and assumes CipherAESGCM for earlier versions.
+func (g *KeyGenerator) cipherMapper(version uint16) bin.Mapper {
+	if version >= cipherFormatVersion {
+		return bin.MapSequence(
+			bin.Byte((*uint8)(&g.cipher)),
+			bin.Any(
+				func(_ io.Reader, _ binary.ByteOrder) error {
+					return g.checkCipher()
+				},
+				func(_ io.Writer, _ binary.ByteOrder) error {
+					return nil
+				},
+			),
+		)
+	}
+	return bin.Any(
+		func(_ io.Reader, _ binary.ByteOrder) error {
+			g.cipher = CipherAESGCM
+			return nil
+		},
+		func(_ io.Writer, _ binary.ByteOrder) error {
+			if g.cipher != CipherAESGCM {
+				return fmt.Errorf("%w: format version %d only supports %s", ErrUnknownCipher, version, CipherAESGCM)
+			}
+			return nil
+		},
+	)
+}
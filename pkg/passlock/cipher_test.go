@@ -0,0 +1,87 @@
+package passlock
+
+import (
+	"bytes"
+	"encoding/binary"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/chacha20poly1305"
+	"testing"
+)
+
+func TestKeyGenerator_NewLocker_XChaCha20Poly1305(t *testing.T) {
+	const password = "password"
+	const data = "How wonderful life is while you're in the world"
+	gen, err := NewKeyGenerator(SetShortDelayIterations(), SetXChaCha20Poly1305())
+	require.NoError(t, err)
+	assert.Equal(t, CipherXChaCha20Poly1305, gen.Cipher())
+	key, salt, err := gen.GenerateKey([]byte(password))
+	require.NoError(t, err)
+
+	l, err := gen.NewLocker(key, salt)
+	require.NoError(t, err)
+	encrypted, err := l.Seal([]byte(data))
+	require.NoError(t, err)
+	assert.Len(t, encrypted, chacha20poly1305.NonceSizeX+len(data)+chacha20poly1305.Overhead+len(salt))
+
+	key2, err := gen.DeriveKey([]byte(password), encrypted)
+	require.NoError(t, err)
+	l, err = gen.NewLocker(key2, nil)
+	require.NoError(t, err)
+	unencrypted, err := l.Open(encrypted)
+	require.NoError(t, err)
+	assert.Equal(t, data, string(unencrypted))
+
+	_, err = Unlock(key2, encrypted)
+	assert.Error(t, err, "AES-GCM should not open an XChaCha20-Poly1305 payload")
+}
+
+func TestMultiLocker_XChaCha20Poly1305(t *testing.T) {
+	var (
+		basePass = Passphrase("base key pass")
+		surPass  = Passphrase("sur key pass")
+		payload  = Plaintext("test payload")
+	)
+	gen, err := NewKeyGenerator(SetShortDelayIterations(), SetXChaCha20Poly1305())
+	require.NoError(t, err)
+	mk := NewMultiLocker(gen)
+	require.NoError(t, mk.Lock(basePass, payload))
+	require.NoError(t, mk.AddSurrogatePass("developer", surPass))
+
+	for name, codec := range map[string]Codec{"Binary": BinaryCodec, "JSON": JSONCodec, "Protobuf": ProtobufCodec} {
+		t.Run(name, func(t *testing.T) {
+			var buf bytes.Buffer
+			require.NoError(t, codec.Encode(&buf, mk))
+			read, err := codec.Decode(&buf)
+			require.NoError(t, err)
+			assert.Equal(t, CipherXChaCha20Poly1305, read.keyGen.Cipher())
+
+			data, err := read.Unlock(basePass)
+			require.NoError(t, err)
+			assert.Equal(t, payload, data)
+			data, err = read.SurrogateUnlock("developer", surPass)
+			require.NoError(t, err)
+			assert.Equal(t, payload, data)
+		})
+	}
+}
+
+func TestSetXChaCha20Poly1305_Neg(t *testing.T) {
+	_, err := NewKeyGenerator(SetXChaCha20Poly1305(), SetAES128KeySize())
+	assert.Error(t, err, "XChaCha20-Poly1305 requires a 256-bit key")
+
+	gen, err := NewKeyGenerator(SetShortDelayIterations(), SetXChaCha20Poly1305())
+	require.NoError(t, err)
+	var buf bytes.Buffer
+	assert.ErrorIs(t, gen.mapper(historyFormatVersion).Write(&buf, binary.BigEndian), ErrUnknownCipher, "Older formats can't record the cipher")
+
+	gen.cipher = 0xff
+	_, err = gen.NewLocker(make(Key, AES256KeySize), nil)
+	assert.ErrorIs(t, err, ErrUnknownCipher)
+
+	id, err := ParseCipherID(CipherXChaCha20Poly1305.String())
+	assert.NoError(t, err)
+	assert.Equal(t, CipherXChaCha20Poly1305, id)
+	_, err = ParseCipherID("rot13")
+	assert.ErrorIs(t, err, ErrUnknownCipher)
+}
@@ -0,0 +1,61 @@
+package passlock
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// Codec encodes and decodes a MultiLocker for persistence in a particular format.
+// This allows a MultiLocker to be stored in systems that prefer structured formats.
+type Codec interface {
+	// Encode writes the MultiLocker to the io.Writer.
+	Encode(w io.Writer, l *MultiLocker) error
+	// Decode reads a MultiLocker from the io.Reader.
+	Decode(r io.Reader) (*MultiLocker, error)
+}
+
+var (
+	// BinaryCodec uses the binary format of MultiLocker.Write and ReadMultiLocker. This is the default format.
+	BinaryCodec Codec = binaryCodec{}
+	// ArmoredCodec uses the PEM encoded format of MultiLocker.WriteArmored and ReadArmored.
+	ArmoredCodec Codec = armoredCodec{}
+	// JSONCodec uses the JSON representation of MultiLocker.MarshalJSON.
+	JSONCodec Codec = jsonCodec{}
+	// ProtobufCodec uses a protocol buffers encoding, see the documentation for ProtobufCodec.Encode for the schema.
+	ProtobufCodec Codec = protobufCodec{}
+)
+
+type binaryCodec struct{}
+
+func (binaryCodec) Encode(w io.Writer, l *MultiLocker) error {
+	return l.Write(w)
+}
+
+func (binaryCodec) Decode(r io.Reader) (*MultiLocker, error) {
+	return ReadMultiLocker(r)
+}
+
+type armoredCodec struct{}
+
+func (armoredCodec) Encode(w io.Writer, l *MultiLocker) error {
+	return l.WriteArmored(w)
+}
+
+func (armoredCodec) Decode(r io.Reader) (*MultiLocker, error) {
+	return ReadArmored(r)
+}
+
+type jsonCodec struct{}
+
+func (jsonCodec) Encode(w io.Writer, l *MultiLocker) error {
+	return json.NewEncoder(w).Encode(l)
+}
+
+func (jsonCodec) Decode(r io.Reader) (*MultiLocker, error) {
+	l := new(MultiLocker)
+	if err := json.NewDecoder(r).Decode(l); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidHeader, err)
+	}
+	return l, nil
+}
@@ -0,0 +1,102 @@
+package passlock
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCodec_RoundTrip(t *testing.T) {
+	var (
+		basePass = Passphrase("base key pass")
+		surPass  = Passphrase("sur key pass")
+		payload  = Plaintext("test payload")
+	)
+	mk := newTestMultiLocker(t, basePass, surPass, payload)
+	require.NoError(t, mk.EnableUpdate(basePass))
+	require.NoError(t, mk.AddSurrogatePass("labeled", surPass, WithLabel("A label"), WithExpiry(time.Now().Add(time.Hour)), ReadOnly()))
+	require.NoError(t, mk.SetHistoryLimit(2))
+	require.NoError(t, mk.Lock(basePass, Plaintext("new payload")))
+	require.NoError(t, mk.Lock(basePass, payload))
+	mk.DisableUpdate()
+
+	tests := map[string]Codec{
+		"Binary":   BinaryCodec,
+		"Armored":  ArmoredCodec,
+		"JSON":     JSONCodec,
+		"Protobuf": ProtobufCodec,
+	}
+
+	for name, codec := range tests {
+		t.Run(name, func(t *testing.T) {
+			var buf bytes.Buffer
+			require.NoError(t, codec.Encode(&buf, mk))
+
+			read, err := codec.Decode(&buf)
+			require.NoError(t, err)
+			assert.Equal(t, mk.ListKeys(KeyFilter{}), read.ListKeys(KeyFilter{}))
+			assert.Equal(t, mk.History(), read.History())
+
+			data, err := read.UnlockGeneration(1, basePass)
+			require.NoError(t, err)
+			assert.Equal(t, Plaintext("new payload"), data)
+
+			data, err = read.Unlock(basePass)
+			require.NoError(t, err)
+			assert.Equal(t, payload, data)
+
+			data, err = read.SurrogateUnlock("developer", surPass)
+			require.NoError(t, err)
+			assert.Equal(t, payload, data)
+		})
+	}
+}
+
+func TestProtobufCodec_UnknownFields(t *testing.T) {
+	mk := newTestMultiLocker(t, Passphrase("base key pass"), Passphrase("sur key pass"), Plaintext("test payload"))
+	var buf bytes.Buffer
+	require.NoError(t, ProtobufCodec.Encode(&buf, mk))
+
+	data := buf.Bytes()
+	data = pbAppendVarintField(data, 15, 42)
+	data = pbAppendBytesField(data, 16, []byte("ignored"))
+	data = append(data, 13<<3|pbFixed32, 1, 2, 3, 4)
+	data = append(data, 14<<3|pbFixed64, 1, 2, 3, 4, 5, 6, 7, 8)
+
+	read, err := ProtobufCodec.Decode(bytes.NewReader(data))
+	require.NoError(t, err)
+	result, err := read.Unlock(Passphrase("base key pass"))
+	require.NoError(t, err)
+	assert.Equal(t, Plaintext("test payload"), result)
+}
+
+func TestProtobufCodec_Decode_Neg(t *testing.T) {
+	mk := newTestMultiLocker(t, Passphrase("base key pass"), Passphrase("sur key pass"), Plaintext("test payload"))
+	var buf bytes.Buffer
+	require.NoError(t, ProtobufCodec.Encode(&buf, mk))
+	valid := buf.Bytes()
+
+	tests := map[string][]byte{
+		"Truncated":     valid[:len(valid)-1],
+		"No generator":  pbAppendBytesField(nil, 4, []byte("payload")),
+		"Bad key size":  pbAppendBytesField(nil, 2, pbAppendVarintField(nil, 4, 3)),
+		"Bad wire type": {1<<3 | 7},
+		"Field zero":    {0},
+	}
+
+	for name, data := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := ProtobufCodec.Decode(bytes.NewReader(data))
+			assert.ErrorIs(t, err, ErrInvalidHeader)
+		})
+	}
+
+	t.Run("Future version", func(t *testing.T) {
+		data := pbAppendVarintField(nil, 1, uint64(CurrentFormatVersion)+1)
+		_, err := ProtobufCodec.Decode(bytes.NewReader(data))
+		assert.ErrorIs(t, err, ErrUnsupportedVersion)
+	})
+}
@@ -29,21 +29,50 @@ A [MultiLocker] is created using a KeyGenerator, and the encrypted payload is se
 Once created, surrogate keys may be added to the [MultiLocker] that allow reading the encrypted payload.
 A [MultiLocker] with surrogate keys and encrypted payload may be persisted to disk in binary form and read back, including key generation settings.
 
+The binary form starts with a format version header. [ReadMultiLocker] only accepts the current format version, while [ReadMultiLockerAnyVersion] also accepts older layouts, including the layout written before format versions were introduced.
+Every format version listed by [SupportedFormats] is guaranteed to remain readable by future releases of this package, and only the current format version is written.
+[ReadMultiLockerFormats] constrains the format versions that a program will accept, and custom formats may be added with [RegisterFormat] and written with [MultiLocker.WriteFormat].
+
 A freshly read [MultiLocker] may not be changed in any way. Editing is enabled by calling [MultiLocker.EnableUpdate] with the base pass phrase.
 After this call completes successfully, surrogate keys may be added or removed.
 A new encrypted payload may only be set in a [MultiLocker] with the base pass phrase.
 A WriteMultiLocker allows surrogate passphrases to be used to lock a new payload, instead of just the base pass phrase.
 
+Each surrogate key records the key generation settings used to create it, independent of the payload settings.
+This allows [MultiLocker.SetUpgradeGenerator] to transparently strengthen key derivation parameters as each passphrase is used to unlock the payload.
+
+Surrogate keys may also be given a label, an expiry time, and a read only flag with SurrogateOpt functions when they're added.
+[MultiLocker.ListKeys] returns this metadata with optional filtering, which allows auditing access without unlocking the payload.
+An expired surrogate key can't be used to unlock the payload, and a read only surrogate key can't be used with WriteMultiLocker.SurrogateLock.
+
+A [MultiLocker] may retain a bounded number of previous payload generations with [MultiLocker.SetHistoryLimit].
+This provides a safety net against accidentally overwriting the payload, since a previous generation may be recovered with UnlockGeneration or SurrogateUnlockGeneration.
+
 # General guidelines:
   - It's possible to customize the CPU cost, iteration count, and relative block size parameters directly for key generation. If you don't know what you're doing, then don't use SetIterations, SetCPUCost, or SetRelativeBlockSize.
   - Both short and long delay iteration GeneratorOpt functions are provided, choose the correct iterations for your use-case using either SetLongDelayIterations or SetShortDelayIterations.
+  - scrypt memory use grows with the iteration count and relative block size, and DefaultLargeIterations requires a very large amount of memory. Use SetMaxMemory to fail with ErrMemoryBudget instead of exhausting system memory, and KeyGenerator.MemoryRequired to check the requirement up front. Settings read with a [MultiLocker] come from storage, so use SetDefaultMaxMemory to apply a budget to them as well.
   - If encrypted data is intended to be stored and available for a long time, choose the SetLongDelayIterations option for key generation.
-  - This method of encryption ([AES-GCM]) supports encrypting and authenticating at most about 64GB at a time. You could get around this by splitting a very large file into multiple chunks that include some metadata to prevent reordering or truncating.
+  - This method of encryption ([AES-GCM]) supports encrypting and authenticating at most about 64GB at a time. Use LockLarge and UnlockLarge to split a very large payload into multiple authenticated chunks that can't be reordered or truncated.
+  - AES-GCM uses 96-bit random nonces, which become statistically risky after a very large number of payloads are encrypted with the same Key. Use SetXChaCha20Poly1305 for high-volume use cases, which uses 192-bit nonces and is recorded with the KeyGenerator settings. Use KeyGenerator.NewLocker to encrypt and decrypt with the KeyGenerator's cipher.
   - AES-256 is a good default for a lot of cases, with excellent security and good throughput speeds.
   - This library supports AES-256 since that is the best supported by the Go standard lib, but AES-128 may also be used for situations where more throughput is desired.
   - The main limit to operation speed comes from key generation (as intended), the AES key size makes a much smaller impact to performance unless a very large payload is encrypted.
+  - When many payloads are encrypted or decrypted with the same Key, create a [Locker] once with NewLocker and use Locker.Seal and Locker.Open to avoid rebuilding the cipher for each payload.
+  - Use LockEnvelope and UnlockEnvelope to encrypt a payload with a random data key that is wrapped by the passphrase derived Key. Changing the passphrase with RewrapEnvelope doesn't require re-encrypting the payload.
+  - Use LockWithMAC and UnlockWithMAC to additionally authenticate the nonce and trailing salt with an HMAC keyed by an independent subkey. This is not required for confidentiality, but detects tampering before decryption is attempted.
+  - A [GuardedKey] may be used to keep a Key in locked memory with guard pages, which excludes it from swap and (where supported) core dumps. Pass GuardedKey.Key to Lock or Unlock, and always call GuardedKey.Destroy when finished.
+  - LockFile and UnlockFile encrypt and decrypt whole files, and read the pass phrase from a [PassSource] like PassFromFD, PassFromEnv, or PassFromPrompt. Output is written atomically, so an incorrect pass phrase never leaves a partial file behind.
+  - The SetRandomSource option and LockWithSource function exist to produce reproducible output for tests. Never use a predictable random source for real data.
+  - A [MultiLocker] may be persisted with any [Codec]. BinaryCodec is the default format, and ArmoredCodec, JSONCodec, and ProtobufCodec are provided for systems that prefer structured or text formats.
+  - Use MultiLocker.SetUnlockObserver to receive an UnlockEvent for every unlock attempt, which includes the outcome, duration, key generation settings, and a hash of the surrogate key ID. SlogUnlockObserver writes these events to a slog.Logger, so security monitoring can detect brute-force attempts.
+  - Use EqualSecret rather than bytes.Equal to compare passphrases, keys, or other secret values, so their contents aren't leaked through timing differences.
+  - An organization's approved key derivation function may be used in place of scrypt by implementing the [KDF] interface, registering it with RegisterKDF, and selecting it with SetKDF. The KDF's ID and parameters are persisted with the KeyGenerator settings, so it must be registered before a [MultiLocker] using it can be read.
+  - Fuzz targets for Unlock and ReadMultiLocker are included with the package tests, and may be run from a dependent module before handling hostile input, such as with "go test -fuzz=FuzzReadMultiLocker github.com/saylorsolutions/gocryptx/pkg/passlock".
   - When deriving the key from an encrypted payload, make sure that the same KeyGenerator settings are used. Not doing so will result in an incorrect key.
+  - KeyGenerator.DeriveKeyWithSalt derives a key with a caller-provided salt, for deterministic keys. A fixed salt allows guesses to be precomputed, so prefer GenerateKey with a random salt whenever the salt can be stored.
   - Technically, a surrogate key could be used to update a [MultiLocker] encrypted payload without invalidating other surrogate keys, since there are no cryptographic blockers to that. The base [MultiLocker] doesn't provide that function as a logical constraint only.
+  - Use MultiLocker.ExportBaseMnemonic to print the base pass phrase as a checksummed word list for offline disaster recovery, and MultiLocker.EnableUpdateMnemonic to enable update with it later. Store the word list as securely as the base pass phrase itself.
   - The [MultiLocker] base key may be updated without invalidating all surrogate keys, because the base key's pass phrase is what is encrypted in surrogate key payloads. Reusing salt values is insecure.
 
 [scrypt]: https://en.wikipedia.org/wiki/Scrypt
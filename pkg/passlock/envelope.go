@@ -0,0 +1,100 @@
+package passlock
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"fmt"
+	"io"
+)
+
+const (
+	wrappedLenSize = 2
+)
+
+// LockEnvelope encrypts the payload with a randomly generated data encryption key (DEK), and wraps the DEK with the given Key.
+// The wrapped DEK is stored in a header before the encrypted payload, and the Salt is appended to the end as with Lock.
+// This allows changing the passphrase with RewrapEnvelope without re-encrypting the payload, which is much faster for large payloads.
+// A payload locked with LockEnvelope must be unlocked with UnlockEnvelope.
+func LockEnvelope(key Key, salt Salt, data Plaintext) (Encrypted, error) {
+	return LockEnvelopeWithSource(rand.Reader, key, salt, data)
+}
+
+// LockEnvelopeWithSource is the same as LockEnvelope, except that the DEK and nonces are read from the given random source.
+func LockEnvelopeWithSource(random io.Reader, key Key, salt Salt, data Plaintext) (Encrypted, error) {
+	dek := make(Key, len(key))
+	if _, err := io.ReadFull(random, dek); err != nil {
+		return nil, err
+	}
+	defer wipe(dek)
+	dekAEAD, err := newAEAD(dek)
+	if err != nil {
+		return nil, err
+	}
+	payload, err := seal(random, dekAEAD, data)
+	if err != nil {
+		return nil, err
+	}
+	return wrapEnvelope(random, key, salt, dek, payload)
+}
+
+// UnlockEnvelope unwraps the DEK in a payload created with LockEnvelope, and uses it to decrypt the payload.
+func UnlockEnvelope(key Key, data Encrypted) (Plaintext, error) {
+	dek, payload, err := unwrapEnvelope(key, data)
+	if err != nil {
+		return nil, err
+	}
+	defer wipe(dek)
+	dekAEAD, err := newAEAD(dek)
+	if err != nil {
+		return nil, err
+	}
+	return open(dekAEAD, payload)
+}
+
+// RewrapEnvelope unwraps the DEK in a payload created with LockEnvelope with the old Key, and wraps it again with the new Key and Salt.
+// The encrypted payload is not decrypted or changed, so this is a constant time operation relative to the payload size.
+func RewrapEnvelope(oldKey Key, newKey Key, newSalt Salt, data Encrypted) (Encrypted, error) {
+	dek, payload, err := unwrapEnvelope(oldKey, data)
+	if err != nil {
+		return nil, err
+	}
+	defer wipe(dek)
+	return wrapEnvelope(rand.Reader, newKey, newSalt, dek, payload)
+}
+
+func wrapEnvelope(random io.Reader, key Key, salt Salt, dek Key, payload []byte) (Encrypted, error) {
+	kekAEAD, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+	wrapped, err := seal(random, kekAEAD, dek)
+	if err != nil {
+		return nil, err
+	}
+	out := make(Encrypted, wrappedLenSize, wrappedLenSize+len(wrapped)+len(payload)+len(salt))
+	binary.BigEndian.PutUint16(out, uint16(len(wrapped)))
+	out = append(out, wrapped...)
+	out = append(out, payload...)
+	return append(out, salt...), nil
+}
+
+func unwrapEnvelope(key Key, data Encrypted) (dek Key, payload []byte, err error) {
+	if len(data) < wrappedLenSize+len(key) {
+		return nil, nil, fmt.Errorf("%w: input data isn't long enough to contain an envelope", ErrInvalidData)
+	}
+	data = data[:len(data)-len(key)]
+	wrappedLen := int(binary.BigEndian.Uint16(data))
+	data = data[wrappedLenSize:]
+	if len(data) < wrappedLen {
+		return nil, nil, fmt.Errorf("%w: input data isn't long enough to contain a wrapped key", ErrInvalidData)
+	}
+	kekAEAD, err := newAEAD(key)
+	if err != nil {
+		return nil, nil, err
+	}
+	dek, err = open(kekAEAD, data[:wrappedLen])
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: unable to unwrap data key", ErrInvalidPassword)
+	}
+	return dek, data[wrappedLen:], nil
+}
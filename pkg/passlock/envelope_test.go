@@ -0,0 +1,66 @@
+package passlock
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestLockUnlockEnvelope(t *testing.T) {
+	const password = "password"
+	const data = "How wonderful life is while you're in the world"
+
+	gen, err := NewKeyGenerator(SetShortDelayIterations())
+	require.NoError(t, err)
+	key, salt, err := gen.GenerateKey([]byte(password))
+	require.NoError(t, err)
+
+	encrypted, err := LockEnvelope(key, salt, []byte(data))
+	require.NoError(t, err)
+
+	key2, err := gen.DeriveKey([]byte(password), encrypted)
+	require.NoError(t, err)
+	assert.Equal(t, key, key2)
+	unencrypted, err := UnlockEnvelope(key2, encrypted)
+	assert.NoError(t, err)
+	assert.Equal(t, data, string(unencrypted))
+}
+
+func TestRewrapEnvelope(t *testing.T) {
+	const data = "How wonderful life is while you're in the world"
+
+	gen, err := NewKeyGenerator(SetShortDelayIterations())
+	require.NoError(t, err)
+	oldKey, oldSalt, err := gen.GenerateKey([]byte("old password"))
+	require.NoError(t, err)
+	newKey, newSalt, err := gen.GenerateKey([]byte("new password"))
+	require.NoError(t, err)
+
+	encrypted, err := LockEnvelope(oldKey, oldSalt, []byte(data))
+	require.NoError(t, err)
+	rewrapped, err := RewrapEnvelope(oldKey, newKey, newSalt, encrypted)
+	require.NoError(t, err)
+	assert.Equal(t, len(encrypted), len(rewrapped))
+
+	_, err = UnlockEnvelope(oldKey, rewrapped)
+	assert.ErrorIs(t, err, ErrInvalidPassword, "Old key should no longer unwrap the data key")
+	derived, err := gen.DeriveKey([]byte("new password"), rewrapped)
+	require.NoError(t, err)
+	unencrypted, err := UnlockEnvelope(derived, rewrapped)
+	assert.NoError(t, err)
+	assert.Equal(t, data, string(unencrypted))
+
+	_, err = RewrapEnvelope(oldKey, newKey, newSalt, rewrapped)
+	assert.ErrorIs(t, err, ErrInvalidPassword)
+}
+
+func TestUnlockEnvelope_Neg(t *testing.T) {
+	key := make(Key, AES256KeySize)
+	_, err := UnlockEnvelope(key, Encrypted{0x0})
+	assert.ErrorIs(t, err, ErrInvalidData)
+
+	data := make(Encrypted, 2+len(key))
+	data[0] = 0xff
+	_, err = UnlockEnvelope(key, data)
+	assert.ErrorIs(t, err, ErrInvalidData)
+}
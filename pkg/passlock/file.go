@@ -0,0 +1,198 @@
+package passlock
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+var (
+	ErrPassSource = errors.New("unable to read pass phrase")
+)
+
+// PassSource provides the pass phrase used by LockFile and UnlockFile.
+// The returned Passphrase is wiped after use, so a PassSource should return a new slice each time it's called.
+type PassSource interface {
+	Passphrase() (Passphrase, error)
+}
+
+// PassSourceFunc allows a function to be used as a PassSource.
+// This is useful for integrating a terminal library that reads a pass phrase without echoing it.
+type PassSourceFunc func() (Passphrase, error)
+
+func (f PassSourceFunc) Passphrase() (Passphrase, error) {
+	return f()
+}
+
+// PassFromFD reads a pass phrase from the first line of the given file descriptor, like one passed to a child process with --pass-fd.
+// The file descriptor is closed after the pass phrase is read, so the source may only be used once.
+func PassFromFD(fd uintptr) PassSource {
+	return PassSourceFunc(func() (Passphrase, error) {
+		f := os.NewFile(fd, fmt.Sprintf("fd%d", fd))
+		if f == nil {
+			return nil, fmt.Errorf("%w: invalid file descriptor %d", ErrPassSource, fd)
+		}
+		defer func() {
+			_ = f.Close()
+		}()
+		return readPassLine(f)
+	})
+}
+
+// PassFromFile reads a pass phrase from the first line of the file at the given path.
+func PassFromFile(path string) PassSource {
+	return PassSourceFunc(func() (Passphrase, error) {
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrPassSource, err)
+		}
+		defer func() {
+			_ = f.Close()
+		}()
+		return readPassLine(f)
+	})
+}
+
+// PassFromEnv reads a pass phrase from the named environment variable.
+// Environment variables may be visible to other processes owned by the same user, so prefer PassFromFD where possible.
+func PassFromEnv(name string) PassSource {
+	return PassSourceFunc(func() (Passphrase, error) {
+		val, ok := os.LookupEnv(name)
+		if !ok {
+			return nil, fmt.Errorf("%w: environment variable '%s' is not set", ErrPassSource, name)
+		}
+		if len(val) == 0 {
+			return nil, ErrEmptyPassPhrase
+		}
+		return Passphrase(val), nil
+	})
+}
+
+// PassFromPrompt writes the prompt to out, and reads a pass phrase from the next line of in.
+// The input is echoed if in is a terminal, use PassSourceFunc with a terminal library to avoid that.
+func PassFromPrompt(prompt string, in io.Reader, out io.Writer) PassSource {
+	return PassSourceFunc(func() (Passphrase, error) {
+		if _, err := io.WriteString(out, prompt); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrPassSource, err)
+		}
+		return readPassLine(in)
+	})
+}
+
+// readPassLine reads the first line from r, excluding the line ending.
+func readPassLine(r io.Reader) (Passphrase, error) {
+	line, err := bufio.NewReader(r).ReadBytes('\n')
+	if err != nil && !errors.Is(err, io.EOF) {
+		wipe(line)
+		return nil, fmt.Errorf("%w: %v", ErrPassSource, err)
+	}
+	n := len(line)
+	if n > 0 && line[n-1] == '\n' {
+		n--
+		if n > 0 && line[n-1] == '\r' {
+			n--
+		}
+	}
+	wipe(line[n:])
+	if n == 0 {
+		return nil, ErrEmptyPassPhrase
+	}
+	return Passphrase(line[:n]), nil
+}
+
+// LockFile encrypts the file at inPath with a pass phrase from the PassSource, and writes the encrypted payload to outPath.
+// The output is written to a temporary file in the same directory, which replaces outPath only after it's completely written.
+// The pass phrase and key are wiped after use. The input file may be at most MaxPlaintextSize, since it's encrypted with the KeyGenerator's Locker.
+func LockFile(gen *KeyGenerator, inPath, outPath string, src PassSource) error {
+	if gen == nil {
+		return errors.New("nil key generator")
+	}
+	data, err := os.ReadFile(inPath)
+	if err != nil {
+		return err
+	}
+	defer wipe(data)
+	pass, err := src.Passphrase()
+	if err != nil {
+		return err
+	}
+	defer wipe(pass)
+	key, salt, err := gen.GenerateKey(pass)
+	if err != nil {
+		return err
+	}
+	defer wipe(key)
+	l, err := gen.NewLocker(key, salt)
+	if err != nil {
+		return err
+	}
+	encrypted, err := l.Seal(data)
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(outPath, encrypted)
+}
+
+// UnlockFile decrypts the file at inPath with a pass phrase from the PassSource, and writes the plaintext payload to outPath.
+// The KeyGenerator must have the same settings that were used with LockFile.
+// Nothing is written to outPath if the pass phrase is incorrect or the payload has been tampered with.
+func UnlockFile(gen *KeyGenerator, inPath, outPath string, src PassSource) error {
+	if gen == nil {
+		return errors.New("nil key generator")
+	}
+	data, err := os.ReadFile(inPath)
+	if err != nil {
+		return err
+	}
+	pass, err := src.Passphrase()
+	if err != nil {
+		return err
+	}
+	defer wipe(pass)
+	key, err := gen.DeriveKey(pass, data)
+	if err != nil {
+		return err
+	}
+	defer wipe(key)
+	l, err := gen.NewLocker(key, nil)
+	if err != nil {
+		return err
+	}
+	plaintext, err := l.Open(data)
+	if err != nil {
+		return err
+	}
+	defer wipe(plaintext)
+	return writeFileAtomic(outPath, plaintext)
+}
+
+// writeFileAtomic writes data to a temporary file in the same directory as path, and renames it to path once it's synced.
+// The file is only readable and writable by the owner.
+func writeFileAtomic(path string, data []byte) (err error) {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			_ = tmp.Close()
+			_ = os.Remove(tmp.Name())
+		}
+	}()
+	if err := tmp.Chmod(0600); err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
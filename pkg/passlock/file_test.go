@@ -0,0 +1,86 @@
+package passlock
+
+import (
+	"bytes"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLockUnlockFile(t *testing.T) {
+	const data = "How wonderful life is while you're in the world"
+	dir := t.TempDir()
+	plainPath := filepath.Join(dir, "plain.txt")
+	lockedPath := filepath.Join(dir, "locked.bin")
+	unlockedPath := filepath.Join(dir, "unlocked.txt")
+	require.NoError(t, os.WriteFile(plainPath, []byte(data), 0600))
+
+	gen, err := NewKeyGenerator(SetShortDelayIterations())
+	require.NoError(t, err)
+	t.Setenv("PASSLOCK_TEST_PASS", "password")
+	require.NoError(t, LockFile(gen, plainPath, lockedPath, PassFromEnv("PASSLOCK_TEST_PASS")))
+
+	locked, err := os.ReadFile(lockedPath)
+	require.NoError(t, err)
+	assert.NotContains(t, string(locked), data)
+	info, err := os.Stat(lockedPath)
+	require.NoError(t, err)
+	if os.PathSeparator == '/' {
+		assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+	}
+
+	err = UnlockFile(gen, lockedPath, unlockedPath, PassFromPrompt("Pass phrase: ", strings.NewReader("wrong\n"), &bytes.Buffer{}))
+	assert.Error(t, err)
+	assert.NoFileExists(t, unlockedPath, "Nothing should be written with an incorrect pass phrase")
+
+	passPath := filepath.Join(dir, "pass.txt")
+	require.NoError(t, os.WriteFile(passPath, []byte("password\r\nignored\n"), 0600))
+	require.NoError(t, UnlockFile(gen, lockedPath, unlockedPath, PassFromFile(passPath)))
+	unlocked, err := os.ReadFile(unlockedPath)
+	require.NoError(t, err)
+	assert.Equal(t, data, string(unlocked))
+
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Len(t, entries, 4, "Temporary files should not be left behind")
+}
+
+func TestPassSources(t *testing.T) {
+	var prompt bytes.Buffer
+	pass, err := PassFromPrompt("Pass phrase: ", strings.NewReader("secret\nnext"), &prompt).Passphrase()
+	require.NoError(t, err)
+	assert.Equal(t, Passphrase("secret"), pass)
+	assert.Equal(t, "Pass phrase: ", prompt.String())
+
+	pass, err = PassFromPrompt("", strings.NewReader("no newline"), &prompt).Passphrase()
+	require.NoError(t, err)
+	assert.Equal(t, Passphrase("no newline"), pass)
+
+	r, w, err := os.Pipe()
+	require.NoError(t, err)
+	_, err = w.WriteString("from pipe\n")
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+	pass, err = PassFromFD(r.Fd()).Passphrase()
+	_ = r.Close() // Already closed by PassFromFD.
+	require.NoError(t, err)
+	assert.Equal(t, Passphrase("from pipe"), pass)
+}
+
+func TestPassSources_Neg(t *testing.T) {
+	tests := map[string]PassSource{
+		"Unset env":    PassFromEnv("PASSLOCK_TEST_UNSET_VARIABLE"),
+		"Missing file": PassFromFile(filepath.Join(t.TempDir(), "missing")),
+		"Empty line":   PassFromPrompt("", strings.NewReader("\nsecret\n"), &bytes.Buffer{}),
+		"Empty input":  PassFromPrompt("", strings.NewReader(""), &bytes.Buffer{}),
+	}
+	for name, src := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := src.Passphrase()
+			assert.Error(t, err)
+		})
+	}
+}
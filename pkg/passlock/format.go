@@ -0,0 +1,127 @@
+package passlock
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"sync"
+)
+
+const (
+	// MinCustomFormatVersion is the lowest format version that may be registered with RegisterFormat.
+	// Lower versions are reserved for formats provided by this package.
+	MinCustomFormatVersion uint16 = 0x8000
+)
+
+// Format reads and writes the binary layout of a MultiLocker that follows the format header for a particular format version.
+//
+// Every format version provided by this package is registered, and will remain readable by future releases of this package.
+// Only CurrentFormatVersion may be written, since older formats can't represent every MultiLocker setting.
+type Format interface {
+	// ReadMultiLocker reads a MultiLocker from the io.Reader, which is positioned just after the format header.
+	ReadMultiLocker(r io.Reader) (*MultiLocker, error)
+	// WriteMultiLocker writes the MultiLocker to the io.Writer, after the format header has been written.
+	WriteMultiLocker(w io.Writer, l *MultiLocker) error
+}
+
+var formatRegistry = struct {
+	sync.RWMutex
+	formats map[uint16]Format
+}{
+	formats: map[uint16]Format{},
+}
+
+func init() {
+	for version := legacyFormatVersion; version <= CurrentFormatVersion; version++ {
+		formatRegistry.formats[version] = builtinFormat(version)
+	}
+}
+
+// builtinFormat is a binary format version provided by this package.
+type builtinFormat uint16
+
+func (f builtinFormat) ReadMultiLocker(r io.Reader) (*MultiLocker, error) {
+	return readVersion(uint16(f), r)
+}
+
+func (f builtinFormat) WriteMultiLocker(w io.Writer, l *MultiLocker) error {
+	return l.mapper(uint16(f)).Write(w, binary.BigEndian)
+}
+
+// RegisterFormat registers a custom Format with a format version, so it may be used with WriteFormat and ReadMultiLockerAnyVersion.
+// The version must be at least MinCustomFormatVersion, and may only be registered once.
+// This is intended to be called during program initialization.
+func RegisterFormat(version uint16, format Format) error {
+	if version < MinCustomFormatVersion {
+		return fmt.Errorf("format version %d is reserved, custom formats must be at least %d", version, MinCustomFormatVersion)
+	}
+	if format == nil {
+		return errors.New("format cannot be nil")
+	}
+	formatRegistry.Lock()
+	defer formatRegistry.Unlock()
+	if _, ok := formatRegistry.formats[version]; ok {
+		return fmt.Errorf("format version %d is already registered", version)
+	}
+	formatRegistry.formats[version] = format
+	return nil
+}
+
+// SupportedFormats returns every format version that this binary is able to read, in ascending order.
+// This includes the format versions provided by this package, and any registered with RegisterFormat.
+func SupportedFormats() []uint16 {
+	formatRegistry.RLock()
+	defer formatRegistry.RUnlock()
+	versions := make([]uint16, 0, len(formatRegistry.formats))
+	for version := range formatRegistry.formats {
+		versions = append(versions, version)
+	}
+	slices.Sort(versions)
+	return versions
+}
+
+func lookupFormat(version uint16) (Format, error) {
+	formatRegistry.RLock()
+	defer formatRegistry.RUnlock()
+	format, ok := formatRegistry.formats[version]
+	if !ok {
+		return nil, &UnsupportedVersionError{Version: version}
+	}
+	return format, nil
+}
+
+// ReadMultiLockerFormats will read a MultiLocker as a binary payload from the io.Reader, only accepting the given format versions.
+// This allows a program to constrain what it will accept, such as refusing older formats after a migration.
+// An error wrapping ErrUnsupportedVersion is returned if the detected version isn't allowed, or isn't in SupportedFormats.
+func ReadMultiLockerFormats(r io.Reader, allowed ...uint16) (*MultiLocker, error) {
+	version, r, err := readFormatVersion(r)
+	if err != nil {
+		return nil, err
+	}
+	if !slices.Contains(allowed, version) {
+		return nil, &UnsupportedVersionError{Version: version}
+	}
+	format, err := lookupFormat(version)
+	if err != nil {
+		return nil, err
+	}
+	return format.ReadMultiLocker(r)
+}
+
+// WriteFormat will write the MultiLocker as a binary payload to the io.Writer, using the given format version.
+// The version must be CurrentFormatVersion, or a version registered with RegisterFormat.
+func (l *MultiLocker) WriteFormat(w io.Writer, version uint16) error {
+	if version < MinCustomFormatVersion && version != CurrentFormatVersion {
+		return fmt.Errorf("format version %d is supported for reading only", version)
+	}
+	format, err := lookupFormat(version)
+	if err != nil {
+		return err
+	}
+	if err := writeFormatVersion(w, version); err != nil {
+		return err
+	}
+	return format.WriteMultiLocker(w, l)
+}
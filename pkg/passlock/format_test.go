@@ -0,0 +1,98 @@
+package passlock
+
+import (
+	"bytes"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io"
+	"os"
+	"testing"
+)
+
+const testFormatJSON = MinCustomFormatVersion + 1
+
+// testJSONFormat is a custom Format that uses JSONCodec after the format header.
+type testJSONFormat struct{}
+
+func (testJSONFormat) ReadMultiLocker(r io.Reader) (*MultiLocker, error) {
+	return JSONCodec.Decode(r)
+}
+
+func (testJSONFormat) WriteMultiLocker(w io.Writer, l *MultiLocker) error {
+	return JSONCodec.Encode(w, l)
+}
+
+func init() {
+	if err := RegisterFormat(testFormatJSON, testJSONFormat{}); err != nil {
+		panic(err)
+	}
+}
+
+func TestSupportedFormats(t *testing.T) {
+	supported := SupportedFormats()
+	for version := legacyFormatVersion; version <= CurrentFormatVersion; version++ {
+		assert.Contains(t, supported, version)
+	}
+	assert.Contains(t, supported, testFormatJSON)
+	assert.IsIncreasing(t, supported)
+}
+
+func TestRegisterFormat_Neg(t *testing.T) {
+	tests := map[string]struct {
+		version uint16
+		format  Format
+	}{
+		"Current version": {CurrentFormatVersion, testJSONFormat{}},
+		"Reserved":        {MinCustomFormatVersion - 1, testJSONFormat{}},
+		"Duplicate":       {testFormatJSON, testJSONFormat{}},
+		"Nil format":      {MinCustomFormatVersion + 100, nil},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert.Error(t, RegisterFormat(tc.version, tc.format))
+		})
+	}
+	assert.NotContains(t, SupportedFormats(), MinCustomFormatVersion+100, "Failed registrations shouldn't be retained")
+}
+
+func TestWriteFormat_Custom(t *testing.T) {
+	mk := newTestMultiLocker(t, Passphrase("base key pass"), Passphrase("sur key pass"), Plaintext("test payload"))
+	var buf bytes.Buffer
+	require.NoError(t, mk.WriteFormat(&buf, testFormatJSON))
+
+	_, err := ReadMultiLocker(bytes.NewReader(buf.Bytes()))
+	assert.ErrorIs(t, err, ErrUnsupportedVersion)
+
+	read, err := ReadMultiLockerAnyVersion(bytes.NewReader(buf.Bytes()))
+	require.NoError(t, err)
+	plaintext, err := read.SurrogateUnlock("developer", Passphrase("sur key pass"))
+	require.NoError(t, err)
+	assert.Equal(t, "test payload", string(plaintext))
+
+	_, err = ReadMultiLockerFormats(bytes.NewReader(buf.Bytes()), CurrentFormatVersion)
+	assert.ErrorIs(t, err, ErrUnsupportedVersion)
+	_, err = ReadMultiLockerFormats(bytes.NewReader(buf.Bytes()), CurrentFormatVersion, testFormatJSON)
+	assert.NoError(t, err)
+}
+
+func TestWriteFormat_Neg(t *testing.T) {
+	mk := newTestMultiLocker(t, Passphrase("base key pass"), Passphrase("sur key pass"), Plaintext("test payload"))
+	var buf bytes.Buffer
+	assert.Error(t, mk.WriteFormat(&buf, historyFormatVersion), "Older formats may only be read")
+	assert.ErrorIs(t, mk.WriteFormat(&buf, MinCustomFormatVersion+100), ErrUnsupportedVersion)
+	assert.Zero(t, buf.Len(), "Nothing should be written for an unsupported format")
+
+	require.NoError(t, mk.WriteFormat(&buf, CurrentFormatVersion))
+	_, err := ReadMultiLocker(&buf)
+	assert.NoError(t, err)
+}
+
+func TestReadMultiLockerFormats(t *testing.T) {
+	legacy, err := os.ReadFile("testdata/legacy_v0.bin")
+	require.NoError(t, err)
+	_, err = ReadMultiLockerFormats(bytes.NewReader(legacy), historyFormatVersion, CurrentFormatVersion)
+	assert.ErrorIs(t, err, ErrUnsupportedVersion)
+	mk, err := ReadMultiLockerFormats(bytes.NewReader(legacy), legacyFormatVersion)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"developer"}, mk.ListKeyIDs())
+}
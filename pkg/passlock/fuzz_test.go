@@ -0,0 +1,225 @@
+package passlock
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/binary"
+	bin "github.com/saylorsolutions/binmap"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/quick"
+)
+
+// The fuzz targets in this file may be run from any module that depends on passlock, to gain confidence before handling hostile input.
+//
+//	go test -fuzz=FuzzUnlock github.com/saylorsolutions/gocryptx/pkg/passlock
+//	go test -fuzz=FuzzUnlockLarge github.com/saylorsolutions/gocryptx/pkg/passlock
+//	go test -fuzz=FuzzReadMultiLocker github.com/saylorsolutions/gocryptx/pkg/passlock
+
+func FuzzUnlock(f *testing.F) {
+	key := bytes.Repeat([]byte{0x42}, int(AES256KeySize))
+	salt := bytes.Repeat([]byte{0x24}, int(AES256KeySize))
+	encrypted, err := Lock(key, salt, Plaintext("fuzz payload"))
+	require.NoError(f, err)
+	f.Add([]byte(key), []byte(encrypted))
+	f.Add([]byte(key[:AES128KeySize]), []byte(encrypted))
+	f.Add([]byte{}, []byte{})
+
+	f.Fuzz(func(t *testing.T, key []byte, data []byte) {
+		plaintext, err := Unlock(key, data)
+		if err != nil {
+			return
+		}
+		// Successfully unlocked data must round trip with the same key.
+		encrypted, err := Lock(key, data[len(data)-len(key):], plaintext)
+		require.NoError(t, err)
+		got, err := Unlock(key, encrypted)
+		require.NoError(t, err)
+		assert.Equal(t, plaintext, got)
+	})
+}
+
+func FuzzUnlockLarge(f *testing.F) {
+	key := bytes.Repeat([]byte{0x42}, int(AES256KeySize))
+	salt := bytes.Repeat([]byte{0x24}, int(AES256KeySize))
+	for _, size := range []int{0, 1, 16, 40} {
+		encrypted, err := lockChunked(rand.Reader, key, salt, bytes.Repeat([]byte("fuzz"), size), 16)
+		require.NoError(f, err)
+		f.Add([]byte(key), []byte(encrypted))
+	}
+	short := make([]byte, chunkHeaderSize+17+int(AES128KeySize))
+	binary.BigEndian.PutUint32(short, 1)
+	binary.BigEndian.PutUint64(short[4:], 1)
+	f.Add([]byte(key[:AES128KeySize]), short)
+	f.Add([]byte{}, []byte{})
+
+	f.Fuzz(func(t *testing.T, key []byte, data []byte) {
+		plaintext, err := UnlockLarge(key, data)
+		if err != nil {
+			return
+		}
+		// Successfully unlocked data must round trip with the same key.
+		encrypted, err := LockLarge(key, data[len(data)-len(key):], plaintext)
+		require.NoError(t, err)
+		got, err := UnlockLarge(key, encrypted)
+		require.NoError(t, err)
+		assert.Equal(t, []byte(plaintext), []byte(got))
+	})
+}
+
+func FuzzReadMultiLocker(f *testing.F) {
+	fixtures, err := filepath.Glob("testdata/*.bin")
+	require.NoError(f, err)
+	for _, fixture := range fixtures {
+		data, err := os.ReadFile(fixture)
+		require.NoError(f, err)
+		f.Add(data)
+	}
+	var buf bytes.Buffer
+	require.NoError(f, newTestMultiLocker(f, Passphrase("base key pass"), Passphrase("sur key pass"), Plaintext("fuzz payload")).Write(&buf))
+	f.Add(buf.Bytes())
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		mk, err := ReadMultiLockerAnyVersion(bytes.NewReader(data))
+		if err != nil {
+			return
+		}
+		// Anything that can be read must be written in the current format, and read back the same way.
+		var first, second bytes.Buffer
+		require.NoError(t, mk.Write(&first))
+		read, err := ReadMultiLocker(bytes.NewReader(first.Bytes()))
+		require.NoError(t, err)
+		require.NoError(t, read.Write(&second))
+		assert.Equal(t, mk.ListKeys(KeyFilter{}), read.ListKeys(KeyFilter{}))
+		assert.Equal(t, mk.History(), read.History())
+		assert.Equal(t, first.Len(), second.Len())
+	})
+}
+
+func TestReadMultiLocker_HostileLengths(t *testing.T) {
+	data := []byte("some data")
+	var expected, buf bytes.Buffer
+	require.NoError(t, bin.DynamicSlice(&data, func(e *byte) bin.Mapper {
+		return bin.Byte(e)
+	}).Write(&expected, binary.BigEndian))
+	require.NoError(t, dynamicBytes(&data).Write(&buf, binary.BigEndian))
+	assert.Equal(t, expected.Bytes(), buf.Bytes(), "Layout should match bin.DynamicSlice")
+	var read []byte
+	require.NoError(t, dynamicBytes(&read).Read(&buf, binary.BigEndian))
+	assert.Equal(t, data, read)
+
+	hostile := []byte{0xff, 0xff, 0xff, 0xff, 1, 2, 3}
+	err := dynamicBytes(&read).Read(bytes.NewReader(hostile), binary.BigEndian)
+	assert.ErrorIs(t, err, io.ErrUnexpectedEOF, "A length longer than the input should fail without allocating it")
+
+	l := new(MultiLocker)
+	hostile = []byte{2, 0xff, 0xff, 0xff, 0xff}
+	err = l.historyMapper(CurrentFormatVersion).Read(bytes.NewReader(hostile), binary.BigEndian)
+	assert.ErrorContains(t, err, "exceeds the history limit", "Generation count should be checked before allocating")
+}
+
+func TestLockUnlock_Property(t *testing.T) {
+	roundTrip := func(data []byte, aes128 bool, xchacha bool) bool {
+		keySize := AES256KeySize
+		if aes128 && !xchacha {
+			keySize = AES128KeySize
+		}
+		opts := []GeneratorOpt{SetIterations(1 << 4)}
+		if keySize == AES128KeySize {
+			opts = append(opts, SetAES128KeySize())
+		}
+		if xchacha {
+			opts = append(opts, SetXChaCha20Poly1305())
+		}
+		gen, err := NewKeyGenerator(opts...)
+		if err != nil {
+			t.Log(err)
+			return false
+		}
+		pass := make(Passphrase, 16)
+		if _, err := rand.Read(pass); err != nil {
+			t.Log(err)
+			return false
+		}
+		key, salt, err := gen.GenerateKey(pass)
+		if err != nil {
+			t.Log(err)
+			return false
+		}
+		locker, err := gen.NewLocker(key, salt)
+		if err != nil {
+			t.Log(err)
+			return false
+		}
+		encrypted, err := locker.Seal(data)
+		if err != nil {
+			t.Log(err)
+			return false
+		}
+		derived, err := gen.DeriveKey(pass, encrypted)
+		if err != nil {
+			t.Log(err)
+			return false
+		}
+		opened, err := gen.NewLocker(derived, nil)
+		if err != nil {
+			t.Log(err)
+			return false
+		}
+		plaintext, err := opened.Open(encrypted)
+		if err != nil {
+			t.Log(err)
+			return false
+		}
+		return bytes.Equal(data, plaintext)
+	}
+	assert.NoError(t, quick.Check(roundTrip, &quick.Config{MaxCount: 50}))
+}
+
+func TestMultiLocker_Property(t *testing.T) {
+	roundTrip := func(data []byte, ids []string) bool {
+		gen, err := NewKeyGenerator(SetIterations(1 << 4))
+		if err != nil {
+			t.Log(err)
+			return false
+		}
+		mk := NewMultiLocker(gen)
+		if err := mk.Lock(Passphrase("base key pass"), data); err != nil {
+			t.Log(err)
+			return false
+		}
+		for _, id := range ids {
+			if len(id) == 0 || len(id) > idFieldLen || len(mk.ListKeyIDs()) > 4 {
+				continue
+			}
+			if err := mk.AddSurrogatePass(id, Passphrase("sur "+id)); err != nil {
+				// Duplicate IDs are expected from random input.
+				continue
+			}
+		}
+		var buf bytes.Buffer
+		if err := mk.Write(&buf); err != nil {
+			t.Log(err)
+			return false
+		}
+		read, err := ReadMultiLocker(&buf)
+		if err != nil {
+			t.Log(err)
+			return false
+		}
+		for _, id := range read.ListKeyIDs() {
+			plaintext, err := read.SurrogateUnlock(id, Passphrase("sur "+id))
+			if err != nil || !bytes.Equal(data, plaintext) {
+				t.Log(id, err)
+				return false
+			}
+		}
+		plaintext, err := read.Unlock(Passphrase("base key pass"))
+		return err == nil && bytes.Equal(data, plaintext)
+	}
+	assert.NoError(t, quick.Check(roundTrip, &quick.Config{MaxCount: 20}))
+}
@@ -0,0 +1,138 @@
+package passlock
+
+import (
+	"crypto/rand"
+	"errors"
+	"io"
+	"sync"
+)
+
+const (
+	guardCanaryLen = 16
+)
+
+var (
+	ErrGuardCorrupted = errors.New("guarded key canary has been corrupted")
+	ErrKeyDestroyed   = errors.New("guarded key has been destroyed")
+)
+
+// GuardedKey holds a Key in memory that is locked into RAM, to exclude it from swap, and surrounded by inaccessible guard pages.
+// A canary value is placed immediately before the Key to detect buffer underflows, while an overflow will fault on the trailing guard page.
+// Where supported, the memory is also excluded from core dumps.
+//
+// Memory protections are only available on Linux, macOS, and FreeBSD.
+// On other platforms a GuardedKey is held in normal memory, and Protected will return false.
+// Protected will also return false if the memory can't be locked, for example because the RLIMIT_MEMLOCK limit has been reached, since the Key could still be swapped.
+//
+// A GuardedKey must be destroyed with Destroy when it's no longer needed to release the locked memory.
+// The memory is never released automatically, since a Key returned by Key may still be in use after the GuardedKey itself is unreachable.
+type GuardedKey struct {
+	mux       sync.Mutex
+	region    []byte
+	canary    []byte
+	canaryVal []byte
+	key       Key
+	protected bool
+}
+
+// NewGuardedKey copies the given Key into guarded memory, and wipes the original Key.
+func NewGuardedKey(key Key) (*GuardedKey, error) {
+	if len(key) == 0 {
+		return nil, errors.New("cannot guard an empty key")
+	}
+	canaryVal := make([]byte, guardCanaryLen)
+	if _, err := io.ReadFull(rand.Reader, canaryVal); err != nil {
+		return nil, err
+	}
+	region, data, protected, err := allocGuarded(guardCanaryLen + len(key))
+	if err != nil {
+		return nil, err
+	}
+	g := &GuardedKey{
+		region:    region,
+		canary:    data[len(data)-len(key)-guardCanaryLen : len(data)-len(key)],
+		canaryVal: canaryVal,
+		key:       data[len(data)-len(key):],
+		protected: protected,
+	}
+	copy(g.canary, canaryVal)
+	copy(g.key, key)
+	wipe(key)
+	return g, nil
+}
+
+// Key returns the guarded Key, which may be passed to Lock or Unlock.
+// The returned Key refers to the guarded memory, so it remains valid until Destroy is called, and must not be retained or used after that.
+// Nil is returned if the GuardedKey has been destroyed.
+func (g *GuardedKey) Key() Key {
+	g.mux.Lock()
+	defer g.mux.Unlock()
+	return g.key
+}
+
+// Protected reports whether the Key is held in locked memory with guard pages on this platform.
+func (g *GuardedKey) Protected() bool {
+	return g.protected
+}
+
+// Verify checks that the canary value preceding the Key has not been overwritten.
+func (g *GuardedKey) Verify() error {
+	g.mux.Lock()
+	defer g.mux.Unlock()
+	if g.region == nil {
+		return ErrKeyDestroyed
+	}
+	if !EqualSecret(g.canary, g.canaryVal) {
+		return ErrGuardCorrupted
+	}
+	return nil
+}
+
+// Destroy wipes the Key and releases the guarded memory.
+// Calling Destroy more than once has no effect.
+func (g *GuardedKey) Destroy() error {
+	g.mux.Lock()
+	defer g.mux.Unlock()
+	if g.region == nil {
+		return nil
+	}
+	wipe(g.key)
+	wipe(g.canary)
+	err := freeGuarded(g.region, g.protected)
+	g.region, g.canary, g.key = nil, nil, nil
+	return err
+}
+
+// GenerateGuardedKey is the same as GenerateKey, except that the generated Key is returned as a GuardedKey.
+func (g *KeyGenerator) GenerateGuardedKey(pass Passphrase) (*GuardedKey, Salt, error) {
+	key, salt, err := g.GenerateKey(pass)
+	if err != nil {
+		return nil, nil, err
+	}
+	guarded, err := NewGuardedKey(key)
+	if err != nil {
+		wipe(key)
+		return nil, nil, err
+	}
+	return guarded, salt, nil
+}
+
+// DeriveGuardedKey is the same as DeriveKey, except that the derived Key is returned as a GuardedKey.
+func (g *KeyGenerator) DeriveGuardedKey(pass Passphrase, data Encrypted) (*GuardedKey, error) {
+	key, err := g.DeriveKey(pass, data)
+	if err != nil {
+		return nil, err
+	}
+	guarded, err := NewGuardedKey(key)
+	if err != nil {
+		wipe(key)
+		return nil, err
+	}
+	return guarded, nil
+}
+
+func wipe(buf []byte) {
+	for i := range buf {
+		buf[i] = 0
+	}
+}
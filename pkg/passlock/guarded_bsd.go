@@ -0,0 +1,7 @@
+//go:build darwin || freebsd
+
+package passlock
+
+func excludeFromCoreDump([]byte) {
+	// Not supported consistently on these platforms.
+}
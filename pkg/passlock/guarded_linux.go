@@ -0,0 +1,10 @@
+package passlock
+
+import (
+	"golang.org/x/sys/unix"
+)
+
+func excludeFromCoreDump(pages []byte) {
+	// This is best effort, the memory is still locked and guarded if the kernel doesn't support this.
+	_ = unix.Madvise(pages, unix.MADV_DONTDUMP)
+}
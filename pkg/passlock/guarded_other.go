@@ -0,0 +1,13 @@
+//go:build !(linux || darwin || freebsd)
+
+package passlock
+
+// allocGuarded falls back to normal memory on platforms where memory locking and guard pages aren't supported.
+func allocGuarded(size int) (region []byte, data []byte, protected bool, err error) {
+	region = make([]byte, size)
+	return region, region, false, nil
+}
+
+func freeGuarded([]byte, bool) error {
+	return nil
+}
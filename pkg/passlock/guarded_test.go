@@ -0,0 +1,69 @@
+package passlock
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"runtime"
+	"testing"
+)
+
+func TestGuardedKey(t *testing.T) {
+	const password = "password"
+	const data = "How wonderful life is while you're in the world"
+
+	gen, err := NewKeyGenerator(SetShortDelayIterations())
+	require.NoError(t, err)
+	guarded, salt, err := gen.GenerateGuardedKey([]byte(password))
+	require.NoError(t, err)
+	defer func() {
+		assert.NoError(t, guarded.Destroy())
+	}()
+	assert.NoError(t, guarded.Verify())
+	assert.Len(t, guarded.Key(), int(AES256KeySize))
+
+	encrypted, err := Lock(guarded.Key(), salt, []byte(data))
+	require.NoError(t, err)
+
+	derived, err := gen.DeriveGuardedKey([]byte(password), encrypted)
+	require.NoError(t, err)
+	assert.Equal(t, guarded.Key(), derived.Key())
+	unencrypted, err := Unlock(derived.Key(), encrypted)
+	assert.NoError(t, err)
+	assert.Equal(t, data, string(unencrypted))
+
+	assert.NoError(t, derived.Destroy())
+	assert.Nil(t, derived.Key(), "Destroyed key should not be accessible")
+	assert.ErrorIs(t, derived.Verify(), ErrKeyDestroyed)
+	assert.NoError(t, derived.Destroy(), "Destroy should be idempotent")
+}
+
+func TestNewGuardedKey(t *testing.T) {
+	key := Key{0x1, 0x2, 0x3, 0x4}
+	guarded, err := NewGuardedKey(key)
+	require.NoError(t, err)
+	defer func() {
+		assert.NoError(t, guarded.Destroy())
+	}()
+	t.Logf("Memory protections enabled: %v", guarded.Protected())
+	assert.Equal(t, Key{0x1, 0x2, 0x3, 0x4}, guarded.Key())
+	assert.Equal(t, Key{0, 0, 0, 0}, key, "Original key should be wiped")
+
+	guarded.canary[0] ^= 0xff
+	assert.ErrorIs(t, guarded.Verify(), ErrGuardCorrupted)
+
+	_, err = NewGuardedKey(nil)
+	assert.Error(t, err)
+}
+
+func TestGuardedKey_Unreachable(t *testing.T) {
+	guarded, err := NewGuardedKey(Key{0x1, 0x2, 0x3, 0x4})
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		assert.NoError(t, guarded.Destroy())
+	})
+	key := guarded.Key()
+	runtime.GC()
+	runtime.GC()
+	// The memory must not be released by garbage collection, only when Destroy is called.
+	assert.Equal(t, Key{0x1, 0x2, 0x3, 0x4}, key)
+}
@@ -0,0 +1,45 @@
+//go:build linux || darwin || freebsd
+
+package passlock
+
+import (
+	"fmt"
+	"golang.org/x/sys/unix"
+	"os"
+)
+
+// allocGuarded maps enough pages to hold size bytes, plus a leading and trailing guard page.
+// The returned data slice is right-aligned against the trailing guard page.
+// If the pages can't be locked, for example because RLIMIT_MEMLOCK has been reached, the mapping is still used but protected is false.
+func allocGuarded(size int) (region []byte, data []byte, protected bool, err error) {
+	pageSize := os.Getpagesize()
+	dataLen := ((size + pageSize - 1) / pageSize) * pageSize
+	region, err = unix.Mmap(-1, 0, dataLen+2*pageSize, unix.PROT_READ|unix.PROT_WRITE, unix.MAP_PRIVATE|unix.MAP_ANON)
+	if err != nil {
+		return nil, nil, false, fmt.Errorf("failed to allocate guarded memory: %w", err)
+	}
+	fail := func(err error) ([]byte, []byte, bool, error) {
+		_ = unix.Munmap(region)
+		return nil, nil, false, err
+	}
+	if err := unix.Mprotect(region[:pageSize], unix.PROT_NONE); err != nil {
+		return fail(fmt.Errorf("failed to protect leading guard page: %w", err))
+	}
+	if err := unix.Mprotect(region[pageSize+dataLen:], unix.PROT_NONE); err != nil {
+		return fail(fmt.Errorf("failed to protect trailing guard page: %w", err))
+	}
+	pages := region[pageSize : pageSize+dataLen]
+	protected = unix.Mlock(pages) == nil
+	excludeFromCoreDump(pages)
+	return region, pages[dataLen-size:], protected, nil
+}
+
+func freeGuarded(region []byte, protected bool) error {
+	pageSize := os.Getpagesize()
+	if protected {
+		if err := unix.Munlock(region[pageSize : len(region)-pageSize]); err != nil {
+			return err
+		}
+	}
+	return unix.Munmap(region)
+}
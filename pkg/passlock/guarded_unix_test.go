@@ -0,0 +1,30 @@
+//go:build linux || darwin || freebsd
+
+package passlock
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/sys/unix"
+	"testing"
+)
+
+func TestNewGuardedKey_MemlockLimit(t *testing.T) {
+	var limit unix.Rlimit
+	require.NoError(t, unix.Getrlimit(unix.RLIMIT_MEMLOCK, &limit))
+	lowered := limit
+	lowered.Cur = 0
+	require.NoError(t, unix.Setrlimit(unix.RLIMIT_MEMLOCK, &lowered))
+	t.Cleanup(func() {
+		assert.NoError(t, unix.Setrlimit(unix.RLIMIT_MEMLOCK, &limit))
+	})
+
+	// Processes with CAP_IPC_LOCK may still lock memory, so either outcome is valid, but an error isn't.
+	for i := 0; i < 64; i++ {
+		guarded, err := NewGuardedKey(Key{0x1, 0x2, 0x3, 0x4})
+		require.NoError(t, err, "Exceeding the memory lock limit should fall back to unlocked memory")
+		assert.Equal(t, Key{0x1, 0x2, 0x3, 0x4}, guarded.Key())
+		assert.NoError(t, guarded.Verify())
+		assert.NoError(t, guarded.Destroy())
+	}
+}
@@ -0,0 +1,171 @@
+package passlock
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	bin "github.com/saylorsolutions/binmap"
+	"io"
+	"math"
+	"time"
+)
+
+var (
+	ErrGenerationNotFound = errors.New("payload generation not found")
+)
+
+// payloadGeneration is a previous encrypted payload, with the generator settings needed to derive its key.
+type payloadGeneration struct {
+	payload  Encrypted
+	keyGen   *KeyGenerator
+	replaced time.Time
+}
+
+func (g *payloadGeneration) mapper(version uint16) bin.Mapper {
+	if g.keyGen == nil {
+		g.keyGen = new(KeyGenerator)
+	}
+	return bin.MapSequence(
+		g.keyGen.mapper(version),
+		timeMapper(&g.replaced),
+		dynamicBytes((*[]byte)(&g.payload)),
+	)
+}
+
+// historyMapper maps the history limit, followed by the retained generations with the same layout as bin.DynamicSlice.
+// The generation count is validated against the limit before anything is allocated.
+func (l *MultiLocker) historyMapper(version uint16) bin.Mapper {
+	mapGeneration := func(g *payloadGeneration) bin.Mapper {
+		return g.mapper(version)
+	}
+	return bin.MapSequence(
+		bin.Byte(&l.historyLimit),
+		bin.Any(
+			func(r io.Reader, endian binary.ByteOrder) error {
+				var count uint32
+				if err := binary.Read(r, endian, &count); err != nil {
+					return err
+				}
+				if count > uint32(l.historyLimit) {
+					return fmt.Errorf("%d payload generations exceeds the history limit of %d", count, l.historyLimit)
+				}
+				return bin.Slice(&l.history, count, mapGeneration).Read(r, endian)
+			},
+			func(w io.Writer, endian binary.ByteOrder) error {
+				return bin.DynamicSlice(&l.history, mapGeneration).Write(w, endian)
+			},
+		),
+	)
+}
+
+// GenerationInfo describes a previous payload generation retained in a MultiLocker.
+type GenerationInfo struct {
+	// Generation is the number of payload replacements since this generation was current, starting at 1 for the immediately previous payload.
+	Generation int
+	// Replaced is when this generation was replaced by a newer payload.
+	Replaced time.Time
+}
+
+// SetHistoryLimit sets how many previous payload generations are retained when the payload is replaced with Lock, InvalidateLock, or WriteMultiLocker.SurrogateLock.
+// This provides a way to recover from accidentally overwriting the payload with UnlockGeneration.
+// The limit may be at most 255, and setting a limit of 0 disables history and removes any retained generations.
+// The limit and retained generations are persisted with the MultiLocker.
+func (l *MultiLocker) SetHistoryLimit(limit int) error {
+	if limit < 0 || limit > math.MaxUint8 {
+		return fmt.Errorf("history limit must be in the range 0-%d", math.MaxUint8)
+	}
+	l.historyLimit = uint8(limit)
+	l.trimHistory()
+	return nil
+}
+
+// History lists the retained payload generations, starting with the most recently replaced.
+func (l *MultiLocker) History() []GenerationInfo {
+	infos := make([]GenerationInfo, len(l.history))
+	for i, g := range l.history {
+		infos[i] = GenerationInfo{
+			Generation: i + 1,
+			Replaced:   g.replaced,
+		}
+	}
+	return infos
+}
+
+// pushHistory retains the current payload as a previous generation, if history is enabled.
+// This must be called before the current payload or generator are replaced.
+func (l *MultiLocker) pushHistory() {
+	if l.historyLimit == 0 || len(l.payload) == 0 {
+		return
+	}
+	current := payloadGeneration{
+		payload:  l.payload,
+		keyGen:   l.keyGen.clone(),
+		replaced: time.Now().Truncate(time.Second).UTC(),
+	}
+	l.history = append([]payloadGeneration{current}, l.history...)
+	l.trimHistory()
+}
+
+func (l *MultiLocker) trimHistory() {
+	if len(l.history) > int(l.historyLimit) {
+		l.history = l.history[:l.historyLimit]
+	}
+	if len(l.history) == 0 {
+		l.history = nil
+	}
+}
+
+func (l *MultiLocker) generation(generation int) (payloadGeneration, error) {
+	if generation < 1 || generation > len(l.history) {
+		return payloadGeneration{}, fmt.Errorf("%w: generation %d", ErrGenerationNotFound, generation)
+	}
+	return l.history[generation-1], nil
+}
+
+// UnlockGeneration will unlock a previous payload generation with the base pass phrase that was used when it was current.
+// See History for the available generations.
+func (l *MultiLocker) UnlockGeneration(generation int, basePass Passphrase) (Plaintext, error) {
+	start, gen := time.Now(), l.generationGenerator(generation)
+	data, err := l.unlockGeneration(generation, basePass)
+	l.observeUnlock(OpUnlockGeneration, "", generation, gen, start, err)
+	return data, err
+}
+
+func (l *MultiLocker) unlockGeneration(generation int, basePass Passphrase) (Plaintext, error) {
+	g, err := l.generation(generation)
+	if err != nil {
+		return nil, err
+	}
+	baseKey, err := g.keyGen.DeriveKey(basePass, g.payload)
+	if err != nil {
+		if errors.Is(err, ErrMemoryBudget) {
+			return nil, err
+		}
+		return nil, ErrInvalidPassword
+	}
+	data, err := g.keyGen.unlock(baseKey, g.payload)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid base key", ErrInvalidPassword)
+	}
+	return data, nil
+}
+
+// SurrogateUnlockGeneration will unlock a previous payload generation with a surrogate key.
+// This only succeeds if the base pass phrase hasn't changed since the generation was current.
+func (l *MultiLocker) SurrogateUnlockGeneration(generation int, id string, pass Passphrase) (Plaintext, error) {
+	start, gen := time.Now(), l.surrogateGenerator(id)
+	data, err := l.surrogateUnlockGeneration(generation, id, pass)
+	l.observeUnlock(OpSurrogateUnlockGeneration, id, generation, gen, start, err)
+	return data, err
+}
+
+func (l *MultiLocker) surrogateUnlockGeneration(generation int, id string, pass Passphrase) (Plaintext, error) {
+	if _, err := l.generation(generation); err != nil {
+		return nil, err
+	}
+	basePass, err := l.surrogateBasePass(id, pass)
+	if err != nil {
+		return nil, err
+	}
+	return l.unlockGeneration(generation, basePass)
+}
@@ -0,0 +1,73 @@
+package passlock
+
+import (
+	"bytes"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestMultiLocker_History(t *testing.T) {
+	var (
+		basePass = Passphrase("base key pass")
+		surPass  = Passphrase("sur key pass")
+	)
+	gen, err := NewKeyGenerator(SetShortDelayIterations())
+	require.NoError(t, err)
+	mk := NewWriteMultiLocker(gen)
+	require.NoError(t, mk.SetHistoryLimit(2))
+	require.NoError(t, mk.Lock(basePass, Plaintext("first")))
+	assert.Empty(t, mk.History(), "The first payload shouldn't create history")
+	require.NoError(t, mk.AddSurrogatePass("developer", surPass))
+
+	require.NoError(t, mk.Lock(basePass, Plaintext("second")))
+	require.NoError(t, mk.SurrogateLock("developer", surPass, Plaintext("third")))
+	require.NoError(t, mk.Lock(basePass, Plaintext("fourth")))
+
+	history := mk.History()
+	require.Len(t, history, 2, "History should be bounded by the limit")
+	assert.Equal(t, 1, history[0].Generation)
+	assert.WithinDuration(t, time.Now(), history[0].Replaced, 2*time.Second)
+
+	var buf bytes.Buffer
+	require.NoError(t, mk.Write(&buf))
+	read, err := ReadMultiLocker(&buf)
+	require.NoError(t, err)
+	assert.Equal(t, mk.History(), read.History())
+
+	data, err := read.UnlockGeneration(1, basePass)
+	require.NoError(t, err)
+	assert.Equal(t, "third", string(data))
+	data, err = read.SurrogateUnlockGeneration(2, "developer", surPass)
+	require.NoError(t, err)
+	assert.Equal(t, "second", string(data))
+
+	_, err = read.UnlockGeneration(3, basePass)
+	assert.ErrorIs(t, err, ErrGenerationNotFound)
+	_, err = read.UnlockGeneration(0, basePass)
+	assert.ErrorIs(t, err, ErrGenerationNotFound)
+	_, err = read.UnlockGeneration(1, Passphrase("wrong pass"))
+	assert.ErrorIs(t, err, ErrInvalidPassword)
+	_, err = read.SurrogateUnlockGeneration(1, "developer", Passphrase("wrong pass"))
+	assert.ErrorIs(t, err, ErrInvalidPassword)
+
+	require.NoError(t, read.InvalidateLock(Passphrase("new base pass"), Plaintext("fifth")))
+	data, err = read.UnlockGeneration(1, basePass)
+	require.NoError(t, err, "InvalidateLock should retain the previous generation")
+	assert.Equal(t, "fourth", string(data))
+
+	require.NoError(t, read.SetHistoryLimit(1))
+	assert.Len(t, read.History(), 1)
+	require.NoError(t, read.SetHistoryLimit(0))
+	assert.Empty(t, read.History())
+	assert.Error(t, read.SetHistoryLimit(256))
+	assert.Error(t, read.SetHistoryLimit(-1))
+}
+
+func TestMultiLocker_History_Disabled(t *testing.T) {
+	basePass := Passphrase("base key pass")
+	mk := newTestMultiLocker(t, basePass, Passphrase("sur key pass"), Plaintext("first"))
+	require.NoError(t, mk.Lock(basePass, Plaintext("second")))
+	assert.Empty(t, mk.History())
+}
@@ -0,0 +1,134 @@
+package passlock
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sync"
+)
+
+var (
+	ErrUnknownKDF = errors.New("unknown key derivation function")
+)
+
+// KDFID identifies the key derivation function used by a KeyGenerator, and is persisted with its settings.
+type KDFID uint8
+
+const (
+	// KDFScrypt identifies the [scrypt] key derivation function, which is the default.
+	//
+	// [scrypt]: https://en.wikipedia.org/wiki/Scrypt
+	KDFScrypt KDFID = 1
+)
+
+func (id KDFID) String() string {
+	switch id {
+	case KDFScrypt:
+		return "scrypt"
+	default:
+		if reg, ok := lookupKDF(id); ok {
+			return reg.name
+		}
+		return fmt.Sprintf("unknown(%d)", uint8(id))
+	}
+}
+
+// ParseKDFID returns the KDFID for the given name, as returned by KDFID.String.
+// Names of KDFs added with RegisterKDF are also recognized.
+func ParseKDFID(name string) (KDFID, error) {
+	switch name {
+	case "scrypt":
+		return KDFScrypt, nil
+	default:
+		kdfRegistry.RLock()
+		defer kdfRegistry.RUnlock()
+		for id, reg := range kdfRegistry.kdfs {
+			if reg.name == name {
+				return id, nil
+			}
+		}
+		return 0, fmt.Errorf("%w: '%s'", ErrUnknownKDF, name)
+	}
+}
+
+// KDF is a key derivation function that may be used by a KeyGenerator in place of scrypt.
+// This allows using an organization's approved derivation function, while still using Lock, Unlock, and MultiLocker persistence.
+type KDF interface {
+	// Derive derives a Key from the pass phrase and salt, using the parameters set with SetKDF.
+	// The Key must be the same length as the Salt, which is the key size of the KeyGenerator.
+	// The parameters are opaque to this package, so Derive is responsible for validating them.
+	Derive(pass Passphrase, salt Salt, params []byte) (Key, error)
+}
+
+type registeredKDF struct {
+	name string
+	kdf  KDF
+}
+
+var kdfRegistry = struct {
+	sync.RWMutex
+	kdfs map[KDFID]registeredKDF
+}{
+	kdfs: map[KDFID]registeredKDF{},
+}
+
+// RegisterKDF registers a KDF with an ID and name, so it may be used with SetKDF.
+// The ID is persisted with a KeyGenerator's settings, so the same KDF must be registered with the same ID before a MultiLocker using it can be read.
+// IDs and names may only be registered once, and the IDs of KDFs provided by this package may not be used.
+// This is intended to be called during program initialization.
+func RegisterKDF(id KDFID, name string, kdf KDF) error {
+	if id == 0 || id == KDFScrypt {
+		return fmt.Errorf("KDF ID %d is reserved", id)
+	}
+	if len(name) == 0 {
+		return errors.New("KDF name cannot be empty")
+	}
+	if kdf == nil {
+		return errors.New("KDF cannot be nil")
+	}
+	if _, err := ParseKDFID(name); err == nil {
+		return fmt.Errorf("KDF name '%s' is already registered", name)
+	}
+	kdfRegistry.Lock()
+	defer kdfRegistry.Unlock()
+	if _, ok := kdfRegistry.kdfs[id]; ok {
+		return fmt.Errorf("KDF ID %d is already registered", id)
+	}
+	kdfRegistry.kdfs[id] = registeredKDF{
+		name: name,
+		kdf:  kdf,
+	}
+	return nil
+}
+
+func lookupKDF(id KDFID) (registeredKDF, bool) {
+	kdfRegistry.RLock()
+	defer kdfRegistry.RUnlock()
+	reg, ok := kdfRegistry.kdfs[id]
+	return reg, ok
+}
+
+// SetKDF selects the key derivation function with the given KDFID, which must be KDFScrypt or registered with RegisterKDF.
+// The parameters are passed to KDF.Derive, and are persisted with the KeyGenerator's settings.
+// Parameters may not be given for KDFScrypt, since scrypt is configured with the other GeneratorOpt functions.
+func SetKDF(id KDFID, params []byte) GeneratorOpt {
+	return func(gen *KeyGenerator) error {
+		if id == KDFScrypt {
+			if len(params) > 0 {
+				return fmt.Errorf("%s parameters are set with GeneratorOpt functions", KDFScrypt)
+			}
+			gen.kdf = KDFScrypt
+			gen.kdfParams = nil
+			return nil
+		}
+		if _, ok := lookupKDF(id); !ok {
+			return fmt.Errorf("%w: %s", ErrUnknownKDF, id)
+		}
+		if len(params) > math.MaxUint16 {
+			return fmt.Errorf("KDF parameters may be at most %d bytes", math.MaxUint16)
+		}
+		gen.kdf = id
+		gen.kdfParams = append([]byte{}, params...)
+		return nil
+	}
+}
@@ -0,0 +1,137 @@
+package passlock
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/pbkdf2"
+	"testing"
+)
+
+const (
+	testKDFPBKDF2 KDFID = 200
+	testKDFShort  KDFID = 201
+)
+
+// testPBKDF2 uses the parameters as a big endian uint32 iteration count.
+type testPBKDF2 struct{}
+
+func (testPBKDF2) Derive(pass Passphrase, salt Salt, params []byte) (Key, error) {
+	if len(params) != 4 {
+		return nil, errors.New("expected a 4 byte iteration count")
+	}
+	return pbkdf2.Key(pass, salt, int(binary.BigEndian.Uint32(params)), len(salt), sha256.New), nil
+}
+
+type testShortKDF struct{}
+
+func (testShortKDF) Derive(_ Passphrase, _ Salt, _ []byte) (Key, error) {
+	return Key("too short"), nil
+}
+
+func init() {
+	if err := RegisterKDF(testKDFPBKDF2, "test-pbkdf2", testPBKDF2{}); err != nil {
+		panic(err)
+	}
+	if err := RegisterKDF(testKDFShort, "test-short", testShortKDF{}); err != nil {
+		panic(err)
+	}
+}
+
+func TestRegisterKDF_Neg(t *testing.T) {
+	tests := map[string]struct {
+		id   KDFID
+		name string
+		kdf  KDF
+	}{
+		"Zero ID":        {0, "zero", testPBKDF2{}},
+		"Scrypt ID":      {KDFScrypt, "other-scrypt", testPBKDF2{}},
+		"Duplicate ID":   {testKDFPBKDF2, "other-pbkdf2", testPBKDF2{}},
+		"Duplicate name": {250, "test-pbkdf2", testPBKDF2{}},
+		"Scrypt name":    {250, "scrypt", testPBKDF2{}},
+		"Empty name":     {250, "", testPBKDF2{}},
+		"Nil KDF":        {250, "nil", nil},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert.Error(t, RegisterKDF(tc.id, tc.name, tc.kdf))
+		})
+	}
+	assert.Equal(t, "unknown(250)", KDFID(250).String(), "Failed registrations shouldn't be retained")
+}
+
+func TestSetKDF(t *testing.T) {
+	params := binary.BigEndian.AppendUint32(nil, 1000)
+	gen, err := NewKeyGenerator(SetKDF(testKDFPBKDF2, params))
+	require.NoError(t, err)
+	assert.Equal(t, testKDFPBKDF2, gen.KDF())
+	assert.Equal(t, "test-pbkdf2", gen.KDF().String())
+	assert.Zero(t, gen.MemoryRequired())
+	id, err := ParseKDFID("test-pbkdf2")
+	require.NoError(t, err)
+	assert.Equal(t, testKDFPBKDF2, id)
+
+	key, salt, err := gen.GenerateKey(Passphrase("a test password"))
+	require.NoError(t, err)
+	assert.Equal(t, pbkdf2.Key([]byte("a test password"), salt, 1000, len(salt), sha256.New), []byte(key))
+
+	gen, err = NewKeyGenerator(SetKDF(testKDFPBKDF2, params), SetKDF(KDFScrypt, nil))
+	require.NoError(t, err)
+	assert.Equal(t, KDFScrypt, gen.KDF())
+	assert.Empty(t, gen.kdfParams)
+
+	_, err = NewKeyGenerator(SetKDF(0xff, nil))
+	assert.ErrorIs(t, err, ErrUnknownKDF)
+	_, err = NewKeyGenerator(SetKDF(KDFScrypt, params))
+	assert.Error(t, err, "Scrypt parameters should be set with other options")
+
+	gen, err = NewKeyGenerator(SetKDF(testKDFPBKDF2, []byte{1}))
+	require.NoError(t, err)
+	_, _, err = gen.GenerateKey(Passphrase("a test password"))
+	assert.ErrorContains(t, err, "iteration count", "KDF errors should be returned")
+
+	gen, err = NewKeyGenerator(SetKDF(testKDFShort, nil))
+	require.NoError(t, err)
+	_, _, err = gen.GenerateKey(Passphrase("a test password"))
+	assert.ErrorContains(t, err, "bytes are required", "Keys of the wrong length should be rejected")
+}
+
+func TestSetKDF_Codecs(t *testing.T) {
+	var (
+		basePass = Passphrase("base key pass")
+		surPass  = Passphrase("sur key pass")
+		payload  = Plaintext("test payload")
+	)
+	gen, err := NewKeyGenerator(SetKDF(testKDFPBKDF2, binary.BigEndian.AppendUint32(nil, 1000)))
+	require.NoError(t, err)
+	mk := NewMultiLocker(gen)
+	require.NoError(t, mk.Lock(basePass, payload))
+	require.NoError(t, mk.AddSurrogatePass("developer", surPass))
+
+	tests := map[string]Codec{
+		"Binary":   BinaryCodec,
+		"Armored":  ArmoredCodec,
+		"JSON":     JSONCodec,
+		"Protobuf": ProtobufCodec,
+	}
+	for name, codec := range tests {
+		t.Run(name, func(t *testing.T) {
+			var buf bytes.Buffer
+			require.NoError(t, codec.Encode(&buf, mk))
+			read, err := codec.Decode(&buf)
+			require.NoError(t, err)
+			assert.Equal(t, gen.kdf, read.keyGen.kdf)
+			assert.Equal(t, gen.kdfParams, read.keyGen.kdfParams)
+
+			data, err := read.Unlock(basePass)
+			require.NoError(t, err)
+			assert.Equal(t, payload, data)
+			data, err = read.SurrogateUnlock("developer", surPass)
+			require.NoError(t, err)
+			assert.Equal(t, payload, data)
+		})
+	}
+}
@@ -1,11 +1,17 @@
 package passlock
 
 import (
+	"bytes"
 	"crypto/rand"
+	"encoding/binary"
 	"errors"
 	"fmt"
 	bin "github.com/saylorsolutions/binmap"
 	"golang.org/x/crypto/scrypt"
+	"io"
+	"math"
+	"math/bits"
+	"sync/atomic"
 )
 
 const (
@@ -20,6 +26,7 @@ const (
 var (
 	ErrEmptyPassPhrase = errors.New("cannot use an empty passphrase")
 	ErrInvalidData     = errors.New("unable to use input data")
+	ErrMemoryBudget    = errors.New("key generation parameters exceed the memory budget")
 )
 
 // Key is an AES key that can be used to encrypt or decrypt an encrypted payload.
@@ -38,18 +45,122 @@ type Encrypted []byte
 type Plaintext []byte
 
 type KeyGenerator struct {
+	kdf               KDFID
+	kdfParams         []byte
+	cipher            CipherID
 	iterations        uint64
 	relativeBlockSize uint8
 	cpuCost           uint8
 	aesKeySize        uint8
+
+	random    io.Reader
+	maxMemory uint64
 }
 
-func (g *KeyGenerator) mapper() bin.Mapper {
+// mapper reads and writes the KeyGenerator settings in the layout for the given MultiLocker format version.
+func (g *KeyGenerator) mapper(version uint16) bin.Mapper {
+	return bin.MapSequence(
+		g.kdfMapper(version),
+		g.cipherMapper(version),
+	)
+}
+
+// kdfMapper maps the key derivation settings.
+// Before kdfFormatVersion, only scrypt parameters were stored.
+// From kdfFormatVersion, the KDFID and key size are followed by a length-prefixed, algorithm-specific parameter block.
+func (g *KeyGenerator) kdfMapper(version uint16) bin.Mapper {
+	if version < kdfFormatVersion {
+		return bin.MapSequence(
+			bin.Any(
+				func(_ io.Reader, _ binary.ByteOrder) error {
+					g.kdf = KDFScrypt
+					return nil
+				},
+				func(_ io.Writer, _ binary.ByteOrder) error {
+					if g.kdf != KDFScrypt {
+						return fmt.Errorf("%w: format version %d only supports %s", ErrUnknownKDF, version, KDFScrypt)
+					}
+					return nil
+				},
+			),
+			g.scryptMapper(),
+			bin.Byte(&g.aesKeySize),
+		)
+	}
+	return bin.MapSequence(
+		bin.Byte((*uint8)(&g.kdf)),
+		bin.Byte(&g.aesKeySize),
+		bin.Any(
+			func(r io.Reader, endian binary.ByteOrder) error {
+				var (
+					params []byte
+					length uint16
+				)
+				if err := bin.LenBytes(&params, &length).Read(r, endian); err != nil {
+					return err
+				}
+				mapper, err := g.paramMapper()
+				if err != nil {
+					return err
+				}
+				pr := bytes.NewReader(params)
+				if err := mapper.Read(pr, endian); err != nil {
+					return fmt.Errorf("invalid %s parameters: %v", g.kdf, err)
+				}
+				if pr.Len() != 0 {
+					return fmt.Errorf("invalid %s parameters: %d unexpected trailing bytes", g.kdf, pr.Len())
+				}
+				return nil
+			},
+			func(w io.Writer, endian binary.ByteOrder) error {
+				mapper, err := g.paramMapper()
+				if err != nil {
+					return err
+				}
+				var buf bytes.Buffer
+				if err := mapper.Write(&buf, endian); err != nil {
+					return err
+				}
+				params := buf.Bytes()
+				length := uint16(len(params))
+				return bin.LenBytes(&params, &length).Write(w, endian)
+			},
+		),
+	)
+}
+
+// paramMapper returns the mapper for the KDF specific parameter block.
+// The parameters for a registered KDF are stored as-is.
+func (g *KeyGenerator) paramMapper() (bin.Mapper, error) {
+	switch g.kdf {
+	case KDFScrypt:
+		return g.scryptMapper(), nil
+	default:
+		if _, ok := lookupKDF(g.kdf); !ok {
+			return nil, fmt.Errorf("%w: %s", ErrUnknownKDF, g.kdf)
+		}
+		return bin.Any(
+			func(r io.Reader, _ binary.ByteOrder) error {
+				params, err := io.ReadAll(r)
+				if err != nil {
+					return err
+				}
+				g.kdfParams = params
+				return nil
+			},
+			func(w io.Writer, _ binary.ByteOrder) error {
+				_, err := w.Write(g.kdfParams)
+				return err
+			},
+		), nil
+	}
+}
+
+func (g *KeyGenerator) scryptMapper() bin.Mapper {
 	return bin.MapSequence(
 		bin.Int(&g.iterations),
 		bin.Byte(&g.relativeBlockSize),
 		bin.Byte(&g.cpuCost),
-		bin.Byte(&g.aesKeySize),
 	)
 }
 
@@ -129,10 +240,50 @@ func SetRelativeBlockSize(size uint8) GeneratorOpt {
 	}
 }
 
+// SetRandomSource overrides the entropy source used to generate salts and nonces, which is [crypto/rand.Reader] by default.
+// This is intended for tests and known answer vectors that need reproducible output, and should not be used otherwise.
+// The random source is not persisted with the KeyGenerator settings.
+func SetRandomSource(random io.Reader) GeneratorOpt {
+	return func(gen *KeyGenerator) error {
+		if random == nil {
+			return errors.New("random source cannot be nil")
+		}
+		gen.random = random
+		return nil
+	}
+}
+
+// SetMaxMemory limits the memory that scrypt may use for key generation to the given number of bytes.
+// A KeyGenerator with parameters that would exceed this budget will return ErrMemoryBudget, rather than risk the process being killed for running out of memory.
+// The memory budget is checked after all other options are applied, and is not persisted with the KeyGenerator settings.
+// Use SetDefaultMaxMemory to apply a budget to KeyGenerators read from storage.
+func SetMaxMemory(maxBytes uint64) GeneratorOpt {
+	return func(gen *KeyGenerator) error {
+		if maxBytes == 0 {
+			return errors.New("max memory must be greater than 0")
+		}
+		gen.maxMemory = maxBytes
+		return nil
+	}
+}
+
+// defaultMaxMemory is the memory budget for KeyGenerators that don't set one with SetMaxMemory.
+var defaultMaxMemory atomic.Uint64
+
+// SetDefaultMaxMemory sets the memory budget for every KeyGenerator that doesn't set its own with SetMaxMemory, including those read from a persisted MultiLocker.
+// Key generation settings read from storage are under the control of whoever can write to it, so a corrupted or hostile store could otherwise make scrypt allocate an unbounded amount of memory when a key is derived.
+// A KeyGenerator with parameters that exceed the default budget returns ErrMemoryBudget when a key is generated or derived.
+// A budget of 0 removes the default, which is the initial setting.
+func SetDefaultMaxMemory(maxBytes uint64) {
+	defaultMaxMemory.Store(maxBytes)
+}
+
 // NewKeyGenerator creates a new KeyGenerator using the options provided as zero or more GeneratorOpt.
 // By default, the generator generates a key for AES256KeySize using DefaultLargeIterations.
 func NewKeyGenerator(opts ...GeneratorOpt) (*KeyGenerator, error) {
 	gen := &KeyGenerator{
+		kdf:               KDFScrypt,
+		cipher:            CipherAESGCM,
 		iterations:        DefaultLargeIterations,
 		relativeBlockSize: DefaultRelBlockSize,
 		cpuCost:           DefaultCpuCost,
@@ -144,22 +295,117 @@ func NewKeyGenerator(opts ...GeneratorOpt) (*KeyGenerator, error) {
 			return nil, err
 		}
 	}
+	if err := gen.checkMemory(); err != nil {
+		return nil, err
+	}
+	if err := gen.checkCipher(); err != nil {
+		return nil, err
+	}
 	return gen, nil
 }
 
+// MemoryRequired returns the approximate number of bytes that scrypt will allocate to generate a key with this KeyGenerator's settings.
+// The result is saturated at math.MaxUint64, and is 0 if a KDF other than scrypt is used.
+func (g *KeyGenerator) MemoryRequired() uint64 {
+	if g.kdf != KDFScrypt {
+		return 0
+	}
+	// scrypt allocates 128*r*N bytes for its working array, and 128*r*p bytes for the block buffer.
+	blockLen := 128 * uint64(g.relativeBlockSize)
+	hi, work := bits.Mul64(blockLen, g.iterations)
+	if hi != 0 {
+		return math.MaxUint64
+	}
+	total, carry := bits.Add64(work, blockLen*uint64(g.cpuCost), 0)
+	if carry != 0 {
+		return math.MaxUint64
+	}
+	return total
+}
+
+func (g *KeyGenerator) checkMemory() error {
+	budget := g.maxMemory
+	if budget == 0 {
+		budget = defaultMaxMemory.Load()
+	}
+	if budget == 0 {
+		return nil
+	}
+	if required := g.MemoryRequired(); required > budget {
+		return fmt.Errorf("%w: %d bytes required with N=%d, r=%d, p=%d, but the budget is %d bytes", ErrMemoryBudget, required, g.iterations, g.relativeBlockSize, g.cpuCost, budget)
+	}
+	return nil
+}
+
 // GenerateKey will generate an AES key and salt using the configuration of the KeyGenerator.
 func (g *KeyGenerator) GenerateKey(pass Passphrase) (key Key, salt Salt, err error) {
 	if len(pass) == 0 {
 		return nil, nil, ErrEmptyPassPhrase
 	}
+	if err := g.checkMemory(); err != nil {
+		return nil, nil, err
+	}
 	salt = make(Salt, g.aesKeySize)
-	if _, err = rand.Read(salt); err != nil {
+	if _, err = io.ReadFull(g.randomSource(), salt); err != nil {
 		return nil, nil, err
 	}
-	key, err = scrypt.Key(pass, salt, int(g.iterations), int(g.relativeBlockSize), int(g.cpuCost), int(g.aesKeySize))
+	key, err = g.deriveKey(pass, salt)
 	return key, salt, err
 }
 
+// DeriveKeyWithSalt will derive a key from the passphrase and a salt provided by the caller, instead of a salt taken from an encrypted payload.
+// The salt must be the same length as the key size of the KeyGenerator.
+// This is intended for deterministic key derivation with a fixed, application-specific salt, which gives up the protection that a random salt provides against precomputed guesses.
+func (g *KeyGenerator) DeriveKeyWithSalt(pass Passphrase, salt Salt) (Key, error) {
+	if len(pass) == 0 {
+		return nil, ErrEmptyPassPhrase
+	}
+	if len(salt) != int(g.aesKeySize) {
+		return nil, fmt.Errorf("%w: salt must be %d bytes", ErrInvalidData, g.aesKeySize)
+	}
+	if err := g.checkMemory(); err != nil {
+		return nil, err
+	}
+	return g.deriveKey(pass, salt)
+}
+
+func (g *KeyGenerator) deriveKey(pass Passphrase, salt Salt) (Key, error) {
+	switch g.kdf {
+	case KDFScrypt:
+		return scrypt.Key(pass, salt, int(g.iterations), int(g.relativeBlockSize), int(g.cpuCost), int(g.aesKeySize))
+	default:
+		reg, ok := lookupKDF(g.kdf)
+		if !ok {
+			return nil, fmt.Errorf("%w: %s", ErrUnknownKDF, g.kdf)
+		}
+		key, err := reg.kdf.Derive(pass, salt, g.kdfParams)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", g.kdf, err)
+		}
+		if len(key) != len(salt) {
+			return nil, fmt.Errorf("%s: derived a %d byte key, but %d bytes are required", g.kdf, len(key), len(salt))
+		}
+		return key, nil
+	}
+}
+
+// KDF returns the KDFID of the key derivation function used by this KeyGenerator.
+func (g *KeyGenerator) KDF() KDFID {
+	return g.kdf
+}
+
+func (g *KeyGenerator) clone() *KeyGenerator {
+	c := *g
+	return &c
+}
+
+func (g *KeyGenerator) randomSource() io.Reader {
+	if g.random == nil {
+		return rand.Reader
+	}
+	return g.random
+}
+
 // DeriveKey will recover a key with the salt in the payload and the given passphrase.
 // This doesn't ensure that the given passphrase is the *correct* passphrase used to encrypt the payload.
 func (g *KeyGenerator) DeriveKey(pass Passphrase, data Encrypted) (key Key, err error) {
@@ -176,8 +422,11 @@ func (g *KeyGenerator) DeriveKeySalt(pass Passphrase, data Encrypted) (key Key,
 	if uint64(len(data)) <= uint64(g.aesKeySize) {
 		return nil, nil, fmt.Errorf("%w: input data isn't long enough to contain a key salt", ErrInvalidData)
 	}
+	if err := g.checkMemory(); err != nil {
+		return nil, nil, err
+	}
 	salt = Salt(data[len(data)-int(g.aesKeySize):])
-	key, err = scrypt.Key(pass, salt, int(g.iterations), int(g.relativeBlockSize), int(g.cpuCost), int(g.aesKeySize))
+	key, err = g.deriveKey(pass, salt)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -3,7 +3,10 @@ package passlock
 import (
 	"bytes"
 	"encoding/binary"
+	"fmt"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"math"
 	"testing"
 )
 
@@ -45,24 +48,140 @@ func TestNewKeyGenerator_Custom(t *testing.T) {
 }
 
 func TestKeyGenerator_mapper(t *testing.T) {
-	var (
-		buf bytes.Buffer
-	)
+	for _, version := range []uint16{legacyFormatVersion, 1, CurrentFormatVersion} {
+		t.Run(fmt.Sprintf("Version %d", version), func(t *testing.T) {
+			var buf bytes.Buffer
+			gen, err := NewKeyGenerator(SetShortDelayIterations())
+			assert.NoError(t, err)
+			assert.NotNil(t, gen)
+
+			assert.NoError(t, gen.mapper(version).Write(&buf, binary.BigEndian))
+			updated, err := NewKeyGenerator(
+				SetIterations(1<<4),
+				SetCPUCost(4),
+				SetRelativeBlockSize(128),
+				SetAES128KeySize(),
+			)
+			assert.NoError(t, err)
+			updated.kdf = 0
+			assert.NoError(t, updated.mapper(version).Read(&buf, binary.BigEndian))
+			assert.Equal(t, 0, buf.Len())
+			assert.Equal(t, KDFScrypt, updated.KDF())
+			assert.Equal(t, DefaultInteractiveIterations, updated.iterations)
+			assert.Equal(t, DefaultCpuCost, updated.cpuCost)
+			assert.Equal(t, DefaultRelBlockSize, updated.relativeBlockSize)
+			assert.Equal(t, AES256KeySize, updated.aesKeySize)
+		})
+	}
+}
+
+func TestKeyGenerator_mapper_KDF(t *testing.T) {
+	var buf bytes.Buffer
 	gen, err := NewKeyGenerator(SetShortDelayIterations())
+	require.NoError(t, err)
+	require.NoError(t, gen.mapper(CurrentFormatVersion).Write(&buf, binary.BigEndian))
+	data := buf.Bytes()
+	assert.Equal(t, byte(KDFScrypt), data[0])
+
+	unknown := append([]byte{0xff}, data[1:]...)
+	err = new(KeyGenerator).mapper(CurrentFormatVersion).Read(bytes.NewReader(unknown), binary.BigEndian)
+	assert.ErrorIs(t, err, ErrUnknownKDF)
+
+	trailing := append([]byte{}, data...)
+	binary.BigEndian.PutUint16(trailing[2:], binary.BigEndian.Uint16(data[2:])+1)
+	trailing = append(trailing, 0)
+	err = new(KeyGenerator).mapper(CurrentFormatVersion).Read(bytes.NewReader(trailing), binary.BigEndian)
+	assert.ErrorContains(t, err, "trailing", "Unexpected trailing parameter bytes should be rejected")
+
+	gen.kdf = 0xff
+	assert.ErrorIs(t, gen.mapper(CurrentFormatVersion).Write(&buf, binary.BigEndian), ErrUnknownKDF)
+	assert.ErrorIs(t, gen.mapper(1).Write(&buf, binary.BigEndian), ErrUnknownKDF)
+	_, _, err = gen.GenerateKey([]byte("a test password"))
+	assert.ErrorIs(t, err, ErrUnknownKDF)
+
+	id, err := ParseKDFID(KDFScrypt.String())
 	assert.NoError(t, err)
-	assert.NotNil(t, gen)
+	assert.Equal(t, KDFScrypt, id)
+	_, err = ParseKDFID("argon2id")
+	assert.ErrorIs(t, err, ErrUnknownKDF)
+}
 
-	assert.NoError(t, gen.mapper().Write(&buf, binary.BigEndian))
-	updated, err := NewKeyGenerator(
-		SetIterations(1<<4),
-		SetCPUCost(4),
-		SetRelativeBlockSize(128),
-		SetAES128KeySize(),
-	)
+func TestSetRandomSource(t *testing.T) {
+	_, err := NewKeyGenerator(SetRandomSource(nil))
+	assert.Error(t, err, "Nil random source should be rejected")
+
+	gen, err := NewKeyGenerator(SetShortDelayIterations(), SetRandomSource(bytes.NewReader(nil)))
+	assert.NoError(t, err)
+	_, _, err = gen.GenerateKey([]byte("a test password"))
+	assert.Error(t, err, "An exhausted random source should return an error")
+}
+
+func TestSetMaxMemory(t *testing.T) {
+	gen, err := NewKeyGenerator(SetShortDelayIterations(), SetMaxMemory(1<<30))
 	assert.NoError(t, err)
-	assert.NoError(t, updated.mapper().Read(&buf, binary.BigEndian))
-	assert.Equal(t, DefaultInteractiveIterations, updated.iterations)
-	assert.Equal(t, DefaultCpuCost, updated.cpuCost)
-	assert.Equal(t, DefaultRelBlockSize, updated.relativeBlockSize)
-	assert.Equal(t, AES256KeySize, updated.aesKeySize)
+	assert.Equal(t, uint64(128*8*DefaultInteractiveIterations+128*8), gen.MemoryRequired())
+
+	_, err = NewKeyGenerator(SetMaxMemory(1 << 30))
+	assert.ErrorIs(t, err, ErrMemoryBudget, "Default long delay iterations should exceed a 1GiB budget")
+	_, err = NewKeyGenerator(SetMaxMemory(1<<30), SetShortDelayIterations(), SetRelativeBlockSize(255), SetIterations(1<<20))
+	assert.ErrorIs(t, err, ErrMemoryBudget, "Budget should be checked after all options are applied")
+	_, err = NewKeyGenerator(SetMaxMemory(0))
+	assert.Error(t, err)
+
+	huge, err := NewKeyGenerator(SetIterations(1<<62), SetRelativeBlockSize(255))
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(math.MaxUint64), huge.MemoryRequired(), "Memory calculation should saturate")
+	huge.maxMemory = 1 << 30
+	_, _, err = huge.GenerateKey([]byte("a test password"))
+	assert.ErrorIs(t, err, ErrMemoryBudget)
+	_, _, err = huge.DeriveKeySalt([]byte("a test password"), make(Encrypted, 64))
+	assert.ErrorIs(t, err, ErrMemoryBudget)
+}
+
+func TestSetDefaultMaxMemory(t *testing.T) {
+	var (
+		basePass = Passphrase("base key pass")
+		surPass  = Passphrase("sur key pass")
+	)
+	mk := newTestMultiLocker(t, basePass, surPass, Plaintext("test payload"))
+	var buf bytes.Buffer
+	require.NoError(t, mk.Write(&buf))
+	stored := buf.Bytes()
+	mk.keyGen.iterations = 1 << 40
+	buf = bytes.Buffer{}
+	require.NoError(t, mk.Write(&buf))
+	oversized := buf.Bytes()
+
+	SetDefaultMaxMemory(1 << 30)
+	t.Cleanup(func() {
+		SetDefaultMaxMemory(0)
+	})
+	read, err := ReadMultiLocker(bytes.NewReader(oversized))
+	require.NoError(t, err)
+	_, err = read.Unlock(basePass)
+	assert.ErrorIs(t, err, ErrMemoryBudget, "The default budget should apply to settings read from storage")
+
+	read, err = ReadMultiLocker(bytes.NewReader(stored))
+	require.NoError(t, err)
+	_, err = read.Unlock(basePass)
+	assert.NoError(t, err, "Settings within the default budget should be unaffected")
+
+	gen, err := NewKeyGenerator(SetIterations(1<<40), SetMaxMemory(math.MaxUint64))
+	assert.NoError(t, err, "SetMaxMemory should override the default budget")
+	assert.NoError(t, gen.checkMemory())
+}
+
+func TestKeyGenerator_DeriveKeyWithSalt(t *testing.T) {
+	gen, err := NewKeyGenerator(SetShortDelayIterations())
+	require.NoError(t, err)
+	key, salt, err := gen.GenerateKey([]byte("a test password"))
+	require.NoError(t, err)
+	derived, err := gen.DeriveKeyWithSalt([]byte("a test password"), salt)
+	require.NoError(t, err)
+	assert.Equal(t, key, derived)
+
+	_, err = gen.DeriveKeyWithSalt(nil, salt)
+	assert.ErrorIs(t, err, ErrEmptyPassPhrase)
+	_, err = gen.DeriveKeyWithSalt([]byte("a test password"), salt[:AES128KeySize])
+	assert.ErrorIs(t, err, ErrInvalidData)
 }
@@ -0,0 +1,195 @@
+package passlock
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	bin "github.com/saylorsolutions/binmap"
+	"io"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	labelFieldLen = 255
+)
+
+var (
+	ErrSurrogateExpired  = errors.New("surrogate key has expired")
+	ErrSurrogateReadOnly = errors.New("surrogate key is read only")
+)
+
+// surrogateInfo is descriptive metadata for a surrogate key that can be read without unlocking the payload.
+type surrogateInfo struct {
+	label    string
+	created  time.Time
+	expires  time.Time
+	readOnly bool
+}
+
+func (i *surrogateInfo) mapper() bin.Mapper {
+	return bin.MapSequence(
+		bin.Any(
+			func(r io.Reader, endian binary.ByteOrder) error {
+				var (
+					label  []byte
+					length uint8
+				)
+				if err := bin.LenBytes(&label, &length).Read(r, endian); err != nil {
+					return err
+				}
+				i.label = string(label)
+				return nil
+			},
+			func(w io.Writer, endian binary.ByteOrder) error {
+				label := []byte(i.label)
+				length := uint8(len(label))
+				return bin.LenBytes(&label, &length).Write(w, endian)
+			},
+		),
+		timeMapper(&i.created),
+		timeMapper(&i.expires),
+		bin.Bool(&i.readOnly),
+	)
+}
+
+// timeMapper maps a time.Time as Unix seconds, with 0 representing the zero time.
+func timeMapper(t *time.Time) bin.Mapper {
+	return bin.Any(
+		func(r io.Reader, endian binary.ByteOrder) error {
+			var secs int64
+			if err := bin.Int(&secs).Read(r, endian); err != nil {
+				return err
+			}
+			*t = unixTime(secs)
+			return nil
+		},
+		func(w io.Writer, endian binary.ByteOrder) error {
+			secs := timeUnix(*t)
+			return bin.Int(&secs).Write(w, endian)
+		},
+	)
+}
+
+func unixTime(secs int64) time.Time {
+	if secs == 0 {
+		return time.Time{}
+	}
+	return time.Unix(secs, 0).UTC()
+}
+
+func timeUnix(t time.Time) int64 {
+	if t.IsZero() {
+		return 0
+	}
+	return t.Unix()
+}
+
+func (i *surrogateInfo) keyInfo(id string) KeyInfo {
+	return KeyInfo{
+		ID:       id,
+		Label:    i.label,
+		Created:  i.created,
+		Expires:  i.expires,
+		Writable: !i.readOnly,
+	}
+}
+
+// SurrogateOpt sets metadata for a surrogate key when it's added with MultiLocker.AddSurrogatePass.
+type SurrogateOpt = func(*surrogateInfo) error
+
+// WithLabel sets a human-readable label for a surrogate key, such as the name of the person or system that uses it.
+// The label may be at most 255 bytes.
+func WithLabel(label string) SurrogateOpt {
+	return func(info *surrogateInfo) error {
+		if len(label) > labelFieldLen {
+			return fmt.Errorf("label is longer than the maximum of %d bytes", labelFieldLen)
+		}
+		info.label = label
+		return nil
+	}
+}
+
+// WithExpiry sets a time after which the surrogate key may no longer be used to unlock the payload.
+// Expired surrogate keys are not removed automatically.
+func WithExpiry(expires time.Time) SurrogateOpt {
+	return func(info *surrogateInfo) error {
+		if expires.IsZero() {
+			return errors.New("expiry time must be set")
+		}
+		info.expires = expires.Truncate(time.Second).UTC()
+		return nil
+	}
+}
+
+// ReadOnly prevents the surrogate key from being used to lock a new payload with WriteMultiLocker.SurrogateLock.
+func ReadOnly() SurrogateOpt {
+	return func(info *surrogateInfo) error {
+		info.readOnly = true
+		return nil
+	}
+}
+
+// KeyInfo describes a surrogate key in a MultiLocker.
+type KeyInfo struct {
+	// ID is the surrogate key ID.
+	ID string
+	// Label is the human-readable label of the surrogate key, if any.
+	Label string
+	// Created is when the surrogate key was added, and will be zero for keys added before this was recorded.
+	Created time.Time
+	// Expires is when the surrogate key expires, and will be zero if it doesn't expire.
+	Expires time.Time
+	// Writable indicates whether the surrogate key may be used with WriteMultiLocker.SurrogateLock.
+	Writable bool
+}
+
+// Expired reports whether the surrogate key is expired at the given time.
+func (i KeyInfo) Expired(at time.Time) bool {
+	return !i.Expires.IsZero() && !at.Before(i.Expires)
+}
+
+// KeyFilter selects the surrogate keys returned from MultiLocker.ListKeys.
+// The zero value matches all keys.
+type KeyFilter struct {
+	// IDPrefix matches keys with an ID that starts with the given prefix.
+	IDPrefix string
+	// Label matches keys with exactly the given label.
+	Label string
+	// ExcludeExpired excludes keys that are expired at the time ListKeys is called.
+	ExcludeExpired bool
+	// WritableOnly excludes read only keys.
+	WritableOnly bool
+}
+
+func (f KeyFilter) matches(info KeyInfo, now time.Time) bool {
+	switch {
+	case !strings.HasPrefix(info.ID, f.IDPrefix):
+		return false
+	case len(f.Label) > 0 && info.Label != f.Label:
+		return false
+	case f.ExcludeExpired && info.Expired(now):
+		return false
+	case f.WritableOnly && !info.Writable:
+		return false
+	}
+	return true
+}
+
+// ListKeys lists the surrogate keys in this MultiLocker that match the filter, ordered by ID.
+// This doesn't require unlocking the payload.
+func (l *MultiLocker) ListKeys(filter KeyFilter) []KeyInfo {
+	now := time.Now()
+	var keys []KeyInfo
+	for id, sur := range l.surKeys {
+		info := sur.info.keyInfo(id)
+		if filter.matches(info, now) {
+			keys = append(keys, info)
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return keys[i].ID < keys[j].ID
+	})
+	return keys
+}
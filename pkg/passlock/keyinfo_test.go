@@ -0,0 +1,107 @@
+package passlock
+
+import (
+	"bytes"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMultiLocker_ListKeys(t *testing.T) {
+	var (
+		basePass = Passphrase("base key pass")
+		surPass  = Passphrase("sur key pass")
+		expired  = time.Now().Add(-time.Hour)
+		expires  = time.Now().Add(time.Hour)
+	)
+	mk := newTestMultiLocker(t, basePass, surPass, Plaintext("test payload"))
+	require.NoError(t, mk.EnableUpdate(basePass))
+	require.NoError(t, mk.AddSurrogatePass("ci-build", surPass, WithLabel("CI"), ReadOnly()))
+	before := time.Now().Truncate(time.Second)
+	require.NoError(t, mk.AddSurrogatePass("ci-deploy", surPass, WithLabel("CI"), WithExpiry(expires)))
+	after := time.Now()
+	require.NoError(t, mk.AddSurrogatePass("contractor", surPass, WithExpiry(expired)))
+	mk.DisableUpdate()
+
+	var buf bytes.Buffer
+	require.NoError(t, mk.Write(&buf))
+	mk, err := ReadMultiLocker(&buf)
+	require.NoError(t, err)
+
+	ids := func(keys []KeyInfo) []string {
+		var ids []string
+		for _, key := range keys {
+			ids = append(ids, key.ID)
+		}
+		return ids
+	}
+	tests := map[string]struct {
+		filter   KeyFilter
+		expected []string
+	}{
+		"All":             {KeyFilter{}, []string{"ci-build", "ci-deploy", "contractor", "developer"}},
+		"Prefix":          {KeyFilter{IDPrefix: "ci-"}, []string{"ci-build", "ci-deploy"}},
+		"Label":           {KeyFilter{Label: "CI"}, []string{"ci-build", "ci-deploy"}},
+		"Exclude expired": {KeyFilter{ExcludeExpired: true}, []string{"ci-build", "ci-deploy", "developer"}},
+		"Writable":        {KeyFilter{WritableOnly: true, IDPrefix: "c"}, []string{"ci-deploy", "contractor"}},
+		"No match":        {KeyFilter{IDPrefix: "nope"}, nil},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, ids(mk.ListKeys(tc.filter)))
+		})
+	}
+
+	keys := mk.ListKeys(KeyFilter{IDPrefix: "ci-deploy"})
+	require.Len(t, keys, 1)
+	assert.Equal(t, "CI", keys[0].Label)
+	assert.True(t, keys[0].Writable)
+	assert.Equal(t, expires.Truncate(time.Second).UTC(), keys[0].Expires)
+	assert.False(t, keys[0].Created.Before(before), "Created should be no earlier than when the key was added")
+	assert.False(t, keys[0].Created.After(after), "Created should be no later than when the key was added")
+	assert.False(t, keys[0].Expired(time.Now()))
+	assert.True(t, keys[0].Expired(expires.Add(time.Second)))
+}
+
+func TestMultiLocker_SurrogateInfo_Enforced(t *testing.T) {
+	var (
+		basePass = Passphrase("base key pass")
+		surPass  = Passphrase("sur key pass")
+		payload  = Plaintext("test payload")
+	)
+	gen, err := NewKeyGenerator(SetShortDelayIterations())
+	require.NoError(t, err)
+	mk := NewWriteMultiLocker(gen)
+	require.NoError(t, mk.Lock(basePass, payload))
+	require.NoError(t, mk.AddSurrogatePass("reader", surPass, ReadOnly(), WithLabel("Reader")))
+	require.NoError(t, mk.AddSurrogatePass("expired", surPass, WithExpiry(time.Now().Add(-time.Minute))))
+
+	data, err := mk.SurrogateUnlock("reader", surPass)
+	require.NoError(t, err)
+	assert.Equal(t, payload, data)
+	assert.ErrorIs(t, mk.SurrogateLock("reader", surPass, Plaintext("new payload")), ErrSurrogateReadOnly)
+
+	_, err = mk.SurrogateUnlock("expired", surPass)
+	assert.ErrorIs(t, err, ErrSurrogateExpired)
+	assert.ErrorIs(t, mk.SurrogateLock("expired", surPass, Plaintext("new payload")), ErrSurrogateExpired)
+
+	require.NoError(t, mk.UpdateSurrogatePass("reader", Passphrase("new sur pass")))
+	keys := mk.ListKeys(KeyFilter{IDPrefix: "reader"})
+	require.Len(t, keys, 1)
+	assert.Equal(t, "Reader", keys[0].Label, "Metadata should be retained when the passphrase is updated")
+	assert.False(t, keys[0].Writable)
+}
+
+func TestSurrogateOpt_Neg(t *testing.T) {
+	var (
+		basePass = Passphrase("base key pass")
+		surPass  = Passphrase("sur key pass")
+	)
+	mk := newTestMultiLocker(t, basePass, surPass, Plaintext("test payload"))
+	require.NoError(t, mk.EnableUpdate(basePass))
+	assert.Error(t, mk.AddSurrogatePass("long", surPass, WithLabel(strings.Repeat("a", labelFieldLen+1))))
+	assert.Error(t, mk.AddSurrogatePass("zero", surPass, WithExpiry(time.Time{})))
+	assert.Equal(t, []string{"developer"}, mk.ListKeyIDs())
+}
@@ -4,45 +4,110 @@ import (
 	"crypto/aes"
 	"crypto/cipher"
 	"crypto/rand"
+	"fmt"
+	"io"
 )
 
 // Lock will encrypt the payload with the given Key, and append the given Salt to the payload.
+// ErrPayloadTooLarge is returned if the payload is larger than MaxPlaintextSize, use LockLarge for very large payloads.
 // Exposure of the Salt doesn't weaken the Key, since the passphrase is also required to arrive at the same Key.
 // Salt exposure is required to be able to derive the same Key from the same passphrase.
 // However, tampering with the Salt or the payload would prevent Unlock from recovering the Plaintext payload.
 func Lock(key Key, salt Salt, data Plaintext) (Encrypted, error) {
-	block, err := aes.NewCipher(key)
+	return LockWithSource(rand.Reader, key, salt, data)
+}
+
+// LockWithSource is the same as Lock, except that the nonce is read from the given random source.
+// This is intended for reproducible output in tests, and the source must be cryptographically secure otherwise.
+func LockWithSource(random io.Reader, key Key, salt Salt, data Plaintext) (Encrypted, error) {
+	l, err := NewLocker(key, salt)
 	if err != nil {
 		return nil, err
 	}
-	gcm, err := cipher.NewGCM(block)
+	return l.SealWithSource(random, data)
+}
+
+// Unlock will decrypt the payload after stripping the Salt from the end of it.
+// The Salt length is expected to match the Key length (which is enforced by KeyGenerator).
+func Unlock(key Key, data Encrypted) (Plaintext, error) {
+	l, err := NewLocker(key, nil)
 	if err != nil {
 		return nil, err
 	}
+	return l.Open(data)
+}
+
+// Locker performs the same operations as Lock and Unlock, but constructs the underlying cipher only once for a Key.
+// This is much more efficient when many small payloads are encrypted or decrypted with the same Key.
+// A Locker is safe for concurrent use.
+type Locker struct {
+	keyLen int
+	salt   Salt
+	aead   cipher.AEAD
+}
 
-	nonce := make([]byte, gcm.NonceSize())
-	if _, err := rand.Read(nonce); err != nil {
+// NewLocker creates a Locker for the given Key, which will append the given Salt to payloads encrypted with Seal.
+// The Salt may be nil if the Locker will only be used to Open payloads.
+func NewLocker(key Key, salt Salt) (*Locker, error) {
+	gcm, err := newAEAD(key)
+	if err != nil {
 		return nil, err
 	}
+	return &Locker{
+		keyLen: len(key),
+		salt:   salt,
+		aead:   gcm,
+	}, nil
+}
 
-	cipherText := append(gcm.Seal(nonce, nonce, data, nil), salt...)
-	return cipherText, nil
+// Seal encrypts the payload in the same way as Lock.
+func (l *Locker) Seal(data Plaintext) (Encrypted, error) {
+	return l.SealWithSource(rand.Reader, data)
 }
 
-// Unlock will decrypt the payload after stripping the Salt from the end of it.
-// The Salt length is expected to match the Key length (which is enforced by KeyGenerator).
-func Unlock(key Key, data Encrypted) (Plaintext, error) {
-	block, err := aes.NewCipher(key)
+// SealWithSource is the same as Seal, except that the nonce is read from the given random source.
+// See LockWithSource for details.
+func (l *Locker) SealWithSource(random io.Reader, data Plaintext) (Encrypted, error) {
+	if err := checkPlaintextSize(uint64(len(data))); err != nil {
+		return nil, err
+	}
+	cipherText, err := seal(random, l.aead, data)
 	if err != nil {
 		return nil, err
 	}
-	gcm, err := cipher.NewGCM(block)
+	return append(cipherText, l.salt...), nil
+}
+
+// Open decrypts the payload in the same way as Unlock.
+func (l *Locker) Open(data Encrypted) (Plaintext, error) {
+	if len(data) < l.keyLen {
+		return nil, fmt.Errorf("%w: input data isn't long enough to contain a key salt", ErrInvalidData)
+	}
+	return open(l.aead, data[:len(data)-l.keyLen])
+}
+
+func newAEAD(key Key) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
 	if err != nil {
 		return nil, err
 	}
+	return cipher.NewGCM(block)
+}
 
-	nonceSize := gcm.NonceSize()
-	nonce, cipherText := data[:nonceSize], data[nonceSize:]
-	cipherText = cipherText[:len(cipherText)-len(key)]
-	return gcm.Open(nil, nonce, cipherText, nil)
+// seal encrypts the data with a random nonce, and prepends the nonce to the output.
+func seal(random io.Reader, aead cipher.AEAD, data []byte) ([]byte, error) {
+	nonce := make([]byte, aead.NonceSize(), aead.NonceSize()+len(data)+aead.Overhead())
+	if _, err := io.ReadFull(random, nonce); err != nil {
+		return nil, err
+	}
+	return aead.Seal(nonce, nonce, data, nil), nil
+}
+
+// open decrypts data created with seal.
+func open(aead cipher.AEAD, data []byte) ([]byte, error) {
+	nonceSize := aead.NonceSize()
+	if len(data) < nonceSize+aead.Overhead() {
+		return nil, fmt.Errorf("%w: input data isn't long enough to contain a nonce and tag", ErrInvalidData)
+	}
+	return aead.Open(nil, data[:nonceSize], data[nonceSize:], nil)
 }
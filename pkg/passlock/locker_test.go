@@ -1,7 +1,10 @@
 package passlock
 
 import (
+	"bytes"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"math/rand/v2"
 	"testing"
 )
 
@@ -56,3 +59,103 @@ func TestLockUnlock128(t *testing.T) {
 	assert.NotEqual(t, encrypted, unencrypted)
 	assert.Equal(t, data, string(unencrypted))
 }
+
+func TestLockWithSource_Reproducible(t *testing.T) {
+	const password = "password"
+	const data = "How wonderful life is while you're in the world"
+	var seed [32]byte
+	copy(seed[:], "a fixed seed for reproducibility")
+
+	lockWithSeed := func() Encrypted {
+		random := rand.NewChaCha8(seed)
+		gen, err := NewKeyGenerator(SetShortDelayIterations(), SetRandomSource(random))
+		require.NoError(t, err)
+		key, salt, err := gen.GenerateKey([]byte(password))
+		require.NoError(t, err)
+		encrypted, err := LockWithSource(random, key, salt, []byte(data))
+		require.NoError(t, err)
+		return encrypted
+	}
+
+	first, second := lockWithSeed(), lockWithSeed()
+	assert.Equal(t, first, second, "Same seed should produce the same ciphertext")
+
+	gen, err := NewKeyGenerator(SetShortDelayIterations())
+	require.NoError(t, err)
+	key, err := gen.DeriveKey([]byte(password), first)
+	require.NoError(t, err)
+	unencrypted, err := Unlock(key, first)
+	assert.NoError(t, err)
+	assert.Equal(t, data, string(unencrypted))
+}
+
+func TestLockWithSource_Neg(t *testing.T) {
+	key := make(Key, AES256KeySize)
+	salt := make(Salt, AES256KeySize)
+	_, err := LockWithSource(bytes.NewReader(nil), key, salt, []byte("data"))
+	assert.Error(t, err, "An exhausted random source should return an error")
+}
+
+func TestUnlock_Neg(t *testing.T) {
+	key := make(Key, AES256KeySize)
+	_, err := Unlock(key, Encrypted{0x0})
+	assert.ErrorIs(t, err, ErrInvalidData, "Short input should not panic")
+	_, err = Unlock(key, make(Encrypted, len(key)+1))
+	assert.ErrorIs(t, err, ErrInvalidData, "Short input should not panic")
+}
+
+func TestLocker(t *testing.T) {
+	const password = "password"
+	gen, err := NewKeyGenerator(SetShortDelayIterations())
+	require.NoError(t, err)
+	key, salt, err := gen.GenerateKey([]byte(password))
+	require.NoError(t, err)
+
+	l, err := NewLocker(key, salt)
+	require.NoError(t, err)
+	for _, data := range []string{"first payload", "second payload", ""} {
+		encrypted, err := l.Seal([]byte(data))
+		require.NoError(t, err)
+
+		key2, err := gen.DeriveKey([]byte(password), encrypted)
+		require.NoError(t, err)
+		unencrypted, err := Unlock(key2, encrypted)
+		require.NoError(t, err)
+		assert.Equal(t, data, string(unencrypted), "Locker output should be compatible with Unlock")
+
+		locked, err := Lock(key, salt, []byte(data))
+		require.NoError(t, err)
+		unencrypted, err = l.Open(locked)
+		require.NoError(t, err)
+		assert.Equal(t, data, string(unencrypted), "Locker should open Lock output")
+	}
+
+	_, err = l.Open(Encrypted{0x0})
+	assert.ErrorIs(t, err, ErrInvalidData)
+	_, err = NewLocker(make(Key, 3), nil)
+	assert.Error(t, err)
+}
+
+func BenchmarkLocker_Seal(b *testing.B) {
+	key := make(Key, AES256KeySize)
+	salt := make(Salt, AES256KeySize)
+	data := make(Plaintext, 256)
+	b.Run("Lock", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			if _, err := Lock(key, salt, data); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	b.Run("Locker", func(b *testing.B) {
+		l, err := NewLocker(key, salt)
+		if err != nil {
+			b.Fatal(err)
+		}
+		for i := 0; i < b.N; i++ {
+			if _, err := l.Seal(data); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
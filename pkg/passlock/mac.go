@@ -0,0 +1,78 @@
+package passlock
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"golang.org/x/crypto/hkdf"
+	"io"
+)
+
+const (
+	macSize = sha256.Size
+	macInfo = "gocryptx passlock payload mac"
+)
+
+var (
+	ErrMACMismatch = errors.New("payload MAC does not match")
+)
+
+// LockWithMAC is the same as Lock, but also computes an HMAC-SHA-256 over the entire encrypted payload, including the nonce and Salt.
+// The MAC key is an independent subkey derived from the Key with HKDF, so the encryption key is never used directly for the MAC.
+// The MAC is placed between the encrypted payload and the Salt, so the Salt may still be recovered with KeyGenerator.DeriveKey.
+// This is a belt-and-suspenders measure that detects tampering with fields that aren't covered by the AEAD tag, like the trailing Salt.
+// A payload locked with LockWithMAC must be unlocked with UnlockWithMAC.
+func LockWithMAC(key Key, salt Salt, data Plaintext) (Encrypted, error) {
+	return LockWithSourceMAC(rand.Reader, key, salt, data)
+}
+
+// LockWithSourceMAC is the same as LockWithMAC, except that the nonce is read from the given random source.
+func LockWithSourceMAC(random io.Reader, key Key, salt Salt, data Plaintext) (Encrypted, error) {
+	encrypted, err := LockWithSource(random, key, salt, data)
+	if err != nil {
+		return nil, err
+	}
+	body := encrypted[:len(encrypted)-len(salt)]
+	mac, err := payloadMAC(key, body, salt)
+	if err != nil {
+		return nil, err
+	}
+	out := make(Encrypted, 0, len(encrypted)+macSize)
+	out = append(out, body...)
+	out = append(out, mac...)
+	return append(out, salt...), nil
+}
+
+// UnlockWithMAC will verify the MAC applied with LockWithMAC before decrypting the payload with Unlock.
+// ErrMACMismatch is returned if the MAC doesn't match the payload, which indicates tampering or an incorrect Key.
+func UnlockWithMAC(key Key, data Encrypted) (Plaintext, error) {
+	if len(data) < len(key)+macSize {
+		return nil, fmt.Errorf("%w: input data isn't long enough to contain a MAC", ErrInvalidData)
+	}
+	salt := Salt(data[len(data)-len(key):])
+	mac := data[len(data)-len(key)-macSize : len(data)-len(key)]
+	body := data[:len(data)-len(key)-macSize]
+	expected, err := payloadMAC(key, body, salt)
+	if err != nil {
+		return nil, err
+	}
+	if !EqualSecret(mac, expected) {
+		return nil, ErrMACMismatch
+	}
+	unwrapped := make(Encrypted, 0, len(body)+len(salt))
+	unwrapped = append(unwrapped, body...)
+	return Unlock(key, append(unwrapped, salt...))
+}
+
+func payloadMAC(key Key, body []byte, salt Salt) ([]byte, error) {
+	macKey := make([]byte, macSize)
+	if _, err := io.ReadFull(hkdf.New(sha256.New, key, salt, []byte(macInfo)), macKey); err != nil {
+		return nil, fmt.Errorf("failed to derive MAC key: %w", err)
+	}
+	h := hmac.New(sha256.New, macKey)
+	h.Write(body)
+	h.Write(salt)
+	return h.Sum(nil), nil
+}
@@ -0,0 +1,47 @@
+package passlock
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestLockUnlockWithMAC(t *testing.T) {
+	const password = "password"
+	const data = "How wonderful life is while you're in the world"
+
+	gen, err := NewKeyGenerator(SetShortDelayIterations())
+	require.NoError(t, err)
+	key, salt, err := gen.GenerateKey([]byte(password))
+	require.NoError(t, err)
+
+	encrypted, err := LockWithMAC(key, salt, []byte(data))
+	require.NoError(t, err)
+
+	key2, err := gen.DeriveKey([]byte(password), encrypted)
+	require.NoError(t, err)
+	assert.Equal(t, key, key2, "Salt should still be recoverable from a MAC payload")
+
+	unencrypted, err := UnlockWithMAC(key2, encrypted)
+	assert.NoError(t, err)
+	assert.Equal(t, data, string(unencrypted))
+}
+
+func TestUnlockWithMAC_Tampered(t *testing.T) {
+	gen, err := NewKeyGenerator(SetShortDelayIterations())
+	require.NoError(t, err)
+	key, salt, err := gen.GenerateKey([]byte("password"))
+	require.NoError(t, err)
+	encrypted, err := LockWithMAC(key, salt, []byte("some data"))
+	require.NoError(t, err)
+
+	for _, idx := range []int{0, len(encrypted) / 2, len(encrypted) - len(salt) - 1, len(encrypted) - 1} {
+		tampered := append(Encrypted{}, encrypted...)
+		tampered[idx] ^= 0x1
+		_, err := UnlockWithMAC(key, tampered)
+		assert.ErrorIs(t, err, ErrMACMismatch, "Tampering at index %d should be detected", idx)
+	}
+
+	_, err = UnlockWithMAC(key, encrypted[:len(key)])
+	assert.ErrorIs(t, err, ErrInvalidData)
+}
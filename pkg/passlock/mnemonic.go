@@ -0,0 +1,124 @@
+package passlock
+
+import (
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+const (
+	mnemonicChecksumLen = 4
+	mnemonicPrefixLen   = 4
+)
+
+var (
+	ErrInvalidMnemonic  = errors.New("invalid mnemonic")
+	ErrMnemonicChecksum = errors.New("mnemonic checksum mismatch")
+)
+
+// mnemonicWords has one word for each byte value.
+// Every word is unique in its first 4 letters, so a mnemonic may be recorded or entered with abbreviated words.
+var mnemonicWords = [256]string{
+	"able", "acid", "acre", "actor", "adapt", "admit", "adult", "agent",
+	"agree", "alarm", "album", "alien", "alpha", "amber", "ample", "angle",
+	"ankle", "apple", "april", "arena", "argue", "armor", "arrow", "artist",
+	"aspect", "atlas", "audit", "autumn", "avoid", "awake", "award", "axis",
+	"bacon", "badge", "baker", "bamboo", "banjo", "barrel", "basket", "beach",
+	"beaver", "bench", "berry", "binder", "birch", "blanket", "blossom", "blue",
+	"board", "bonus", "border", "bottle", "boxer", "brave", "breeze", "bridge",
+	"bronze", "bubble", "bucket", "buffalo", "bundle", "burger", "butter", "cabin",
+	"cactus", "camera", "canal", "candle", "canyon", "captain", "carbon", "carpet",
+	"castle", "cedar", "cellar", "cement", "chalk", "chapter", "cherry", "chimney",
+	"chorus", "cider", "cinema", "circle", "civil", "clever", "climb", "clock",
+	"cloud", "coast", "coconut", "coffee", "column", "comet", "copper", "coral",
+	"cotton", "cousin", "coyote", "crane", "crater", "credit", "crimson", "crystal",
+	"cuckoo", "culture", "curtain", "cushion", "cycle", "dairy", "damp", "dancer",
+	"danger", "daring", "dawn", "debate", "decade", "deer", "delta", "denim",
+	"depot", "desert", "detail", "devote", "diamond", "diesel", "digital", "dinner",
+	"direct", "dish", "divide", "doctor", "dolphin", "domain", "donkey", "double",
+	"dragon", "drama", "drift", "dune", "dusk", "eagle", "early", "earth",
+	"easel", "echo", "eclipse", "editor", "effort", "eight", "elbow", "elder",
+	"element", "elite", "embark", "ember", "emerald", "empire", "enable", "engine",
+	"enjoy", "entry", "equal", "erode", "escape", "essay", "estate", "evening",
+	"evolve", "exact", "exhibit", "exotic", "expand", "fabric", "factor", "falcon",
+	"family", "fancy", "farmer", "fashion", "feather", "fence", "ferry", "fiber",
+	"fiction", "figure", "filter", "finch", "fire", "flag", "flame", "flavor",
+	"fleet", "flight", "floor", "flute", "focus", "folder", "forest", "fossil",
+	"fountain", "fragile", "frame", "fresh", "frog", "frozen", "fruit", "fuel",
+	"funnel", "galaxy", "garden", "garlic", "gazelle", "gecko", "gentle", "giant",
+	"ginger", "giraffe", "glacier", "glass", "globe", "glove", "goat", "golden",
+	"gorilla", "grape", "gravel", "green", "grid", "grocery", "guitar", "gulf",
+	"habit", "hammer", "harbor", "harvest", "hawk", "hazel", "health", "heart",
+	"helmet", "herbal", "hero", "hidden", "highway", "hint", "history", "hobby",
+	"hockey", "holiday", "honey", "horizon", "hotel", "humble", "hunter", "hybrid",
+}
+
+var mnemonicIndex = func() map[string]byte {
+	idx := make(map[string]byte, len(mnemonicWords))
+	for i, word := range mnemonicWords {
+		idx[word[:mnemonicPrefixLen]] = byte(i)
+	}
+	return idx
+}()
+
+// EncodeMnemonic encodes a secret as a space-separated list of words, followed by 4 checksum words.
+// Each word represents one byte of the secret, so a mnemonic is suitable for writing down or printing as an offline backup.
+func EncodeMnemonic(secret []byte) string {
+	sum := sha256.Sum256(secret)
+	data := make([]byte, 0, len(secret)+mnemonicChecksumLen)
+	data = append(data, secret...)
+	data = append(data, sum[:mnemonicChecksumLen]...)
+	words := make([]string, len(data))
+	for i, b := range data {
+		words[i] = mnemonicWords[b]
+	}
+	wipe(data)
+	return strings.Join(words, " ")
+}
+
+// DecodeMnemonic decodes a mnemonic created with EncodeMnemonic, and verifies its checksum.
+// Words are case-insensitive, may be separated by any whitespace, and may be abbreviated to their first 4 letters.
+func DecodeMnemonic(mnemonic string) ([]byte, error) {
+	words := strings.Fields(strings.ToLower(mnemonic))
+	if len(words) <= mnemonicChecksumLen {
+		return nil, fmt.Errorf("%w: expected more than %d words", ErrInvalidMnemonic, mnemonicChecksumLen)
+	}
+	data := make([]byte, len(words))
+	for i, word := range words {
+		if len(word) < mnemonicPrefixLen {
+			return nil, fmt.Errorf("%w: unrecognized word %d '%s'", ErrInvalidMnemonic, i+1, word)
+		}
+		b, ok := mnemonicIndex[word[:mnemonicPrefixLen]]
+		if !ok || !strings.HasPrefix(mnemonicWords[b], word) {
+			return nil, fmt.Errorf("%w: unrecognized word %d '%s'", ErrInvalidMnemonic, i+1, word)
+		}
+		data[i] = b
+	}
+	secret, checksum := data[:len(data)-mnemonicChecksumLen], data[len(data)-mnemonicChecksumLen:]
+	sum := sha256.Sum256(secret)
+	if !EqualSecret(sum[:mnemonicChecksumLen], checksum) {
+		wipe(data)
+		return nil, ErrMnemonicChecksum
+	}
+	return secret, nil
+}
+
+// ExportBaseMnemonic exports the base passphrase of this MultiLocker as a mnemonic, which may be used as an offline disaster recovery backup.
+// Update must be enabled in this MultiLocker before this can be done.
+// Anyone with the mnemonic can unlock and update the MultiLocker, so it must be stored as securely as the base passphrase.
+func (l *MultiLocker) ExportBaseMnemonic() (string, error) {
+	if err := l.validateForUpdate(); err != nil {
+		return "", err
+	}
+	return EncodeMnemonic(l.basePass), nil
+}
+
+// EnableUpdateMnemonic is the same as EnableUpdate, except that the base passphrase is recovered from a mnemonic created with ExportBaseMnemonic.
+func (l *MultiLocker) EnableUpdateMnemonic(mnemonic string) error {
+	pass, err := DecodeMnemonic(mnemonic)
+	if err != nil {
+		return err
+	}
+	return l.EnableUpdate(pass)
+}
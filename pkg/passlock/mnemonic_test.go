@@ -0,0 +1,88 @@
+package passlock
+
+import (
+	"bytes"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"strings"
+	"testing"
+)
+
+func TestMnemonicWords(t *testing.T) {
+	seen := map[string]bool{}
+	for _, word := range mnemonicWords {
+		require.GreaterOrEqual(t, len(word), mnemonicPrefixLen)
+		prefix := word[:mnemonicPrefixLen]
+		assert.False(t, seen[prefix], "Word prefix '%s' should be unique", prefix)
+		seen[prefix] = true
+	}
+}
+
+func TestEncodeMnemonic(t *testing.T) {
+	secret := []byte("a very secret base passphrase")
+	mnemonic := EncodeMnemonic(secret)
+	assert.Len(t, strings.Fields(mnemonic), len(secret)+mnemonicChecksumLen)
+
+	decoded, err := DecodeMnemonic(mnemonic)
+	require.NoError(t, err)
+	assert.Equal(t, secret, decoded)
+
+	var abbreviated []string
+	for _, word := range strings.Fields(mnemonic) {
+		abbreviated = append(abbreviated, strings.ToUpper(word[:mnemonicPrefixLen]))
+	}
+	decoded, err = DecodeMnemonic("\n  " + strings.Join(abbreviated, "\t ") + "\n")
+	require.NoError(t, err, "Abbreviated words, case, and whitespace shouldn't matter")
+	assert.Equal(t, secret, decoded)
+}
+
+func TestDecodeMnemonic_Neg(t *testing.T) {
+	words := strings.Fields(EncodeMnemonic([]byte("secret")))
+	swapped := append([]string{}, words...)
+	swapped[0], swapped[1] = swapped[1], swapped[0]
+	misspelled := append([]string{}, words...)
+	misspelled[2] += "x"
+
+	tests := map[string]struct {
+		mnemonic string
+		err      error
+	}{
+		"Empty":           {"", ErrInvalidMnemonic},
+		"Checksum only":   {strings.Join(words[len(words)-mnemonicChecksumLen:], " "), ErrInvalidMnemonic},
+		"Unknown word":    {"xylophone " + strings.Join(words[1:], " "), ErrInvalidMnemonic},
+		"Short word":      {strings.Join(append([]string{words[0][:3]}, words[1:]...), " "), ErrInvalidMnemonic},
+		"Misspelled word": {strings.Join(misspelled, " "), ErrInvalidMnemonic},
+		"Swapped words":   {strings.Join(swapped, " "), ErrMnemonicChecksum},
+		"Missing word":    {strings.Join(words[1:], " "), ErrMnemonicChecksum},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := DecodeMnemonic(tc.mnemonic)
+			assert.ErrorIs(t, err, tc.err)
+		})
+	}
+}
+
+func TestMultiLocker_ExportBaseMnemonic(t *testing.T) {
+	basePass := Passphrase("base key pass")
+	gen, err := NewKeyGenerator(SetShortDelayIterations())
+	require.NoError(t, err)
+	mk := NewWriteMultiLocker(gen)
+	require.NoError(t, mk.Lock(basePass, Plaintext("payload")))
+	mnemonic, err := mk.ExportBaseMnemonic()
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	require.NoError(t, mk.Write(&buf))
+	read, err := ReadMultiLocker(&buf)
+	require.NoError(t, err)
+	_, err = read.ExportBaseMnemonic()
+	assert.Error(t, err, "Update must be enabled to export the base passphrase")
+
+	assert.Error(t, read.EnableUpdateMnemonic(EncodeMnemonic([]byte("wrong pass"))))
+	require.NoError(t, read.EnableUpdateMnemonic(mnemonic))
+	require.NoError(t, read.AddSurrogatePass("developer", Passphrase("sur key pass")))
+	data, err := read.Unlock(basePass)
+	require.NoError(t, err)
+	assert.Equal(t, "payload", string(data))
+}
@@ -7,7 +7,9 @@ import (
 	"fmt"
 	bin "github.com/saylorsolutions/binmap"
 	"io"
+	"math"
 	"sort"
+	"time"
 )
 
 const (
@@ -21,6 +23,8 @@ var (
 
 type surrogateKey struct {
 	encryptedPass Encrypted
+	keyGen        *KeyGenerator
+	info          surrogateInfo
 }
 
 // MultiLocker allows using surrogate keys - in addition to a base key - for reading an encrypted payload.
@@ -29,8 +33,13 @@ type MultiLocker struct {
 	surKeys map[string]surrogateKey
 	payload Encrypted
 
-	basePass Passphrase
-	keyGen   *KeyGenerator
+	basePass     Passphrase
+	keyGen       *KeyGenerator
+	upgradeGen   *KeyGenerator
+	upgraded     bool
+	historyLimit uint8
+	history      []payloadGeneration
+	observer     UnlockObserver
 }
 
 func NewMultiLocker(gen *KeyGenerator) *MultiLocker {
@@ -67,16 +76,40 @@ func (l *MultiLocker) validateForUpdate() error {
 	return nil
 }
 
-func (l *MultiLocker) mapper() bin.Mapper {
+func (l *MultiLocker) mapper(version uint16) bin.Mapper {
 	return bin.MapSequence(
 		bin.Map(&l.surKeys, func(key *string) bin.Mapper {
 			return bin.FixedString(key, idFieldLen)
 		}, func(val *surrogateKey) bin.Mapper {
-			return bin.DynamicSlice((*[]byte)(&val.encryptedPass), func(e *byte) bin.Mapper {
-				return bin.Byte(e)
-			})
+			if version == legacyFormatVersion {
+				// Legacy surrogate keys share the payload's generator settings, which are populated after reading.
+				return dynamicBytes((*[]byte)(&val.encryptedPass))
+			}
+			if val.keyGen == nil {
+				val.keyGen = new(KeyGenerator)
+			}
+			mappers := []bin.Mapper{val.keyGen.mapper(version)}
+			if version >= surrogateInfoFormatVersion {
+				mappers = append(mappers, val.info.mapper())
+			}
+			mappers = append(mappers, dynamicBytes((*[]byte)(&val.encryptedPass)))
+			return bin.MapSequence(mappers...)
 		}),
-		l.keyGen.mapper(),
+		l.keyGen.mapper(version),
+		bin.Any(
+			func(r io.Reader, endian binary.ByteOrder) error {
+				if version < historyFormatVersion {
+					return nil
+				}
+				return l.historyMapper(version).Read(r, endian)
+			},
+			func(w io.Writer, endian binary.ByteOrder) error {
+				if version < historyFormatVersion {
+					return nil
+				}
+				return l.historyMapper(version).Write(w, endian)
+			},
+		),
 		bin.Any(
 			func(r io.Reader, endian binary.ByteOrder) error {
 				payload, err := io.ReadAll(r)
@@ -94,25 +127,104 @@ func (l *MultiLocker) mapper() bin.Mapper {
 	)
 }
 
+// dynamicBytes maps a byte slice with the same layout as bin.DynamicSlice, which is a uint32 length followed by the bytes.
+// The slice grows as data is read, so a corrupt or malicious length can't cause a huge allocation up front.
+func dynamicBytes(target *[]byte) bin.Mapper {
+	return bin.Any(
+		func(r io.Reader, endian binary.ByteOrder) error {
+			var length uint32
+			if err := binary.Read(r, endian, &length); err != nil {
+				return err
+			}
+			var buf bytes.Buffer
+			if _, err := io.CopyN(&buf, r, int64(length)); err != nil {
+				if errors.Is(err, io.EOF) {
+					return io.ErrUnexpectedEOF
+				}
+				return err
+			}
+			*target = buf.Bytes()
+			return nil
+		},
+		func(w io.Writer, endian binary.ByteOrder) error {
+			if uint64(len(*target)) > math.MaxUint32 {
+				return fmt.Errorf("%d bytes is too long to be written", len(*target))
+			}
+			if err := binary.Write(w, endian, uint32(len(*target))); err != nil {
+				return err
+			}
+			_, err := w.Write(*target)
+			return err
+		},
+	)
+}
+
 // ReadMultiLocker will read a MultiLocker as a binary payload from the io.Reader.
+// Only the current format version is accepted, an error wrapping ErrUnsupportedVersion is returned otherwise.
+// Use ReadMultiLockerAnyVersion to read a MultiLocker written in any known format version.
 func ReadMultiLocker(r io.Reader) (*MultiLocker, error) {
+	version, r, err := readFormatVersion(r)
+	if err != nil {
+		return nil, err
+	}
+	if version != CurrentFormatVersion {
+		return nil, &UnsupportedVersionError{Version: version}
+	}
+	return readVersion(version, r)
+}
+
+// ReadMultiLockerAnyVersion will read a MultiLocker as a binary payload from the io.Reader, accepting any format version in SupportedFormats.
+// This includes the legacy layout that was written before format versions were introduced, and custom formats registered with RegisterFormat.
+// A MultiLocker read in an older format will be written in the current format with Write.
+func ReadMultiLockerAnyVersion(r io.Reader) (*MultiLocker, error) {
+	version, r, err := readFormatVersion(r)
+	if err != nil {
+		return nil, err
+	}
+	format, err := lookupFormat(version)
+	if err != nil {
+		return nil, err
+	}
+	return format.ReadMultiLocker(r)
+}
+
+func readVersion(version uint16, r io.Reader) (*MultiLocker, error) {
 	l := &MultiLocker{
 		keyGen: new(KeyGenerator),
 	}
-	if err := l.mapper().Read(r, binary.BigEndian); err != nil {
+	if err := l.mapper(version).Read(r, binary.BigEndian); err != nil {
 		return nil, fmt.Errorf("%w: %v", ErrInvalidHeader, err)
 	}
+	if len(l.history) > int(l.historyLimit) {
+		return nil, fmt.Errorf("%w: %d payload generations exceeds the history limit of %d", ErrInvalidHeader, len(l.history), l.historyLimit)
+	}
+	if version == legacyFormatVersion {
+		for id, sur := range l.surKeys {
+			sur.keyGen = l.keyGen.clone()
+			l.surKeys[id] = sur
+		}
+	}
 	return l, nil
 }
 
-// Write will write the MultiLocker as a binary payload to the io.Writer.
+// Write will write the MultiLocker as a binary payload to the io.Writer, using the current format version.
 func (l *MultiLocker) Write(w io.Writer) error {
-	return l.mapper().Write(w, binary.BigEndian)
+	if err := writeFormatVersion(w, CurrentFormatVersion); err != nil {
+		return err
+	}
+	return l.mapper(CurrentFormatVersion).Write(w, binary.BigEndian)
 }
 
 // EnableUpdate validates the MultiLocker and ensures that it's in a suitable state for updating by setting the base key.
 // The original base passphrase must be used, not a surrogate passphrase, to validate that the correct key is populated.
 func (l *MultiLocker) EnableUpdate(pass Passphrase) error {
+	start, gen := time.Now(), l.keyGen
+	err := l.enableUpdate(pass)
+	l.observeUnlock(OpEnableUpdate, "", 0, gen, start, err)
+	return err
+}
+
+func (l *MultiLocker) enableUpdate(pass Passphrase) error {
 	err := l.validateInitialized()
 	if err != nil {
 		return err
@@ -121,7 +233,7 @@ func (l *MultiLocker) EnableUpdate(pass Passphrase) error {
 	if err != nil {
 		return fmt.Errorf("failed to derive baseKey from payload: %w", err)
 	}
-	_, err = Unlock(derivedKey, l.payload)
+	_, err = l.keyGen.unlock(derivedKey, l.payload)
 	if err != nil {
 		return fmt.Errorf("%w: invalid base pass", ErrInvalidPassword)
 	}
@@ -134,6 +246,76 @@ func (l *MultiLocker) DisableUpdate() {
 	l.basePass = nil
 }
 
+// SetUpgradeGenerator enables automatic key derivation parameter upgrades for this MultiLocker.
+// When the iteration count used for the payload or a surrogate key is lower than the given KeyGenerator's, a successful Unlock or SurrogateUnlock will re-encrypt it using the given KeyGenerator's settings.
+// Surrogate keys are only upgraded when they're used, since their passphrase is required to re-encrypt them.
+// Use Upgraded to determine if the MultiLocker should be persisted again after unlocking.
+func (l *MultiLocker) SetUpgradeGenerator(gen *KeyGenerator) {
+	l.upgradeGen = gen
+}
+
+// Upgraded reports whether key derivation parameters have been upgraded in this MultiLocker since it was created or read.
+func (l *MultiLocker) Upgraded() bool {
+	return l.upgraded
+}
+
+func (l *MultiLocker) shouldUpgrade(current *KeyGenerator) bool {
+	return l.upgradeGen != nil && current.iterations < l.upgradeGen.iterations
+}
+
+// upgradePayload re-encrypts the payload with the upgrade generator if its settings are stronger than the current settings.
+func (l *MultiLocker) upgradePayload(basePass Passphrase, data Plaintext) error {
+	if !l.shouldUpgrade(l.keyGen) {
+		return nil
+	}
+	gen := l.upgradeGen.clone()
+	key, salt, err := gen.GenerateKey(basePass)
+	if err != nil {
+		return fmt.Errorf("failed to upgrade payload key: %w", err)
+	}
+	payload, err := gen.lock(key, salt, data)
+	if err != nil {
+		return fmt.Errorf("failed to upgrade payload: %w", err)
+	}
+	l.keyGen = gen
+	l.payload = payload
+	l.upgraded = true
+	return nil
+}
+
+// upgradeSurrogate re-encrypts the surrogate key with the upgrade generator if its settings are stronger than the current settings.
+func (l *MultiLocker) upgradeSurrogate(id string, pass Passphrase, basePass Passphrase) error {
+	if !l.shouldUpgrade(l.surKeys[id].keyGen) {
+		return nil
+	}
+	sur, err := l.newSurrogateKey(l.upgradeGen, pass, basePass)
+	if err != nil {
+		return fmt.Errorf("failed to upgrade surrogate key: %w", err)
+	}
+	sur.info = l.surKeys[id].info
+	l.surKeys[id] = sur
+	l.upgraded = true
+	return nil
+}
+
+// newSurrogateKey encrypts the base passphrase with a key generated from the surrogate passphrase.
+// The surrogate key retains a copy of the generator settings so that it may be recovered independently of the payload settings.
+func (l *MultiLocker) newSurrogateKey(gen *KeyGenerator, pass Passphrase, basePass Passphrase) (surrogateKey, error) {
+	gen = gen.clone()
+	passKey, salt, err := gen.GenerateKey(pass)
+	if err != nil {
+		return surrogateKey{}, err
+	}
+	encryptedPass, err := gen.lock(passKey, salt, Plaintext(basePass))
+	if err != nil {
+		return surrogateKey{}, err
+	}
+	return surrogateKey{
+		encryptedPass: encryptedPass,
+		keyGen:        gen,
+	}, nil
+}
+
 // ListKeyIDs lists all surrogate key IDs in this MultiLocker.
 func (l *MultiLocker) ListKeyIDs() []string {
 	ids := make([]string, len(l.surKeys))
@@ -147,8 +329,9 @@ func (l *MultiLocker) ListKeyIDs() []string {
 }
 
 // AddSurrogatePass will add a new surrogate key to this MultiLocker.
+// Metadata for the surrogate key may be set with zero or more SurrogateOpt, and is available from ListKeys.
 // Update must be enabled in this MultiLocker before this can be done.
-func (l *MultiLocker) AddSurrogatePass(id string, pass Passphrase) error {
+func (l *MultiLocker) AddSurrogatePass(id string, pass Passphrase, opts ...SurrogateOpt) error {
 	if len(id) > idFieldLen || len(id) == 0 {
 		return fmt.Errorf("id value is not within the valid range of 1-%d bytes", idFieldLen)
 	}
@@ -158,17 +341,19 @@ func (l *MultiLocker) AddSurrogatePass(id string, pass Passphrase) error {
 	if err := l.validateForUpdate(); err != nil {
 		return err
 	}
-	newPassKey, salt, err := l.keyGen.GenerateKey(pass)
-	if err != nil {
-		return err
+	info := surrogateInfo{
+		created: time.Now().Truncate(time.Second).UTC(),
+	}
+	for _, opt := range opts {
+		if err := opt(&info); err != nil {
+			return err
+		}
 	}
-	encryptedPass, err := Lock(newPassKey, salt, Plaintext(l.basePass))
+	newKey, err := l.newSurrogateKey(l.keyGen, pass, l.basePass)
 	if err != nil {
 		return err
 	}
-	newKey := surrogateKey{
-		encryptedPass: encryptedPass,
-	}
+	newKey.info = info
 	l.surKeys[id] = newKey
 	return nil
 }
@@ -193,22 +378,18 @@ func (l *MultiLocker) UpdateSurrogatePass(id string, newPass Passphrase) error {
 	if len(id) > idFieldLen {
 		return fmt.Errorf("id value is greater than the maximum field width of %d", idFieldLen)
 	}
-	sur, ok := l.surKeys[id]
-	if !ok {
+	if _, ok := l.surKeys[id]; !ok {
 		return errors.New("given ID is not present in this MultiLocker")
 	}
 	if err := l.validateForUpdate(); err != nil {
 		return err
 	}
-	newPassKey, salt, err := l.keyGen.GenerateKey(newPass)
+	sur, err := l.newSurrogateKey(l.keyGen, newPass, l.basePass)
 	if err != nil {
 		return err
 	}
-	encryptedPass, err := Lock(newPassKey, salt, Plaintext(l.basePass))
-	if err != nil {
-		return err
-	}
-	sur.encryptedPass = encryptedPass
+	sur.info = l.surKeys[id].info
+	l.surKeys[id] = sur
 	return nil
 }
 
@@ -222,10 +403,12 @@ func (l *MultiLocker) InvalidateLock(pass Passphrase, unencrypted Plaintext) err
 	if err != nil {
 		return err
 	}
-	l.payload, err = Lock(key, salt, unencrypted)
+	payload, err := l.keyGen.lock(key, salt, unencrypted)
 	if err != nil {
 		return err
 	}
+	l.pushHistory()
+	l.payload = payload
 	l.surKeys = map[string]surrogateKey{}
 	return nil
 }
@@ -245,7 +428,7 @@ func (l *MultiLocker) Lock(basePass Passphrase, unencrypted Plaintext) error {
 			return err
 		}
 		// Check that the key is valid
-		_, err = Unlock(key, l.payload)
+		_, err = l.keyGen.unlock(key, l.payload)
 		if err != nil {
 			return ErrInvalidPassword
 		}
@@ -254,10 +437,11 @@ func (l *MultiLocker) Lock(basePass Passphrase, unencrypted Plaintext) error {
 	if err != nil {
 		return err
 	}
-	encrypted, err := Lock(key, salt, unencrypted)
+	encrypted, err := l.keyGen.lock(key, salt, unencrypted)
 	if err != nil {
 		return err
 	}
+	l.pushHistory()
 	l.payload = encrypted
 	l.basePass = basePass
 	return nil
@@ -265,50 +449,88 @@ func (l *MultiLocker) Lock(basePass Passphrase, unencrypted Plaintext) error {
 
 // Unlock will unlock the [MultiLocker]'s payload with the base pass phrase.
 func (l *MultiLocker) Unlock(basePass Passphrase) (Plaintext, error) {
+	start, gen := time.Now(), l.keyGen
+	data, err := l.unlockBase(basePass)
+	l.observeUnlock(OpUnlock, "", 0, gen, start, err)
+	return data, err
+}
+
+func (l *MultiLocker) unlockBase(basePass Passphrase) (Plaintext, error) {
 	if err := l.validateInitialized(); err != nil {
 		return nil, err
 	}
 	baseKey, err := l.keyGen.DeriveKey(basePass, l.payload)
 	if err != nil {
+		if errors.Is(err, ErrMemoryBudget) {
+			return nil, err
+		}
 		return nil, ErrInvalidPassword
 	}
-	data, err := Unlock(baseKey, l.payload)
+	data, err := l.keyGen.unlock(baseKey, l.payload)
 	baseKey = nil
 	if err != nil {
 		return nil, fmt.Errorf("%w: invalid base key", ErrInvalidPassword)
 	}
+	if err := l.upgradePayload(basePass, data); err != nil {
+		return nil, err
+	}
 	return data, nil
 }
 
 // SurrogateUnlock will unlock the payload with a surrogate key.
 func (l *MultiLocker) SurrogateUnlock(id string, pass Passphrase) (Plaintext, error) {
+	start, gen := time.Now(), l.surrogateGenerator(id)
+	data, err := l.surrogateUnlock(id, pass)
+	l.observeUnlock(OpSurrogateUnlock, id, 0, gen, start, err)
+	return data, err
+}
+
+func (l *MultiLocker) surrogateUnlock(id string, pass Passphrase) (Plaintext, error) {
 	if err := l.validateInitialized(); err != nil {
 		return nil, err
 	}
-	sur, ok := l.surKeys[id]
-	if !ok {
-		return nil, errors.New("surrogate key ID not found")
-	}
-	passKey, err := l.keyGen.DeriveKey(pass, sur.encryptedPass)
+	basePass, err := l.surrogateBasePass(id, pass)
 	if err != nil {
 		return nil, err
 	}
-	basePass, err := Unlock(passKey, sur.encryptedPass)
-	if err != nil {
-		return nil, ErrInvalidPassword
-	}
-	baseKey, err := l.keyGen.DeriveKey(Passphrase(basePass), l.payload)
+	baseKey, err := l.keyGen.DeriveKey(basePass, l.payload)
 	if err != nil {
 		return nil, err
 	}
-	data, err := Unlock(baseKey, l.payload)
+	data, err := l.keyGen.unlock(baseKey, l.payload)
 	baseKey = nil
 	if err != nil {
 		return nil, fmt.Errorf("%w: invalid base key", ErrInvalidPassword)
 	}
+	if err := l.upgradeSurrogate(id, pass, basePass); err != nil {
+		return nil, err
+	}
+	if err := l.upgradePayload(basePass, data); err != nil {
+		return nil, err
+	}
 	return data, nil
 }
 
+// surrogateBasePass recovers the base pass phrase encrypted by a surrogate key.
+func (l *MultiLocker) surrogateBasePass(id string, pass Passphrase) (Passphrase, error) {
+	sur, ok := l.surKeys[id]
+	if !ok {
+		return nil, errors.New("surrogate key ID not found")
+	}
+	if sur.info.keyInfo(id).Expired(time.Now()) {
+		return nil, ErrSurrogateExpired
+	}
+	passKey, err := sur.keyGen.DeriveKey(pass, sur.encryptedPass)
+	if err != nil {
+		return nil, err
+	}
+	basePass, err := sur.keyGen.unlock(passKey, sur.encryptedPass)
+	if err != nil {
+		return nil, ErrInvalidPassword
+	}
+	return Passphrase(basePass), nil
+}
+
 // WriteMultiLocker is the same as MultiLocker, except that the logical constraint that surrogate keys cannot write a new payload is lifted.
 type WriteMultiLocker struct {
 	*MultiLocker
@@ -322,29 +544,38 @@ func NewWriteMultiLocker(gen *KeyGenerator) *WriteMultiLocker {
 
 // SurrogateLock will Lock a new payload in the MultiLocker using a surrogate key.
 func (l *WriteMultiLocker) SurrogateLock(id string, pass Passphrase, unencrypted Plaintext) error {
+	start, gen := time.Now(), l.surrogateGenerator(id)
+	err := l.surrogateLock(id, pass, unencrypted)
+	l.observeUnlock(OpSurrogateLock, id, 0, gen, start, err)
+	return err
+}
+
+func (l *WriteMultiLocker) surrogateLock(id string, pass Passphrase, unencrypted Plaintext) error {
 	if err := l.validateInitialized(); err != nil {
 		return err
 	}
-	sur, ok := l.surKeys[id]
-	if !ok {
-		return errors.New("surrogate key ID not found")
+	if sur, ok := l.surKeys[id]; ok && sur.info.readOnly {
+		return ErrSurrogateReadOnly
 	}
-	passKey, err := l.keyGen.DeriveKey(pass, sur.encryptedPass)
+	basePass, err := l.surrogateBasePass(id, pass)
 	if err != nil {
 		return err
 	}
-	unencKey, err := Unlock(passKey, sur.encryptedPass)
+	baseKey, salt, err := l.keyGen.DeriveKeySalt(basePass, l.payload)
 	if err != nil {
-		return ErrInvalidPassword
+		return err
 	}
-	basePass := Passphrase(unencKey)
-	baseKey, salt, err := l.keyGen.DeriveKeySalt(basePass, l.payload)
 	// Ensure the baseKey is valid
-	_, err = Unlock(baseKey, l.payload)
+	_, err = l.keyGen.unlock(baseKey, l.payload)
 	if err != nil {
 		return fmt.Errorf("%w: invalid base key", ErrInvalidPassword)
 	}
-	l.payload, err = Lock(baseKey, salt, unencrypted)
+	payload, err := l.keyGen.lock(baseKey, salt, unencrypted)
 	baseKey = nil
-	return err
+	if err != nil {
+		return err
+	}
+	l.pushHistory()
+	l.payload = payload
+	return nil
 }
@@ -158,3 +158,77 @@ func TestWriteMultiLocker_SurrogateLock(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, plaintext, string(got))
 }
+
+func TestMultiLocker_SetUpgradeGenerator(t *testing.T) {
+	var (
+		baseKeyPass = Passphrase("base key pass")
+		surKeyPass  = Passphrase("sur key pass")
+		payload     = Plaintext("test payload")
+		buf         bytes.Buffer
+	)
+	weakGen, err := NewKeyGenerator(SetIterations(1 << 10))
+	require.NoError(t, err)
+	strongGen, err := NewKeyGenerator(SetShortDelayIterations())
+	require.NoError(t, err)
+
+	mk := NewMultiLocker(weakGen)
+	require.NoError(t, mk.Lock(baseKeyPass, payload))
+	require.NoError(t, mk.AddSurrogatePass("test", surKeyPass))
+	require.NoError(t, mk.AddSurrogatePass("other", surKeyPass))
+	require.NoError(t, mk.Write(&buf))
+
+	mk, err = ReadMultiLocker(&buf)
+	require.NoError(t, err)
+	plaintext, err := mk.Unlock(baseKeyPass)
+	require.NoError(t, err)
+	assert.Equal(t, payload, plaintext)
+	assert.False(t, mk.Upgraded(), "Should not upgrade without an upgrade generator")
+
+	mk.SetUpgradeGenerator(strongGen)
+	plaintext, err = mk.Unlock(baseKeyPass)
+	require.NoError(t, err)
+	assert.Equal(t, payload, plaintext)
+	assert.True(t, mk.Upgraded())
+	assert.Equal(t, DefaultInteractiveIterations, mk.keyGen.iterations, "Payload should be upgraded")
+	assert.Equal(t, uint64(1<<10), mk.surKeys["test"].keyGen.iterations, "Surrogate keys are only upgraded when used")
+
+	plaintext, err = mk.SurrogateUnlock("test", surKeyPass)
+	require.NoError(t, err)
+	assert.Equal(t, payload, plaintext)
+	assert.Equal(t, DefaultInteractiveIterations, mk.surKeys["test"].keyGen.iterations, "Surrogate key should be upgraded")
+	assert.Equal(t, uint64(1<<10), mk.surKeys["other"].keyGen.iterations, "Unused surrogate keys should be unchanged")
+
+	buf.Reset()
+	require.NoError(t, mk.Write(&buf))
+	mk, err = ReadMultiLocker(&buf)
+	require.NoError(t, err)
+	for _, id := range []string{"test", "other"} {
+		plaintext, err = mk.SurrogateUnlock(id, surKeyPass)
+		assert.NoError(t, err)
+		assert.Equal(t, payload, plaintext)
+	}
+	plaintext, err = mk.Unlock(baseKeyPass)
+	assert.NoError(t, err)
+	assert.Equal(t, payload, plaintext)
+}
+
+func TestMultiLocker_UpdateSurrogatePass(t *testing.T) {
+	var (
+		baseKeyPass = Passphrase("base key pass")
+		surKeyPass  = Passphrase("sur key pass")
+		newPass     = Passphrase("new sur key pass")
+		payload     = Plaintext("test payload")
+	)
+	gen, err := NewKeyGenerator(SetShortDelayIterations())
+	require.NoError(t, err)
+	mk := NewMultiLocker(gen)
+	require.NoError(t, mk.Lock(baseKeyPass, payload))
+	require.NoError(t, mk.AddSurrogatePass("test", surKeyPass))
+	require.NoError(t, mk.UpdateSurrogatePass("test", newPass))
+
+	_, err = mk.SurrogateUnlock("test", surKeyPass)
+	assert.ErrorIs(t, err, ErrInvalidPassword, "Old surrogate pass should no longer work")
+	plaintext, err := mk.SurrogateUnlock("test", newPass)
+	assert.NoError(t, err)
+	assert.Equal(t, payload, plaintext)
+}
@@ -0,0 +1,371 @@
+package passlock
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+)
+
+// The protobuf encoding is written by hand to avoid a dependency on a protobuf runtime for such a small schema.
+// The encoding is compatible with this schema:
+//
+//	syntax = "proto3";
+//
+//	message KeyGenerator {
+//	  uint64 iterations = 1;
+//	  uint32 relative_block_size = 2;
+//	  uint32 cpu_cost = 3;
+//	  uint32 key_size = 4;
+//	  uint32 kdf = 5; // KDFID, scrypt is assumed if not set.
+//	  uint32 cipher = 6; // CipherID, AES-GCM is assumed if not set.
+//	  bytes kdf_params = 7; // Parameters for a KDF added with RegisterKDF.
+//	}
+//
+//	message SurrogateKey {
+//	  KeyGenerator generator = 1;
+//	  bytes encrypted_pass = 2;
+//	  string label = 3;
+//	  int64 created = 4; // Unix seconds, 0 if not set.
+//	  int64 expires = 5; // Unix seconds, 0 if not set.
+//	  bool read_only = 6;
+//	}
+//
+//	message PayloadGeneration {
+//	  KeyGenerator generator = 1;
+//	  int64 replaced = 2; // Unix seconds, 0 if not set.
+//	  bytes payload = 3;
+//	}
+//
+//	message MultiLocker {
+//	  uint32 version = 1;
+//	  KeyGenerator generator = 2;
+//	  map<string, SurrogateKey> surrogate_keys = 3;
+//	  bytes payload = 4;
+//	  uint32 history_limit = 5;
+//	  repeated PayloadGeneration history = 6; // Most recently replaced first.
+//	}
+
+const (
+	pbVarint  = 0
+	pbFixed64 = 1
+	pbBytes   = 2
+	pbFixed32 = 5
+)
+
+var (
+	errProtobufTruncated = errors.New("truncated protobuf message")
+)
+
+type protobufCodec struct{}
+
+func (protobufCodec) Encode(w io.Writer, l *MultiLocker) error {
+	if err := l.validateHasGenerator(); err != nil {
+		return err
+	}
+	var out []byte
+	out = pbAppendVarintField(out, 1, uint64(CurrentFormatVersion))
+	out = pbAppendBytesField(out, 2, pbEncodeGenerator(l.keyGen))
+	for _, id := range l.ListKeyIDs() {
+		sur := l.surKeys[id]
+		var surMsg []byte
+		surMsg = pbAppendBytesField(surMsg, 1, pbEncodeGenerator(sur.keyGen))
+		surMsg = pbAppendBytesField(surMsg, 2, sur.encryptedPass)
+		if len(sur.info.label) > 0 {
+			surMsg = pbAppendBytesField(surMsg, 3, []byte(sur.info.label))
+		}
+		if created := timeUnix(sur.info.created); created != 0 {
+			surMsg = pbAppendVarintField(surMsg, 4, uint64(created))
+		}
+		if expires := timeUnix(sur.info.expires); expires != 0 {
+			surMsg = pbAppendVarintField(surMsg, 5, uint64(expires))
+		}
+		if sur.info.readOnly {
+			surMsg = pbAppendVarintField(surMsg, 6, 1)
+		}
+		var entry []byte
+		entry = pbAppendBytesField(entry, 1, []byte(id))
+		entry = pbAppendBytesField(entry, 2, surMsg)
+		out = pbAppendBytesField(out, 3, entry)
+	}
+	out = pbAppendBytesField(out, 4, l.payload)
+	if l.historyLimit > 0 {
+		out = pbAppendVarintField(out, 5, uint64(l.historyLimit))
+	}
+	for _, g := range l.history {
+		var genMsg []byte
+		genMsg = pbAppendBytesField(genMsg, 1, pbEncodeGenerator(g.keyGen))
+		if replaced := timeUnix(g.replaced); replaced != 0 {
+			genMsg = pbAppendVarintField(genMsg, 2, uint64(replaced))
+		}
+		genMsg = pbAppendBytesField(genMsg, 3, g.payload)
+		out = pbAppendBytesField(out, 6, genMsg)
+	}
+	_, err := w.Write(out)
+	return err
+}
+
+func (protobufCodec) Decode(r io.Reader) (*MultiLocker, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	l := &MultiLocker{
+		surKeys: map[string]surrogateKey{},
+	}
+	var version uint64
+	err = pbParse(data, func(field int, wireType int, val uint64, buf []byte) error {
+		switch {
+		case field == 1 && wireType == pbVarint:
+			version = val
+		case field == 2 && wireType == pbBytes:
+			l.keyGen, err = pbDecodeGenerator(buf)
+			return err
+		case field == 3 && wireType == pbBytes:
+			id, sur, err := pbDecodeSurrogateEntry(buf)
+			if err != nil {
+				return err
+			}
+			l.surKeys[id] = sur
+		case field == 4 && wireType == pbBytes:
+			l.payload = append(Encrypted{}, buf...)
+		case field == 5 && wireType == pbVarint:
+			if val > math.MaxUint8 {
+				return fmt.Errorf("history limit %d is out of range", val)
+			}
+			l.historyLimit = uint8(val)
+		case field == 6 && wireType == pbBytes:
+			g, err := pbDecodeGeneration(buf)
+			if err != nil {
+				return err
+			}
+			l.history = append(l.history, g)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidHeader, err)
+	}
+	if version > uint64(CurrentFormatVersion) {
+		if version > math.MaxUint16 {
+			version = math.MaxUint16
+		}
+		return nil, &UnsupportedVersionError{Version: uint16(version)}
+	}
+	if l.keyGen == nil {
+		return nil, fmt.Errorf("%w: missing generator", ErrInvalidHeader)
+	}
+	if len(l.history) > int(l.historyLimit) {
+		return nil, fmt.Errorf("%w: %d payload generations exceeds the history limit of %d", ErrInvalidHeader, len(l.history), l.historyLimit)
+	}
+	return l, nil
+}
+
+func pbEncodeGenerator(gen *KeyGenerator) []byte {
+	var out []byte
+	out = pbAppendVarintField(out, 1, gen.iterations)
+	out = pbAppendVarintField(out, 2, uint64(gen.relativeBlockSize))
+	out = pbAppendVarintField(out, 3, uint64(gen.cpuCost))
+	out = pbAppendVarintField(out, 4, uint64(gen.aesKeySize))
+	out = pbAppendVarintField(out, 5, uint64(gen.kdf))
+	out = pbAppendVarintField(out, 6, uint64(gen.cipher))
+	if len(gen.kdfParams) > 0 {
+		out = pbAppendBytesField(out, 7, gen.kdfParams)
+	}
+	return out
+}
+
+func pbDecodeGenerator(data []byte) (*KeyGenerator, error) {
+	gen := new(KeyGenerator)
+	err := pbParse(data, func(field int, wireType int, val uint64, buf []byte) error {
+		if field == 7 && wireType == pbBytes {
+			gen.kdfParams = append([]byte{}, buf...)
+			return nil
+		}
+		if wireType != pbVarint {
+			return nil
+		}
+		if field > 1 && val > math.MaxUint8 {
+			return fmt.Errorf("generator field %d value %d is out of range", field, val)
+		}
+		switch field {
+		case 1:
+			gen.iterations = val
+		case 2:
+			gen.relativeBlockSize = uint8(val)
+		case 3:
+			gen.cpuCost = uint8(val)
+		case 4:
+			gen.aesKeySize = uint8(val)
+		case 5:
+			gen.kdf = KDFID(val)
+		case 6:
+			gen.cipher = CipherID(val)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	switch gen.aesKeySize {
+	case AES128KeySize, AES256KeySize:
+	default:
+		return nil, fmt.Errorf("invalid key size %d", gen.aesKeySize)
+	}
+	switch gen.kdf {
+	case 0:
+		gen.kdf = KDFScrypt
+	case KDFScrypt:
+		if len(gen.kdfParams) > 0 {
+			return nil, fmt.Errorf("unexpected %s parameters", gen.kdf)
+		}
+	default:
+		if _, ok := lookupKDF(gen.kdf); !ok {
+			return nil, fmt.Errorf("%w: %s", ErrUnknownKDF, gen.kdf)
+		}
+		if len(gen.kdfParams) > math.MaxUint16 {
+			return nil, fmt.Errorf("%s parameters are longer than %d bytes", gen.kdf, math.MaxUint16)
+		}
+	}
+	if gen.cipher == 0 {
+		gen.cipher = CipherAESGCM
+	}
+	if err := gen.checkCipher(); err != nil {
+		return nil, err
+	}
+	return gen, nil
+}
+
+func pbDecodeSurrogateEntry(data []byte) (string, surrogateKey, error) {
+	var (
+		id  string
+		sur surrogateKey
+	)
+	err := pbParse(data, func(field int, wireType int, _ uint64, buf []byte) error {
+		if wireType != pbBytes {
+			return nil
+		}
+		switch field {
+		case 1:
+			id = string(buf)
+		case 2:
+			return pbParse(buf, func(field int, wireType int, val uint64, buf []byte) error {
+				var err error
+				switch {
+				case field == 1 && wireType == pbBytes:
+					sur.keyGen, err = pbDecodeGenerator(buf)
+				case field == 2 && wireType == pbBytes:
+					sur.encryptedPass = append(Encrypted{}, buf...)
+				case field == 3 && wireType == pbBytes:
+					if len(buf) > labelFieldLen {
+						return fmt.Errorf("label is longer than the maximum of %d bytes", labelFieldLen)
+					}
+					sur.info.label = string(buf)
+				case field == 4 && wireType == pbVarint:
+					sur.info.created = unixTime(int64(val))
+				case field == 5 && wireType == pbVarint:
+					sur.info.expires = unixTime(int64(val))
+				case field == 6 && wireType == pbVarint:
+					sur.info.readOnly = val != 0
+				}
+				return err
+			})
+		}
+		return nil
+	})
+	if err != nil {
+		return "", surrogateKey{}, err
+	}
+	if len(id) > idFieldLen || len(id) == 0 {
+		return "", surrogateKey{}, fmt.Errorf("surrogate key id '%s' is not within the valid range of 1-%d bytes", id, idFieldLen)
+	}
+	if sur.keyGen == nil {
+		return "", surrogateKey{}, fmt.Errorf("surrogate key '%s' is missing a generator", id)
+	}
+	return id, sur, nil
+}
+
+func pbDecodeGeneration(data []byte) (payloadGeneration, error) {
+	var g payloadGeneration
+	err := pbParse(data, func(field int, wireType int, val uint64, buf []byte) error {
+		var err error
+		switch {
+		case field == 1 && wireType == pbBytes:
+			g.keyGen, err = pbDecodeGenerator(buf)
+		case field == 2 && wireType == pbVarint:
+			g.replaced = unixTime(int64(val))
+		case field == 3 && wireType == pbBytes:
+			g.payload = append(Encrypted{}, buf...)
+		}
+		return err
+	})
+	if err != nil {
+		return payloadGeneration{}, err
+	}
+	if g.keyGen == nil {
+		return payloadGeneration{}, errors.New("payload generation is missing a generator")
+	}
+	return g, nil
+}
+
+func pbAppendVarintField(out []byte, field int, val uint64) []byte {
+	out = binary.AppendUvarint(out, uint64(field)<<3|pbVarint)
+	return binary.AppendUvarint(out, val)
+}
+
+func pbAppendBytesField(out []byte, field int, val []byte) []byte {
+	out = binary.AppendUvarint(out, uint64(field)<<3|pbBytes)
+	out = binary.AppendUvarint(out, uint64(len(val)))
+	return append(out, val...)
+}
+
+// pbParse calls the handler for each field in the message.
+// Varint values are passed as val, length-delimited values are passed as buf, and fixed-width values are skipped.
+func pbParse(data []byte, handler func(field int, wireType int, val uint64, buf []byte) error) error {
+	for len(data) > 0 {
+		tag, n := binary.Uvarint(data)
+		if n <= 0 {
+			return errProtobufTruncated
+		}
+		data = data[n:]
+		field, wireType := int(tag>>3), int(tag&0x7)
+		if field == 0 {
+			return errors.New("invalid protobuf field number 0")
+		}
+		switch wireType {
+		case pbVarint:
+			val, n := binary.Uvarint(data)
+			if n <= 0 {
+				return errProtobufTruncated
+			}
+			data = data[n:]
+			if err := handler(field, wireType, val, nil); err != nil {
+				return err
+			}
+		case pbBytes:
+			length, n := binary.Uvarint(data)
+			if n <= 0 || length > uint64(len(data)-n) {
+				return errProtobufTruncated
+			}
+			buf := data[n : n+int(length)]
+			data = data[n+int(length):]
+			if err := handler(field, wireType, 0, buf); err != nil {
+				return err
+			}
+		case pbFixed64:
+			if len(data) < 8 {
+				return errProtobufTruncated
+			}
+			data = data[8:]
+		case pbFixed32:
+			if len(data) < 4 {
+				return errProtobufTruncated
+			}
+			data = data[4:]
+		default:
+			return fmt.Errorf("unsupported protobuf wire type %d", wireType)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,12 @@
+package passlock
+
+import (
+	"crypto/subtle"
+)
+
+// EqualSecret reports whether a and b are equal, taking time that depends only on their lengths and not their contents.
+// Use this instead of bytes.Equal when comparing passphrases, keys, MACs, or other secret values to avoid leaking their contents through timing.
+// A nil and empty slice are considered equal.
+func EqualSecret(a, b []byte) bool {
+	return subtle.ConstantTimeCompare(a, b) == 1
+}
@@ -0,0 +1,27 @@
+package passlock
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestEqualSecret(t *testing.T) {
+	tests := map[string]struct {
+		a, b     []byte
+		expected bool
+	}{
+		"Equal":          {[]byte("secret"), []byte("secret"), true},
+		"Different":      {[]byte("secret"), []byte("secreT"), false},
+		"Prefix":         {[]byte("secret"), []byte("secret value"), false},
+		"Empty":          {[]byte{}, []byte{}, true},
+		"Nil and empty":  {nil, []byte{}, true},
+		"Nil and secret": {nil, []byte("secret"), false},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, EqualSecret(tc.a, tc.b))
+			assert.Equal(t, tc.expected, EqualSecret(tc.b, tc.a))
+		})
+	}
+	assert.True(t, EqualSecret(Passphrase("pass"), Key("pass")), "Named byte slice types should be accepted")
+}
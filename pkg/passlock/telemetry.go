@@ -0,0 +1,137 @@
+package passlock
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"log/slog"
+	"time"
+)
+
+// UnlockOperation identifies the MultiLocker operation that attempted to unlock a payload or surrogate key.
+type UnlockOperation string
+
+const (
+	OpUnlock                    UnlockOperation = "unlock"
+	OpSurrogateUnlock           UnlockOperation = "surrogate_unlock"
+	OpEnableUpdate              UnlockOperation = "enable_update"
+	OpUnlockGeneration          UnlockOperation = "unlock_generation"
+	OpSurrogateUnlockGeneration UnlockOperation = "surrogate_unlock_generation"
+	OpSurrogateLock             UnlockOperation = "surrogate_lock"
+)
+
+// UnlockEvent describes a single attempt to unlock a MultiLocker with a pass phrase.
+// No pass phrase or key material is included in an event.
+type UnlockEvent struct {
+	Operation UnlockOperation
+	// SurrogateIDHash is the result of HashSurrogateID for the surrogate key used in the attempt, and is empty if the base pass phrase was used.
+	SurrogateIDHash string
+	// Generation is the payload generation that was unlocked, and is 0 for the current payload.
+	Generation int
+	Success    bool
+	// Err is the reason for a failed attempt.
+	Err      error
+	Duration time.Duration
+	// KDF, Cipher, Iterations, and KeySize describe the key generation settings for the pass phrase that was checked.
+	// These are zero values if the settings couldn't be determined, such as when a surrogate key ID doesn't exist.
+	KDF        KDFID
+	Cipher     CipherID
+	Iterations uint64
+	KeySize    uint8
+}
+
+// UnlockObserver is called with an UnlockEvent after each unlock attempt.
+// It's called synchronously, so it should return quickly.
+type UnlockObserver = func(event UnlockEvent)
+
+// SetUnlockObserver sets a function that is called after each attempt to unlock this MultiLocker, whether it succeeds or not.
+// Attempts are made with Unlock, SurrogateUnlock, EnableUpdate, UnlockGeneration, SurrogateUnlockGeneration, and WriteMultiLocker.SurrogateLock.
+// This is intended to feed security monitoring that detects brute-force patterns, and is not persisted with the MultiLocker.
+// Passing nil disables observation.
+func (l *MultiLocker) SetUnlockObserver(observer UnlockObserver) {
+	l.observer = observer
+}
+
+// HashSurrogateID returns the value used as UnlockEvent.SurrogateIDHash for a surrogate key ID.
+// This allows correlating events for a surrogate key without writing its ID to logs.
+// Surrogate key IDs are short, so this only prevents casual exposure and isn't a replacement for protecting logs.
+func HashSurrogateID(id string) string {
+	sum := sha256.Sum256([]byte(id))
+	return hex.EncodeToString(sum[:8])
+}
+
+// SlogUnlockObserver creates an UnlockObserver that writes each UnlockEvent to the given slog.Logger.
+// Successful attempts are logged at slog.LevelInfo, and failed attempts at slog.LevelWarn.
+// If logger is nil, then slog.Default is used.
+func SlogUnlockObserver(logger *slog.Logger) UnlockObserver {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	return func(event UnlockEvent) {
+		level := slog.LevelInfo
+		msg := "passlock unlock succeeded"
+		attrs := []slog.Attr{
+			slog.String("operation", string(event.Operation)),
+			slog.Duration("duration", event.Duration),
+			slog.String("kdf", event.KDF.String()),
+			slog.String("cipher", event.Cipher.String()),
+			slog.Uint64("iterations", event.Iterations),
+			slog.Int("key_size", int(event.KeySize)),
+		}
+		if len(event.SurrogateIDHash) > 0 {
+			attrs = append(attrs, slog.String("surrogate", event.SurrogateIDHash))
+		}
+		if event.Generation > 0 {
+			attrs = append(attrs, slog.Int("generation", event.Generation))
+		}
+		if !event.Success {
+			level = slog.LevelWarn
+			msg = "passlock unlock failed"
+			attrs = append(attrs, slog.String("error", event.Err.Error()))
+		}
+		logger.LogAttrs(context.Background(), level, msg, attrs...)
+	}
+}
+
+// observeUnlock sends an UnlockEvent to the observer, if one is set.
+// The generator should be captured before the attempt, since a successful attempt may upgrade it.
+func (l *MultiLocker) observeUnlock(op UnlockOperation, id string, generation int, gen *KeyGenerator, start time.Time, err error) {
+	if l.observer == nil {
+		return
+	}
+	event := UnlockEvent{
+		Operation:  op,
+		Generation: generation,
+		Success:    err == nil,
+		Err:        err,
+		Duration:   time.Since(start),
+	}
+	if len(id) > 0 {
+		event.SurrogateIDHash = HashSurrogateID(id)
+	}
+	if gen != nil {
+		event.KDF = gen.kdf
+		event.Cipher = gen.cipher
+		event.Iterations = gen.iterations
+		event.KeySize = gen.aesKeySize
+	}
+	l.observer(event)
+}
+
+// surrogateGenerator returns the generator for a surrogate key, or nil if it doesn't exist.
+func (l *MultiLocker) surrogateGenerator(id string) *KeyGenerator {
+	sur, ok := l.surKeys[id]
+	if !ok {
+		return nil
+	}
+	return sur.keyGen
+}
+
+// generationGenerator returns the generator for a payload generation, or nil if it doesn't exist.
+func (l *MultiLocker) generationGenerator(generation int) *KeyGenerator {
+	g, err := l.generation(generation)
+	if err != nil {
+		return nil
+	}
+	return g.keyGen
+}
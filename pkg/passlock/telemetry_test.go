@@ -0,0 +1,102 @@
+package passlock
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestMultiLocker_SetUnlockObserver(t *testing.T) {
+	var (
+		basePass = Passphrase("base key pass")
+		surPass  = Passphrase("sur key pass")
+		events   []UnlockEvent
+	)
+	gen, err := NewKeyGenerator(SetShortDelayIterations())
+	require.NoError(t, err)
+	mk := NewWriteMultiLocker(gen)
+	require.NoError(t, mk.SetHistoryLimit(1))
+	require.NoError(t, mk.Lock(basePass, Plaintext("first")))
+	require.NoError(t, mk.AddSurrogatePass("developer", surPass))
+	mk.SetUnlockObserver(func(event UnlockEvent) {
+		events = append(events, event)
+	})
+
+	_, err = mk.Unlock(basePass)
+	require.NoError(t, err)
+	_, err = mk.Unlock(Passphrase("wrong pass"))
+	require.Error(t, err)
+	_, err = mk.SurrogateUnlock("developer", Passphrase("wrong pass"))
+	require.Error(t, err)
+	_, err = mk.SurrogateUnlock("missing", surPass)
+	require.Error(t, err)
+	require.NoError(t, mk.SurrogateLock("developer", surPass, Plaintext("second")))
+	_, err = mk.SurrogateUnlockGeneration(1, "developer", surPass)
+	require.NoError(t, err)
+	require.NoError(t, mk.EnableUpdate(basePass))
+
+	require.Len(t, events, 7, "Each attempt should produce exactly one event")
+	ops := make([]UnlockOperation, len(events))
+	for i, event := range events {
+		ops[i] = event.Operation
+		assert.Equal(t, event.Err == nil, event.Success)
+		assert.Positive(t, event.Duration)
+	}
+	assert.Equal(t, []UnlockOperation{OpUnlock, OpUnlock, OpSurrogateUnlock, OpSurrogateUnlock, OpSurrogateLock, OpSurrogateUnlockGeneration, OpEnableUpdate}, ops)
+
+	assert.True(t, events[0].Success)
+	assert.Empty(t, events[0].SurrogateIDHash)
+	assert.Equal(t, KDFScrypt, events[0].KDF)
+	assert.Equal(t, CipherAESGCM, events[0].Cipher)
+	assert.Equal(t, gen.iterations, events[0].Iterations)
+	assert.Equal(t, AES256KeySize, events[0].KeySize)
+
+	assert.ErrorIs(t, events[1].Err, ErrInvalidPassword)
+	assert.Equal(t, HashSurrogateID("developer"), events[2].SurrogateIDHash)
+	assert.NotContains(t, events[2].SurrogateIDHash, "developer")
+	assert.Zero(t, events[3].Iterations, "Unknown surrogate keys have no generator settings")
+	assert.Equal(t, 1, events[5].Generation)
+
+	mk.SetUnlockObserver(nil)
+	_, err = mk.Unlock(basePass)
+	require.NoError(t, err)
+	assert.Len(t, events, 7)
+}
+
+func TestSlogUnlockObserver(t *testing.T) {
+	var buf bytes.Buffer
+	observer := SlogUnlockObserver(slog.New(slog.NewJSONHandler(&buf, nil)))
+	observer(UnlockEvent{
+		Operation:       OpSurrogateUnlock,
+		SurrogateIDHash: HashSurrogateID("developer"),
+		Err:             ErrInvalidPassword,
+		KDF:             KDFScrypt,
+		Cipher:          CipherAESGCM,
+		Iterations:      DefaultInteractiveIterations,
+		KeySize:         AES256KeySize,
+	})
+	observer(UnlockEvent{
+		Operation: OpUnlock,
+		Success:   true,
+	})
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, lines, 2)
+	var failed, succeeded map[string]any
+	require.NoError(t, json.Unmarshal([]byte(lines[0]), &failed))
+	require.NoError(t, json.Unmarshal([]byte(lines[1]), &succeeded))
+
+	assert.Equal(t, "WARN", failed["level"])
+	assert.Equal(t, "surrogate_unlock", failed["operation"])
+	assert.Equal(t, HashSurrogateID("developer"), failed["surrogate"])
+	assert.Equal(t, "scrypt", failed["kdf"])
+	assert.Equal(t, ErrInvalidPassword.Error(), failed["error"])
+
+	assert.Equal(t, "INFO", succeeded["level"])
+	assert.NotContains(t, succeeded, "surrogate")
+	assert.NotContains(t, succeeded, "error")
+}
@@ -0,0 +1,78 @@
+package passlock
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+)
+
+const (
+	// CurrentFormatVersion is the binary format version written by MultiLocker.Write.
+	//
+	// Version history:
+	//   - 0: The legacy layout without a format header. Surrogate keys share the payload's key generation settings.
+	//   - 1: Adds a format header, and key generation settings for each surrogate key.
+	//   - 2: Key generation settings include a KDFID and an algorithm-specific parameter block.
+	//   - 3: Adds a label, creation time, expiry time, and read only flag for each surrogate key.
+	//   - 4: Adds the payload history limit and retained payload generations.
+	//   - 5: Key generation settings include a CipherID.
+	CurrentFormatVersion uint16 = 5
+
+	legacyFormatVersion        uint16 = 0
+	kdfFormatVersion           uint16 = 2
+	surrogateInfoFormatVersion uint16 = 3
+	historyFormatVersion       uint16 = 4
+	cipherFormatVersion        uint16 = 5
+)
+
+var (
+	formatMagic = []byte("PASSLOCK")
+
+	ErrUnsupportedVersion = errors.New("unsupported MultiLocker format version")
+)
+
+// UnsupportedVersionError is returned when a MultiLocker is read with a format version that can't be handled.
+// It matches ErrUnsupportedVersion with errors.Is.
+type UnsupportedVersionError struct {
+	Version uint16
+}
+
+func (e *UnsupportedVersionError) Error() string {
+	return fmt.Sprintf("%s: detected version %d, current version is %d", ErrUnsupportedVersion, e.Version, CurrentFormatVersion)
+}
+
+func (e *UnsupportedVersionError) Is(target error) bool {
+	return target == ErrUnsupportedVersion
+}
+
+// readFormatVersion reads the format header from the io.Reader, and returns the detected version.
+// If no format header is present, then the legacy version is returned.
+// The returned io.Reader must be used to read the remaining data, since legacy data may have been consumed while detecting the header.
+func readFormatVersion(r io.Reader) (uint16, io.Reader, error) {
+	head := make([]byte, len(formatMagic))
+	n, err := io.ReadFull(r, head)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) {
+		if errors.Is(err, io.EOF) {
+			return 0, nil, fmt.Errorf("%w: no data", ErrInvalidHeader)
+		}
+		return 0, nil, err
+	}
+	head = head[:n]
+	if !bytes.Equal(head, formatMagic) {
+		return legacyFormatVersion, io.MultiReader(bytes.NewReader(head), r), nil
+	}
+	var version uint16
+	if err := binary.Read(r, binary.BigEndian, &version); err != nil {
+		return 0, nil, fmt.Errorf("%w: failed to read format version: %v", ErrInvalidHeader, err)
+	}
+	return version, r, nil
+}
+
+func writeFormatVersion(w io.Writer, version uint16) error {
+	if _, err := w.Write(formatMagic); err != nil {
+		return err
+	}
+	return binary.Write(w, binary.BigEndian, version)
+}
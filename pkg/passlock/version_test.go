@@ -0,0 +1,185 @@
+package passlock
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"os"
+	"testing"
+)
+
+// testdata/legacy_v0.bin was written by the MultiLocker implementation before format versions were introduced.
+// testdata/v1.bin was written in format version 1, before the KDFID was persisted, with the same passphrases and a payload of "v1 payload".
+// testdata/v4.bin was written in format version 4, before the cipher was persisted, with the same passphrases and a payload of "v4 payload".
+// It has a history limit of 2, and retains a previous payload of "v4 previous payload".
+// testdata/v3.bin was written in format version 3, before payload history was persisted, with the same passphrases and a payload of "v3 payload".
+// Its "developer" surrogate key is read only, with a label of "Developer".
+// testdata/v2.bin was written in format version 2, before surrogate key metadata was persisted, with the same passphrases and a payload of "v2 payload".
+const (
+	legacyBasePass = "base key pass"
+	legacySurPass  = "sur key pass"
+	legacyPayload  = "legacy payload"
+)
+
+func TestMultiLocker_Write_Version(t *testing.T) {
+	var buf bytes.Buffer
+	mk := newTestMultiLocker(t, Passphrase("base key pass"), Passphrase("sur key pass"), Plaintext("test payload"))
+	require.NoError(t, mk.Write(&buf))
+	assert.True(t, bytes.HasPrefix(buf.Bytes(), formatMagic))
+	assert.Equal(t, CurrentFormatVersion, binary.BigEndian.Uint16(buf.Bytes()[len(formatMagic):]))
+}
+
+func TestReadMultiLocker_Legacy(t *testing.T) {
+	legacy, err := os.ReadFile("testdata/legacy_v0.bin")
+	require.NoError(t, err)
+
+	_, err = ReadMultiLocker(bytes.NewReader(legacy))
+	assert.ErrorIs(t, err, ErrUnsupportedVersion)
+	var versionErr *UnsupportedVersionError
+	require.True(t, errors.As(err, &versionErr))
+	assert.Equal(t, legacyFormatVersion, versionErr.Version)
+
+	mk, err := ReadMultiLockerAnyVersion(bytes.NewReader(legacy))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"developer"}, mk.ListKeyIDs())
+	plaintext, err := mk.SurrogateUnlock("developer", Passphrase(legacySurPass))
+	require.NoError(t, err)
+	assert.Equal(t, legacyPayload, string(plaintext))
+
+	var buf bytes.Buffer
+	require.NoError(t, mk.Write(&buf))
+	mk, err = ReadMultiLocker(&buf)
+	require.NoError(t, err, "A legacy MultiLocker should be written in the current format")
+	plaintext, err = mk.SurrogateUnlock("developer", Passphrase(legacySurPass))
+	assert.NoError(t, err)
+	assert.Equal(t, legacyPayload, string(plaintext))
+	plaintext, err = mk.Unlock(Passphrase(legacyBasePass))
+	assert.NoError(t, err)
+	assert.Equal(t, legacyPayload, string(plaintext))
+}
+
+func TestReadMultiLocker_V1(t *testing.T) {
+	v1, err := os.ReadFile("testdata/v1.bin")
+	require.NoError(t, err)
+
+	_, err = ReadMultiLocker(bytes.NewReader(v1))
+	assert.ErrorIs(t, err, ErrUnsupportedVersion)
+
+	mk, err := ReadMultiLockerAnyVersion(bytes.NewReader(v1))
+	require.NoError(t, err)
+	assert.Equal(t, KDFScrypt, mk.keyGen.KDF())
+	plaintext, err := mk.SurrogateUnlock("developer", Passphrase(legacySurPass))
+	require.NoError(t, err)
+	assert.Equal(t, "v1 payload", string(plaintext))
+
+	var buf bytes.Buffer
+	require.NoError(t, mk.Write(&buf))
+	mk, err = ReadMultiLocker(&buf)
+	require.NoError(t, err, "A version 1 MultiLocker should be written in the current format")
+	plaintext, err = mk.Unlock(Passphrase(legacyBasePass))
+	assert.NoError(t, err)
+	assert.Equal(t, "v1 payload", string(plaintext))
+}
+
+func TestReadMultiLocker_V2(t *testing.T) {
+	v2, err := os.ReadFile("testdata/v2.bin")
+	require.NoError(t, err)
+
+	_, err = ReadMultiLocker(bytes.NewReader(v2))
+	assert.ErrorIs(t, err, ErrUnsupportedVersion)
+
+	mk, err := ReadMultiLockerAnyVersion(bytes.NewReader(v2))
+	require.NoError(t, err)
+	assert.Equal(t, []KeyInfo{{ID: "developer", Writable: true}}, mk.ListKeys(KeyFilter{}))
+	plaintext, err := mk.SurrogateUnlock("developer", Passphrase(legacySurPass))
+	require.NoError(t, err)
+	assert.Equal(t, "v2 payload", string(plaintext))
+
+	var buf bytes.Buffer
+	require.NoError(t, mk.Write(&buf))
+	mk, err = ReadMultiLocker(&buf)
+	require.NoError(t, err, "A version 2 MultiLocker should be written in the current format")
+	plaintext, err = mk.Unlock(Passphrase(legacyBasePass))
+	assert.NoError(t, err)
+	assert.Equal(t, "v2 payload", string(plaintext))
+}
+
+func TestReadMultiLocker_V3(t *testing.T) {
+	v3, err := os.ReadFile("testdata/v3.bin")
+	require.NoError(t, err)
+
+	_, err = ReadMultiLocker(bytes.NewReader(v3))
+	assert.ErrorIs(t, err, ErrUnsupportedVersion)
+
+	mk, err := ReadMultiLockerAnyVersion(bytes.NewReader(v3))
+	require.NoError(t, err)
+	keys := mk.ListKeys(KeyFilter{})
+	require.Len(t, keys, 1)
+	assert.Equal(t, "Developer", keys[0].Label)
+	assert.False(t, keys[0].Writable)
+	assert.Empty(t, mk.History())
+	plaintext, err := mk.SurrogateUnlock("developer", Passphrase(legacySurPass))
+	require.NoError(t, err)
+	assert.Equal(t, "v3 payload", string(plaintext))
+
+	var buf bytes.Buffer
+	require.NoError(t, mk.Write(&buf))
+	mk, err = ReadMultiLocker(&buf)
+	require.NoError(t, err, "A version 3 MultiLocker should be written in the current format")
+	plaintext, err = mk.Unlock(Passphrase(legacyBasePass))
+	assert.NoError(t, err)
+	assert.Equal(t, "v3 payload", string(plaintext))
+}
+
+func TestReadMultiLocker_V4(t *testing.T) {
+	v4, err := os.ReadFile("testdata/v4.bin")
+	require.NoError(t, err)
+
+	_, err = ReadMultiLocker(bytes.NewReader(v4))
+	assert.ErrorIs(t, err, ErrUnsupportedVersion)
+
+	mk, err := ReadMultiLockerAnyVersion(bytes.NewReader(v4))
+	require.NoError(t, err)
+	assert.Equal(t, CipherAESGCM, mk.keyGen.Cipher())
+	require.Len(t, mk.History(), 1)
+	plaintext, err := mk.SurrogateUnlockGeneration(1, "developer", Passphrase(legacySurPass))
+	require.NoError(t, err)
+	assert.Equal(t, "v4 previous payload", string(plaintext))
+
+	var buf bytes.Buffer
+	require.NoError(t, mk.Write(&buf))
+	mk, err = ReadMultiLocker(&buf)
+	require.NoError(t, err, "A version 4 MultiLocker should be written in the current format")
+	plaintext, err = mk.Unlock(Passphrase(legacyBasePass))
+	assert.NoError(t, err)
+	assert.Equal(t, "v4 payload", string(plaintext))
+}
+
+func TestReadMultiLocker_FutureVersion(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, writeFormatVersion(&buf, CurrentFormatVersion+1))
+	buf.WriteString("some data in a future format")
+
+	for name, read := range map[string]func([]byte) (*MultiLocker, error){
+		"ReadMultiLocker": func(data []byte) (*MultiLocker, error) {
+			return ReadMultiLocker(bytes.NewReader(data))
+		},
+		"ReadMultiLockerAnyVersion": func(data []byte) (*MultiLocker, error) {
+			return ReadMultiLockerAnyVersion(bytes.NewReader(data))
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := read(buf.Bytes())
+			var versionErr *UnsupportedVersionError
+			require.True(t, errors.As(err, &versionErr))
+			assert.Equal(t, CurrentFormatVersion+1, versionErr.Version)
+
+			_, err = read(nil)
+			assert.ErrorIs(t, err, ErrInvalidHeader)
+			_, err = read(formatMagic)
+			assert.ErrorIs(t, err, ErrInvalidHeader)
+		})
+	}
+}
@@ -0,0 +1,80 @@
+package xor
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+)
+
+// TextEncoding selects how screened data is encoded as text by EncodeScreenedString.
+type TextEncoding uint8
+
+const (
+	// TextBase64 uses standard base64 encoding with padding, which is the default.
+	TextBase64 TextEncoding = iota
+	// TextBase64URL uses unpadded URL-safe base64 encoding, which is safe for URLs, file names, and most shells.
+	TextBase64URL
+	// TextHex uses lower case hex encoding.
+	TextHex
+)
+
+func (e TextEncoding) String() string {
+	switch e {
+	case TextBase64:
+		return "base64"
+	case TextBase64URL:
+		return "base64url"
+	case TextHex:
+		return "hex"
+	default:
+		return fmt.Sprintf("unknown(%d)", uint8(e))
+	}
+}
+
+// EncodeScreenedString screens a copy of data with the key starting at offset, and encodes it as text with the given TextEncoding.
+// This allows embedding screened secrets in environment variables, JSON, YAML, etc. without binary-safety issues.
+// Use DecodeScreenedString with the same key, offset, and TextEncoding to recover the data.
+func EncodeScreenedString(data []byte, key []byte, offset int, enc TextEncoding) (string, error) {
+	screened, err := TransformBytes(data, key, offset)
+	if err != nil {
+		return "", err
+	}
+	switch enc {
+	case TextBase64:
+		return base64.StdEncoding.EncodeToString(screened), nil
+	case TextBase64URL:
+		return base64.RawURLEncoding.EncodeToString(screened), nil
+	case TextHex:
+		return hex.EncodeToString(screened), nil
+	default:
+		return "", fmt.Errorf("unknown text encoding %s", enc)
+	}
+}
+
+// DecodeScreenedString decodes text created by EncodeScreenedString, and unscreens it with the key starting at offset.
+func DecodeScreenedString(s string, key []byte, offset int, enc TextEncoding) ([]byte, error) {
+	if err := validateKeyOffset(key, offset); err != nil {
+		return nil, err
+	}
+	var (
+		screened []byte
+		err      error
+	)
+	switch enc {
+	case TextBase64:
+		screened, err = base64.StdEncoding.DecodeString(s)
+	case TextBase64URL:
+		screened, err = base64.RawURLEncoding.DecodeString(s)
+	case TextHex:
+		screened, err = hex.DecodeString(s)
+	default:
+		return nil, fmt.Errorf("unknown text encoding %s", enc)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode %s text: %w", enc, err)
+	}
+	if err := Screen(screened, key, offset); err != nil {
+		return nil, err
+	}
+	return screened, nil
+}
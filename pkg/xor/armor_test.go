@@ -0,0 +1,48 @@
+package xor
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestEncodeScreenedString(t *testing.T) {
+	key := []byte{0xde, 0xad, 0xbe, 0xef}
+	data := []byte("A string with some text")
+
+	tests := map[string]struct {
+		enc      TextEncoding
+		alphabet string
+	}{
+		"Base64":     {TextBase64, "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789+/="},
+		"Base64 URL": {TextBase64URL, "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789-_"},
+		"Hex":        {TextHex, "0123456789abcdef"},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			encoded, err := EncodeScreenedString(data, key, 1, tc.enc)
+			require.NoError(t, err)
+			for _, c := range encoded {
+				assert.Contains(t, tc.alphabet, string(c))
+			}
+
+			decoded, err := DecodeScreenedString(encoded, key, 1, tc.enc)
+			require.NoError(t, err)
+			assert.Equal(t, data, decoded)
+		})
+	}
+}
+
+func TestEncodeScreenedString_Neg(t *testing.T) {
+	key := []byte{0xde, 0xad, 0xbe, 0xef}
+	_, err := EncodeScreenedString([]byte("data"), key, 0, TextEncoding(100))
+	assert.Error(t, err)
+	_, err = EncodeScreenedString([]byte("data"), nil, 0, TextHex)
+	assert.Error(t, err)
+	_, err = DecodeScreenedString("not hex", key, 0, TextHex)
+	assert.Error(t, err)
+	_, err = DecodeScreenedString("00", key, 0, TextEncoding(100))
+	assert.Error(t, err)
+	_, err = DecodeScreenedString("00", key, 4, TextHex)
+	assert.Error(t, err)
+}
@@ -0,0 +1,114 @@
+package xor
+
+import (
+	"bytes"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestReaderAt(t *testing.T) {
+	var (
+		data = []byte(strings.Repeat("Random access to screened data. ", 8))
+		key  = []byte{0xde, 0xad, 0xbe, 0xef, 0x01}
+	)
+	screened, err := TransformBytes(data, key, 2)
+	require.NoError(t, err)
+	ra, err := NewReaderAt(bytes.NewReader(screened), key, 2)
+	require.NoError(t, err)
+
+	tests := map[string]struct {
+		off    int64
+		length int
+	}{
+		"Start":        {0, 10},
+		"Middle":       {37, 20},
+		"Key boundary": {int64(len(key)) * 4, 7},
+		"Single byte":  {101, 1},
+		"To end":       {int64(len(data)) - 12, 12},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			buf := make([]byte, tc.length)
+			n, err := ra.ReadAt(buf, tc.off)
+			require.NoError(t, err)
+			assert.Equal(t, tc.length, n)
+			assert.Equal(t, data[tc.off:tc.off+int64(tc.length)], buf)
+		})
+	}
+
+	buf := make([]byte, 10)
+	n, err := ra.ReadAt(buf, int64(len(data))-4)
+	assert.ErrorIs(t, err, io.EOF)
+	assert.Equal(t, 4, n)
+	assert.Equal(t, data[len(data)-4:], buf[:n], "Partial reads should be screened")
+
+	_, err = ra.ReadAt(buf, -1)
+	assert.Error(t, err)
+
+	_, err = NewReaderAt(bytes.NewReader(screened), nil)
+	assert.Error(t, err)
+}
+
+func TestReaderAt_Concurrent(t *testing.T) {
+	var (
+		data = bytes.Repeat([]byte("concurrent"), 100)
+		key  = []byte{0xde, 0xad, 0xbe, 0xef}
+		wg   sync.WaitGroup
+	)
+	screened, err := TransformBytes(data, key, 1)
+	require.NoError(t, err)
+	ra, err := NewReaderAt(bytes.NewReader(screened), key, 1)
+	require.NoError(t, err)
+
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(off int64) {
+			defer wg.Done()
+			buf := make([]byte, 50)
+			_, err := ra.ReadAt(buf, off)
+			assert.NoError(t, err)
+			assert.Equal(t, data[off:off+50], buf)
+		}(int64(i * 73))
+	}
+	wg.Wait()
+}
+
+func TestWriterAt(t *testing.T) {
+	var (
+		data = []byte("Data written out of order should be screened by position")
+		key  = []byte{0xde, 0xad, 0xbe, 0xef, 0x01}
+	)
+	f, err := os.Create(filepath.Join(t.TempDir(), "screened.bin"))
+	require.NoError(t, err)
+	defer func() {
+		_ = f.Close()
+	}()
+	wa, err := NewWriterAt(f, key, 3)
+	require.NoError(t, err)
+
+	in := append([]byte{}, data...)
+	_, err = wa.WriteAt(in[30:], 30)
+	require.NoError(t, err)
+	_, err = wa.WriteAt(in[:13], 0)
+	require.NoError(t, err)
+	_, err = wa.WriteAt(in[13:30], 13)
+	require.NoError(t, err)
+	assert.Equal(t, data, in, "Input should not be modified")
+
+	_, err = wa.WriteAt(in, -1)
+	assert.Error(t, err)
+
+	_, err = f.Seek(0, io.SeekStart)
+	require.NoError(t, err)
+	r, err := NewReader(f, key, 3)
+	require.NoError(t, err)
+	got, err := io.ReadAll(r)
+	require.NoError(t, err)
+	assert.Equal(t, data, got)
+}
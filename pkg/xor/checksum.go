@@ -0,0 +1,117 @@
+package xor
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash"
+	"hash/crc32"
+	"io"
+)
+
+const (
+	checksumSize       = crc32.Size
+	checksumBufferSize = 4096
+)
+
+var (
+	ErrChecksumMismatch = errors.New("unscreened data doesn't match its checksum")
+)
+
+var _ io.WriteCloser = (*checksumWriter)(nil)
+
+type checksumWriter struct {
+	w      Writer
+	crc    hash.Hash32
+	closed bool
+}
+
+// NewChecksumWriter constructs an io.WriteCloser that screens all bytes written like NewWriterWith, and also tracks a CRC-32 checksum of the unscreened bytes.
+// Close appends the screened checksum as a trailer, which allows NewChecksumReader to detect a wrong key or offset.
+// Close doesn't close the target, and nothing may be written after Close.
+func NewChecksumWriter(target io.Writer, key []byte, opts ...ScreenOpt) (io.WriteCloser, error) {
+	w, err := NewWriterWith(target, key, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return &checksumWriter{
+		w:   w,
+		crc: crc32.NewIEEE(),
+	}, nil
+}
+
+func (w *checksumWriter) Write(in []byte) (int, error) {
+	if w.closed {
+		return 0, errors.New("write after close")
+	}
+	n, err := w.w.Write(in)
+	w.crc.Write(in[:n])
+	return n, err
+}
+
+func (w *checksumWriter) Close() error {
+	if w.closed {
+		return nil
+	}
+	w.closed = true
+	var trailer [checksumSize]byte
+	binary.BigEndian.PutUint32(trailer[:], w.crc.Sum32())
+	_, err := w.w.Write(trailer[:])
+	return err
+}
+
+var _ io.Reader = (*checksumReader)(nil)
+
+type checksumReader struct {
+	r     io.Reader
+	crc   hash.Hash32
+	buf   []byte
+	start int
+	end   int
+	err   error
+}
+
+// NewChecksumReader constructs an io.Reader that unscreens data written with NewChecksumWriter, using the same key and options.
+// The checksum trailer is not returned from Read. Once the source is exhausted, the trailer is checked against the unscreened data.
+// Read returns ErrChecksumMismatch instead of io.EOF if they don't match, which is most likely caused by using the wrong key or offset.
+// Data is returned before it can be verified, so it should not be trusted until io.EOF is returned.
+func NewChecksumReader(source io.Reader, key []byte, opts ...ScreenOpt) (io.Reader, error) {
+	r, err := NewReaderWith(source, key, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return &checksumReader{
+		r:   r,
+		crc: crc32.NewIEEE(),
+		buf: make([]byte, checksumBufferSize+checksumSize),
+	}, nil
+}
+
+func (r *checksumReader) Read(out []byte) (int, error) {
+	// The last checksumSize bytes are held back, since they may be the trailer.
+	for r.end-r.start <= checksumSize && r.err == nil {
+		if r.start > 0 {
+			r.end = copy(r.buf, r.buf[r.start:r.end])
+			r.start = 0
+		}
+		var n int
+		n, r.err = r.r.Read(r.buf[r.end:])
+		r.end += n
+	}
+	if available := r.end - r.start - checksumSize; available > 0 {
+		n := copy(out, r.buf[r.start:r.start+min(available, len(out))])
+		r.crc.Write(out[:n])
+		r.start += n
+		return n, nil
+	}
+	if r.err != io.EOF {
+		return 0, r.err
+	}
+	if r.end-r.start < checksumSize {
+		return 0, fmt.Errorf("%w: data is too short to contain a checksum", ErrChecksumMismatch)
+	}
+	if binary.BigEndian.Uint32(r.buf[r.start:r.end]) != r.crc.Sum32() {
+		return 0, ErrChecksumMismatch
+	}
+	return 0, io.EOF
+}
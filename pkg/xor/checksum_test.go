@@ -0,0 +1,89 @@
+package xor
+
+import (
+	"bytes"
+	"crypto/rand"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io"
+	"testing"
+	"testing/iotest"
+)
+
+func checksumScreen(t *testing.T, data, key []byte, opts ...ScreenOpt) []byte {
+	var screened bytes.Buffer
+	w, err := NewChecksumWriter(&screened, key, opts...)
+	require.NoError(t, err)
+	_, err = w.Write(data)
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+	return screened.Bytes()
+}
+
+func TestChecksum(t *testing.T) {
+	key := []byte{0xde, 0xad, 0xbe, 0xef}
+	data := make([]byte, checksumBufferSize*2+11)
+	_, err := rand.Read(data)
+	require.NoError(t, err)
+
+	tests := map[string][]byte{
+		"Empty": {},
+		"Short": []byte("abc"),
+		"Large": data,
+	}
+	for name, data := range tests {
+		t.Run(name, func(t *testing.T) {
+			screened := checksumScreen(t, data, key, WithOffset(1))
+			assert.Len(t, screened, len(data)+checksumSize)
+
+			r, err := NewChecksumReader(bytes.NewReader(screened), key, WithOffset(1))
+			require.NoError(t, err)
+			got, err := io.ReadAll(r)
+			require.NoError(t, err)
+			assert.Equal(t, len(data), len(got))
+			assert.True(t, bytes.Equal(data, got))
+
+			r, err = NewChecksumReader(iotest.OneByteReader(bytes.NewReader(screened)), key, WithOffset(1))
+			require.NoError(t, err)
+			got, err = io.ReadAll(iotest.OneByteReader(r))
+			require.NoError(t, err)
+			assert.True(t, bytes.Equal(data, got))
+		})
+	}
+}
+
+func TestChecksum_Mismatch(t *testing.T) {
+	key := []byte{0xde, 0xad, 0xbe, 0xef}
+	screened := checksumScreen(t, []byte("A string with some text"), key, WithOffset(1))
+
+	tests := map[string]struct {
+		data []byte
+		key  []byte
+		opts []ScreenOpt
+	}{
+		"Wrong offset": {screened, key, []ScreenOpt{WithOffset(2)}},
+		"Wrong key":    {screened, []byte{0xde, 0xad, 0xbe, 0xee}, []ScreenOpt{WithOffset(1)}},
+		"Wrong mode":   {screened, key, []ScreenOpt{WithOffset(1), WithKeystream()}},
+		"Truncated":    {screened[:len(screened)-1], key, []ScreenOpt{WithOffset(1)}},
+		"Too short":    {screened[:2], key, []ScreenOpt{WithOffset(1)}},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			r, err := NewChecksumReader(bytes.NewReader(tc.data), tc.key, tc.opts...)
+			require.NoError(t, err)
+			_, err = io.ReadAll(r)
+			assert.ErrorIs(t, err, ErrChecksumMismatch)
+		})
+	}
+}
+
+func TestChecksumWriter_Close(t *testing.T) {
+	var screened bytes.Buffer
+	w, err := NewChecksumWriter(&screened, []byte{0x1})
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+	require.NoError(t, w.Close(), "Close should be idempotent")
+	assert.Equal(t, checksumSize, screened.Len())
+	_, err = w.Write([]byte("data"))
+	assert.Error(t, err)
+}
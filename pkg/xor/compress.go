@@ -0,0 +1,31 @@
+package xor
+
+import (
+	"compress/gzip"
+	"io"
+)
+
+// NewCompressedWriter constructs an io.WriteCloser that gzip compresses all bytes written, and screens the compressed bytes like NewWriterWith.
+// This is the same order that xorgen uses for compressed payloads, since screened data doesn't compress well.
+// Close must be called to flush the compressed data, but it doesn't close the target.
+//
+// Note that the gzip header is somewhat predictable, which could make part of the key easier to recover.
+func NewCompressedWriter(target io.Writer, key []byte, opts ...ScreenOpt) (io.WriteCloser, error) {
+	w, err := NewWriterWith(target, key, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return gzip.NewWriterLevel(w, gzip.BestCompression)
+}
+
+// NewCompressedReader constructs an io.ReadCloser that unscreens bytes read from the source like NewReaderWith, and decompresses them.
+// This reads data written with NewCompressedWriter, or a compressed payload generated by xorgen.
+// The gzip header is read immediately, so a wrong key or offset is usually detected here with an error.
+// Close doesn't close the source.
+func NewCompressedReader(source io.Reader, key []byte, opts ...ScreenOpt) (io.ReadCloser, error) {
+	r, err := NewReaderWith(source, key, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return gzip.NewReader(r)
+}
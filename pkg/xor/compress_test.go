@@ -0,0 +1,75 @@
+package xor
+
+import (
+	"bytes"
+	"compress/gzip"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io"
+	"testing"
+)
+
+func TestCompressed(t *testing.T) {
+	key := []byte{0xde, 0xad, 0xbe, 0xef}
+	data := bytes.Repeat([]byte("A string with some text. "), 100)
+
+	tests := map[string][]ScreenOpt{
+		"Default":   {WithOffset(2)},
+		"Keystream": {WithOffset(2), WithKeystream()},
+	}
+	for name, opts := range tests {
+		t.Run(name, func(t *testing.T) {
+			var screened bytes.Buffer
+			w, err := NewCompressedWriter(&screened, key, opts...)
+			require.NoError(t, err)
+			_, err = w.Write(data)
+			require.NoError(t, err)
+			require.NoError(t, w.Close())
+			assert.Less(t, screened.Len(), len(data), "Data should be compressed")
+
+			r, err := NewCompressedReader(bytes.NewReader(screened.Bytes()), key, opts...)
+			require.NoError(t, err)
+			got, err := io.ReadAll(r)
+			require.NoError(t, err)
+			require.NoError(t, r.Close())
+			assert.Equal(t, data, got)
+		})
+	}
+}
+
+func TestCompressed_Order(t *testing.T) {
+	key := []byte{0xde, 0xad, 0xbe, 0xef}
+	data := []byte("A string with some text")
+
+	var screened bytes.Buffer
+	w, err := NewCompressedWriter(&screened, key)
+	require.NoError(t, err)
+	_, err = w.Write(data)
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+
+	unscreened, err := TransformBytes(screened.Bytes(), key, 0)
+	require.NoError(t, err)
+	r, err := gzip.NewReader(bytes.NewReader(unscreened))
+	require.NoError(t, err, "Data should be compressed before it's screened")
+	got, err := io.ReadAll(r)
+	require.NoError(t, err)
+	assert.Equal(t, data, got)
+}
+
+func TestCompressed_Neg(t *testing.T) {
+	key := []byte{0xde, 0xad, 0xbe, 0xef}
+	var screened bytes.Buffer
+	w, err := NewCompressedWriter(&screened, key)
+	require.NoError(t, err)
+	_, err = w.Write([]byte("A string with some text"))
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+
+	_, err = NewCompressedReader(bytes.NewReader(screened.Bytes()), key, WithOffset(1))
+	assert.Error(t, err, "A wrong offset should fail to read the gzip header")
+	_, err = NewCompressedWriter(io.Discard, nil)
+	assert.Error(t, err)
+	_, err = NewCompressedReader(bytes.NewReader(nil), key, WithOffset(4))
+	assert.Error(t, err)
+}
@@ -22,9 +22,35 @@ Failing to do so will likely result in garbled or partly de-obfuscated data.
 
 # General guidelines:
   - Longer keys are better, but have limited usefulness with a short payload.
-  - Key length should ideally be a function of payload length.
+  - Key length should ideally be a function of payload length. GenKeyMatched will choose an appropriate key length and offset for a given payload, and RecommendedKeyLen and GenKeyForPayload apply the same policy when only the payload length is known.
   - For shorter payloads, using a shorter key with random offset is sufficient, but will still yield a predictable pattern.
   - Using securely generated keys with the OS entropy pool (like with GenKey or GenKeyAndOffset) are better.
+  - GenKeyFrom, GenKeyAndOffsetFrom, and GenKeyMatchedFrom read from a provided entropy source instead of crypto/rand, for deterministic tests or platforms with their own RNG.
   - Using a random offset is recommended, but not required.
+  - SaveKeyFile and LoadKeyFile store a key, offset, and optional label in a small binary file format, so a generator and a runtime component can share keys without inventing their own encoding.
+  - If a random key can't be stored, KeyFromPassphrase derives the same key from a shared passphrase every time. Use a long passphrase, since the derivation uses a fixed salt.
+  - KeyFromSeed quickly derives a key from a seed string with SHA-256, so reproducible builds can regenerate identical screened artifacts from a checked-in seed.
+  - For small payloads that are already in memory, Screen, Unscreen, TransformBytes, and TransformString avoid the Reader and Writer plumbing. These accept any byte slice or string type, such as passlock.Encrypted, without conversion.
+  - EncodeScreenedString and DecodeScreenedString combine screening with base64 or hex encoding, for embedding screened values in environment variables, JSON, or YAML.
+  - NewReaderWith and NewWriterWith accept ScreenOpt options for more control. When the plaintext has a known format, ExpectMagic will detect a wrong key or offset on the first Read instead of returning garbled data.
+  - WriteHeader prepends a small header that records the offset, mode, and a key ID, so ReadHeader only needs the key to unscreen the data. A wrong key is detected with ErrKeyMismatch.
+  - Verify unscreens a sample and uses the LikelyUnscreened heuristic to return ErrLikelyGarbled when a wrong key or offset produced garbage. This works well for text or structured data, but not for compressed or random payloads.
+  - When the plaintext has no known format, NewChecksumWriter appends a screened CRC-32 checksum trailer, and NewChecksumReader returns ErrChecksumMismatch at the end of the data if the wrong key or offset was used.
+  - A repeating key leaves a periodic pattern in long payloads. The WithKeystream option uses the key to seed a ChaCha20 key stream instead, which has no such pattern. This still isn't encryption, since the key is all that's needed to reverse it.
+  - The WithFeedback option mutates each key byte with the screened byte it produced, which resists trivial frequency analysis better than a repeating key. It only supports sequential access, and known plaintext still reveals the key.
+  - WithInterleavedKey screens even and odd stream positions with two different keys, which defeats naive single key recovery from repetitive payloads at almost no extra cost.
+  - NewPermutedWriter and NewPermutedReader also shuffle the screened bytes within fixed size blocks, using a permutation derived from the key. This resists the way known plaintext reveals the key with XOR alone, but it's still obfuscation and NOT encryption.
+  - WithRanges only screens the given ranges of the stream and passes other bytes through, which can leave a plaintext header readable while obfuscating the body.
+  - NewCompressedWriter and NewCompressedReader chain gzip compression with the screen in the same order as xorgen, so runtime code doesn't need to get the ordering right itself.
+  - ScreenSplit wraps a bufio.SplitFunc, so a bufio.Scanner can read lines or tokens from a screened source without unscreening it all first.
+  - Stream positions are tracked as 64-bit values on every platform, so seeking and random access work consistently for very large streams. WithStartPosition resumes screening at an absolute position without screening everything before it.
+  - NewReadSeeker supports random access to a screened source, by moving to the matching key position on Seek. This allows serving a screened file with http.ServeContent, including range requests.
+  - NewFS wraps an fs.FS, like an embed.FS of screened assets, and unscreens files as they're read. The result may be used anywhere an fs.FS is accepted, such as http.FS or template.ParseFS.
+  - NewEmbeddedFS serves EmbeddedFile values from Go source as an fs.FS, unscreening each file with its own key as it's opened. An EmbeddedFile may include a SHA-256 Checksum, which is verified when the file is opened. This is what xorgen generates with the --dir flag.
+  - ScreenParallel and CopyParallel split very large inputs into chunks that are screened across multiple goroutines, with each chunk's key position derived from its absolute position.
+  - Reader implements io.ByteReader and Writer implements io.ByteWriter, so they may be used directly with byte oriented functions like binary.ReadUvarint.
+  - WithProgress reports the running total of bytes processed by a Reader or Writer, which is useful for rendering a progress bar when screening very large files.
+  - A Reader or Writer tracks its position within the key, so it's not safe for concurrent use. Wrap a shared Writer with NewSyncWriter, or use the stateless ScreenAt to screen any part of a stream from multiple goroutines.
+  - NewReaderAt and NewWriterAt screen bytes at any position by deriving the key position from the absolute offset. These are stateless and safe for concurrent use, which suits random access to large screened assets.
 */
 package xor
@@ -0,0 +1,215 @@
+package xor
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"io/fs"
+	"path"
+	"slices"
+	"strings"
+	"time"
+)
+
+// EmbeddedFile is a screened file that is embedded in Go source, such as with xorgen's --dir flag.
+// Each file has its own key and offset, and may be gzip compressed before it's screened.
+type EmbeddedFile struct {
+	// Name is the slash separated path of the file within the fs.FS.
+	Name string
+	// Key and Offset are used to unscreen Data.
+	Key    []byte
+	Offset int
+	// Data is the screened content of the file.
+	Data string
+	// Size is the size of the file after it's unscreened and decompressed.
+	Size int64
+	// Compressed indicates that the file was gzip compressed before it was screened.
+	Compressed bool
+	// ModTime is the modification time reported for the file, which is the zero time if it's not known.
+	// This is used by http.FileServer for Last-Modified headers.
+	ModTime time.Time
+	// Checksum is an optional SHA-256 checksum of the unscreened and decompressed content.
+	// If it's set, then opening the file fails with ErrChecksumMismatch if the content doesn't match.
+	Checksum []byte
+}
+
+var _ fs.FS = (*embeddedFS)(nil)
+
+type embeddedFS struct {
+	files map[string]*EmbeddedFile
+	dirs  map[string][]fs.DirEntry
+}
+
+// NewEmbeddedFS constructs a read-only fs.FS of embedded files, which unscreens each file when it's opened.
+// Directories are implied by the file names, like with embed.FS, so empty directories can't be represented.
+// Opened files support io.Seeker and io.ReaderAt.
+func NewEmbeddedFS(files ...EmbeddedFile) (fs.FS, error) {
+	efs := &embeddedFS{
+		files: map[string]*EmbeddedFile{},
+		dirs:  map[string][]fs.DirEntry{".": nil},
+	}
+	for i := range files {
+		f := &files[i]
+		if !fs.ValidPath(f.Name) || f.Name == "." {
+			return nil, fmt.Errorf("invalid embedded file name '%s'", f.Name)
+		}
+		if err := validateKeyOffset(f.Key, f.Offset); err != nil {
+			return nil, fmt.Errorf("embedded file '%s': %w", f.Name, err)
+		}
+		if len(f.Checksum) > 0 && len(f.Checksum) != sha256.Size {
+			return nil, fmt.Errorf("embedded file '%s': checksum must be %d bytes", f.Name, sha256.Size)
+		}
+		if _, ok := efs.files[f.Name]; ok {
+			return nil, fmt.Errorf("duplicate embedded file '%s'", f.Name)
+		}
+		efs.files[f.Name] = f
+	}
+	for name, f := range efs.files {
+		var child fs.DirEntry = fs.FileInfoToDirEntry(fileInfo(path.Base(name), f.Size, f.ModTime))
+		for dir := path.Dir(name); ; dir = path.Dir(dir) {
+			if _, ok := efs.files[dir]; ok {
+				return nil, fmt.Errorf("embedded file '%s' is also used as a directory", dir)
+			}
+			_, seen := efs.dirs[dir]
+			efs.dirs[dir] = append(efs.dirs[dir], child)
+			if seen || dir == "." {
+				break
+			}
+			child = fs.FileInfoToDirEntry(dirInfo(path.Base(dir)))
+		}
+	}
+	for _, entries := range efs.dirs {
+		slices.SortFunc(entries, func(a, b fs.DirEntry) int {
+			return strings.Compare(a.Name(), b.Name())
+		})
+	}
+	return efs, nil
+}
+
+func (e *embeddedFS) Open(name string) (fs.File, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrInvalid}
+	}
+	if entries, ok := e.dirs[name]; ok {
+		return &embeddedDir{
+			info:    dirInfo(path.Base(name)),
+			path:    name,
+			entries: entries,
+		}, nil
+	}
+	f, ok := e.files[name]
+	if !ok {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+	}
+	content, err := f.unscreen()
+	if err != nil {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: err}
+	}
+	return &embeddedOpenFile{
+		Reader: bytes.NewReader(content),
+		info:   fileInfo(path.Base(name), int64(len(content)), f.ModTime),
+	}, nil
+}
+
+// unscreen returns the unscreened and decompressed content of the file, verifying its checksum if there is one.
+func (f *EmbeddedFile) unscreen() ([]byte, error) {
+	content, err := TransformBytes([]byte(f.Data), f.Key, f.Offset)
+	if err != nil {
+		return nil, err
+	}
+	if f.Compressed {
+		r, err := gzip.NewReader(bytes.NewReader(content))
+		if err != nil {
+			return nil, err
+		}
+		defer func() {
+			_ = r.Close()
+		}()
+		if content, err = io.ReadAll(r); err != nil {
+			return nil, err
+		}
+	}
+	if len(f.Checksum) > 0 {
+		if sum := sha256.Sum256(content); !bytes.Equal(sum[:], f.Checksum) {
+			return nil, ErrChecksumMismatch
+		}
+	}
+	return content, nil
+}
+
+var (
+	_ io.Seeker   = (*embeddedOpenFile)(nil)
+	_ io.ReaderAt = (*embeddedOpenFile)(nil)
+)
+
+type embeddedOpenFile struct {
+	*bytes.Reader
+	info fs.FileInfo
+}
+
+func (f *embeddedOpenFile) Stat() (fs.FileInfo, error) {
+	return f.info, nil
+}
+
+func (f *embeddedOpenFile) Close() error {
+	return nil
+}
+
+var _ fs.ReadDirFile = (*embeddedDir)(nil)
+
+type embeddedDir struct {
+	info    fs.FileInfo
+	path    string
+	entries []fs.DirEntry
+	read    int
+}
+
+func (d *embeddedDir) Stat() (fs.FileInfo, error) {
+	return d.info, nil
+}
+
+func (d *embeddedDir) Read([]byte) (int, error) {
+	return 0, &fs.PathError{Op: "read", Path: d.path, Err: fs.ErrInvalid}
+}
+
+func (d *embeddedDir) Close() error {
+	return nil
+}
+
+func (d *embeddedDir) ReadDir(count int) ([]fs.DirEntry, error) {
+	n := len(d.entries) - d.read
+	if n == 0 && count > 0 {
+		return nil, io.EOF
+	}
+	if count > 0 && n > count {
+		n = count
+	}
+	list := slices.Clone(d.entries[d.read : d.read+n])
+	d.read += n
+	return list, nil
+}
+
+// embeddedInfo is the fs.FileInfo for embedded files and their implied directories.
+type embeddedInfo struct {
+	name    string
+	size    int64
+	mode    fs.FileMode
+	modTime time.Time
+}
+
+func fileInfo(name string, size int64, modTime time.Time) fs.FileInfo {
+	return &embeddedInfo{name: name, size: size, mode: 0444, modTime: modTime}
+}
+
+func dirInfo(name string) fs.FileInfo {
+	return &embeddedInfo{name: name, mode: fs.ModeDir | 0555}
+}
+
+func (i *embeddedInfo) Name() string       { return i.name }
+func (i *embeddedInfo) Size() int64        { return i.size }
+func (i *embeddedInfo) Mode() fs.FileMode  { return i.mode }
+func (i *embeddedInfo) ModTime() time.Time { return i.modTime }
+func (i *embeddedInfo) IsDir() bool        { return i.mode.IsDir() }
+func (i *embeddedInfo) Sys() any           { return nil }
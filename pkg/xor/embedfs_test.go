@@ -0,0 +1,155 @@
+package xor
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+func embedFile(t *testing.T, name, content string, compressed bool) EmbeddedFile {
+	t.Helper()
+	key, offset, err := GenKeyAndOffset(16)
+	require.NoError(t, err)
+	data := []byte(content)
+	if compressed {
+		var buf bytes.Buffer
+		w := gzip.NewWriter(&buf)
+		_, err := w.Write(data)
+		require.NoError(t, err)
+		require.NoError(t, w.Close())
+		data = buf.Bytes()
+	}
+	return EmbeddedFile{
+		Name:       name,
+		Key:        key,
+		Offset:     offset,
+		Data:       string(screenBytes(t, data, key, offset)),
+		Size:       int64(len(content)),
+		Compressed: compressed,
+	}
+}
+
+func TestNewEmbeddedFS(t *testing.T) {
+	files := map[string]string{
+		"index.html":          "<html><body>A page with some text</body></html>",
+		"assets/app.js":       "console.log('A script with some text');",
+		"assets/app.css":      "body { color: red; }",
+		"assets/img/logo.svg": "<svg></svg>",
+		"empty.md":            "",
+	}
+	var embedded []EmbeddedFile
+	var expected []string
+	for name, content := range files {
+		embedded = append(embedded, embedFile(t, name, content, name == "assets/app.js"))
+		expected = append(expected, name)
+	}
+	fsys, err := NewEmbeddedFS(embedded...)
+	require.NoError(t, err)
+	require.NoError(t, fstest.TestFS(fsys, expected...))
+
+	for name, content := range files {
+		got, err := fs.ReadFile(fsys, name)
+		require.NoError(t, err)
+		assert.Equal(t, content, string(got))
+	}
+	_, err = fsys.Open("missing.txt")
+	assert.ErrorIs(t, err, fs.ErrNotExist)
+	_, err = fsys.Open("/index.html")
+	assert.ErrorIs(t, err, fs.ErrInvalid)
+}
+
+func TestNewEmbeddedFS_Neg(t *testing.T) {
+	valid := embedFile(t, "file.txt", "A string with some text", false)
+	invalidOffset := valid
+	invalidOffset.Offset = len(valid.Key)
+	absolute := valid
+	absolute.Name = "/file.txt"
+	parent := valid
+	parent.Name = "file.txt/child.txt"
+
+	shortChecksum := valid
+	shortChecksum.Checksum = []byte{0x01}
+
+	tests := map[string][]EmbeddedFile{
+		"Short checksum":    {shortChecksum},
+		"Invalid name":      {absolute},
+		"Invalid offset":    {invalidOffset},
+		"Duplicate":         {valid, valid},
+		"File and dir":      {valid, parent},
+		"Root name":         {{Name: ".", Key: valid.Key}},
+		"Empty key":         {{Name: "file.txt"}},
+		"Parent escape":     {{Name: "../file.txt", Key: valid.Key}},
+		"Trailing slash":    {{Name: "dir/", Key: valid.Key}},
+		"Unclean separator": {{Name: "dir//file.txt", Key: valid.Key}},
+	}
+	for name, files := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := NewEmbeddedFS(files...)
+			assert.Error(t, err)
+		})
+	}
+
+	corrupt := embedFile(t, "file.txt", "A string with some text", true)
+	corrupt.Data = "not gzip data"
+	fsys, err := NewEmbeddedFS(corrupt)
+	require.NoError(t, err)
+	_, err = fs.ReadFile(fsys, "file.txt")
+	assert.Error(t, err)
+}
+
+func TestNewEmbeddedFS_Checksum(t *testing.T) {
+	const content = "A string with some text"
+	sum := sha256.Sum256([]byte(content))
+	for _, compressed := range []bool{false, true} {
+		t.Run(fmt.Sprintf("compressed=%v", compressed), func(t *testing.T) {
+			file := embedFile(t, "file.txt", content, compressed)
+			file.Checksum = sum[:]
+			fsys, err := NewEmbeddedFS(file)
+			require.NoError(t, err)
+			got, err := fs.ReadFile(fsys, "file.txt")
+			require.NoError(t, err)
+			assert.Equal(t, content, string(got))
+
+			file.Checksum = make([]byte, sha256.Size)
+			fsys, err = NewEmbeddedFS(file)
+			require.NoError(t, err)
+			_, err = fs.ReadFile(fsys, "file.txt")
+			assert.ErrorIs(t, err, ErrChecksumMismatch)
+		})
+	}
+}
+
+func TestNewEmbeddedFS_HTTP(t *testing.T) {
+	modTime := time.Date(2024, time.March, 1, 12, 0, 0, 0, time.UTC)
+	file := embedFile(t, "static/index.html", "<html><body>A page with some text</body></html>", true)
+	file.ModTime = modTime
+	fsys, err := NewEmbeddedFS(file)
+	require.NoError(t, err)
+
+	info, err := fs.Stat(fsys, "static/index.html")
+	require.NoError(t, err)
+	assert.True(t, modTime.Equal(info.ModTime()))
+
+	server := httptest.NewServer(http.FileServer(http.FS(fsys)))
+	defer server.Close()
+	resp, err := http.Get(server.URL + "/static/index.html")
+	require.NoError(t, err)
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, modTime.Format(http.TimeFormat), resp.Header.Get("Last-Modified"))
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	assert.Equal(t, "<html><body>A page with some text</body></html>", string(body))
+}
@@ -0,0 +1,97 @@
+package xor
+
+import (
+	"math"
+	"math/bits"
+)
+
+var _ screener = (*feedbackScreen)(nil)
+
+// feedbackScreen screens data with a key that is mutated by the screened output as it's used.
+// Each key byte is rotated and XORed with the screened byte it produced, so the key doesn't repeat over the data.
+type feedbackScreen struct {
+	key    []byte
+	init   int
+	decode bool
+	state  []byte
+	cur    int
+	// prevState, prevCur, and last record the state before the last call to xor, and the screened bytes it produced.
+	// This allows rewinding within the last call, which is what a Writer needs to handle short writes.
+	prevState []byte
+	prevCur   int
+	last      []byte
+}
+
+func newFeedbackScreen(key []byte, offset int, decode bool) (*feedbackScreen, error) {
+	if err := validateKeyOffset(key, offset); err != nil {
+		return nil, err
+	}
+	s := &feedbackScreen{
+		key:       key,
+		init:      offset,
+		decode:    decode,
+		state:     make([]byte, len(key)),
+		prevState: make([]byte, len(key)),
+	}
+	s.reset()
+	return s, nil
+}
+
+func (s *feedbackScreen) xor(dst, src []byte) {
+	copy(s.prevState, s.state)
+	s.prevCur = s.cur
+	for i, b := range src {
+		out := b ^ s.state[s.cur]
+		dst[i] = out
+		if s.decode {
+			s.advance(b)
+		} else {
+			s.advance(out)
+		}
+	}
+	if s.decode {
+		s.last = src
+	} else {
+		s.last = dst[:len(src)]
+	}
+}
+
+// advance mutates the current key byte with the screened byte it produced, and moves to the next key byte.
+func (s *feedbackScreen) advance(screened byte) {
+	s.state[s.cur] = bits.RotateLeft8(s.state[s.cur], 3) ^ screened
+	s.cur++
+	if s.cur == len(s.state) {
+		s.cur = 0
+	}
+}
+
+// rewind moves back n bytes within the last call to xor, by restoring the state before it and replaying the screened bytes that are kept.
+// The screened bytes from the last call must not have been modified, which is the case for a Writer.
+func (s *feedbackScreen) rewind(n int) {
+	keep := s.last[:len(s.last)-n]
+	copy(s.state, s.prevState)
+	s.cur = s.prevCur
+	for _, b := range keep {
+		s.advance(b)
+	}
+	s.last = keep
+}
+
+// setStart is only supported for position 0, since the key state depends on every screened byte before it.
+// Other positions are rejected when the screen is created.
+func (s *feedbackScreen) setStart(pos uint64) {
+	if pos != 0 {
+		panic("feedback screen can't start after position 0")
+	}
+	s.reset()
+}
+
+func (s *feedbackScreen) remaining() uint64 {
+	return math.MaxUint64
+}
+
+func (s *feedbackScreen) reset() {
+	copy(s.state, s.key)
+	s.cur = s.init
+	s.last = nil
+}
@@ -0,0 +1,98 @@
+package xor
+
+import (
+	"bufio"
+	"bytes"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+func feedbackScreenBytes(t *testing.T, data, key []byte, offset int) []byte {
+	var screened bytes.Buffer
+	w, err := NewWriterWith(&screened, key, WithFeedback(), WithOffset(offset))
+	require.NoError(t, err)
+	_, err = w.Write(data)
+	require.NoError(t, err)
+	return screened.Bytes()
+}
+
+func TestWithFeedback(t *testing.T) {
+	key := []byte{0xde, 0xad, 0xbe, 0xef}
+	data := make([]byte, 64)
+
+	screened := feedbackScreenBytes(t, data, key, 1)
+	assert.NotEqual(t, referenceScreen(data, key, 1), screened, "Feedback output should differ from a repeating key")
+	assert.NotEqual(t, screened[:len(key)], screened[len(key):2*len(key)], "Feedback output should not repeat with the key length")
+
+	r, err := NewReaderWith(iotest.OneByteReader(bytes.NewReader(screened)), key, WithFeedback(), WithOffset(1))
+	require.NoError(t, err)
+	got, err := io.ReadAll(r)
+	require.NoError(t, err)
+	assert.Equal(t, data, got)
+
+	r.Reset(bytes.NewReader(screened))
+	got, err = io.ReadAll(r)
+	require.NoError(t, err)
+	assert.Equal(t, data, got, "Reset should restore the original key")
+
+	r, err = NewReaderWith(bytes.NewReader(screened), key, WithOffset(1))
+	require.NoError(t, err)
+	got, err = io.ReadAll(r)
+	require.NoError(t, err)
+	assert.NotEqual(t, data, got, "Feedback data should not be unscreened with the default mode")
+}
+
+func TestWithFeedback_ShortWrite(t *testing.T) {
+	key := []byte("some key")
+	data := bytes.Repeat([]byte("A string with some text"), 10)
+	expected := feedbackScreenBytes(t, data, key, 2)
+
+	target := &shortWriter{limit: 37}
+	w, err := NewWriterWith(target, key, WithFeedback(), WithOffset(2))
+	require.NoError(t, err)
+	written := 0
+	for written < len(data) {
+		n, _ := w.Write(data[written:])
+		written += n
+	}
+	assert.Equal(t, expected, target.Bytes(), "The key state should only advance for bytes that were written")
+}
+
+func TestWithFeedback_Compose(t *testing.T) {
+	key := []byte("some key")
+	lines := []string{"first line", "second line", "third line"}
+	data := []byte(strings.Join(lines, "\n") + "\n")
+
+	var screened bytes.Buffer
+	w, err := WriteHeader(&screened, key, WithFeedback())
+	require.NoError(t, err)
+	_, err = w.Write(data)
+	require.NoError(t, err)
+	r, err := ReadHeader(bytes.NewReader(screened.Bytes()), key)
+	require.NoError(t, err)
+	got, err := io.ReadAll(r)
+	require.NoError(t, err)
+	assert.Equal(t, data, got)
+
+	split, err := ScreenSplit(bufio.ScanLines, key, WithFeedback())
+	require.NoError(t, err)
+	scanner := bufio.NewScanner(bytes.NewReader(feedbackScreenBytes(t, data, key, 0)))
+	scanner.Split(split)
+	var gotLines []string
+	for scanner.Scan() {
+		gotLines = append(gotLines, scanner.Text())
+	}
+	require.NoError(t, scanner.Err())
+	assert.Equal(t, lines, gotLines)
+}
+
+func TestScreenMode_Neg(t *testing.T) {
+	_, err := NewWriterWith(io.Discard, []byte{0x1}, WithKeystream(), WithFeedback())
+	assert.Error(t, err, "Only one mode may be used")
+	_, err = NewWriterWith(io.Discard, []byte{0x1}, WithKeystream(), WithKeystream(false), WithFeedback())
+	assert.NoError(t, err, "Disabling a mode should allow another mode")
+}
@@ -0,0 +1,101 @@
+package xor
+
+import (
+	"errors"
+	"io"
+	"io/fs"
+)
+
+var _ fs.FS = (*screenedFS)(nil)
+
+type screenedFS struct {
+	inner  fs.FS
+	key    []byte
+	offset int
+}
+
+// NewFS constructs an fs.FS that unscreens the contents of every regular file opened from the inner fs.FS, using the provided key, starting at offset.
+// Directories are returned as-is, so a directory of screened assets (or an embed.FS produced by xorgen) may be used with templates, http.FileServer, etc.
+// Opened files support io.Seeker and io.ReaderAt when the inner file does, which is the case for embed.FS and os.DirFS.
+func NewFS(inner fs.FS, key []byte, offset int) (fs.FS, error) {
+	if inner == nil {
+		return nil, errors.New("nil inner fs.FS")
+	}
+	if err := validateKeyOffset(key, offset); err != nil {
+		return nil, err
+	}
+	return &screenedFS{
+		inner:  inner,
+		key:    key,
+		offset: offset,
+	}, nil
+}
+
+func (s *screenedFS) Open(name string) (fs.File, error) {
+	f, err := s.inner.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		_ = f.Close()
+		return nil, err
+	}
+	if info.IsDir() {
+		return f, nil
+	}
+	scr, err := newXorScreen(s.key, s.offset)
+	if err != nil {
+		_ = f.Close()
+		return nil, err
+	}
+	return &screenedFile{
+		File: f,
+		scr:  scr,
+	}, nil
+}
+
+var (
+	_ io.Seeker   = (*screenedFile)(nil)
+	_ io.ReaderAt = (*screenedFile)(nil)
+)
+
+type screenedFile struct {
+	fs.File
+	scr *xorScreen
+}
+
+func (f *screenedFile) Read(out []byte) (n int, err error) {
+	n, err = f.File.Read(out)
+	f.scr.xor(out[:n], out[:n])
+	return n, err
+}
+
+// Seek will seek within the inner file, and move to the matching position within the key.
+// An error is returned if the inner file doesn't implement io.Seeker.
+func (f *screenedFile) Seek(offset int64, whence int) (int64, error) {
+	seeker, ok := f.File.(io.Seeker)
+	if !ok {
+		return 0, errors.New("inner file doesn't support seeking")
+	}
+	pos, err := seeker.Seek(offset, whence)
+	if err != nil {
+		return pos, err
+	}
+	f.scr.seek(uint64(pos))
+	return pos, nil
+}
+
+// ReadAt reads from the inner file at the given position, without changing the key position used by Read.
+// An error is returned if the inner file doesn't implement io.ReaderAt.
+func (f *screenedFile) ReadAt(out []byte, off int64) (n int, err error) {
+	readerAt, ok := f.File.(io.ReaderAt)
+	if !ok {
+		return 0, errors.New("inner file doesn't support reading at a position")
+	}
+	n, err = readerAt.ReadAt(out, off)
+	if n > 0 {
+		f.scr.screenAt(out[:n], uint64(off))
+	}
+	return n, err
+}
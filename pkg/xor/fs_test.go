@@ -0,0 +1,64 @@
+package xor
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"testing/fstest"
+)
+
+func TestNewFS(t *testing.T) {
+	key := []byte{0xde, 0xad, 0xbe, 0xef}
+	files := map[string]string{
+		"index.html":      "<html><body>A page with some text</body></html>",
+		"assets/app.js":   "console.log('A script with some text');",
+		"assets/app.css":  "body { color: red; }",
+		"assets/empty.md": "",
+	}
+	inner := fstest.MapFS{}
+	for name, content := range files {
+		inner[name] = &fstest.MapFile{Data: screenBytes(t, []byte(content), key, 1)}
+	}
+	fsys, err := NewFS(inner, key, 1)
+	require.NoError(t, err)
+
+	expected := make([]string, 0, len(files))
+	for name := range files {
+		expected = append(expected, name)
+	}
+	require.NoError(t, fstest.TestFS(fsys, expected...))
+
+	for name, content := range files {
+		got, err := fs.ReadFile(fsys, name)
+		require.NoError(t, err)
+		assert.Equal(t, content, string(got))
+	}
+
+	server := httptest.NewServer(http.FileServer(http.FS(fsys)))
+	defer server.Close()
+	req, err := http.NewRequest(http.MethodGet, server.URL+"/assets/app.js", nil)
+	require.NoError(t, err)
+	req.Header.Set("Range", "bytes=12-17")
+	resp, err := server.Client().Do(req)
+	require.NoError(t, err)
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusPartialContent, resp.StatusCode)
+	assert.Equal(t, files["assets/app.js"][12:18], string(body))
+}
+
+func TestNewFS_Neg(t *testing.T) {
+	_, err := NewFS(nil, []byte{0x1}, 0)
+	assert.Error(t, err)
+	_, err = NewFS(fstest.MapFS{}, nil, 0)
+	assert.Error(t, err)
+	_, err = NewFS(fstest.MapFS{}, []byte{0x1}, 1)
+	assert.Error(t, err)
+}
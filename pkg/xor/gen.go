@@ -2,33 +2,82 @@ package xor
 
 import (
 	"crypto/rand"
-	"encoding/binary"
 	"errors"
 	"fmt"
+	"io"
+	"math/big"
+)
+
+const (
+	idealMinKeyLen = 20
 )
 
 // GenKey will generate an XOR key with the given length.
 func GenKey(length int) ([]byte, error) {
-	if length == 0 {
-		return nil, errors.New("asked to generate a 0-length key")
+	return GenKeyFrom(rand.Reader, length)
+}
+
+// GenKeyFrom will generate an XOR key with the given length, reading from the given entropy source instead of crypto/rand.
+// This allows deterministic keys in tests, or a platform-specific RNG on embedded systems. The source should be cryptographically secure otherwise.
+func GenKeyFrom(random io.Reader, length int) ([]byte, error) {
+	if length <= 0 {
+		return nil, fmt.Errorf("asked to generate a %d-length key", length)
+	}
+	if random == nil {
+		return nil, errors.New("nil entropy source")
 	}
 	buf := make([]byte, length)
-	n, err := rand.Read(buf)
-	if n < length {
-		return nil, fmt.Errorf("failed to read requested bytes: %v", err)
+	if _, err := io.ReadFull(random, buf); err != nil {
+		return nil, fmt.Errorf("failed to read requested bytes: %w", err)
 	}
 	return buf, nil
 }
 
+// GenKeyAndOffset will generate an XOR key with the given length, and a uniformly random offset within the key.
 func GenKeyAndOffset(length int) ([]byte, int, error) {
-	key, err := GenKey(length)
+	return GenKeyAndOffsetFrom(rand.Reader, length)
+}
+
+// GenKeyAndOffsetFrom is the same as GenKeyAndOffset, except that the key and offset are read from the given entropy source.
+func GenKeyAndOffsetFrom(random io.Reader, length int) ([]byte, int, error) {
+	key, err := GenKeyFrom(random, length)
 	if err != nil {
 		return nil, 0, err
 	}
-	buf := make([]byte, 4)
-	_, err = rand.Read(buf)
+	offset, err := rand.Int(random, big.NewInt(int64(length)))
 	if err != nil {
 		return nil, 0, err
 	}
-	return key, int(binary.BigEndian.Uint32(buf)) % length, nil
+	return key, int(offset.Int64()), nil
+}
+
+// RecommendedKeyLen returns the key length that GenKeyMatched and xorgen use for a payload of the given length.
+// Longer payloads will use a key that is a fraction of the payload length, while short payloads will use a key as long as the payload.
+// The key is never shorter than idealMinKeyLen bytes unless the payload is shorter than that.
+func RecommendedKeyLen(payloadLen int) int {
+	switch {
+	case payloadLen > 3*idealMinKeyLen:
+		return payloadLen / 3
+	case payloadLen > 2*idealMinKeyLen:
+		return payloadLen / 2
+	default:
+		return max(payloadLen, 0)
+	}
+}
+
+// GenKeyForPayload will generate an XOR key and offset with the RecommendedKeyLen for a payload of the given length.
+// This is useful when the payload isn't in memory, such as when it will be streamed through a Writer.
+func GenKeyForPayload(payloadLen int) ([]byte, int, error) {
+	return GenKeyAndOffset(RecommendedKeyLen(payloadLen))
+}
+
+// GenKeyMatched will generate an XOR key and offset with a key length that is appropriate for the given payload.
+// The key length is chosen with RecommendedKeyLen, which is the same sizing policy used by xorgen.
+func GenKeyMatched(payload []byte) ([]byte, int, error) {
+	return GenKeyMatchedFrom(rand.Reader, payload)
+}
+
+// GenKeyMatchedFrom is the same as GenKeyMatched, except that the key and offset are read from the given entropy source.
+func GenKeyMatchedFrom(random io.Reader, payload []byte) ([]byte, int, error) {
+	return GenKeyAndOffsetFrom(random, RecommendedKeyLen(len(payload)))
 }
@@ -27,3 +27,74 @@ func TestGenKeyAndOffset_Neg(t *testing.T) {
 	_, _, err = GenKeyAndOffset(10)
 	assert.Error(t, err)
 }
+
+func TestGenKeyMatched(t *testing.T) {
+	tests := map[string]struct {
+		payloadLen int
+		keyLen     int
+	}{
+		"Short payload":  {payloadLen: 10, keyLen: 10},
+		"Medium payload": {payloadLen: 50, keyLen: 25},
+		"Long payload":   {payloadLen: 300, keyLen: 100},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			key, offset, err := GenKeyMatched(make([]byte, tc.payloadLen))
+			assert.NoError(t, err)
+			assert.Len(t, key, tc.keyLen)
+			assert.GreaterOrEqual(t, offset, 0)
+			assert.Less(t, offset, tc.keyLen)
+		})
+	}
+
+	_, _, err := GenKeyMatched(nil)
+	assert.Error(t, err, "Empty payload should return an error")
+}
+
+func TestGenKeyFrom(t *testing.T) {
+	seed := bytes.Repeat([]byte{0x1, 0x2, 0x3, 0x4, 0x5}, 20)
+	keyA, offsetA, err := GenKeyAndOffsetFrom(bytes.NewReader(seed), 32)
+	assert.NoError(t, err)
+	keyB, offsetB, err := GenKeyAndOffsetFrom(bytes.NewReader(seed), 32)
+	assert.NoError(t, err)
+	assert.Equal(t, seed[:32], keyA)
+	assert.Equal(t, keyA, keyB, "The same entropy should produce the same key")
+	assert.Equal(t, offsetA, offsetB, "The same entropy should produce the same offset")
+
+	key, _, err := GenKeyMatchedFrom(bytes.NewReader(seed), make([]byte, 50))
+	assert.NoError(t, err)
+	assert.Equal(t, seed[:25], key)
+
+	_, err = GenKeyFrom(nil, 10)
+	assert.Error(t, err)
+	_, err = GenKeyFrom(bytes.NewReader(seed), -1)
+	assert.Error(t, err)
+	_, err = GenKeyFrom(bytes.NewReader(seed[:5]), 10)
+	assert.Error(t, err, "An exhausted entropy source should return an error")
+}
+
+func TestRecommendedKeyLen(t *testing.T) {
+	tests := map[string]struct {
+		payloadLen int
+		keyLen     int
+	}{
+		"Empty payload":     {payloadLen: 0, keyLen: 0},
+		"Negative length":   {payloadLen: -1, keyLen: 0},
+		"Short payload":     {payloadLen: 10, keyLen: 10},
+		"Medium boundary":   {payloadLen: 41, keyLen: 20},
+		"Long boundary":     {payloadLen: 61, keyLen: 20},
+		"Very long payload": {payloadLen: 3000, keyLen: 1000},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, tc.keyLen, RecommendedKeyLen(tc.payloadLen))
+		})
+	}
+
+	key, offset, err := GenKeyForPayload(300)
+	assert.NoError(t, err)
+	assert.Len(t, key, 100)
+	assert.Less(t, offset, 100)
+	_, _, err = GenKeyForPayload(0)
+	assert.Error(t, err)
+}
@@ -0,0 +1,109 @@
+package xor
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+)
+
+const (
+	headerVersion   byte = 1
+	headerSize           = 18
+	headerKeystream byte = 1 << 0
+	headerFeedback  byte = 1 << 1
+)
+
+var (
+	headerMagic = []byte("XSCR")
+
+	ErrInvalidHeader = errors.New("invalid screened payload header")
+	ErrKeyMismatch   = errors.New("key doesn't match the screened payload header")
+)
+
+// WriteHeader writes a small header to the target that records the offset, key length, and a key ID, and returns a Writer that screens data after it.
+// The options are the same as NewWriterWith. Data written this way must be read with ReadHeader, which doesn't require the offset or options.
+// The key ID is the first 4 bytes of a SHA-256 hash of the key, which allows detecting a wrong key, but also allows checking a guessed key without the payload.
+func WriteHeader(target io.Writer, key []byte, opts ...ScreenOpt) (Writer, error) {
+	conf, err := newScreenConfig(opts...)
+	if err != nil {
+		return nil, err
+	}
+	if len(conf.ranges) > 0 {
+		return nil, errors.New("ranges can't be recorded in a header")
+	}
+	if conf.mode == modeInterleaved {
+		return nil, fmt.Errorf("%s mode can't be recorded in a header", conf.mode)
+	}
+	w, err := NewWriterWith(target, key, opts...)
+	if err != nil {
+		return nil, err
+	}
+	var flags byte
+	switch conf.mode {
+	case modeKeystream:
+		flags |= headerKeystream
+	case modeFeedback:
+		flags |= headerFeedback
+	}
+	id := keyID(key)
+	header := make([]byte, 0, headerSize)
+	header = append(header, headerMagic...)
+	header = append(header, headerVersion, flags)
+	header = binary.BigEndian.AppendUint32(header, uint32(conf.offset))
+	header = binary.BigEndian.AppendUint32(header, uint32(len(key)))
+	header = append(header, id[:]...)
+	if _, err := target.Write(header); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+// ReadHeader reads a header written by WriteHeader from the source, and returns a Reader that unscreens the data after it with the recorded offset and mode.
+// An error wrapping ErrKeyMismatch is returned if the key doesn't match the length and key ID in the header.
+// Options may be given to use ExpectMagic, but the offset and mode are always taken from the header.
+func ReadHeader(source io.Reader, key []byte, opts ...ScreenOpt) (Reader, error) {
+	header := make([]byte, headerSize)
+	if _, err := io.ReadFull(source, header); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidHeader, err)
+	}
+	if !bytes.Equal(header[:len(headerMagic)], headerMagic) {
+		return nil, fmt.Errorf("%w: missing header", ErrInvalidHeader)
+	}
+	header = header[len(headerMagic):]
+	if header[0] != headerVersion {
+		return nil, fmt.Errorf("%w: unsupported version %d", ErrInvalidHeader, header[0])
+	}
+	flags := header[1]
+	var mode screenMode
+	switch flags {
+	case 0:
+		mode = modeRepeat
+	case headerKeystream:
+		mode = modeKeystream
+	case headerFeedback:
+		mode = modeFeedback
+	default:
+		return nil, fmt.Errorf("%w: unknown flags %#x", ErrInvalidHeader, flags)
+	}
+	offset := binary.BigEndian.Uint32(header[2:])
+	keyLen := binary.BigEndian.Uint32(header[6:])
+	if uint64(keyLen) != uint64(len(key)) {
+		return nil, fmt.Errorf("%w: expected a key of length %d", ErrKeyMismatch, keyLen)
+	}
+	if id := keyID(key); !bytes.Equal(header[10:], id[:]) {
+		return nil, ErrKeyMismatch
+	}
+	if offset >= keyLen {
+		return nil, fmt.Errorf("%w: offset %d out of range for key length %d", ErrInvalidHeader, offset, keyLen)
+	}
+	opts = append(opts[:len(opts):len(opts)], WithOffset(int(offset)), withMode(mode))
+	return NewReaderWith(source, key, opts...)
+}
+
+func keyID(key []byte) [4]byte {
+	sum := sha256.Sum256(key)
+	return [4]byte(sum[:4])
+}
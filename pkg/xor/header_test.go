@@ -0,0 +1,75 @@
+package xor
+
+import (
+	"bytes"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io"
+	"testing"
+)
+
+func TestHeader(t *testing.T) {
+	key := []byte{0xde, 0xad, 0xbe, 0xef}
+	data := []byte("{\"text\": \"A string with some text\"}")
+
+	tests := map[string][]ScreenOpt{
+		"Default":   nil,
+		"Offset":    {WithOffset(3)},
+		"Keystream": {WithOffset(2), WithKeystream()},
+	}
+	for name, opts := range tests {
+		t.Run(name, func(t *testing.T) {
+			var screened bytes.Buffer
+			w, err := WriteHeader(&screened, key, opts...)
+			require.NoError(t, err)
+			_, err = w.Write(data)
+			require.NoError(t, err)
+			assert.Equal(t, headerSize+len(data), screened.Len())
+
+			r, err := ReadHeader(bytes.NewReader(screened.Bytes()), key, ExpectMagic(MagicJSONObject))
+			require.NoError(t, err)
+			got, err := io.ReadAll(r)
+			require.NoError(t, err)
+			assert.Equal(t, data, got)
+
+			r, err = ReadHeader(bytes.NewReader(screened.Bytes()), key, WithOffset(1), WithKeystream())
+			require.NoError(t, err)
+			got, err = io.ReadAll(r)
+			require.NoError(t, err)
+			assert.Equal(t, data, got, "Header values should take precedence over options")
+		})
+	}
+}
+
+func TestReadHeader_Neg(t *testing.T) {
+	key := []byte{0xde, 0xad, 0xbe, 0xef}
+	var screened bytes.Buffer
+	_, err := WriteHeader(&screened, key, WithOffset(1))
+	require.NoError(t, err)
+	valid := screened.Bytes()
+
+	corrupt := func(i int, b byte) []byte {
+		data := bytes.Clone(valid)
+		data[i] = b
+		return data
+	}
+	tests := map[string]struct {
+		data []byte
+		key  []byte
+		err  error
+	}{
+		"Short":          {valid[:headerSize-1], key, ErrInvalidHeader},
+		"No magic":       {corrupt(0, 'Y'), key, ErrInvalidHeader},
+		"Bad version":    {corrupt(4, 2), key, ErrInvalidHeader},
+		"Unknown flags":  {corrupt(5, 0x80), key, ErrInvalidHeader},
+		"Bad offset":     {corrupt(9, 4), key, ErrInvalidHeader},
+		"Wrong key":      {valid, []byte{0xde, 0xad, 0xbe, 0xee}, ErrKeyMismatch},
+		"Wrong key size": {valid, []byte{0xde, 0xad, 0xbe}, ErrKeyMismatch},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := ReadHeader(bytes.NewReader(tc.data), tc.key)
+			assert.ErrorIs(t, err, tc.err)
+		})
+	}
+}
@@ -0,0 +1,82 @@
+package xor
+
+import (
+	"bytes"
+	"errors"
+	"math"
+)
+
+var _ screener = (*interleavedScreen)(nil)
+
+// WithInterleavedKey screens even stream positions with the main key, and odd stream positions with oddKey.
+// Each key repeats over its own half of the data, so a repetitive payload doesn't reveal a single key with naive recovery.
+// The offset applies to both keys, so it must be within the length of each.
+// Keys of different lengths that don't share a common factor give the longest period before the combined pattern repeats.
+// Data screened in this mode can only be unscreened with this mode and the same keys.
+func WithInterleavedKey(oddKey []byte) ScreenOpt {
+	return func(conf *screenConfig) error {
+		if len(oddKey) == 0 {
+			return errors.New("cannot use empty odd key")
+		}
+		if err := conf.setMode(modeInterleaved, true); err != nil {
+			return err
+		}
+		conf.oddKey = bytes.Clone(oddKey)
+		return nil
+	}
+}
+
+// interleavedScreen screens even positions with one key and odd positions with another.
+type interleavedScreen struct {
+	even  *xorScreen
+	odd   *xorScreen
+	start uint64
+	pos   uint64
+}
+
+func newInterleavedScreen(key, oddKey []byte, offset int) (*interleavedScreen, error) {
+	if bytes.Equal(key, oddKey) {
+		return nil, errors.New("interleaved keys must be different")
+	}
+	even, err := newXorScreen(key, offset)
+	if err != nil {
+		return nil, err
+	}
+	odd, err := newXorScreen(oddKey, offset)
+	if err != nil {
+		return nil, err
+	}
+	return &interleavedScreen{
+		even: even,
+		odd:  odd,
+	}, nil
+}
+
+func (s *interleavedScreen) xor(dst, src []byte) {
+	for i := range src {
+		pos := s.pos + uint64(i)
+		k := s.even
+		if pos%2 == 1 {
+			k = s.odd
+		}
+		dst[i] = src[i] ^ k.key[k.keyIndex(pos/2)]
+	}
+	s.pos += uint64(len(src))
+}
+
+func (s *interleavedScreen) rewind(n int) {
+	s.pos -= uint64(n)
+}
+
+func (s *interleavedScreen) remaining() uint64 {
+	return math.MaxUint64
+}
+
+func (s *interleavedScreen) reset() {
+	s.pos = s.start
+}
+
+func (s *interleavedScreen) setStart(pos uint64) {
+	s.start = pos
+	s.pos = pos
+}
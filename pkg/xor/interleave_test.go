@@ -0,0 +1,88 @@
+package xor
+
+import (
+	"bytes"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io"
+	"testing"
+	"testing/iotest"
+)
+
+func referenceInterleaved(data, key, oddKey []byte, offset int) []byte {
+	out := make([]byte, len(data))
+	for i, b := range data {
+		if i%2 == 0 {
+			out[i] = b ^ key[(offset+i/2)%len(key)]
+		} else {
+			out[i] = b ^ oddKey[(offset+i/2)%len(oddKey)]
+		}
+	}
+	return out
+}
+
+func TestWithInterleavedKey(t *testing.T) {
+	key := []byte{0xde, 0xad, 0xbe, 0xef}
+	oddKey := []byte{0x01, 0x02, 0x03}
+	data := bytes.Repeat([]byte("AAAA"), 20)
+	expected := referenceInterleaved(data, key, oddKey, 1)
+	assert.NotEqual(t, referenceScreen(data, key, 1), expected)
+
+	var screened bytes.Buffer
+	w, err := NewWriterWith(&screened, key, WithOffset(1), WithInterleavedKey(oddKey))
+	require.NoError(t, err)
+	for i := 0; i < len(data); i += 7 {
+		_, err := w.Write(data[i:min(i+7, len(data))])
+		require.NoError(t, err)
+	}
+	assert.Equal(t, expected, screened.Bytes())
+
+	r, err := NewReaderWith(iotest.OneByteReader(bytes.NewReader(expected)), key, WithOffset(1), WithInterleavedKey(oddKey))
+	require.NoError(t, err)
+	got, err := io.ReadAll(r)
+	require.NoError(t, err)
+	assert.Equal(t, data, got)
+
+	r, err = NewReaderWith(bytes.NewReader(expected[13:]), key, WithOffset(1), WithInterleavedKey(oddKey), WithStartPosition(13))
+	require.NoError(t, err)
+	got, err = io.ReadAll(r)
+	require.NoError(t, err)
+	assert.Equal(t, data[13:], got, "Interleaving should be applied at the start position")
+}
+
+func TestWithInterleavedKey_ShortWrite(t *testing.T) {
+	key := []byte{0xde, 0xad, 0xbe, 0xef}
+	oddKey := []byte{0x01, 0x02, 0x03}
+	data := bytes.Repeat([]byte("A string with some text"), 5)
+	expected := referenceInterleaved(data, key, oddKey, 0)
+
+	target := &shortWriter{limit: 13}
+	w, err := NewWriterWith(target, key, WithInterleavedKey(oddKey))
+	require.NoError(t, err)
+	written := 0
+	for written < len(data) {
+		n, _ := w.Write(data[written:])
+		written += n
+	}
+	assert.Equal(t, expected, target.Bytes())
+}
+
+func TestWithInterleavedKey_Neg(t *testing.T) {
+	key := []byte{0xde, 0xad, 0xbe, 0xef}
+	tests := map[string][]ScreenOpt{
+		"Empty odd key":      {WithInterleavedKey(nil)},
+		"Same keys":          {WithInterleavedKey(key)},
+		"Odd key too short":  {WithOffset(2), WithInterleavedKey([]byte{0x01, 0x02})},
+		"With keystream":     {WithKeystream(), WithInterleavedKey([]byte{0x01})},
+		"Keystream after":    {WithInterleavedKey([]byte{0x01}), WithKeystream()},
+		"With feedback mode": {WithFeedback(), WithInterleavedKey([]byte{0x01})},
+	}
+	for name, opts := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := NewWriterWith(io.Discard, key, opts...)
+			assert.Error(t, err)
+		})
+	}
+	_, err := WriteHeader(io.Discard, key, WithInterleavedKey([]byte{0x01}))
+	assert.Error(t, err, "The odd key can't be recorded in a header")
+}
@@ -1,42 +1,172 @@
 package xor
 
 import (
-	"bytes"
+	"errors"
 	"io"
+	"sync/atomic"
+)
+
+var (
+	ErrConcurrentUse = errors.New("concurrent use of a Writer, use NewSyncWriter to share a Writer between goroutines")
+)
+
+const (
+	// scratchSize is the maximum size of the buffer used to screen data in Writer.Write, and in the io.WriterTo and io.ReaderFrom fast paths.
+	scratchSize = 32 * 1024
 )
 
 // Reader extends io.Reader, but also provides a way to reuse a key with a different source.
+// A Reader tracks the position within the key, so it's not safe for concurrent use.
 type Reader interface {
 	io.Reader
+	io.ByteReader
 	// Reset will use the provided io.Reader and reset the offset position within the key to its initial value.
 	Reset(source io.Reader)
 }
 
 // Writer extends io.Writer, but also provides a way to reuse a key with a different target.
+// A Writer tracks the position within the key, so it's not safe for concurrent use.
+// Overlapping calls to Write return ErrConcurrentUse instead of corrupting the key position. Use NewSyncWriter to share a Writer between goroutines.
 type Writer interface {
 	io.Writer
+	io.ByteWriter
 	// Reset will use the provided io.Writer and reset the offset position within the key to its initial value.
 	Reset(target io.Writer)
 }
 
-var _ Reader = (*reader)(nil)
+var (
+	_ Reader      = (*reader)(nil)
+	_ io.WriterTo = (*reader)(nil)
+)
 
 type reader struct {
-	source io.Reader
-	scr    *xorScreen
+	source  io.Reader
+	scr     screener
+	magic   [][]byte
+	checked bool
+	pending []byte
+	err     error
+	scratch []byte
+	one     [1]byte
+	// progress is called with the running total of bytes read, if set by WithProgress.
+	progress func(total uint64)
+	total    uint64
 }
 
 func (r *reader) Read(out []byte) (n int, err error) {
-	n, err = r.source.Read(out)
-	for i := 0; i < n; i++ {
-		out[i] = r.scr.screen(out[i])
+	if err := r.prepare(); err != nil {
+		return 0, err
+	}
+	if len(r.pending) > 0 {
+		n = copy(out, r.pending)
+		r.pending = r.pending[n:]
+		r.report(n)
+		return n, nil
 	}
+	size, err := screenLimit(r.scr, r.source, len(out))
+	if err != nil {
+		return 0, err
+	}
+	n, err = r.source.Read(out[:size])
+	r.scr.xor(out[:n], out[:n])
+	r.report(n)
 	return n, err
 }
 
+// report adds n to the total number of bytes read, and calls the progress callback if one is set.
+func (r *reader) report(n int) {
+	if n == 0 || r.progress == nil {
+		return
+	}
+	r.total += uint64(n)
+	r.progress(r.total)
+}
+
+// ReadByte implements io.ByteReader, which allows a Reader to be used with byte oriented consumers like binary.ReadUvarint.
+// Each call reads a single byte from the source, so wrap the source with bufio.Reader if it's expensive to read from.
+func (r *reader) ReadByte() (byte, error) {
+	if err := r.prepare(); err != nil {
+		return 0, err
+	}
+	if len(r.pending) > 0 {
+		b := r.pending[0]
+		r.pending = r.pending[1:]
+		r.report(1)
+		return b, nil
+	}
+	if _, err := screenLimit(r.scr, r.source, 1); err != nil {
+		return 0, err
+	}
+	if _, err := io.ReadFull(r.source, r.one[:]); err != nil {
+		return 0, err
+	}
+	r.scr.xor(r.one[:], r.one[:])
+	r.report(1)
+	return r.one[0], nil
+}
+
+// prepare validates the magic value if it hasn't been validated yet, and returns any sticky error.
+func (r *reader) prepare() error {
+	if len(r.magic) > 0 && !r.checked {
+		r.err = r.checkMagic()
+	}
+	return r.err
+}
+
+// WriteTo implements io.WriterTo, which allows io.Copy to screen directly into an internal buffer instead of allocating its own.
+func (r *reader) WriteTo(w io.Writer) (n int64, err error) {
+	if err := r.prepare(); err != nil {
+		return 0, err
+	}
+	if len(r.pending) > 0 {
+		nw, err := w.Write(r.pending)
+		n += int64(nw)
+		r.pending = r.pending[nw:]
+		r.report(nw)
+		if err != nil {
+			return n, err
+		}
+	}
+	if r.scratch == nil {
+		r.scratch = make([]byte, scratchSize)
+	}
+	for {
+		size, err := screenLimit(r.scr, r.source, len(r.scratch))
+		if err == io.EOF {
+			return n, nil
+		}
+		if err != nil {
+			return n, err
+		}
+		nr, rerr := r.source.Read(r.scratch[:size])
+		if nr > 0 {
+			r.scr.xor(r.scratch[:nr], r.scratch[:nr])
+			nw, werr := w.Write(r.scratch[:nr])
+			n += int64(nw)
+			r.report(nw)
+			if werr == nil && nw < nr {
+				werr = io.ErrShortWrite
+			}
+			if werr != nil {
+				return n, werr
+			}
+		}
+		if rerr == io.EOF {
+			return n, nil
+		}
+		if rerr != nil {
+			return n, rerr
+		}
+	}
+}
+
 func (r *reader) Reset(source io.Reader) {
 	r.source = source
 	r.scr.reset()
+	r.checked = false
+	r.pending = nil
+	r.err = nil
+	r.total = 0
 }
 
 // NewReader constructs a new Reader that will perform XOR operations on all bytes read, using the provided key, starting at offset.
@@ -52,11 +182,20 @@ func NewReader(r io.Reader, key []byte, offset ...int) (Reader, error) {
 	return xReader, nil
 }
 
-var _ Writer = (*writer)(nil)
+var (
+	_ Writer        = (*writer)(nil)
+	_ io.ReaderFrom = (*writer)(nil)
+)
 
 type writer struct {
-	target io.Writer
-	scr    *xorScreen
+	target  io.Writer
+	scr     screener
+	scratch []byte
+	one     [1]byte
+	busy    atomic.Bool
+	// progress is called with the running total of bytes written, if set by WithProgress.
+	progress func(total uint64)
+	total    uint64
 }
 
 func NewWriter(target io.Writer, key []byte, offset ...int) (Writer, error) {
@@ -71,15 +210,102 @@ func NewWriter(target io.Writer, key []byte, offset ...int) (Writer, error) {
 	return xWriter, nil
 }
 
+// Write screens the input through an internal buffer that is reused between calls, so a Writer is not safe for concurrent use.
 func (w *writer) Write(in []byte) (n int, err error) {
-	var buf bytes.Buffer
-	for i := 0; i < len(in); i++ {
-		buf.WriteByte(w.scr.screen(in[i]))
+	if !w.busy.CompareAndSwap(false, true) {
+		return 0, ErrConcurrentUse
+	}
+	defer w.busy.Store(false)
+	if size := min(len(in), scratchSize); cap(w.scratch) < size {
+		w.scratch = make([]byte, size)
+	}
+	for len(in) > 0 {
+		chunk := w.scratch[:screenable(w.scr, min(len(in), cap(w.scratch)))]
+		if len(chunk) == 0 {
+			return n, ErrKeystreamLimit
+		}
+		w.scr.xor(chunk, in[:len(chunk)])
+		nw, err := w.writeScreened(chunk)
+		n += nw
+		if err != nil {
+			return n, err
+		}
+		in = in[nw:]
+	}
+	return n, nil
+}
+
+// WriteByte implements io.ByteWriter, which allows a Writer to be used with byte oriented producers.
+// Each call writes a single byte to the target, so wrap the target with bufio.Writer if it's expensive to write to.
+func (w *writer) WriteByte(b byte) error {
+	if !w.busy.CompareAndSwap(false, true) {
+		return ErrConcurrentUse
+	}
+	defer w.busy.Store(false)
+	if screenable(w.scr, 1) == 0 {
+		return ErrKeystreamLimit
+	}
+	w.one[0] = b
+	w.scr.xor(w.one[:], w.one[:])
+	_, err := w.writeScreened(w.one[:])
+	return err
+}
+
+// ReadFrom implements io.ReaderFrom, which allows io.Copy to read directly into an internal buffer instead of allocating its own.
+func (w *writer) ReadFrom(r io.Reader) (n int64, err error) {
+	if !w.busy.CompareAndSwap(false, true) {
+		return 0, ErrConcurrentUse
+	}
+	defer w.busy.Store(false)
+	if cap(w.scratch) < scratchSize {
+		w.scratch = make([]byte, scratchSize)
+	}
+	buf := w.scratch[:scratchSize]
+	for {
+		size, err := screenLimit(w.scr, r, len(buf))
+		if err == io.EOF {
+			return n, nil
+		}
+		if err != nil {
+			return n, err
+		}
+		nr, rerr := r.Read(buf[:size])
+		if nr > 0 {
+			w.scr.xor(buf[:nr], buf[:nr])
+			nw, werr := w.writeScreened(buf[:nr])
+			n += int64(nw)
+			if werr != nil {
+				return n, werr
+			}
+		}
+		if rerr == io.EOF {
+			return n, nil
+		}
+		if rerr != nil {
+			return n, rerr
+		}
+	}
+}
+
+// writeScreened writes screened data to the target.
+// If the target doesn't accept all of it, then the key position is moved back to match the bytes that were written.
+func (w *writer) writeScreened(screened []byte) (int, error) {
+	nw, err := w.target.Write(screened)
+	if nw > 0 && w.progress != nil {
+		w.total += uint64(nw)
+		w.progress(w.total)
+	}
+	if nw < len(screened) {
+		w.scr.rewind(len(screened) - nw)
+		if err == nil {
+			err = io.ErrShortWrite
+		}
 	}
-	return w.target.Write(buf.Bytes())
+	return nw, err
 }
 
 func (w *writer) Reset(target io.Writer) {
 	w.target = target
 	w.scr.reset()
+	w.total = 0
 }
@@ -2,7 +2,10 @@ package xor
 
 import (
 	"bytes"
+	"crypto/rand"
+	"encoding/binary"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"io"
 	"strings"
 	"testing"
@@ -69,3 +72,170 @@ func TestReader_Reset(t *testing.T) {
 	assert.Equal(t, 2, n)
 	assert.Equal(t, []byte{0x1, 0x0}, outB)
 }
+
+func TestWriter_LargeWrite(t *testing.T) {
+	data := make([]byte, scratchSize*2+100)
+	_, err := rand.Read(data)
+	require.NoError(t, err)
+	key := []byte{0xde, 0xad, 0xbe, 0xef, 0x01}
+
+	var out bytes.Buffer
+	w, err := NewWriter(&out, key, 2)
+	require.NoError(t, err)
+	n, err := w.Write(data)
+	require.NoError(t, err)
+	assert.Equal(t, len(data), n)
+	assert.Equal(t, referenceScreen(data, key, 2), out.Bytes())
+
+	allocs := testing.AllocsPerRun(10, func() {
+		w.Reset(io.Discard)
+		_, _ = w.Write(data)
+	})
+	assert.Zero(t, allocs, "The scratch buffer should be reused between writes")
+}
+
+// shortWriter accepts at most limit bytes per Write.
+type shortWriter struct {
+	bytes.Buffer
+	limit int
+}
+
+func (s *shortWriter) Write(p []byte) (int, error) {
+	if len(p) > s.limit {
+		n, _ := s.Buffer.Write(p[:s.limit])
+		return n, io.ErrShortWrite
+	}
+	return s.Buffer.Write(p)
+}
+
+func TestWriter_ShortWrite(t *testing.T) {
+	data := []byte("A string with some text")
+	key := []byte{0xde, 0xad, 0xbe, 0xef, 0x01}
+	target := &shortWriter{limit: 7}
+	w, err := NewWriter(target, key, 1)
+	require.NoError(t, err)
+
+	written := 0
+	for written < len(data) {
+		n, err := w.Write(data[written:])
+		written += n
+		if written < len(data) {
+			assert.ErrorIs(t, err, io.ErrShortWrite)
+		}
+	}
+	assert.Equal(t, referenceScreen(data, key, 1), target.Bytes(), "The key position should only advance for bytes that were written")
+}
+
+func TestReadFromWriteTo(t *testing.T) {
+	data := make([]byte, scratchSize*3+17)
+	_, err := rand.Read(data)
+	require.NoError(t, err)
+	data[0], data[1] = MagicGzip[0], MagicGzip[1]
+	key := []byte{0xde, 0xad, 0xbe, 0xef, 0x01}
+	screened := referenceScreen(data, key, 3)
+
+	var out bytes.Buffer
+	w, err := NewWriter(&out, key, 3)
+	require.NoError(t, err)
+	n, err := w.(io.ReaderFrom).ReadFrom(bytes.NewReader(data))
+	require.NoError(t, err)
+	assert.Equal(t, int64(len(data)), n)
+	assert.Equal(t, screened, out.Bytes())
+
+	r, err := NewReaderWith(bytes.NewReader(screened), key, WithOffset(3), ExpectMagic(MagicGzip))
+	require.NoError(t, err)
+	out.Reset()
+	n, err = r.(io.WriterTo).WriteTo(&out)
+	require.NoError(t, err)
+	assert.Equal(t, int64(len(data)), n)
+	assert.Equal(t, data, out.Bytes(), "Bytes read while checking magic values should be included")
+
+	r, err = NewReaderWith(bytes.NewReader(screened), []byte{0x01}, ExpectMagic(MagicGzip))
+	require.NoError(t, err)
+	_, err = io.Copy(io.Discard, r)
+	assert.ErrorIs(t, err, ErrMagicMismatch)
+}
+
+func TestByteReaderWriter(t *testing.T) {
+	key := []byte{0xde, 0xad, 0xbe, 0xef, 0x01}
+	values := []uint64{0, 1, 300, 1 << 40}
+
+	var screened bytes.Buffer
+	w, err := NewWriter(&screened, key, 2)
+	require.NoError(t, err)
+	for _, v := range values {
+		for _, b := range binary.AppendUvarint(nil, v) {
+			require.NoError(t, w.WriteByte(b))
+		}
+	}
+	_, err = w.Write([]byte("trailer"))
+	require.NoError(t, err)
+
+	var expected []byte
+	for _, v := range values {
+		expected = binary.AppendUvarint(expected, v)
+	}
+	expected = append(expected, "trailer"...)
+	assert.Equal(t, referenceScreen(expected, key, 2), screened.Bytes())
+
+	r, err := NewReader(&screened, key, 2)
+	require.NoError(t, err)
+	for _, v := range values {
+		got, err := binary.ReadUvarint(r)
+		require.NoError(t, err)
+		assert.Equal(t, v, got)
+	}
+	rest, err := io.ReadAll(r)
+	require.NoError(t, err)
+	assert.Equal(t, "trailer", string(rest), "ReadByte and Read should share the key position")
+	_, err = r.ReadByte()
+	assert.ErrorIs(t, err, io.EOF)
+
+	target := &shortWriter{limit: 0}
+	w, err = NewWriter(target, key)
+	require.NoError(t, err)
+	assert.ErrorIs(t, w.WriteByte('a'), io.ErrShortWrite)
+}
+
+func TestWithProgress(t *testing.T) {
+	key := []byte{0xde, 0xad, 0xbe, 0xef}
+	data := bytes.Repeat([]byte("A string with some text. "), 4000)
+
+	var writeTotals []uint64
+	target := &shortWriter{limit: 40000}
+	w, err := NewWriterWith(target, key, WithProgress(func(total uint64) {
+		writeTotals = append(writeTotals, total)
+	}))
+	require.NoError(t, err)
+	written := 0
+	for written < len(data) {
+		n, _ := w.Write(data[written:])
+		written += n
+	}
+	require.NotEmpty(t, writeTotals)
+	assert.Equal(t, uint64(len(data)), writeTotals[len(writeTotals)-1])
+	assert.IsIncreasing(t, writeTotals)
+
+	var readTotals []uint64
+	r, err := NewReaderWith(bytes.NewReader(target.Bytes()), key, ExpectMagic([]byte("A string")), WithProgress(func(total uint64) {
+		readTotals = append(readTotals, total)
+	}))
+	require.NoError(t, err)
+	b, err := r.ReadByte()
+	require.NoError(t, err)
+	assert.Equal(t, byte('A'), b)
+	var out bytes.Buffer
+	_, err = io.Copy(&out, r)
+	require.NoError(t, err)
+	assert.Equal(t, data[1:], out.Bytes())
+	require.NotEmpty(t, readTotals)
+	assert.Equal(t, uint64(1), readTotals[0])
+	assert.Equal(t, uint64(len(data)), readTotals[len(readTotals)-1])
+	assert.IsIncreasing(t, readTotals)
+
+	readTotals = nil
+	r.Reset(bytes.NewReader(target.Bytes()[:10]))
+	_, err = io.ReadAll(r)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(10), readTotals[len(readTotals)-1], "Reset should start the total over")
+}
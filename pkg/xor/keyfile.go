@@ -0,0 +1,114 @@
+package xor
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+)
+
+const (
+	keyFileVersion    byte = 1
+	keyFileHeaderSize      = 15
+	maxKeyFileKeyLen       = 1 << 24
+)
+
+var (
+	keyFileMagic = []byte("XKEY")
+
+	ErrInvalidKeyFile = errors.New("invalid key file")
+)
+
+// KeyFile is a key and offset pair, with an optional label to identify it.
+type KeyFile struct {
+	Key    []byte
+	Offset int
+	Label  string
+}
+
+// WriteKeyFile writes the KeyFile to the target in a small binary format that can be read with ReadKeyFile.
+func WriteKeyFile(target io.Writer, kf KeyFile) error {
+	if err := validateKeyOffset(kf.Key, kf.Offset); err != nil {
+		return err
+	}
+	if len(kf.Key) > maxKeyFileKeyLen {
+		return fmt.Errorf("key length %d exceeds the maximum of %d", len(kf.Key), maxKeyFileKeyLen)
+	}
+	if len(kf.Label) > math.MaxUint16 {
+		return fmt.Errorf("label length %d exceeds the maximum of %d", len(kf.Label), math.MaxUint16)
+	}
+	buf := make([]byte, 0, keyFileHeaderSize+len(kf.Key)+len(kf.Label))
+	buf = append(buf, keyFileMagic...)
+	buf = append(buf, keyFileVersion)
+	buf = binary.BigEndian.AppendUint32(buf, uint32(kf.Offset))
+	buf = binary.BigEndian.AppendUint32(buf, uint32(len(kf.Key)))
+	buf = binary.BigEndian.AppendUint16(buf, uint16(len(kf.Label)))
+	buf = append(buf, kf.Key...)
+	buf = append(buf, kf.Label...)
+	_, err := target.Write(buf)
+	return err
+}
+
+// ReadKeyFile reads a KeyFile written by WriteKeyFile from the source.
+// An error wrapping ErrInvalidKeyFile is returned if the data isn't a valid key file.
+func ReadKeyFile(source io.Reader) (KeyFile, error) {
+	header := make([]byte, keyFileHeaderSize)
+	if _, err := io.ReadFull(source, header); err != nil {
+		return KeyFile{}, fmt.Errorf("%w: %v", ErrInvalidKeyFile, err)
+	}
+	if !bytes.Equal(header[:len(keyFileMagic)], keyFileMagic) {
+		return KeyFile{}, fmt.Errorf("%w: missing header", ErrInvalidKeyFile)
+	}
+	header = header[len(keyFileMagic):]
+	if header[0] != keyFileVersion {
+		return KeyFile{}, fmt.Errorf("%w: unsupported version %d", ErrInvalidKeyFile, header[0])
+	}
+	offset := binary.BigEndian.Uint32(header[1:])
+	keyLen := binary.BigEndian.Uint32(header[5:])
+	labelLen := binary.BigEndian.Uint16(header[9:])
+	if keyLen == 0 || keyLen > maxKeyFileKeyLen {
+		return KeyFile{}, fmt.Errorf("%w: invalid key length %d", ErrInvalidKeyFile, keyLen)
+	}
+	if offset >= keyLen {
+		return KeyFile{}, fmt.Errorf("%w: offset %d out of range for key length %d", ErrInvalidKeyFile, offset, keyLen)
+	}
+	body := make([]byte, int(keyLen)+int(labelLen))
+	if _, err := io.ReadFull(source, body); err != nil {
+		return KeyFile{}, fmt.Errorf("%w: %v", ErrInvalidKeyFile, err)
+	}
+	return KeyFile{
+		Key:    body[:keyLen],
+		Offset: int(offset),
+		Label:  string(body[keyLen:]),
+	}, nil
+}
+
+// SaveKeyFile writes the KeyFile to the file at path with WriteKeyFile.
+// The file is created with 0600 permissions if it doesn't exist, and truncated if it does.
+func SaveKeyFile(path string, kf KeyFile) (err error) {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if cerr := f.Close(); cerr != nil && err == nil {
+			err = cerr
+		}
+	}()
+	return WriteKeyFile(f, kf)
+}
+
+// LoadKeyFile reads a KeyFile from the file at path, which was written with SaveKeyFile or WriteKeyFile.
+func LoadKeyFile(path string) (KeyFile, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return KeyFile{}, err
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+	return ReadKeyFile(f)
+}
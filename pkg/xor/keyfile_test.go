@@ -0,0 +1,56 @@
+package xor
+
+import (
+	"bytes"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"path/filepath"
+	"testing"
+)
+
+func TestKeyFile(t *testing.T) {
+	key, offset, err := GenKeyAndOffset(32)
+	require.NoError(t, err)
+
+	tests := map[string]KeyFile{
+		"No label":   {Key: key, Offset: offset},
+		"With label": {Key: key, Offset: offset, Label: "assets key"},
+	}
+	for name, kf := range tests {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "xor.key")
+			require.NoError(t, SaveKeyFile(path, kf))
+			loaded, err := LoadKeyFile(path)
+			require.NoError(t, err)
+			assert.Equal(t, kf, loaded)
+		})
+	}
+}
+
+func TestKeyFile_Neg(t *testing.T) {
+	key := []byte{0xde, 0xad, 0xbe, 0xef}
+	assert.Error(t, WriteKeyFile(&bytes.Buffer{}, KeyFile{}), "Empty keys should be rejected")
+	assert.Error(t, WriteKeyFile(&bytes.Buffer{}, KeyFile{Key: key, Offset: 4}), "Invalid offsets should be rejected")
+
+	var buf bytes.Buffer
+	require.NoError(t, WriteKeyFile(&buf, KeyFile{Key: key, Offset: 1, Label: "label"}))
+	valid := buf.Bytes()
+
+	tests := map[string][]byte{
+		"Empty":           nil,
+		"Wrong magic":     append([]byte("XSCR"), valid[4:]...),
+		"Wrong version":   append(append(bytes.Clone(valid[:4]), 2), valid[5:]...),
+		"Truncated key":   valid[:keyFileHeaderSize+2],
+		"Truncated label": valid[:len(valid)-1],
+		"Offset too big":  append(append(bytes.Clone(valid[:5]), 0, 0, 0, 4), valid[9:]...),
+	}
+	for name, data := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := ReadKeyFile(bytes.NewReader(data))
+			assert.ErrorIs(t, err, ErrInvalidKeyFile)
+		})
+	}
+
+	_, err := LoadKeyFile(filepath.Join(t.TempDir(), "missing.key"))
+	assert.Error(t, err)
+}
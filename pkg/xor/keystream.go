@@ -0,0 +1,134 @@
+package xor
+
+import (
+	"crypto/sha256"
+	"errors"
+	"golang.org/x/crypto/chacha20"
+	"io"
+)
+
+const (
+	// keystreamLimit is the length of the ChaCha20 key stream, which has a 32-bit block counter.
+	keystreamLimit uint64 = 64 << 32
+)
+
+var (
+	ErrKeystreamLimit = errors.New("key stream limit reached, no more data can be screened")
+)
+
+// screener applies a key stream to data that passes through a Reader or Writer.
+type screener interface {
+	// xor screens src into dst, which may be the same slice, and advances the key stream position.
+	xor(dst, src []byte)
+	// rewind moves the key stream position back by n bytes.
+	rewind(n int)
+	// reset moves the key stream position back to its initial value.
+	reset()
+	// setStart sets the absolute position in the screened data that reset returns to, and moves to it.
+	setStart(pos uint64)
+	// remaining returns how many more bytes may be screened, which is math.MaxUint64 for screens without a limit.
+	// Screening more than this is a programming error.
+	remaining() uint64
+}
+
+// screenable limits size to the number of bytes that the screener can still screen.
+func screenable(scr screener, size int) int {
+	if rem := scr.remaining(); rem < uint64(size) {
+		return int(rem)
+	}
+	return size
+}
+
+// screenLimit limits size to the number of bytes that the screener can still screen, before reading from the source.
+// At the limit, the source is read to check whether more data follows, so a stream ending exactly at the limit still ends with io.EOF.
+// ErrKeystreamLimit is returned if there is more data.
+func screenLimit(scr screener, source io.Reader, size int) (int, error) {
+	if size == 0 {
+		return 0, nil
+	}
+	if n := screenable(scr, size); n > 0 {
+		return n, nil
+	}
+	var probe [1]byte
+	if _, err := io.ReadFull(source, probe[:]); err != nil {
+		return 0, err
+	}
+	return 0, ErrKeystreamLimit
+}
+
+var (
+	_ screener = (*xorScreen)(nil)
+	_ screener = (*keystreamScreen)(nil)
+)
+
+// keystreamScreen screens data with a ChaCha20 key stream seeded by the key, instead of repeating the key.
+type keystreamScreen struct {
+	seed   [chacha20.KeySize]byte
+	init   uint64
+	start  uint64
+	pos    uint64
+	cipher *chacha20.Cipher
+}
+
+func newKeystreamScreen(key []byte, offset int) (*keystreamScreen, error) {
+	if err := validateKeyOffset(key, offset); err != nil {
+		return nil, err
+	}
+	s := &keystreamScreen{
+		seed: sha256.Sum256(key),
+		init: uint64(offset),
+	}
+	s.reset()
+	return s, nil
+}
+
+func (s *keystreamScreen) xor(dst, src []byte) {
+	if uint64(len(src)) > s.remaining() {
+		panic("screened past the key stream limit")
+	}
+	s.cipher.XORKeyStream(dst[:len(src)], src)
+	s.pos += uint64(len(src))
+}
+
+func (s *keystreamScreen) remaining() uint64 {
+	return keystreamLimit - s.pos
+}
+
+func (s *keystreamScreen) rewind(n int) {
+	s.setPos(s.pos - uint64(n))
+}
+
+func (s *keystreamScreen) reset() {
+	s.setPos(s.init + s.start)
+}
+
+func (s *keystreamScreen) setStart(pos uint64) {
+	s.start = pos
+	s.reset()
+}
+
+// setPos moves to the given position in the key stream.
+// The ChaCha20 counter can't be moved backward, so a new cipher is created and advanced to the block containing pos.
+// At the limit there's no block left to advance to, so no cipher is created, and remaining reports that nothing can be screened.
+func (s *keystreamScreen) setPos(pos uint64) {
+	if pos > keystreamLimit {
+		panic("key stream position is past the limit")
+	}
+	s.pos = pos
+	if pos == keystreamLimit {
+		s.cipher = nil
+		return
+	}
+	var nonce [chacha20.NonceSize]byte
+	c, err := chacha20.NewUnauthenticatedCipher(s.seed[:], nonce[:])
+	if err != nil {
+		// The seed and nonce are always the correct size.
+		panic(err)
+	}
+	c.SetCounter(uint32(pos / 64))
+	if rem := pos % 64; rem > 0 {
+		var discard [64]byte
+		c.XORKeyStream(discard[:rem], discard[:rem])
+	}
+	s.cipher = c
+}
@@ -0,0 +1,130 @@
+package xor
+
+import (
+	"bytes"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io"
+	"testing"
+)
+
+func TestWithKeystream(t *testing.T) {
+	key := []byte{0xde, 0xad, 0xbe, 0xef}
+	data := make([]byte, 1000)
+
+	var screened bytes.Buffer
+	w, err := NewWriterWith(&screened, key, WithKeystream(), WithOffset(2))
+	require.NoError(t, err)
+	_, err = w.Write(data[:3])
+	require.NoError(t, err)
+	_, err = w.Write(data[3:])
+	require.NoError(t, err)
+	require.Equal(t, len(data), screened.Len())
+
+	out := screened.Bytes()
+	assert.NotEqual(t, referenceScreen(data, key, 2), out, "Keystream output should differ from a repeating key")
+	assert.NotEqual(t, out[:len(key)], out[len(key):2*len(key)], "Keystream should not repeat with the key length")
+
+	r, err := NewReaderWith(bytes.NewReader(out), key, WithKeystream(), WithOffset(2))
+	require.NoError(t, err)
+	unscreened, err := io.ReadAll(r)
+	require.NoError(t, err)
+	assert.Equal(t, data, unscreened)
+
+	r, err = NewReaderWith(bytes.NewReader(out), key, WithKeystream())
+	require.NoError(t, err)
+	unscreened, err = io.ReadAll(r)
+	require.NoError(t, err)
+	assert.NotEqual(t, data, unscreened, "A different offset should not unscreen the data")
+}
+
+func TestWithKeystream_Reset(t *testing.T) {
+	key := []byte("some key")
+	data := []byte("A string with some text that spans more than a single ChaCha20 block of sixty four bytes")
+
+	var outA, outB bytes.Buffer
+	w, err := NewWriterWith(&outA, key, WithKeystream())
+	require.NoError(t, err)
+	_, err = w.Write(data)
+	require.NoError(t, err)
+	w.Reset(&outB)
+	_, err = w.Write(data)
+	require.NoError(t, err)
+	assert.Equal(t, outA.Bytes(), outB.Bytes())
+}
+
+func TestWithKeystream_ShortWrite(t *testing.T) {
+	key := []byte("some key")
+	data := bytes.Repeat([]byte("A string with some text"), 10)
+
+	var expected bytes.Buffer
+	w, err := NewWriterWith(&expected, key, WithKeystream(), WithOffset(1))
+	require.NoError(t, err)
+	_, err = w.Write(data)
+	require.NoError(t, err)
+
+	target := &shortWriter{limit: 37}
+	w, err = NewWriterWith(target, key, WithKeystream(), WithOffset(1))
+	require.NoError(t, err)
+	written := 0
+	for written < len(data) {
+		n, _ := w.Write(data[written:])
+		written += n
+	}
+	assert.Equal(t, expected.Bytes(), target.Bytes(), "The key stream position should only advance for bytes that were written")
+}
+
+func TestWithKeystream_Limit(t *testing.T) {
+	key := []byte("some key")
+	data := []byte("A string that runs past the end of the key stream")
+	start := keystreamLimit - 20
+
+	var screened bytes.Buffer
+	w, err := NewWriterWith(&screened, key, WithKeystream(), WithStartPosition(start))
+	require.NoError(t, err)
+	n, err := w.Write(data)
+	assert.ErrorIs(t, err, ErrKeystreamLimit)
+	assert.Equal(t, 20, n, "Bytes before the limit should be written")
+	_, err = w.Write(data[n:])
+	assert.ErrorIs(t, err, ErrKeystreamLimit)
+	assert.ErrorIs(t, w.WriteByte('a'), ErrKeystreamLimit)
+	_, err = w.(io.ReaderFrom).ReadFrom(bytes.NewReader(data))
+	assert.ErrorIs(t, err, ErrKeystreamLimit)
+	w.Reset(io.Discard)
+	_, err = w.(io.ReaderFrom).ReadFrom(bytes.NewReader(data[:20]))
+	assert.NoError(t, err, "Exactly reaching the limit shouldn't be an error")
+	require.Equal(t, 20, screened.Len())
+
+	r, err := NewReaderWith(bytes.NewReader(screened.Bytes()), key, WithKeystream(), WithStartPosition(start))
+	require.NoError(t, err)
+	unscreened, err := io.ReadAll(r)
+	require.NoError(t, err, "A stream ending exactly at the limit should read to EOF")
+	assert.Equal(t, data[:20], unscreened)
+
+	r, err = NewReaderWith(bytes.NewReader(append(screened.Bytes(), 0x1)), key, WithKeystream(), WithStartPosition(start))
+	require.NoError(t, err)
+	unscreened, err = io.ReadAll(r)
+	assert.ErrorIs(t, err, ErrKeystreamLimit)
+	assert.Equal(t, data[:20], unscreened)
+
+	r, err = NewReaderWith(bytes.NewReader(append(screened.Bytes(), 0x1)), key, WithKeystream(), WithStartPosition(start))
+	require.NoError(t, err)
+	_, err = io.ReadFull(r, make([]byte, 20))
+	require.NoError(t, err)
+	_, err = r.ReadByte()
+	assert.ErrorIs(t, err, ErrKeystreamLimit)
+
+	r, err = NewReaderWith(bytes.NewReader(append(screened.Bytes(), 0x1)), key, WithKeystream(), WithStartPosition(start))
+	require.NoError(t, err)
+	var out bytes.Buffer
+	_, err = r.(io.WriterTo).WriteTo(&out)
+	assert.ErrorIs(t, err, ErrKeystreamLimit)
+	assert.Equal(t, data[:20], out.Bytes())
+}
+
+func TestWithKeystream_Neg(t *testing.T) {
+	_, err := NewReaderWith(bytes.NewReader(nil), nil, WithKeystream())
+	assert.Error(t, err)
+	_, err = NewWriterWith(io.Discard, []byte{0x1}, WithKeystream(), WithOffset(1))
+	assert.Error(t, err)
+}
@@ -0,0 +1,87 @@
+package xor
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+)
+
+var (
+	ErrMagicMismatch = errors.New("unscreened data doesn't match any expected magic value")
+)
+
+var (
+	// MagicGzip is the leading bytes of a gzip stream.
+	MagicGzip = []byte{0x1f, 0x8b}
+	// MagicPNG is the leading bytes of a PNG image.
+	MagicPNG = []byte{0x89, 'P', 'N', 'G', '\r', '\n', 0x1a, '\n'}
+	// MagicJSONObject is the leading byte of a JSON object without leading whitespace.
+	MagicJSONObject = []byte("{")
+)
+
+// MagicMismatchError is returned from Reader.Read when ExpectMagic is used and the leading unscreened bytes don't match.
+// This is most likely caused by using the wrong key or offset.
+type MagicMismatchError struct {
+	// Found is the leading unscreened data that was read from the source.
+	Found []byte
+}
+
+func (e *MagicMismatchError) Error() string {
+	return fmt.Sprintf("%s: found %x", ErrMagicMismatch.Error(), e.Found)
+}
+
+func (e *MagicMismatchError) Is(target error) bool {
+	return target == ErrMagicMismatch
+}
+
+// ExpectMagic will make a Reader validate that the first unscreened bytes match at least one of the given magic values.
+// If none match, then the first call to Read will return a *MagicMismatchError, which catches a wrong key or offset immediately.
+// The verified bytes are still returned from Read as normal.
+// This option is only supported by Reader.
+func ExpectMagic(magic ...[]byte) ScreenOpt {
+	return func(conf *screenConfig) error {
+		if len(magic) == 0 {
+			return errors.New("at least one magic value must be provided")
+		}
+		for _, m := range magic {
+			if len(m) == 0 {
+				return errors.New("cannot expect an empty magic value")
+			}
+		}
+		conf.magic = append(conf.magic, magic...)
+		return nil
+	}
+}
+
+// checkMagic reads enough from the source to check the expected magic values, and holds the screened bytes to be returned from Read.
+func (r *reader) checkMagic() error {
+	maxLen := 0
+	for _, m := range r.magic {
+		if len(m) > maxLen {
+			maxLen = len(m)
+		}
+	}
+	size, err := screenLimit(r.scr, r.source, maxLen)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return err
+	}
+	buf := make([]byte, size)
+	n, err := io.ReadFull(r.source, buf)
+	switch {
+	case errors.Is(err, io.EOF), errors.Is(err, io.ErrUnexpectedEOF):
+		err = nil
+	case err != nil:
+		return err
+	}
+	buf = buf[:n]
+	r.scr.xor(buf, buf)
+	r.checked = true
+	for _, m := range r.magic {
+		if bytes.HasPrefix(buf, m) {
+			r.pending = buf
+			return nil
+		}
+	}
+	return &MagicMismatchError{Found: buf}
+}
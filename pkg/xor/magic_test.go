@@ -0,0 +1,88 @@
+package xor
+
+import (
+	"bytes"
+	"compress/gzip"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io"
+	"testing"
+)
+
+func screenBytes(t *testing.T, data, key []byte, offset int) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	w, err := NewWriterWith(&buf, key, WithOffset(offset))
+	require.NoError(t, err)
+	_, err = w.Write(data)
+	require.NoError(t, err)
+	return buf.Bytes()
+}
+
+func TestExpectMagic(t *testing.T) {
+	var (
+		key  = []byte{0xde, 0xad, 0xbe, 0xef}
+		data bytes.Buffer
+	)
+	gz := gzip.NewWriter(&data)
+	_, err := gz.Write([]byte("A string with some text"))
+	require.NoError(t, err)
+	require.NoError(t, gz.Close())
+	screened := screenBytes(t, data.Bytes(), key, 1)
+
+	r, err := NewReaderWith(bytes.NewReader(screened), key, WithOffset(1), ExpectMagic(MagicPNG, MagicGzip))
+	require.NoError(t, err)
+	gzr, err := gzip.NewReader(r)
+	require.NoError(t, err)
+	result, err := io.ReadAll(gzr)
+	require.NoError(t, err)
+	assert.Equal(t, "A string with some text", string(result))
+
+	r.Reset(bytes.NewReader(screened))
+	result, err = io.ReadAll(r)
+	require.NoError(t, err)
+	assert.Equal(t, data.Bytes(), result)
+}
+
+func TestExpectMagic_Mismatch(t *testing.T) {
+	key := []byte{0xde, 0xad, 0xbe, 0xef}
+	screened := screenBytes(t, []byte(`{"a": 1}`), key, 1)
+
+	tests := map[string]struct {
+		data   []byte
+		offset int
+		magic  []byte
+	}{
+		"Wrong offset": {data: screened, offset: 2, magic: MagicJSONObject},
+		"Wrong magic":  {data: screened, offset: 1, magic: MagicGzip},
+		"Short data":   {data: screened[:1], offset: 1, magic: []byte(`{"`)},
+		"Empty data":   {data: nil, offset: 1, magic: MagicJSONObject},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			r, err := NewReaderWith(bytes.NewReader(tc.data), key, WithOffset(tc.offset), ExpectMagic(tc.magic))
+			require.NoError(t, err)
+			_, err = io.ReadAll(r)
+			assert.ErrorIs(t, err, ErrMagicMismatch)
+			var mismatch *MagicMismatchError
+			require.ErrorAs(t, err, &mismatch)
+
+			_, err = r.Read(make([]byte, 1))
+			assert.ErrorIs(t, err, ErrMagicMismatch, "Mismatch should be returned until Reset")
+		})
+	}
+}
+
+func TestExpectMagic_Neg(t *testing.T) {
+	key := []byte{0xde, 0xad, 0xbe, 0xef}
+	_, err := NewReaderWith(nil, key, ExpectMagic())
+	assert.Error(t, err)
+	_, err = NewReaderWith(nil, key, ExpectMagic(nil))
+	assert.Error(t, err)
+	_, err = NewWriterWith(io.Discard, key, ExpectMagic(MagicGzip))
+	assert.ErrorIs(t, err, ErrReaderOnlyOpt)
+	_, err = NewReaderWith(nil, key, WithOffset(-1))
+	assert.Error(t, err)
+	_, err = NewReaderWith(nil, key, WithOffset(4))
+	assert.Error(t, err)
+}
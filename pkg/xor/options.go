@@ -0,0 +1,218 @@
+package xor
+
+import (
+	"errors"
+	"fmt"
+	"io"
+)
+
+var (
+	ErrReaderOnlyOpt = errors.New("option is only supported by Reader")
+)
+
+// ScreenOpt configures the behavior of a Reader or Writer created with NewReaderWith or NewWriterWith.
+type ScreenOpt = func(*screenConfig) error
+
+type screenConfig struct {
+	offset int
+	start  uint64
+	magic  [][]byte
+	mode   screenMode
+	ranges []Range
+	oddKey []byte
+	// progress is passed to the Reader or Writer to report the number of bytes processed.
+	progress func(total uint64)
+}
+
+// screenMode selects how the key is applied to the data.
+type screenMode uint8
+
+const (
+	// modeRepeat repeats the key for the length of the data, which is the default.
+	modeRepeat screenMode = iota
+	// modeKeystream uses the key to seed a ChaCha20 key stream.
+	modeKeystream
+	// modeFeedback mutates each key byte with the screened byte it produced.
+	modeFeedback
+	// modeInterleaved screens even and odd positions with different keys.
+	modeInterleaved
+)
+
+func (m screenMode) String() string {
+	switch m {
+	case modeRepeat:
+		return "repeat"
+	case modeKeystream:
+		return "keystream"
+	case modeFeedback:
+		return "feedback"
+	case modeInterleaved:
+		return "interleaved"
+	default:
+		return fmt.Sprintf("unknown(%d)", uint8(m))
+	}
+}
+
+// setMode selects a screen mode, or returns to the default mode if enabled is false and the mode is currently selected.
+// Only one mode may be selected at a time.
+func (conf *screenConfig) setMode(mode screenMode, enabled bool) error {
+	if !enabled {
+		if conf.mode == mode {
+			conf.mode = modeRepeat
+		}
+		return nil
+	}
+	if conf.mode != modeRepeat && conf.mode != mode {
+		return fmt.Errorf("cannot use %s mode with %s mode", mode, conf.mode)
+	}
+	conf.mode = mode
+	return nil
+}
+
+// withMode overrides the screen mode, regardless of previous options.
+func withMode(mode screenMode) ScreenOpt {
+	return func(conf *screenConfig) error {
+		conf.mode = mode
+		return nil
+	}
+}
+
+func newScreenConfig(opts ...ScreenOpt) (*screenConfig, error) {
+	conf := new(screenConfig)
+	for _, opt := range opts {
+		if err := opt(conf); err != nil {
+			return nil, err
+		}
+	}
+	return conf, nil
+}
+
+// WithOffset sets the starting offset within the key, which is the same as the offset parameter of NewReader and NewWriter.
+func WithOffset(offset int) ScreenOpt {
+	return func(conf *screenConfig) error {
+		if offset < 0 {
+			return fmt.Errorf("offset %d may not be negative", offset)
+		}
+		conf.offset = offset
+		return nil
+	}
+}
+
+// WithStartPosition starts screening as if the given number of bytes had already been screened.
+// This allows resuming a very large screened stream at an absolute position without screening everything before it.
+// Positions are 64-bit on every platform. Reset returns to this position instead of the beginning of the stream.
+// This may not be used with WithFeedback, since the key state depends on every byte before the position.
+func WithStartPosition(pos uint64) ScreenOpt {
+	return func(conf *screenConfig) error {
+		conf.start = pos
+		return nil
+	}
+}
+
+// WithProgress calls fn with the total number of bytes that have passed through a Reader or Writer, after each call that processes data.
+// This allows tools screening very large files to report progress without wrapping the stream themselves.
+// The total counts unscreened bytes returned by a Reader, or screened bytes accepted by the target of a Writer, and starts over after Reset.
+// The callback is called synchronously from Read or Write, so it should return quickly.
+func WithProgress(fn func(total uint64)) ScreenOpt {
+	return func(conf *screenConfig) error {
+		conf.progress = fn
+		return nil
+	}
+}
+
+// WithKeystream uses the key to seed a ChaCha20 key stream, instead of repeating the key for the length of the data.
+// This eliminates the periodic pattern that a repeating key leaves in long payloads, but the same key still trivially reverses the screen.
+// The offset is the starting position within the key stream, and must still be within the length of the key.
+// Data screened in this mode can only be unscreened with this mode, and a key stream is limited to 256GiB.
+// Reading or writing past the end of the key stream returns ErrKeystreamLimit.
+func WithKeystream(val ...bool) ScreenOpt {
+	return func(conf *screenConfig) error {
+		return conf.setMode(modeKeystream, len(val) == 0 || val[0])
+	}
+}
+
+// WithFeedback mutates each key byte after it's used, by rotating it and XORing it with the screened byte it produced.
+// This breaks the repeating pattern of the key, so it's stronger against trivial frequency analysis than the default mode.
+// This is still obfuscation and NOT encryption, since the key and offset are all that's needed to reverse it, and known plaintext reveals the key.
+// Data screened in this mode can only be unscreened with this mode, and only sequentially, so it can't be used with seeking or random access.
+func WithFeedback(val ...bool) ScreenOpt {
+	return func(conf *screenConfig) error {
+		return conf.setMode(modeFeedback, len(val) == 0 || val[0])
+	}
+}
+
+// newScreener creates the screener selected by the config.
+// The decode flag indicates whether the screener will unscreen data, which matters for modes that depend on the screened bytes.
+func (conf *screenConfig) newScreener(key []byte, decode bool) (screener, error) {
+	var (
+		scr screener
+		err error
+	)
+	switch conf.mode {
+	case modeKeystream:
+		if conf.start >= keystreamLimit-uint64(conf.offset) {
+			return nil, fmt.Errorf("start position %d exceeds the key stream limit", conf.start)
+		}
+		scr, err = newKeystreamScreen(key, conf.offset)
+	case modeFeedback:
+		if conf.start > 0 {
+			return nil, fmt.Errorf("cannot use a start position with %s mode", conf.mode)
+		}
+		if len(conf.ranges) > 0 {
+			return nil, fmt.Errorf("cannot use ranges with %s mode", conf.mode)
+		}
+		scr, err = newFeedbackScreen(key, conf.offset, decode)
+	case modeInterleaved:
+		scr, err = newInterleavedScreen(key, conf.oddKey, conf.offset)
+	default:
+		scr, err = newXorScreen(key, conf.offset)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(conf.ranges) > 0 {
+		scr = newRangeScreen(scr, conf.ranges)
+	}
+	scr.setStart(conf.start)
+	return scr, nil
+}
+
+// NewReaderWith constructs a new Reader that will perform XOR operations on all bytes read, using the provided key and options.
+func NewReaderWith(r io.Reader, key []byte, opts ...ScreenOpt) (Reader, error) {
+	conf, err := newScreenConfig(opts...)
+	if err != nil {
+		return nil, err
+	}
+	scr, err := conf.newScreener(key, true)
+	if err != nil {
+		return nil, err
+	}
+	xReader := &reader{
+		source:   r,
+		scr:      scr,
+		magic:    conf.magic,
+		progress: conf.progress,
+	}
+	return xReader, nil
+}
+
+// NewWriterWith constructs a new Writer that will perform XOR operations on all bytes written, using the provided key and options.
+func NewWriterWith(target io.Writer, key []byte, opts ...ScreenOpt) (Writer, error) {
+	conf, err := newScreenConfig(opts...)
+	if err != nil {
+		return nil, err
+	}
+	if len(conf.magic) > 0 {
+		return nil, fmt.Errorf("%w: ExpectMagic", ErrReaderOnlyOpt)
+	}
+	scr, err := conf.newScreener(key, false)
+	if err != nil {
+		return nil, err
+	}
+	xWriter := &writer{
+		target:   target,
+		scr:      scr,
+		progress: conf.progress,
+	}
+	return xWriter, nil
+}
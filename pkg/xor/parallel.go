@@ -0,0 +1,116 @@
+package xor
+
+import (
+	"fmt"
+	"io"
+	"runtime"
+	"sync"
+)
+
+const (
+	// parallelChunkSize is the number of bytes screened by a single goroutine at a time in ScreenParallel and CopyParallel.
+	parallelChunkSize = 1024 * 1024
+)
+
+// ScreenParallel screens data in place like Screen, but splits it into chunks that are screened across the given number of goroutines.
+// Each chunk's key position is derived from its absolute position, so the result is the same as Screen.
+// If workers is less than 1, then runtime.GOMAXPROCS(0) is used. Small inputs are screened without starting goroutines.
+func ScreenParallel[T ~[]byte](data T, key []byte, offset int, workers int) error {
+	scr, err := newXorScreen(key, offset)
+	if err != nil {
+		return err
+	}
+	workers = parallelWorkers(workers, int64(len(data)))
+	if workers == 1 {
+		scr.screenAt(data, 0)
+		return nil
+	}
+	chunks := make(chan int64)
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for start := range chunks {
+				end := min(start+parallelChunkSize, int64(len(data)))
+				scr.screenAt(data[start:end], uint64(start))
+			}
+		}()
+	}
+	for start := int64(0); start < int64(len(data)); start += parallelChunkSize {
+		chunks <- start
+	}
+	close(chunks)
+	wg.Wait()
+	return nil
+}
+
+// CopyParallel reads size bytes from src, screens them, and writes them to the same positions in dst, using the given number of goroutines.
+// This is intended for screening very large files, where each goroutine reads, screens, and writes a chunk at a time.
+// Like NewReaderAt and NewWriterAt, the key position is derived from the absolute position, so the key offset applies to position 0.
+// If workers is less than 1, then runtime.GOMAXPROCS(0) is used. The first error encountered is returned, and remaining chunks are skipped.
+func CopyParallel(dst io.WriterAt, src io.ReaderAt, size int64, key []byte, offset int, workers int) error {
+	if size < 0 {
+		return fmt.Errorf("negative size %d", size)
+	}
+	scr, err := newXorScreen(key, offset)
+	if err != nil {
+		return err
+	}
+	workers = parallelWorkers(workers, size)
+	var (
+		wg       sync.WaitGroup
+		errOnce  sync.Once
+		firstErr error
+		chunks   = make(chan int64)
+		done     = make(chan struct{})
+	)
+	fail := func(err error) {
+		errOnce.Do(func() {
+			firstErr = err
+			close(done)
+		})
+	}
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			buf := make([]byte, min(parallelChunkSize, size))
+			for start := range chunks {
+				chunk := buf[:min(parallelChunkSize, size-start)]
+				n, err := src.ReadAt(chunk, start)
+				if n < len(chunk) {
+					if err == nil || err == io.EOF {
+						err = io.ErrUnexpectedEOF
+					}
+					fail(fmt.Errorf("failed to read %d bytes at position %d: %w", len(chunk), start, err))
+					continue
+				}
+				scr.screenAt(chunk, uint64(start))
+				if _, err := dst.WriteAt(chunk, start); err != nil {
+					fail(fmt.Errorf("failed to write %d bytes at position %d: %w", len(chunk), start, err))
+				}
+			}
+		}()
+	}
+feed:
+	for start := int64(0); start < size; start += parallelChunkSize {
+		select {
+		case chunks <- start:
+		case <-done:
+			break feed
+		}
+	}
+	close(chunks)
+	wg.Wait()
+	return firstErr
+}
+
+// parallelWorkers returns the number of goroutines to use for the given input size.
+func parallelWorkers(workers int, size int64) int {
+	if workers < 1 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	chunks := (size + parallelChunkSize - 1) / parallelChunkSize
+	return int(max(min(int64(workers), chunks), 1))
+}
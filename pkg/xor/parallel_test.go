@@ -0,0 +1,74 @@
+package xor
+
+import (
+	"bytes"
+	"crypto/rand"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestScreenParallel(t *testing.T) {
+	key := []byte{0xde, 0xad, 0xbe, 0xef, 0x01, 0x02, 0x03}
+	tests := map[string]int{
+		"Empty":          0,
+		"Small":          100,
+		"Several chunks": parallelChunkSize*3 + 17,
+	}
+	for name, size := range tests {
+		t.Run(name, func(t *testing.T) {
+			data := make([]byte, size)
+			_, err := rand.Read(data)
+			require.NoError(t, err)
+			expected := bytes.Clone(data)
+			require.NoError(t, Screen(expected, key, 5))
+
+			require.NoError(t, ScreenParallel(data, key, 5, 4))
+			assert.True(t, bytes.Equal(expected, data))
+		})
+	}
+	assert.Error(t, ScreenParallel([]byte("data"), key, len(key), 0))
+}
+
+func TestCopyParallel(t *testing.T) {
+	key := []byte{0xde, 0xad, 0xbe, 0xef, 0x01, 0x02, 0x03}
+	data := make([]byte, parallelChunkSize*2+100)
+	_, err := rand.Read(data)
+	require.NoError(t, err)
+	expected := bytes.Clone(data)
+	require.NoError(t, Screen(expected, key, 3))
+
+	out, err := os.Create(filepath.Join(t.TempDir(), "screened"))
+	require.NoError(t, err)
+	defer func() {
+		_ = out.Close()
+	}()
+	require.NoError(t, CopyParallel(out, bytes.NewReader(data), int64(len(data)), key, 3, 0))
+	got, err := os.ReadFile(out.Name())
+	require.NoError(t, err)
+	assert.True(t, bytes.Equal(expected, got))
+}
+
+type failingWriterAt struct{}
+
+func (failingWriterAt) WriteAt([]byte, int64) (int, error) {
+	return 0, errors.New("write failed")
+}
+
+func TestCopyParallel_Neg(t *testing.T) {
+	key := []byte{0xde, 0xad}
+	data := make([]byte, parallelChunkSize*3)
+	out, err := os.Create(filepath.Join(t.TempDir(), "screened"))
+	require.NoError(t, err)
+	defer func() {
+		_ = out.Close()
+	}()
+
+	assert.ErrorContains(t, CopyParallel(out, bytes.NewReader(data), int64(len(data))+1, key, 0, 2), "failed to read")
+	assert.ErrorContains(t, CopyParallel(failingWriterAt{}, bytes.NewReader(data), int64(len(data)), key, 0, 2), "write failed")
+	assert.Error(t, CopyParallel(out, bytes.NewReader(data), -1, key, 0, 2))
+	assert.Error(t, CopyParallel(out, bytes.NewReader(data), 10, nil, 0, 2))
+}
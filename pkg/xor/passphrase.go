@@ -0,0 +1,68 @@
+package xor
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"github.com/saylorsolutions/gocryptx/pkg/passlock"
+	"golang.org/x/crypto/chacha20"
+)
+
+// passphraseSalt is the fixed salt used by KeyFromPassphrase, so the same passphrase always produces the same key.
+var passphraseSalt = sha256.Sum256([]byte("github.com/saylorsolutions/gocryptx/pkg/xor.KeyFromPassphrase"))
+
+// KeyFromPassphrase deterministically derives an XOR key with the given length from a passphrase.
+// This allows tools that can't store a random key to reproduce the same screen from a shared secret.
+//
+// A seed is derived with the same scrypt settings as passlock.SetShortDelayIterations, and expanded to the requested length with a ChaCha20 key stream.
+// The salt is fixed so the key can be reproduced, which means the same passphrase always produces the same key in every program.
+// Use a long passphrase, since a fixed salt allows guesses to be precomputed.
+func KeyFromPassphrase(pass string, length int) ([]byte, error) {
+	if length <= 0 {
+		return nil, errors.New("key length must be positive")
+	}
+	gen, err := passlock.NewKeyGenerator(passlock.SetShortDelayIterations(), passlock.SetAES256KeySize())
+	if err != nil {
+		return nil, err
+	}
+	seed, err := gen.DeriveKeyWithSalt(passlock.Passphrase(pass), passphraseSalt[:])
+	if err != nil {
+		return nil, err
+	}
+	var nonce [chacha20.NonceSize]byte
+	c, err := chacha20.NewUnauthenticatedCipher(seed, nonce[:])
+	if err != nil {
+		return nil, err
+	}
+	key := make([]byte, length)
+	c.XORKeyStream(key, key)
+	return key, nil
+}
+
+// seedDomain separates keys derived by KeyFromSeed from other uses of the same seed string.
+const seedDomain = "github.com/saylorsolutions/gocryptx/pkg/xor.KeyFromSeed"
+
+// KeyFromSeed deterministically derives an XOR key with the given length from a seed string, with a fast hash-based expansion.
+// This allows reproducible builds to regenerate identical screened artifacts from a checked-in seed, instead of committing generated files.
+//
+// Each 32 byte block of the key is the SHA-256 hash of a domain separator, the big-endian block index, and the seed.
+// The derivation is stable, so the same seed and length produce the same key in every version of this package.
+// Unlike KeyFromPassphrase, this is not slowed down to resist guessing, so the seed should be treated as the key itself.
+func KeyFromSeed(seed string, length int) ([]byte, error) {
+	if len(seed) == 0 {
+		return nil, errors.New("cannot use empty seed")
+	}
+	if length <= 0 {
+		return nil, errors.New("key length must be positive")
+	}
+	key := make([]byte, 0, length+sha256.Size)
+	h := sha256.New()
+	for block := uint32(0); len(key) < length; block++ {
+		h.Reset()
+		h.Write([]byte(seedDomain))
+		h.Write(binary.BigEndian.AppendUint32(nil, block))
+		h.Write([]byte(seed))
+		key = h.Sum(key)
+	}
+	return key[:length:length], nil
+}
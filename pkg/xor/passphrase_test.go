@@ -0,0 +1,55 @@
+package xor
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestKeyFromPassphrase(t *testing.T) {
+	key, err := KeyFromPassphrase("a shared secret", 100)
+	require.NoError(t, err)
+	assert.Len(t, key, 100)
+	assert.NotEqual(t, make([]byte, 100), key)
+
+	again, err := KeyFromPassphrase("a shared secret", 20)
+	require.NoError(t, err)
+	assert.Equal(t, key[:20], again, "The same passphrase should always produce the same key")
+
+	other, err := KeyFromPassphrase("another shared secret", 20)
+	require.NoError(t, err)
+	assert.NotEqual(t, again, other)
+}
+
+func TestKeyFromPassphrase_Neg(t *testing.T) {
+	_, err := KeyFromPassphrase("", 20)
+	assert.Error(t, err)
+	_, err = KeyFromPassphrase("a shared secret", 0)
+	assert.Error(t, err)
+}
+
+func TestKeyFromSeed(t *testing.T) {
+	key, err := KeyFromSeed("build seed", 100)
+	require.NoError(t, err)
+	assert.Len(t, key, 100)
+	assert.Equal(t, []byte{0x9a, 0x1c, 0x0e, 0xc0, 0x6b, 0xbb, 0x01, 0xc6}, key[:8], "Derivation must be stable for reproducible builds")
+
+	again, err := KeyFromSeed("build seed", 20)
+	require.NoError(t, err)
+	assert.Equal(t, key[:20], again, "The same seed should always produce the same key")
+
+	other, err := KeyFromSeed("another build seed", 20)
+	require.NoError(t, err)
+	assert.NotEqual(t, again, other)
+
+	passKey, err := KeyFromPassphrase("build seed", 20)
+	require.NoError(t, err)
+	assert.NotEqual(t, passKey, again, "Seed keys should be distinct from passphrase keys")
+}
+
+func TestKeyFromSeed_Neg(t *testing.T) {
+	_, err := KeyFromSeed("", 20)
+	assert.Error(t, err)
+	_, err = KeyFromSeed("build seed", 0)
+	assert.Error(t, err)
+}
@@ -0,0 +1,231 @@
+package xor
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+)
+
+const (
+	MinPermuteBlockSize = 2
+	MaxPermuteBlockSize = 64 * 1024
+)
+
+// permuteDomain separates the permutation seed from other uses of the same key.
+const permuteDomain = "github.com/saylorsolutions/gocryptx/pkg/xor.Permute"
+
+// NewPermutedWriter constructs an io.WriteCloser that screens all bytes written like NewWriterWith, and then shuffles the screened bytes within each block of blockSize bytes.
+// Each block is shuffled with a different permutation derived from the key and the block's position, so known plaintext no longer lines up with the key bytes that screened it.
+// The final block may be shorter than blockSize, and is shuffled when Close is called. Close doesn't close the target, and nothing may be written after Close.
+//
+// This is still obfuscation and NOT encryption. The key is all that's needed to reverse it, and the permutation only hides which key byte screened which plaintext byte.
+// Data written this way must be read with NewPermutedReader, using the same key, block size, and options.
+func NewPermutedWriter(target io.Writer, key []byte, blockSize int, opts ...ScreenOpt) (io.WriteCloser, error) {
+	p, err := newPermuter(key, blockSize)
+	if err != nil {
+		return nil, err
+	}
+	bw := &blockWriter{
+		target: target,
+		perm:   p,
+		block:  make([]byte, 0, blockSize),
+		out:    make([]byte, blockSize),
+	}
+	w, err := NewWriterWith(bw, key, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return &permutedWriter{
+		w:  w,
+		bw: bw,
+	}, nil
+}
+
+// NewPermutedReader constructs an io.Reader that reverses the shuffling done by NewPermutedWriter, and unscreens the result like NewReaderWith.
+// The source must end where the permuted data ends, since the final block is identified by its shorter length.
+// Options like ExpectMagic apply to the unscreened data as usual.
+func NewPermutedReader(source io.Reader, key []byte, blockSize int, opts ...ScreenOpt) (io.Reader, error) {
+	p, err := newPermuter(key, blockSize)
+	if err != nil {
+		return nil, err
+	}
+	br := &blockReader{
+		source: source,
+		perm:   p,
+		block:  make([]byte, blockSize),
+		out:    make([]byte, blockSize),
+	}
+	return NewReaderWith(br, key, opts...)
+}
+
+// permuter shuffles blocks with a permutation derived from a key seed and the block index.
+type permuter struct {
+	seed [sha256.Size]byte
+	perm []int
+}
+
+func newPermuter(key []byte, blockSize int) (*permuter, error) {
+	if len(key) == 0 {
+		return nil, errors.New("cannot use empty key")
+	}
+	if blockSize < MinPermuteBlockSize || blockSize > MaxPermuteBlockSize {
+		return nil, fmt.Errorf("block size %d must be between %d and %d", blockSize, MinPermuteBlockSize, MaxPermuteBlockSize)
+	}
+	h := sha256.New()
+	h.Write([]byte(permuteDomain))
+	h.Write(key)
+	p := &permuter{
+		perm: make([]int, blockSize),
+	}
+	h.Sum(p.seed[:0])
+	return p, nil
+}
+
+// permutation returns the permutation for the block at index with length n, which is only valid until the next call.
+func (p *permuter) permutation(index uint64, n int) []int {
+	h := sha256.New()
+	h.Write(p.seed[:])
+	h.Write(binary.BigEndian.AppendUint64(nil, index))
+	var blockSeed [32]byte
+	h.Sum(blockSeed[:0])
+	// ChaCha8 output is stable, so the permutation is reproducible between versions of Go.
+	src := rand.NewChaCha8(blockSeed)
+	perm := p.perm[:n]
+	for i := range perm {
+		perm[i] = i
+	}
+	for i := n - 1; i > 0; i-- {
+		j := int(src.Uint64() % uint64(i+1))
+		perm[i], perm[j] = perm[j], perm[i]
+	}
+	return perm
+}
+
+// shuffle moves each byte of src to its permuted position in dst.
+func (p *permuter) shuffle(dst, src []byte, index uint64) {
+	for i, to := range p.permutation(index, len(src)) {
+		dst[to] = src[i]
+	}
+}
+
+// unshuffle reverses shuffle.
+func (p *permuter) unshuffle(dst, src []byte, index uint64) {
+	for i, from := range p.permutation(index, len(src)) {
+		dst[i] = src[from]
+	}
+}
+
+var _ io.WriteCloser = (*permutedWriter)(nil)
+
+type permutedWriter struct {
+	w      Writer
+	bw     *blockWriter
+	closed bool
+}
+
+func (w *permutedWriter) Write(in []byte) (int, error) {
+	if w.closed {
+		return 0, errors.New("write after close")
+	}
+	return w.w.Write(in)
+}
+
+func (w *permutedWriter) Close() error {
+	if w.closed {
+		return nil
+	}
+	w.closed = true
+	return w.bw.flush()
+}
+
+// blockWriter collects screened bytes into blocks, and writes each block to the target once it's full and shuffled.
+type blockWriter struct {
+	target io.Writer
+	perm   *permuter
+	block  []byte
+	out    []byte
+	index  uint64
+	err    error
+}
+
+func (w *blockWriter) Write(in []byte) (n int, err error) {
+	if w.err != nil {
+		return 0, w.err
+	}
+	for len(in) > 0 {
+		nc := min(len(in), cap(w.block)-len(w.block))
+		w.block = append(w.block, in[:nc]...)
+		in = in[nc:]
+		n += nc
+		if len(w.block) == cap(w.block) {
+			if err := w.flush(); err != nil {
+				return n, err
+			}
+		}
+	}
+	return n, nil
+}
+
+// flush shuffles and writes the buffered block, even if it's not full.
+// Errors are sticky, since a partly written block can't be recovered.
+func (w *blockWriter) flush() error {
+	if w.err != nil {
+		return w.err
+	}
+	if len(w.block) == 0 {
+		return nil
+	}
+	out := w.out[:len(w.block)]
+	w.perm.shuffle(out, w.block, w.index)
+	nw, err := w.target.Write(out)
+	if err == nil && nw < len(out) {
+		err = io.ErrShortWrite
+	}
+	if err != nil {
+		w.err = err
+		return err
+	}
+	w.index++
+	w.block = w.block[:0]
+	return nil
+}
+
+// blockReader reads whole blocks from the source and reverses their shuffling.
+type blockReader struct {
+	source  io.Reader
+	perm    *permuter
+	block   []byte
+	out     []byte
+	index   uint64
+	pending []byte
+	err     error
+}
+
+func (r *blockReader) Read(out []byte) (int, error) {
+	if len(r.pending) == 0 {
+		if r.err != nil {
+			return 0, r.err
+		}
+		n, err := io.ReadFull(r.source, r.block)
+		switch {
+		case errors.Is(err, io.EOF), errors.Is(err, io.ErrUnexpectedEOF):
+			// A short block can only be the last one.
+			r.err = io.EOF
+		case err != nil:
+			r.err = err
+			return 0, err
+		}
+		if n == 0 {
+			return 0, r.err
+		}
+		r.perm.unshuffle(r.out[:n], r.block[:n], r.index)
+		r.index++
+		r.pending = r.out[:n]
+	}
+	n := copy(out, r.pending)
+	r.pending = r.pending[n:]
+	return n, nil
+}
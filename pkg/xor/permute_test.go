@@ -0,0 +1,105 @@
+package xor
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io"
+	"slices"
+	"testing"
+	"testing/iotest"
+)
+
+func TestPermuted(t *testing.T) {
+	key := []byte{0xde, 0xad, 0xbe, 0xef, 0x01}
+	const blockSize = 16
+	text := bytes.Repeat([]byte("A string with some text. "), 10)
+
+	for _, size := range []int{0, 1, blockSize - 1, blockSize, blockSize + 1, len(text)} {
+		t.Run(fmt.Sprintf("size=%d", size), func(t *testing.T) {
+			data := text[:size]
+			var permuted bytes.Buffer
+			w, err := NewPermutedWriter(&permuted, key, blockSize, WithOffset(2))
+			require.NoError(t, err)
+			for i := 0; i < len(data); i += 7 {
+				_, err := w.Write(data[i:min(i+7, len(data))])
+				require.NoError(t, err)
+			}
+			require.NoError(t, w.Close())
+			require.Equal(t, len(data), permuted.Len())
+
+			screened := referenceScreen(data, key, 2)
+			for i := 0; i < len(data); i += blockSize {
+				expected := slices.Clone(screened[i:min(i+blockSize, len(data))])
+				got := slices.Clone(permuted.Bytes()[i:min(i+blockSize, len(data))])
+				slices.Sort(expected)
+				slices.Sort(got)
+				assert.Equal(t, expected, got, "Bytes should only be shuffled within their block")
+			}
+			if size > blockSize {
+				assert.NotEqual(t, screened, permuted.Bytes())
+			}
+
+			r, err := NewPermutedReader(iotest.OneByteReader(bytes.NewReader(permuted.Bytes())), key, blockSize, WithOffset(2))
+			require.NoError(t, err)
+			got, err := io.ReadAll(r)
+			require.NoError(t, err)
+			assert.Equal(t, string(data), string(got))
+		})
+	}
+}
+
+func TestPermuted_BlocksDiffer(t *testing.T) {
+	key := []byte{0xde, 0xad, 0xbe, 0xef}
+	p, err := newPermuter(key, 64)
+	require.NoError(t, err)
+	first := slices.Clone(p.permutation(0, 64))
+	assert.NotEqual(t, first, p.permutation(1, 64), "Each block should use a different permutation")
+	assert.Equal(t, first, p.permutation(0, 64), "Permutations should be reproducible")
+}
+
+func TestPermuted_Neg(t *testing.T) {
+	key := []byte{0xde, 0xad, 0xbe, 0xef}
+	for _, size := range []int{-1, 0, 1, MaxPermuteBlockSize + 1} {
+		_, err := NewPermutedWriter(io.Discard, key, size)
+		assert.Error(t, err, "Block size %d should be rejected", size)
+		_, err = NewPermutedReader(bytes.NewReader(nil), key, size)
+		assert.Error(t, err, "Block size %d should be rejected", size)
+	}
+	_, err := NewPermutedWriter(io.Discard, nil, 16)
+	assert.Error(t, err)
+
+	w, err := NewPermutedWriter(io.Discard, key, 16)
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+	_, err = w.Write([]byte("data"))
+	assert.Error(t, err, "Writes after close should fail")
+
+	failErr := errors.New("fail")
+	w, err = NewPermutedWriter(&shortWriter{limit: 2}, key, 4)
+	require.NoError(t, err)
+	_, err = w.Write([]byte("A string with some text"))
+	assert.Error(t, err)
+	_, err = w.Write([]byte("more"))
+	assert.Error(t, err, "Write errors should be sticky")
+
+	r, err := NewPermutedReader(iotest.ErrReader(failErr), key, 16)
+	require.NoError(t, err)
+	_, err = io.ReadAll(r)
+	assert.ErrorIs(t, err, failErr)
+
+	var permuted bytes.Buffer
+	w, err = NewPermutedWriter(&permuted, key, 16)
+	require.NoError(t, err)
+	data := []byte("A string with some text, that spans a few blocks")
+	_, err = w.Write(data)
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+	r, err = NewPermutedReader(bytes.NewReader(permuted.Bytes()), key, 8)
+	require.NoError(t, err)
+	got, err := io.ReadAll(r)
+	require.NoError(t, err)
+	assert.NotEqual(t, data, got, "A different block size shouldn't recover the data")
+}
@@ -0,0 +1,72 @@
+package xor
+
+import (
+	"bytes"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io"
+	"testing"
+)
+
+func TestWithStartPosition(t *testing.T) {
+	key := []byte{0xde, 0xad, 0xbe, 0xef, 0x01}
+	data := []byte("A string with some text that spans more than a single ChaCha20 block of sixty four bytes")
+	const start = 70
+
+	tests := map[string][]ScreenOpt{
+		"Repeating key": {WithOffset(2)},
+		"Keystream":     {WithOffset(2), WithKeystream()},
+	}
+	for name, opts := range tests {
+		t.Run(name, func(t *testing.T) {
+			var full bytes.Buffer
+			w, err := NewWriterWith(&full, key, opts...)
+			require.NoError(t, err)
+			_, err = w.Write(data)
+			require.NoError(t, err)
+
+			var resumed bytes.Buffer
+			w, err = NewWriterWith(&resumed, key, append(opts, WithStartPosition(start))...)
+			require.NoError(t, err)
+			_, err = w.Write(data[start:])
+			require.NoError(t, err)
+			assert.Equal(t, full.Bytes()[start:], resumed.Bytes())
+
+			r, err := NewReaderWith(bytes.NewReader([]byte{}), key, append(opts, WithStartPosition(start))...)
+			require.NoError(t, err)
+			r.Reset(bytes.NewReader(full.Bytes()[start:]))
+			got, err := io.ReadAll(r)
+			require.NoError(t, err)
+			assert.Equal(t, data[start:], got, "Reset should return to the start position")
+		})
+	}
+}
+
+func TestXorScreen_LargePositions(t *testing.T) {
+	key := []byte{0xde, 0xad, 0xbe, 0xef, 0x01, 0x02, 0x03}
+	scr, err := newXorScreen(key, 3)
+	require.NoError(t, err)
+
+	positions := []uint64{0, 1 << 31, 1<<32 + 5, 1<<40 + 11, 1<<63 + 1}
+	for _, pos := range positions {
+		assert.Equal(t, int((3+pos%7)%7), scr.keyIndex(pos), "Key index should be consistent for position %d", pos)
+	}
+
+	scr.seek(1<<40 + 11)
+	data := []byte("some text")
+	scr.xor(data, data)
+	assert.Equal(t, uint64(1<<40+11)+uint64(len(data)), scr.pos)
+	scr.rewind(4)
+	assert.Equal(t, uint64(1<<40+11)+uint64(len(data))-4, scr.pos)
+	assert.Equal(t, scr.keyIndex(scr.pos), scr.cur)
+}
+
+func TestWithStartPosition_Neg(t *testing.T) {
+	key := []byte{0xde, 0xad}
+	_, err := NewWriterWith(io.Discard, key, WithFeedback(), WithStartPosition(1))
+	assert.Error(t, err)
+	_, err = NewWriterWith(io.Discard, key, WithKeystream(), WithStartPosition(keystreamLimit))
+	assert.Error(t, err)
+	_, err = NewWriterWith(io.Discard, key, WithFeedback(), WithStartPosition(0))
+	assert.NoError(t, err)
+}
@@ -0,0 +1,138 @@
+package xor
+
+import (
+	"fmt"
+	"math"
+)
+
+// Range is a range of absolute positions in a stream, from Start up to but not including End.
+// An End of 0 means that the range continues to the end of the stream.
+type Range struct {
+	Start uint64
+	End   uint64
+}
+
+func (r Range) end() uint64 {
+	if r.End == 0 {
+		return math.MaxUint64
+	}
+	return r.End
+}
+
+// WithRanges will only screen bytes within the given ranges, and pass other bytes through unchanged.
+// This allows obfuscating a payload body while leaving something like a plaintext magic header readable, such as with WithRanges(Range{Start: 8}).
+// Ranges must be in ascending order and may not overlap. Only the last range may continue to the end of the stream.
+//
+// The key is applied as if the bytes in every range were contiguous, so bytes outside of ranges don't advance the key position.
+// This may not be used with WithFeedback.
+func WithRanges(ranges ...Range) ScreenOpt {
+	return func(conf *screenConfig) error {
+		var prevEnd uint64
+		for i, r := range ranges {
+			if r.End != 0 && r.End <= r.Start {
+				return fmt.Errorf("range %d ends before it starts", i)
+			}
+			if i > 0 && r.Start < prevEnd {
+				return fmt.Errorf("range %d overlaps or is before the previous range", i)
+			}
+			if r.End == 0 && i < len(ranges)-1 {
+				return fmt.Errorf("only the last range may continue to the end of the stream")
+			}
+			prevEnd = r.end()
+		}
+		conf.ranges = append([]Range{}, ranges...)
+		return nil
+	}
+}
+
+var _ screener = (*rangeScreen)(nil)
+
+// rangeScreen only applies an inner screener to bytes within its ranges.
+type rangeScreen struct {
+	inner  screener
+	ranges []Range
+	start  uint64
+	pos    uint64
+}
+
+func newRangeScreen(inner screener, ranges []Range) *rangeScreen {
+	return &rangeScreen{
+		inner:  inner,
+		ranges: ranges,
+	}
+}
+
+// segment returns whether pos is within a range, and where the run of screened or unscreened bytes containing pos ends.
+func (s *rangeScreen) segment(pos uint64) (screened bool, end uint64) {
+	for _, r := range s.ranges {
+		if pos < r.Start {
+			return false, r.Start
+		}
+		if pos < r.end() {
+			return true, r.end()
+		}
+	}
+	return false, math.MaxUint64
+}
+
+// screenedBefore returns the number of bytes within ranges before pos.
+func (s *rangeScreen) screenedBefore(pos uint64) uint64 {
+	var total uint64
+	for _, r := range s.ranges {
+		if pos <= r.Start {
+			break
+		}
+		total += min(pos, r.end()) - r.Start
+	}
+	return total
+}
+
+func (s *rangeScreen) xor(dst, src []byte) {
+	for len(src) > 0 {
+		screened, end := s.segment(s.pos)
+		n := len(src)
+		if remaining := end - s.pos; uint64(n) > remaining {
+			n = int(remaining)
+		}
+		if screened {
+			s.inner.xor(dst[:n], src[:n])
+		} else {
+			copy(dst[:n], src[:n])
+		}
+		dst, src = dst[n:], src[n:]
+		s.pos += uint64(n)
+	}
+}
+
+func (s *rangeScreen) rewind(n int) {
+	pos := s.pos - uint64(n)
+	s.inner.rewind(int(s.screenedBefore(s.pos) - s.screenedBefore(pos)))
+	s.pos = pos
+}
+
+// remaining is limited by the inner screener, which only screens the bytes within ranges.
+func (s *rangeScreen) remaining() uint64 {
+	left := s.inner.remaining()
+	for _, r := range s.ranges {
+		if r.end() <= s.pos {
+			continue
+		}
+		start := max(s.pos, r.Start)
+		if r.end()-start > left {
+			return start + left - s.pos
+		}
+		left -= r.end() - start
+	}
+	return math.MaxUint64
+}
+
+func (s *rangeScreen) reset() {
+	s.inner.reset()
+	s.pos = s.start
+}
+
+func (s *rangeScreen) setStart(pos uint64) {
+	s.inner.setStart(s.screenedBefore(pos))
+	s.start = pos
+	s.pos = pos
+}
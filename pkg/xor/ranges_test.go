@@ -0,0 +1,119 @@
+package xor
+
+import (
+	"bytes"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io"
+	"math"
+	"testing"
+	"testing/iotest"
+)
+
+// referenceRanges screens only the bytes within ranges, as if they were contiguous.
+func referenceRanges(data, key []byte, offset int, ranges []Range) []byte {
+	out := bytes.Clone(data)
+	var screened []byte
+	for _, r := range ranges {
+		screened = append(screened, data[r.Start:min(r.end(), uint64(len(data)))]...)
+	}
+	screened = referenceScreen(screened, key, offset)
+	for _, r := range ranges {
+		n := copy(out[r.Start:min(r.end(), uint64(len(data)))], screened)
+		screened = screened[n:]
+	}
+	return out
+}
+
+func TestWithRanges(t *testing.T) {
+	key := []byte{0xde, 0xad, 0xbe, 0xef, 0x01}
+	data := []byte("MAGIC123 A string with some text, and some more text after that")
+	ranges := []Range{{Start: 8, End: 20}, {Start: 25, End: 30}, {Start: 40}}
+	expected := referenceRanges(data, key, 1, ranges)
+	assert.Equal(t, data[:8], expected[:8], "Bytes outside of ranges should be unchanged")
+
+	var screened bytes.Buffer
+	w, err := NewWriterWith(&screened, key, WithOffset(1), WithRanges(ranges...))
+	require.NoError(t, err)
+	for i := 0; i < len(data); i += 7 {
+		_, err := w.Write(data[i:min(i+7, len(data))])
+		require.NoError(t, err)
+	}
+	assert.Equal(t, expected, screened.Bytes())
+
+	r, err := NewReaderWith(iotest.OneByteReader(bytes.NewReader(expected)), key, WithOffset(1), WithRanges(ranges...), ExpectMagic([]byte("MAGIC")))
+	require.NoError(t, err)
+	got, err := io.ReadAll(r)
+	require.NoError(t, err)
+	assert.Equal(t, data, got)
+
+	r, err = NewReaderWith(bytes.NewReader(expected[22:]), key, WithOffset(1), WithRanges(ranges...), WithStartPosition(22))
+	require.NoError(t, err)
+	got, err = io.ReadAll(r)
+	require.NoError(t, err)
+	assert.Equal(t, data[22:], got, "Ranges should be applied at the start position")
+}
+
+func TestWithRanges_ShortWrite(t *testing.T) {
+	key := []byte{0xde, 0xad, 0xbe, 0xef, 0x01}
+	data := bytes.Repeat([]byte("A string with some text"), 5)
+	ranges := []Range{{Start: 3, End: 10}, {Start: 30, End: 31}, {Start: 50}}
+
+	tests := map[string][]ScreenOpt{
+		"Repeating key": {WithRanges(ranges...)},
+		"Keystream":     {WithRanges(ranges...), WithKeystream()},
+	}
+	for name, opts := range tests {
+		t.Run(name, func(t *testing.T) {
+			var expected bytes.Buffer
+			w, err := NewWriterWith(&expected, key, opts...)
+			require.NoError(t, err)
+			_, err = w.Write(data)
+			require.NoError(t, err)
+
+			target := &shortWriter{limit: 13}
+			w, err = NewWriterWith(target, key, opts...)
+			require.NoError(t, err)
+			written := 0
+			for written < len(data) {
+				n, _ := w.Write(data[written:])
+				written += n
+			}
+			assert.Equal(t, expected.Bytes(), target.Bytes())
+		})
+	}
+}
+
+func TestRangeScreen_Remaining(t *testing.T) {
+	inner, err := newKeystreamScreen([]byte("some key"), 0)
+	require.NoError(t, err)
+	inner.setStart(keystreamLimit - 5)
+	scr := newRangeScreen(inner, []Range{{Start: 10, End: 13}, {Start: 20}})
+	assert.Equal(t, uint64(22), scr.remaining(), "Only bytes within ranges should use the key stream")
+
+	data := make([]byte, 22)
+	scr.xor(data, data)
+	assert.Zero(t, scr.remaining())
+
+	unlimited := newRangeScreen(&xorScreen{}, []Range{{Start: 10}})
+	assert.Equal(t, uint64(math.MaxUint64), unlimited.remaining())
+}
+
+func TestWithRanges_Neg(t *testing.T) {
+	key := []byte{0xde, 0xad}
+	tests := map[string][]ScreenOpt{
+		"Empty range":        {WithRanges(Range{Start: 5, End: 5})},
+		"Overlapping ranges": {WithRanges(Range{Start: 0, End: 5}, Range{Start: 4, End: 8})},
+		"Descending ranges":  {WithRanges(Range{Start: 10, End: 15}, Range{Start: 0, End: 5})},
+		"Open range first":   {WithRanges(Range{Start: 0}, Range{Start: 10, End: 15})},
+		"Feedback mode":      {WithRanges(Range{Start: 0, End: 5}), WithFeedback()},
+	}
+	for name, opts := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := NewWriterWith(io.Discard, key, opts...)
+			assert.Error(t, err)
+		})
+	}
+	_, err := WriteHeader(io.Discard, key, WithRanges(Range{Start: 8}))
+	assert.Error(t, err, "Ranges can't be recorded in a header")
+}
@@ -1,39 +1,119 @@
 package xor
 
 import (
+	"crypto/subtle"
 	"errors"
 	"fmt"
+	"math"
+)
+
+const (
+	// streamSize is the minimum length of a key stream, which is a key repeated a whole number of times.
+	// Short keys are expanded to about this length, so each XOR operation covers many bytes instead of a single key cycle.
+	streamSize = 256
 )
 
 type xorScreen struct {
-	key  []byte
-	init int
-	cur  int
+	key    []byte
+	stream []byte
+	init   int
+	cur    int
+	// start is the absolute position that the screen returns to on reset, and pos is the current absolute position in the screened data.
+	start uint64
+	pos   uint64
 }
 
 func newXorScreen(key []byte, offset ...int) (*xorScreen, error) {
+	var init int
+	if len(offset) > 0 {
+		init = offset[0]
+	}
+	if err := validateKeyOffset(key, init); err != nil {
+		return nil, err
+	}
+	return &xorScreen{
+		key:    key,
+		stream: expandKey(make([]byte, 0, streamSize), key),
+		init:   init,
+		cur:    init,
+	}, nil
+}
+
+func validateKeyOffset(key []byte, offset int) error {
 	if len(key) == 0 {
-		return nil, errors.New("cannot use empty key")
+		return errors.New("cannot use empty key")
 	}
-	s := &xorScreen{
-		key: key,
+	if offset < 0 || offset >= len(key) {
+		return fmt.Errorf("offset %d out of range for provided key of len %d", offset, len(key))
 	}
-	if len(offset) > 0 {
-		if offset[0] < 0 || offset[0] >= len(key) {
-			return nil, fmt.Errorf("offset %d out of range for provided key of len %d", offset, len(key))
-		}
-		s.init = offset[0]
-		s.cur = s.init
+	return nil
+}
+
+// expandKey appends the key to buf as many times as it fits within the capacity of buf, and returns the resulting key stream.
+// If the key is at least as long as the capacity of buf, then the key is returned as-is.
+func expandKey(buf []byte, key []byte) []byte {
+	if len(key) >= cap(buf) {
+		return key
+	}
+	buf = buf[:0]
+	for len(buf)+len(key) <= cap(buf) {
+		buf = append(buf, key...)
+	}
+	return buf
+}
+
+// xorStream XORs src with the key stream starting at key position cur, writes the result to dst, and returns the next key position.
+// The stream must be the key repeated a whole number of times, so every key position has a contiguous run of key bytes after it.
+// This uses subtle.XORBytes, which operates on a machine word or vector register at a time instead of a byte at a time.
+func xorStream(dst, src, stream []byte, keyLen int, cur int) int {
+	for len(src) > 0 {
+		n := subtle.XORBytes(dst, src, stream[cur:])
+		dst, src = dst[n:], src[n:]
+		cur = (cur + n) % keyLen
 	}
-	return s, nil
+	return cur
+}
+
+// xor screens src into dst, which may be the same slice, and advances the key position.
+func (s *xorScreen) xor(dst, src []byte) {
+	s.cur = xorStream(dst, src, s.stream, len(s.key), s.cur)
+	s.pos += uint64(len(src))
 }
 
-func (s *xorScreen) screen(b byte) byte {
-	b ^= s.key[s.cur]
-	s.cur = (s.cur + 1) % len(s.key)
-	return b
+// rewind moves the key position back by n bytes.
+func (s *xorScreen) rewind(n int) {
+	s.cur = (s.cur + len(s.key) - n%len(s.key)) % len(s.key)
+	s.pos -= uint64(n)
 }
 
 func (s *xorScreen) reset() {
-	s.cur = s.init
+	s.seek(s.start)
+}
+
+func (s *xorScreen) remaining() uint64 {
+	return math.MaxUint64
+}
+
+func (s *xorScreen) setStart(pos uint64) {
+	s.start = pos
+	s.seek(pos)
+}
+
+// seek moves the screen to the key position for the given absolute position in the screened data.
+func (s *xorScreen) seek(pos uint64) {
+	s.cur = s.keyIndex(pos)
+	s.pos = pos
+}
+
+// keyIndex returns the key position for the given absolute position in the screened data.
+// Positions are 64-bit on every platform, so this is consistent for very large streams on 32-bit platforms.
+func (s *xorScreen) keyIndex(pos uint64) int {
+	keyLen := uint64(len(s.key))
+	return int((uint64(s.init) + pos%keyLen) % keyLen)
+}
+
+// screenAt screens data in place as if it started at the given absolute position, without changing the current key position.
+// This is safe for concurrent use, since the screen is not modified.
+func (s *xorScreen) screenAt(data []byte, pos uint64) {
+	xorStream(data, data, s.stream, len(s.key), s.keyIndex(pos))
 }
@@ -1,7 +1,12 @@
 package xor
 
 import (
+	"bytes"
+	"crypto/rand"
+	"fmt"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io"
 	"testing"
 )
 
@@ -15,3 +20,90 @@ func TestNewXorScreenNeg(t *testing.T) {
 	_, err = newXorScreen([]byte{0}, 2)
 	assert.Error(t, err)
 }
+
+// referenceScreen is the byte at a time implementation that xorStream must match.
+func referenceScreen(data, key []byte, offset int) []byte {
+	out := make([]byte, len(data))
+	for i := range data {
+		out[i] = data[i] ^ key[(offset+i)%len(key)]
+	}
+	return out
+}
+
+func TestXorScreen_MatchesReference(t *testing.T) {
+	data := make([]byte, 3*streamSize+17)
+	_, err := rand.Read(data)
+	require.NoError(t, err)
+
+	for _, keyLen := range []int{1, 3, 8, 13, streamSize - 1, streamSize, streamSize + 1, 1000} {
+		key := make([]byte, keyLen)
+		_, err := rand.Read(key)
+		require.NoError(t, err)
+		for _, offset := range []int{0, keyLen / 2, keyLen - 1} {
+			expected := referenceScreen(data, key, offset)
+			scr, err := newXorScreen(key, offset)
+			require.NoError(t, err)
+
+			// Screen in uneven pieces to exercise key position tracking across calls.
+			got := make([]byte, len(data))
+			for i, step := 0, 1; i < len(data); i, step = i+step, step*2+1 {
+				end := min(i+step, len(data))
+				scr.xor(got[i:end], data[i:end])
+			}
+			assert.Equal(t, expected, got, "Key length %d, offset %d", keyLen, offset)
+
+			inPlace := append([]byte{}, data...)
+			require.NoError(t, Screen(inPlace, key, offset))
+			assert.Equal(t, expected, inPlace, "Key length %d, offset %d", keyLen, offset)
+		}
+	}
+}
+
+func BenchmarkScreen(b *testing.B) {
+	data := make([]byte, 1<<20)
+	for _, keyLen := range []int{4, 32, 1024} {
+		key := make([]byte, keyLen)
+		_, err := rand.Read(key)
+		require.NoError(b, err)
+		b.Run(fmt.Sprintf("key=%d", keyLen), func(b *testing.B) {
+			b.SetBytes(int64(len(data)))
+			for i := 0; i < b.N; i++ {
+				_ = Screen(data, key, 1)
+			}
+		})
+	}
+}
+
+func BenchmarkReader(b *testing.B) {
+	data := make([]byte, 1<<20)
+	key := make([]byte, 32)
+	_, err := rand.Read(key)
+	require.NoError(b, err)
+	src := bytes.NewReader(data)
+	r, err := NewReader(src, key, 1)
+	require.NoError(b, err)
+	b.SetBytes(int64(len(data)))
+	for i := 0; i < b.N; i++ {
+		src.Reset(data)
+		r.Reset(src)
+		if _, err := io.Copy(io.Discard, r); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkWriter(b *testing.B) {
+	data := make([]byte, 1<<20)
+	key := make([]byte, 32)
+	_, err := rand.Read(key)
+	require.NoError(b, err)
+	w, err := NewWriter(io.Discard, key, 1)
+	require.NoError(b, err)
+	b.SetBytes(int64(len(data)))
+	for i := 0; i < b.N; i++ {
+		w.Reset(io.Discard)
+		if _, err := io.Copy(w, bytes.NewReader(data)); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
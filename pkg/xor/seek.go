@@ -0,0 +1,62 @@
+package xor
+
+import (
+	"io"
+)
+
+// ReadSeeker is a Reader that also supports random access within the screened source.
+// This allows screened files to be read from any position, such as when serving HTTP range requests, without unscreening everything before it.
+type ReadSeeker interface {
+	io.ReadSeeker
+	// Reset will use the provided io.ReadSeeker, and synchronize the position within the key with the source's current position.
+	Reset(source io.ReadSeeker) error
+}
+
+var _ ReadSeeker = (*readSeeker)(nil)
+
+type readSeeker struct {
+	source io.ReadSeeker
+	scr    *xorScreen
+}
+
+// NewReadSeeker constructs a new ReadSeeker that will perform XOR operations on all bytes read, using the provided key, starting at offset.
+// Positions are absolute within the source, so the key offset applies to position 0 of the source regardless of its current position.
+func NewReadSeeker(source io.ReadSeeker, key []byte, offset ...int) (ReadSeeker, error) {
+	scr, err := newXorScreen(key, offset...)
+	if err != nil {
+		return nil, err
+	}
+	rs := &readSeeker{
+		scr: scr,
+	}
+	if err := rs.Reset(source); err != nil {
+		return nil, err
+	}
+	return rs, nil
+}
+
+func (r *readSeeker) Read(out []byte) (n int, err error) {
+	n, err = r.source.Read(out)
+	r.scr.xor(out[:n], out[:n])
+	return n, err
+}
+
+// Seek will seek within the source, and move to the matching position within the key.
+func (r *readSeeker) Seek(offset int64, whence int) (int64, error) {
+	pos, err := r.source.Seek(offset, whence)
+	if err != nil {
+		return pos, err
+	}
+	r.scr.seek(uint64(pos))
+	return pos, nil
+}
+
+func (r *readSeeker) Reset(source io.ReadSeeker) error {
+	pos, err := source.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return err
+	}
+	r.source = source
+	r.scr.seek(uint64(pos))
+	return nil
+}
@@ -0,0 +1,105 @@
+package xor
+
+import (
+	"bytes"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestReadSeeker_Seek(t *testing.T) {
+	var (
+		data = []byte("A string with some text that is longer than the key")
+		key  = []byte{0xde, 0xad, 0xbe, 0xef, 0x01}
+	)
+	screened, err := TransformBytes(data, key, 3)
+	require.NoError(t, err)
+
+	rs, err := NewReadSeeker(bytes.NewReader(screened), key, 3)
+	require.NoError(t, err)
+
+	tests := map[string]struct {
+		offset   int64
+		whence   int
+		expected int64
+	}{
+		"Start":          {0, io.SeekStart, 0},
+		"Middle":         {13, io.SeekStart, 13},
+		"Key boundary":   {int64(len(key)) * 2, io.SeekStart, int64(len(key)) * 2},
+		"From end":       {-9, io.SeekEnd, int64(len(data)) - 9},
+		"End":            {0, io.SeekEnd, int64(len(data))},
+		"Past key wraps": {int64(len(key))*3 + 2, io.SeekStart, int64(len(key))*3 + 2},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			pos, err := rs.Seek(tc.offset, tc.whence)
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, pos)
+			got, err := io.ReadAll(rs)
+			require.NoError(t, err)
+			assert.Equal(t, data[pos:], got)
+		})
+	}
+
+	_, err = rs.Seek(20, io.SeekStart)
+	require.NoError(t, err)
+	pos, err := rs.Seek(-5, io.SeekCurrent)
+	require.NoError(t, err)
+	assert.Equal(t, int64(15), pos)
+	buf := make([]byte, 4)
+	_, err = io.ReadFull(rs, buf)
+	require.NoError(t, err)
+	assert.Equal(t, data[15:19], buf)
+
+	_, err = rs.Seek(-1, io.SeekStart)
+	assert.Error(t, err)
+}
+
+func TestReadSeeker_Reset(t *testing.T) {
+	var (
+		data = []byte("A string with some text")
+		key  = []byte{0xde, 0xad, 0xbe, 0xef}
+	)
+	screened, err := TransformBytes(data, key, 1)
+	require.NoError(t, err)
+
+	source := bytes.NewReader(screened)
+	_, err = source.Seek(6, io.SeekStart)
+	require.NoError(t, err)
+	rs, err := NewReadSeeker(source, key, 1)
+	require.NoError(t, err)
+	got, err := io.ReadAll(rs)
+	require.NoError(t, err)
+	assert.Equal(t, data[6:], got, "The source's current position should be respected")
+
+	require.NoError(t, rs.Reset(bytes.NewReader(screened)))
+	got, err = io.ReadAll(rs)
+	require.NoError(t, err)
+	assert.Equal(t, data, got)
+
+	_, err = NewReadSeeker(bytes.NewReader(screened), nil)
+	assert.Error(t, err)
+}
+
+func TestReadSeeker_ServeContent(t *testing.T) {
+	var (
+		data = []byte(strings.Repeat("Range requests should unscreen from any position. ", 10))
+		key  = []byte{0xde, 0xad, 0xbe, 0xef, 0x01, 0x02, 0x03}
+	)
+	screened, err := TransformBytes(data, key, 2)
+	require.NoError(t, err)
+	rs, err := NewReadSeeker(bytes.NewReader(screened), key, 2)
+	require.NoError(t, err)
+
+	req := httptest.NewRequest(http.MethodGet, "/data.txt", nil)
+	req.Header.Set("Range", "bytes=100-149")
+	rec := httptest.NewRecorder()
+	http.ServeContent(rec, req, "data.txt", time.Time{}, rs)
+	assert.Equal(t, http.StatusPartialContent, rec.Code)
+	assert.Equal(t, data[100:150], rec.Body.Bytes())
+}
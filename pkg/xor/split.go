@@ -0,0 +1,46 @@
+package xor
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+)
+
+// ScreenSplit wraps a bufio.SplitFunc so a bufio.Scanner can scan a screened source directly.
+// Bytes are unscreened in place before the wrapped SplitFunc sees them, so tokens are returned unscreened, and the source doesn't need to be fully buffered.
+// The returned SplitFunc tracks the key position across calls, so it must only be used with a single bufio.Scanner.
+func ScreenSplit(split bufio.SplitFunc, key []byte, opts ...ScreenOpt) (bufio.SplitFunc, error) {
+	if split == nil {
+		return nil, errors.New("nil split function")
+	}
+	conf, err := newScreenConfig(opts...)
+	if err != nil {
+		return nil, err
+	}
+	if len(conf.magic) > 0 {
+		return nil, fmt.Errorf("%w: ExpectMagic", ErrReaderOnlyOpt)
+	}
+	scr, err := conf.newScreener(key, true)
+	if err != nil {
+		return nil, err
+	}
+	// The Scanner passes unconsumed bytes again along with newly read bytes, so only the new bytes are unscreened.
+	// Bytes past the key stream limit are never unscreened, so the wrapped SplitFunc only sees the bytes before it.
+	var unscreened int
+	return func(data []byte, atEOF bool) (advance int, token []byte, err error) {
+		if len(data) > unscreened {
+			n := screenable(scr, len(data)-unscreened)
+			scr.xor(data[unscreened:unscreened+n], data[unscreened:unscreened+n])
+			unscreened += n
+		}
+		limited := unscreened < len(data)
+		advance, token, err = split(data[:unscreened], atEOF && !limited)
+		if limited && advance == 0 && token == nil && err == nil {
+			return 0, nil, ErrKeystreamLimit
+		}
+		if advance > 0 {
+			unscreened -= min(advance, unscreened)
+		}
+		return advance, token, err
+	}, nil
+}
@@ -0,0 +1,77 @@
+package xor
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestScreenSplit(t *testing.T) {
+	key := []byte{0xde, 0xad, 0xbe, 0xef, 0x01}
+	var lines []string
+	var plain bytes.Buffer
+	for i := 0; i < 200; i++ {
+		line := fmt.Sprintf("log line %d with some text", i)
+		lines = append(lines, line)
+		plain.WriteString(line + "\n")
+	}
+
+	tests := map[string][]ScreenOpt{
+		"Repeating key": {WithOffset(3)},
+		"Keystream":     {WithOffset(3), WithKeystream()},
+	}
+	for name, opts := range tests {
+		t.Run(name, func(t *testing.T) {
+			var screened bytes.Buffer
+			w, err := NewWriterWith(&screened, key, opts...)
+			require.NoError(t, err)
+			_, err = w.Write(plain.Bytes())
+			require.NoError(t, err)
+
+			split, err := ScreenSplit(bufio.ScanLines, key, opts...)
+			require.NoError(t, err)
+			scanner := bufio.NewScanner(&screened)
+			// A small buffer forces the Scanner to present unconsumed bytes more than once.
+			scanner.Buffer(make([]byte, 0, 16), 64)
+			scanner.Split(split)
+			var got []string
+			for scanner.Scan() {
+				got = append(got, scanner.Text())
+			}
+			require.NoError(t, scanner.Err())
+			assert.Equal(t, lines, got)
+		})
+	}
+}
+
+func TestScreenSplit_KeystreamLimit(t *testing.T) {
+	key := []byte{0xde, 0xad, 0xbe, 0xef, 0x01}
+	opts := []ScreenOpt{WithKeystream(), WithStartPosition(keystreamLimit - 10)}
+	var screened bytes.Buffer
+	w, err := NewWriterWith(&screened, key, opts...)
+	require.NoError(t, err)
+	_, err = w.Write([]byte("line 1\nline 2\n"))
+	assert.ErrorIs(t, err, ErrKeystreamLimit)
+	screened.WriteString("more")
+
+	split, err := ScreenSplit(bufio.ScanLines, key, opts...)
+	require.NoError(t, err)
+	scanner := bufio.NewScanner(&screened)
+	scanner.Split(split)
+	require.True(t, scanner.Scan())
+	assert.Equal(t, "line 1", scanner.Text(), "Tokens before the limit should still be returned")
+	assert.False(t, scanner.Scan())
+	assert.ErrorIs(t, scanner.Err(), ErrKeystreamLimit)
+}
+
+func TestScreenSplit_Neg(t *testing.T) {
+	_, err := ScreenSplit(nil, []byte{0x1})
+	assert.Error(t, err)
+	_, err = ScreenSplit(bufio.ScanLines, nil)
+	assert.Error(t, err)
+	_, err = ScreenSplit(bufio.ScanLines, []byte{0x1}, ExpectMagic(MagicGzip))
+	assert.ErrorIs(t, err, ErrReaderOnlyOpt)
+}
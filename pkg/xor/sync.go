@@ -0,0 +1,66 @@
+package xor
+
+import (
+	"io"
+	"sync"
+)
+
+// ScreenAt applies the XOR screen to data in place as if it started at the given absolute position in the screened stream, using the key starting at offset.
+// This is stateless, so it's safe to use concurrently with a shared key, and the result is the same as screening the whole stream with NewWriter and the same key and offset.
+// Like Screen, this doesn't allocate.
+func ScreenAt[T ~[]byte](data T, key []byte, offset int, pos uint64) error {
+	if err := validateKeyOffset(key, offset); err != nil {
+		return err
+	}
+	var buf [streamSize]byte
+	keyLen := uint64(len(key))
+	xorStream(data, data, expandKey(buf[:0], key), len(key), int((uint64(offset)+pos%keyLen)%keyLen))
+	return nil
+}
+
+// KeyByteAt returns the key byte that is XORed with the byte at the given absolute position in the screened stream, using the key starting at offset.
+func KeyByteAt(key []byte, offset int, pos uint64) (byte, error) {
+	if err := validateKeyOffset(key, offset); err != nil {
+		return 0, err
+	}
+	keyLen := uint64(len(key))
+	return key[(uint64(offset)+pos%keyLen)%keyLen], nil
+}
+
+var _ Writer = (*syncWriter)(nil)
+
+type syncWriter struct {
+	mux sync.Mutex
+	w   Writer
+}
+
+// NewSyncWriter wraps a Writer so that it may be shared between goroutines.
+// Each call to Write is screened and written as a unit, so data from concurrent calls is never interleaved within a call.
+func NewSyncWriter(w Writer) Writer {
+	return &syncWriter{w: w}
+}
+
+func (s *syncWriter) Write(in []byte) (int, error) {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	return s.w.Write(in)
+}
+
+func (s *syncWriter) WriteByte(b byte) error {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	return s.w.WriteByte(b)
+}
+
+// ReadFrom implements io.ReaderFrom, holding the lock until the io.Reader is exhausted.
+func (s *syncWriter) ReadFrom(r io.Reader) (int64, error) {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	return io.Copy(s.w, r)
+}
+
+func (s *syncWriter) Reset(target io.Writer) {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	s.w.Reset(target)
+}
@@ -0,0 +1,95 @@
+package xor
+
+import (
+	"bytes"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"sync"
+	"testing"
+)
+
+func TestScreenAt(t *testing.T) {
+	key := []byte{0xde, 0xad, 0xbe, 0xef, 0x01}
+	data := []byte("A string with some text")
+	expected := referenceScreen(data, key, 2)
+
+	for pos := 0; pos < len(data); pos += 5 {
+		chunk := bytes.Clone(data[pos:min(pos+5, len(data))])
+		require.NoError(t, ScreenAt(chunk, key, 2, uint64(pos)))
+		assert.Equal(t, expected[pos:pos+len(chunk)], chunk)
+
+		b, err := KeyByteAt(key, 2, uint64(pos))
+		require.NoError(t, err)
+		assert.Equal(t, expected[pos], data[pos]^b)
+	}
+
+	allocs := testing.AllocsPerRun(10, func() {
+		_ = ScreenAt(data, key, 2, 1<<40)
+	})
+	assert.Zero(t, allocs)
+
+	assert.Error(t, ScreenAt(data, key, len(key), 0))
+	_, err := KeyByteAt(nil, 0, 0)
+	assert.Error(t, err)
+}
+
+func TestSyncWriter(t *testing.T) {
+	key := []byte{0xde, 0xad, 0xbe, 0xef, 0x01}
+	const (
+		goroutines = 8
+		writes     = 100
+	)
+	var out bytes.Buffer
+	w, err := NewWriter(&out, key)
+	require.NoError(t, err)
+	sw := NewSyncWriter(w)
+
+	var wg sync.WaitGroup
+	for i := 0; i < goroutines; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < writes; j++ {
+				_, err := sw.Write([]byte("same text"))
+				assert.NoError(t, err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	unscreened := bytes.Clone(out.Bytes())
+	require.NoError(t, Unscreen(unscreened, key, 0))
+	assert.Equal(t, bytes.Repeat([]byte("same text"), goroutines*writes), unscreened, "The key position should be consistent across goroutines")
+}
+
+// blockingWriter blocks each Write until release is closed.
+type blockingWriter struct {
+	entered chan struct{}
+	release chan struct{}
+}
+
+func (b *blockingWriter) Write(p []byte) (int, error) {
+	b.entered <- struct{}{}
+	<-b.release
+	return len(p), nil
+}
+
+func TestWriter_ConcurrentUse(t *testing.T) {
+	target := &blockingWriter{
+		entered: make(chan struct{}),
+		release: make(chan struct{}),
+	}
+	w, err := NewWriter(target, []byte{0x1})
+	require.NoError(t, err)
+
+	done := make(chan error)
+	go func() {
+		_, err := w.Write([]byte("first"))
+		done <- err
+	}()
+	<-target.entered
+	_, err = w.Write([]byte("second"))
+	assert.ErrorIs(t, err, ErrConcurrentUse)
+	close(target.release)
+	assert.NoError(t, <-done)
+}
@@ -0,0 +1,55 @@
+package xor
+
+import (
+	"strings"
+)
+
+// Screen applies the XOR screen to data in place, using the key starting at offset.
+// Any byte slice type may be used, including named types like passlock.Encrypted, without conversion.
+// This doesn't allocate, so it's well suited to small payloads that are screened frequently.
+func Screen[T ~[]byte](data T, key []byte, offset int) error {
+	if err := validateKeyOffset(key, offset); err != nil {
+		return err
+	}
+	var buf [streamSize]byte
+	xorStream(data, data, expandKey(buf[:0], key), len(key), offset)
+	return nil
+}
+
+// Unscreen is the same as Screen, and is provided so that code reversing a screen reads clearly.
+// The same key and offset used to screen the data must be used.
+func Unscreen[T ~[]byte](data T, key []byte, offset int) error {
+	return Screen(data, key, offset)
+}
+
+// TransformBytes returns a screened copy of data, using the key starting at offset.
+// The input is not modified, and the result has the same type as the input.
+func TransformBytes[T ~[]byte](data T, key []byte, offset int) (T, error) {
+	scr, err := newXorScreen(key, offset)
+	if err != nil {
+		return nil, err
+	}
+	out := make(T, len(data))
+	scr.xor(out, data)
+	return out, nil
+}
+
+// TransformString returns a screened copy of a string, using the key starting at offset.
+// The result is built directly as a string, avoiding an intermediate byte slice copy.
+func TransformString[S ~string](s S, key []byte, offset int) (S, error) {
+	scr, err := newXorScreen(key, offset)
+	if err != nil {
+		return "", err
+	}
+	var (
+		out   strings.Builder
+		chunk [streamSize]byte
+	)
+	out.Grow(len(s))
+	for i := 0; i < len(s); i += len(chunk) {
+		n := copy(chunk[:], s[i:])
+		scr.xor(chunk[:n], chunk[:n])
+		out.Write(chunk[:n])
+	}
+	return S(out.String()), nil
+}
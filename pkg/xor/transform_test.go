@@ -0,0 +1,68 @@
+package xor
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+type namedBytes []byte
+
+type namedString string
+
+func TestScreen(t *testing.T) {
+	key := []byte{0xde, 0xad, 0xbe, 0xef}
+	data := namedBytes("A string with some text")
+	screened := screenBytes(t, data, key, 2)
+
+	require.NoError(t, Screen(data, key, 2))
+	assert.Equal(t, namedBytes(screened), data)
+	require.NoError(t, Unscreen(data, key, 2))
+	assert.Equal(t, namedBytes("A string with some text"), data)
+
+	allocs := testing.AllocsPerRun(10, func() {
+		_ = Screen(data, key, 2)
+	})
+	assert.Zero(t, allocs, "Screening in place shouldn't allocate")
+}
+
+func TestTransformBytes(t *testing.T) {
+	key := []byte{0xde, 0xad, 0xbe, 0xef}
+	data := namedBytes("A string with some text")
+
+	screened, err := TransformBytes(data, key, 1)
+	require.NoError(t, err)
+	assert.Equal(t, namedBytes("A string with some text"), data, "Input should not be modified")
+	assert.Equal(t, screenBytes(t, data, key, 1), []byte(screened))
+
+	unscreened, err := TransformBytes(screened, key, 1)
+	require.NoError(t, err)
+	assert.Equal(t, data, unscreened)
+
+	empty, err := TransformBytes(namedBytes{}, key, 0)
+	require.NoError(t, err)
+	assert.Empty(t, empty)
+}
+
+func TestTransformString(t *testing.T) {
+	key := []byte{0xde, 0xad, 0xbe, 0xef}
+	data := namedString("A string with some text")
+
+	screened, err := TransformString(data, key, 3)
+	require.NoError(t, err)
+	assert.Equal(t, screenBytes(t, []byte(data), key, 3), []byte(screened))
+
+	unscreened, err := TransformString(screened, key, 3)
+	require.NoError(t, err)
+	assert.Equal(t, data, unscreened)
+}
+
+func TestTransform_Neg(t *testing.T) {
+	key := []byte{0xde, 0xad}
+	assert.Error(t, Screen([]byte("data"), nil, 0))
+	assert.Error(t, Screen([]byte("data"), key, 2))
+	_, err := TransformBytes([]byte("data"), key, -1)
+	assert.Error(t, err)
+	_, err = TransformString("data", nil, 0)
+	assert.Error(t, err)
+}
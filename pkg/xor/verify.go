@@ -0,0 +1,75 @@
+package xor
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"unicode"
+	"unicode/utf8"
+)
+
+const (
+	// minPrintableRatio is the fraction of runes that must be printable or whitespace for a sample to look like text.
+	minPrintableRatio = 0.95
+	// maxEntropyRatio is the fraction of the maximum possible entropy that a sample may have to look like structured data.
+	maxEntropyRatio = 0.85
+)
+
+var (
+	ErrLikelyGarbled = errors.New("unscreened data looks garbled, the key or offset may be wrong")
+)
+
+// LikelyUnscreened uses a heuristic to report whether data looks like plaintext, rather than screened or garbled bytes.
+// Data is considered plaintext if it's mostly printable UTF-8 text, or if its byte entropy is well below what random bytes would have.
+// This works best with a sample of at least a few dozen bytes of text or structured data.
+// Compressed or already random plaintext will look garbled, so use ExpectMagic or NewChecksumReader to detect a wrong key for those instead.
+func LikelyUnscreened(data []byte) bool {
+	if len(data) == 0 {
+		return true
+	}
+	if utf8.Valid(data) {
+		var total, printable int
+		for _, r := range string(data) {
+			total++
+			if unicode.IsPrint(r) || unicode.IsSpace(r) {
+				printable++
+			}
+		}
+		if float64(printable) >= minPrintableRatio*float64(total) {
+			return true
+		}
+	}
+	return byteEntropy(data) < maxEntropyRatio*math.Log2(float64(min(len(data), 256)))
+}
+
+// byteEntropy returns the Shannon entropy of the byte values in data, in bits per byte.
+func byteEntropy(data []byte) float64 {
+	var counts [256]int
+	for _, b := range data {
+		counts[b]++
+	}
+	var entropy float64
+	total := float64(len(data))
+	for _, c := range counts {
+		if c == 0 {
+			continue
+		}
+		p := float64(c) / total
+		entropy -= p * math.Log2(p)
+	}
+	return entropy
+}
+
+// Verify unscreens a copy of a screened sample with the key and offset, and returns an error wrapping ErrLikelyGarbled if the result doesn't look like plaintext according to LikelyUnscreened.
+// This catches the silent garbage that a wrong key or offset produces, before the rest of the data is processed.
+// The sample should be taken from the start of the screened data, since that's where the offset applies.
+func Verify(key []byte, offset int, sample []byte) error {
+	unscreened, err := TransformBytes(sample, key, offset)
+	if err != nil {
+		return err
+	}
+	if !LikelyUnscreened(unscreened) {
+		return fmt.Errorf("%w: %d byte sample", ErrLikelyGarbled, len(sample))
+	}
+	return nil
+}
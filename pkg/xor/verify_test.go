@@ -0,0 +1,51 @@
+package xor
+
+import (
+	"bytes"
+	"encoding/binary"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestLikelyUnscreened(t *testing.T) {
+	random, err := GenKey(256)
+	require.NoError(t, err)
+	var structured []byte
+	for i := uint32(0); i < 32; i++ {
+		structured = binary.BigEndian.AppendUint32(structured, i)
+	}
+
+	tests := map[string]struct {
+		data     []byte
+		expected bool
+	}{
+		"Empty":        {nil, true},
+		"Text":         {[]byte("A string with some text,\nand a second line with\ttabs."), true},
+		"Unicode text": {[]byte("Ünïcödé text is still text — isn't it?"), true},
+		"JSON":         {[]byte(`{"name": "value", "list": [1, 2, 3], "nested": {"ok": true}}`), true},
+		"Structured":   {structured, true},
+		"Random":       {random, false},
+		"Random short": {random[:48], false},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, LikelyUnscreened(tc.data))
+		})
+	}
+}
+
+func TestVerify(t *testing.T) {
+	key, offset, err := GenKeyAndOffset(32)
+	require.NoError(t, err)
+	data := bytes.Repeat([]byte("A string with some text. "), 4)
+	screened, err := TransformBytes(data, key, offset)
+	require.NoError(t, err)
+
+	assert.NoError(t, Verify(key, offset, screened))
+	assert.ErrorIs(t, Verify(key, (offset+1)%len(key), screened), ErrLikelyGarbled)
+	otherKey, err := GenKey(32)
+	require.NoError(t, err)
+	assert.ErrorIs(t, Verify(otherKey, 0, screened), ErrLikelyGarbled)
+	assert.Error(t, Verify(nil, 0, screened))
+}
@@ -0,0 +1,17 @@
+package xorgen
+
+// CacheData generates an additional accessor that unscreens the data only once, and a wipe function that zeroes the cached buffer.
+// This is intended for callers that need the plaintext once, usually at startup, and don't want it resident in memory afterward.
+// For an input called "secret.txt", the functions are called cachedSecret_txt and wipeSecret_txt, and they're exposed with ExposeFunctions.
+// The cached accessor returns an error after the wipe function has been called.
+// This can't be used when embedding a directory.
+func CacheData(val ...bool) ParamOpt {
+	return func(params *Params) error {
+		if len(val) > 0 {
+			params.cached = val[0]
+			return nil
+		}
+		params.cached = true
+		return nil
+	}
+}
@@ -0,0 +1,49 @@
+package xorgen
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go/ast"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"testing"
+)
+
+func TestCacheData(t *testing.T) {
+	fset := token.NewFileSet()
+	conf := types.Config{Importer: importer.ForCompiler(fset, "source", nil)}
+	for _, decode := range []string{decodeReader, decodeScreen, decodeLoopMod} {
+		for _, exposed := range []bool{false, true} {
+			t.Run(fmt.Sprintf("%s exposed=%v", decode, exposed), func(t *testing.T) {
+				params, err := buildParams("test.txt", CacheData(), ExposeFunctions(exposed), VaryShape())
+				require.NoError(t, err)
+				params.shape.Decode = decode
+				params.importPaths = params.imports()
+				var buf bytes.Buffer
+				require.NoError(t, renderFile(params, &buf))
+				assertValidSource(t, buf.Bytes())
+				if exposed {
+					assert.Contains(t, buf.String(), "func CachedTest_txt() ([]byte, error)")
+					assert.Contains(t, buf.String(), "func WipeTest_txt()")
+				} else {
+					assert.Contains(t, buf.String(), "func cachedTest_txt() ([]byte, error)")
+					assert.Contains(t, buf.String(), "func wipeTest_txt()")
+				}
+
+				f, err := parser.ParseFile(fset, "generated.go", buf.Bytes(), 0)
+				require.NoError(t, err)
+				_, err = conf.Check(params.packageName, fset, []*ast.File{f}, nil)
+				assert.NoError(t, err, "Generated source should type check:\n%s", buf.Bytes())
+			})
+		}
+	}
+}
+
+func TestCacheData_Dir(t *testing.T) {
+	_, err := buildDirParams("testdata/assets", CacheData())
+	assert.Error(t, err, "A cached accessor can't be generated for a directory")
+}
@@ -0,0 +1,358 @@
+package xorgen
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	_ "embed"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"github.com/saylorsolutions/gocryptx/pkg/xor"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"text/template"
+)
+
+var (
+	//go:embed screen_dir.go.tmpl
+	dirTmplText     string
+	dirTmplTemplate = template.Must(template.New("dir").Parse(dirTmplText))
+)
+
+// dirParams are used to render a file that embeds every file in a directory.
+type dirParams struct {
+	Package         string
+	BuildConstraint string
+	Exposed         bool
+	Dir             string
+	FileMethodName  string
+	Files           []dirFile
+	InputHash       string
+	HTTP            bool
+	Names           declNames
+	Version         string
+	SourceDigest    string
+	SharedKey       bool
+
+	targetFileName string
+	outputDir      string
+	withTest       bool
+	force          bool
+	dryRun         io.Writer
+	report         func(Result)
+	warnings       []string
+	sharedKey      sharedKeyParams
+}
+
+// dirFile is a single screened file within dirParams.
+type dirFile struct {
+	Name           string
+	KeyString      string
+	Offset         int
+	DataString     string
+	Size           int64
+	Compressed     bool
+	Checksum       string
+	ChecksumString string
+	ModTime        int64
+}
+
+// GenerateDir will generate a file embedding every file in the input directory with XOR screening, exposed as an fs.FS.
+// Each file is screened with its own random key, based on the selected key strategy. Data is always embedded as string literals, and shapes aren't varied.
+// Like go:embed, files and directories with names beginning with '.' or '_' are skipped.
+func GenerateDir(dir string, opts ...ParamOpt) error {
+	params, err := buildDirParams(dir, opts...)
+	if err != nil {
+		return err
+	}
+
+	target := filepath.Join(params.outputDir, params.targetFileName+".go")
+	if params.dryRun != nil {
+		if params.SharedKey {
+			if err := writeSharedKey(params.outputDir, params.sharedKey, params.dryRun); err != nil {
+				return err
+			}
+		}
+		err := writeDryRun(params.dryRun, target, func(out io.Writer) error {
+			return renderDir(params, out)
+		})
+		if err == nil && params.withTest {
+			testTarget := filepath.Join(params.outputDir, params.targetFileName+"_test.go")
+			err = writeDryRun(params.dryRun, testTarget, func(out io.Writer) error {
+				return renderTest(roundTripDirTemplate, params, out)
+			})
+		}
+		if err != nil {
+			return err
+		}
+		params.reportResult(target, StatusDryRun)
+		return nil
+	}
+	if !params.force {
+		current, err := upToDate(target, params.InputHash)
+		if err != nil {
+			return err
+		}
+		if current && (!params.withTest || exists(filepath.Join(params.outputDir, params.targetFileName+"_test.go"))) && (!params.SharedKey || exists(filepath.Join(params.outputDir, SharedKeyFileName))) {
+			params.reportResult(target, StatusUpToDate)
+			return nil
+		}
+	}
+	if params.SharedKey {
+		if err := writeSharedKey(params.outputDir, params.sharedKey, nil); err != nil {
+			return err
+		}
+	}
+	out, err := os.Create(target)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = out.Close()
+	}()
+
+	if err := renderDir(params, out); err != nil {
+		return err
+	}
+	if params.withTest {
+		if err := writeTestFile(roundTripDirTemplate, params.outputDir, params.targetFileName, params); err != nil {
+			return err
+		}
+	}
+	params.reportResult(target, StatusGenerated)
+	return nil
+}
+
+func buildDirParams(dir string, opts ...ParamOpt) (*dirParams, error) {
+	params := &Params{
+		compressLevel: gzip.BestCompression,
+		maxInputSize:  DefaultMaxInputSize,
+	}
+	for _, opt := range opts {
+		if err := opt(params); err != nil {
+			return nil, err
+		}
+	}
+	if err := populateContextData(params); err != nil {
+		return nil, err
+	}
+	if len(params.keyData) > 0 && !params.sharedKey {
+		return nil, errors.New("a key can't be given for a directory, since each file is screened with its own key")
+	}
+	if params.scatter > 1 {
+		return nil, errors.New("keys can't be scattered when embedding a directory")
+	}
+	if params.cached {
+		return nil, errors.New("a cached accessor can't be generated when embedding a directory")
+	}
+	if params.encrypted {
+		return nil, errors.New("a directory can't be encrypted, only screened")
+	}
+	if params.externalKey {
+		return nil, errors.New("a key file can't be used when embedding a directory, since each file is screened with its own key")
+	}
+	if len(params.detectedPackage) > 0 && params.packageName != params.detectedPackage {
+		return nil, fmt.Errorf("package name '%s' conflicts with package '%s' declared by existing files in the output directory", params.packageName, params.detectedPackage)
+	}
+	if params.sharedKey {
+		if err := params.checkSharedKey(); err != nil {
+			return nil, err
+		}
+		if err := params.loadSharedKey(); err != nil {
+			return nil, err
+		}
+	}
+
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+	base := filepath.Base(abs)
+	// The directory is named relative to the generated file, so absolute paths don't leak into generated code.
+	outAbs, err := filepath.Abs(params.outputDir)
+	if err != nil {
+		return nil, err
+	}
+	rel, err := filepath.Rel(outAbs, abs)
+	if err != nil {
+		rel = base
+	}
+	dp := &dirParams{
+		Package:         params.packageName,
+		BuildConstraint: params.buildConstraint,
+		Exposed:         params.exposed,
+		HTTP:            params.http,
+		Version:         params.version,
+		Dir:             filepath.ToSlash(rel),
+		FileMethodName:  fileCleansePattern.ReplaceAllString(unicap(base), "_"),
+		targetFileName:  fileCleansePattern.ReplaceAllString(base, "_") + "_fs",
+		outputDir:       params.outputDir,
+		withTest:        params.withTest,
+		force:           params.force,
+		dryRun:          params.dryRun,
+		report:          params.report,
+		SharedKey:       params.sharedKey,
+	}
+	if params.sharedKey {
+		dp.sharedKey = params.sharedKeyFile()
+	}
+	if params.exposed && !inInternal(params.outputDir) {
+		dp.warnings = append(dp.warnings, exposedWarning)
+	}
+	names, err := dp.names(params.obfuscateNames)
+	if err != nil {
+		return nil, err
+	}
+	dp.Names = names
+
+	text := dirTmplText
+	if params.withTest {
+		text += roundTripDirText
+	}
+	fingerprint := newFingerprint(text)
+	_, _ = fmt.Fprintf(fingerprint, "%q %q %q %q %v %v %d %v %v %v %v",
+		dp.FileMethodName, dp.Dir, params.packageName, params.buildConstraint, params.exposed, params.compressed,
+		params.compressLevel, params.verifyChecksum, params.withTest, params.fullLength, params.http,
+	)
+	if params.obfuscateNames {
+		_, _ = fmt.Fprint(fingerprint, " obfuscated")
+	}
+	if params.sharedKey {
+		_, _ = fmt.Fprintf(fingerprint, " shared %d %x", params.offset, params.keyData)
+	}
+
+	var total int64
+	digest := sha256.New()
+	err = walkDir(dir, func(path, rel string, d fs.DirEntry) error {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		_, _ = fmt.Fprintf(fingerprint, " %q %d\x00", rel, len(data))
+		fingerprint.Write(data)
+		writeDirDigest(digest, rel, data)
+		total += int64(len(data))
+		if params.maxInputSize > 0 && total > params.maxInputSize {
+			return fmt.Errorf("%w: files in '%s' exceed the maximum of %d bytes. Consider embedding screened files with go:embed and unscreening them at runtime with xor.NewFS instead", ErrInputTooLarge, dir, params.maxInputSize)
+		}
+		file, err := screenDirFile(params, rel, data)
+		if err != nil {
+			return fmt.Errorf("failed to screen '%s': %w", path, err)
+		}
+		if params.http {
+			info, err := d.Info()
+			if err != nil {
+				return err
+			}
+			file.ModTime = info.ModTime().Unix()
+			_, _ = fmt.Fprintf(fingerprint, " %d", file.ModTime)
+		}
+		dp.Files = append(dp.Files, file)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(dp.Files) == 0 {
+		return nil, fmt.Errorf("no files to embed in '%s'", dir)
+	}
+	dp.InputHash = hex.EncodeToString(fingerprint.Sum(nil))
+	// Embedded files can't be encrypted or use a key file, so the digest reveals nothing that can't be unscreened from the generated file.
+	dp.SourceDigest = hex.EncodeToString(digest.Sum(nil))
+	return dp, nil
+}
+
+// walkDir calls fn for each file in dir that should be embedded, with its slash separated path relative to dir.
+// Like go:embed, files and directories with names beginning with '.' or '_' are skipped.
+func walkDir(dir string, fn func(path, rel string, d fs.DirEntry) error) error {
+	return filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if path != dir && (strings.HasPrefix(d.Name(), ".") || strings.HasPrefix(d.Name(), "_")) {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if d.IsDir() {
+			return nil
+		}
+		if !d.Type().IsRegular() {
+			return fmt.Errorf("cannot embed irregular file '%s'", path)
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		return fn(path, filepath.ToSlash(rel), d)
+	})
+}
+
+// screenDirFile screens a single file with its own key, compressing it first if requested.
+func screenDirFile(params *Params, name string, data []byte) (dirFile, error) {
+	var (
+		key    []byte
+		offset int
+		err    error
+	)
+	if params.sharedKey {
+		key, offset = params.keyData, params.offset
+	} else if len(data) == 0 {
+		// There's nothing to screen, but xor.NewEmbeddedFS still requires a valid key.
+		key, offset, err = xor.GenKeyAndOffset(1)
+	} else {
+		key, offset, err = params.generateKey(data)
+	}
+	if err != nil {
+		return dirFile{}, err
+	}
+	size := int64(len(data))
+	sum := sha256.Sum256(data)
+	if params.compressed {
+		var buf bytes.Buffer
+		w, err := gzip.NewWriterLevel(&buf, params.compressLevel)
+		if err != nil {
+			return dirFile{}, err
+		}
+		if _, err := w.Write(data); err != nil {
+			return dirFile{}, err
+		}
+		if err := w.Close(); err != nil {
+			return dirFile{}, err
+		}
+		data = buf.Bytes()
+	}
+	screened, err := xor.TransformBytes(data, key, offset)
+	if err != nil {
+		return dirFile{}, err
+	}
+	keyString := stringByteLiteral(key)
+	if params.sharedKey {
+		keyString = sharedKeyVar
+	}
+	file := dirFile{
+		Name:       name,
+		KeyString:  keyString,
+		Offset:     offset,
+		DataString: strconv.Quote(string(screened)),
+		Size:       size,
+		Compressed: params.compressed,
+		Checksum:   hex.EncodeToString(sum[:]),
+	}
+	if params.verifyChecksum {
+		file.ChecksumString = stringByteLiteral(sum[:])
+	}
+	return file, nil
+}
+
+func renderDir(params *dirParams, out io.Writer) error {
+	return stamp(out, func(out io.Writer) error {
+		return dirTmplTemplate.Execute(out, params)
+	})
+}
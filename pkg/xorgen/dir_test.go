@@ -0,0 +1,63 @@
+package xorgen
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go/ast"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRenderDir(t *testing.T) {
+	fset := token.NewFileSet()
+	conf := types.Config{Importer: importer.ForCompiler(fset, "source", nil)}
+	for _, compressed := range []bool{false, true} {
+		t.Run(fmt.Sprintf("compressed=%v", compressed), func(t *testing.T) {
+			params, err := buildDirParams("testdata/assets", CompressData(compressed), EmbedChecksum(compressed), FullLengthKey())
+			require.NoError(t, err)
+			assert.Equal(t, "Assets", params.FileMethodName)
+			assert.Equal(t, "assets_fs", params.targetFileName)
+			require.Len(t, params.Files, 3)
+			for _, f := range params.Files {
+				assert.Equal(t, compressed, f.Compressed)
+			}
+			var buf bytes.Buffer
+			require.NoError(t, renderDir(params, &buf))
+			assertValidSource(t, buf.Bytes())
+
+			f, err := parser.ParseFile(fset, "generated.go", buf.Bytes(), 0)
+			require.NoError(t, err)
+			_, err = conf.Check(params.Package, fset, []*ast.File{f}, nil)
+			assert.NoError(t, err, "Generated source should type check:\n%s", buf.Bytes())
+		})
+	}
+}
+
+func TestBuildDirParams_Neg(t *testing.T) {
+	empty := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(empty, ".ignored"), []byte("ignored"), 0600))
+
+	tests := map[string]struct {
+		dir  string
+		opts []ParamOpt
+	}{
+		"Missing dir":   {filepath.Join(empty, "missing"), nil},
+		"No files":      {empty, nil},
+		"Key given":     {"testdata/assets", []ParamOpt{UseKeyOffset([]byte{0x01}, 0)}},
+		"Too large":     {"testdata/assets", []ParamOpt{MaxInputSize(int64(len(testMessage)))}},
+		"Wrong package": {"testdata/assets", []ParamOpt{PackageName("other")}},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := buildDirParams(tc.dir, tc.opts...)
+			assert.Error(t, err)
+		})
+	}
+}
@@ -0,0 +1,42 @@
+/*
+Package xorgen generates Go source that embeds XOR screened data, which is the engine behind the xorgen CLI.
+Build tools like mage, modmake, or custom generators can use it to embed screened assets without shelling out to the CLI.
+
+Note that XOR screening is NOT encryption, see the [xor] package documentation for what screening does and doesn't protect against.
+Use [Encrypt] if embedded data needs to be kept confidential, which encrypts it with [passlock] instead.
+
+# How it works:
+
+[GenerateFile] reads an input file, screens it with a random key (optionally compressing it first), and writes a Go file in the output directory.
+The generated file is named after the input file, replacing characters that match the regex pattern [^a-zA-Z0-9_] with "_".
+It contains an unscreen function that returns the original data, and a stream function that returns an io.Reader of it.
+A write-through function, suffixed with "_to", unscreens the data directly into an io.Writer, so large files are never held in memory all at once.
+An open function returns a read-only fs.File of the data with Stat info, for APIs that expect an fs.File.
+
+	err := xorgen.GenerateFile("assets/api-key.txt",
+		xorgen.OutputDir("internal/secrets"),
+		xorgen.CompressData(),
+		xorgen.EmbedChecksum(),
+	)
+
+This creates internal/secrets/api_key_txt.go with functions called unscreenApi_key_txt, streamApi_key_txt, unscreenApi_key_txt_to, and openApi_key_txt.
+The package of the generated file is detected from existing Go files in the output directory, and may be set with [PackageName].
+
+[GenerateDir] embeds every file in a directory instead, and generates a function that returns them as an fs.FS using [xor.NewEmbeddedFS].
+[HTTPFileSystem] also generates a function that returns the embedded data as an http.FileSystem, to serve it with http.FileServer.
+[ObfuscateNames] declares generated functions and variables with random names, and generates an index of variables with the usual names to call them by.
+[CacheData] generates an accessor that only unscreens the data once, and a wipe function that zeroes it when it's no longer needed.
+[KeyFile] writes the key to a file in the [xor.KeyFile] format instead of embedding it, and the generated functions load it at runtime.
+[SharedKey] screens every file generated with it in an output directory with one key, declared in a keys.go file there, so the key can be rotated in one place.
+[ScreenFile] writes a screened copy of a file with a key file next to it, and [GoEmbed] generates accessors that embed it with a go:embed directive instead of a literal.
+[ExtractFile] recovers the original content embedded in a generated file, to audit what was shipped or recover a lost input.
+[Generate] reads input from an io.Reader and writes the generated file to an io.Writer, so data never needs to touch disk unscreened.
+
+[Report] passes a [Result] for each file to a function, with its status, key fingerprint, and any warnings about the options used.
+[DryRun] writes the generated files to an io.Writer instead of creating them, to inspect the result of a set of options.
+Generation is configured with zero or more [ParamOpt], which are applied in order, so later options override earlier ones.
+A generated file records a fingerprint of its input and options, and isn't regenerated with new keys unless one of them changes, or [ForceRegenerate] is used.
+It's also stamped with a checksum of its content and a digest of its source, which [VerifyFile] checks to detect generated files that have been edited or gone stale.
+Every generated file is formatted with go/format before it's written, so it passes gofmt checks as is.
+*/
+package xorgen
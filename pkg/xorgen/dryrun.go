@@ -0,0 +1,25 @@
+package xorgen
+
+import (
+	"fmt"
+	"io"
+	"path/filepath"
+)
+
+// DryRun writes generated files to the io.Writer instead of creating them, so the result of a set of options can be inspected.
+// Each file is preceded by a comment with the path that it would be written to, and companion tests are included.
+// Files are written even if they're up-to-date, since nothing is changed on disk.
+func DryRun(w io.Writer) ParamOpt {
+	return func(params *Params) error {
+		params.dryRun = w
+		return nil
+	}
+}
+
+// writeDryRun writes a comment with the path of the file that would be generated, followed by the rendered file.
+func writeDryRun(w io.Writer, path string, render func(out io.Writer) error) error {
+	if _, err := fmt.Fprintf(w, "// xorgen dry run: %s\n", filepath.ToSlash(path)); err != nil {
+		return err
+	}
+	return render(w)
+}
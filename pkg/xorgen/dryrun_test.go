@@ -0,0 +1,42 @@
+package xorgen
+
+import (
+	"bytes"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestDryRun(t *testing.T) {
+	out := t.TempDir()
+	var buf bytes.Buffer
+	require.NoError(t, GenerateFile("test.txt", OutputDir(out), PackageName("assets"), WithTest(), DryRun(&buf)))
+	assert.Contains(t, buf.String(), "// xorgen dry run: "+filepath.ToSlash(filepath.Join(out, "test_txt.go"))+"\n// Code generated by xorgen")
+	assert.Contains(t, buf.String(), "// xorgen dry run: "+filepath.ToSlash(filepath.Join(out, "test_txt_test.go"))+"\n// Code generated by xorgen")
+	assert.Contains(t, buf.String(), "func unscreenTest_txt()")
+	assert.Contains(t, buf.String(), "func TestXorgenTest_txt(t *testing.T)")
+	entries, err := os.ReadDir(out)
+	require.NoError(t, err)
+	assert.Empty(t, entries, "No files should be created with a dry run")
+
+	// Existing files are written even if they're up-to-date.
+	require.NoError(t, GenerateFile("test.txt", OutputDir(out), PackageName("assets")))
+	buf.Reset()
+	require.NoError(t, GenerateFile("test.txt", OutputDir(out), PackageName("assets"), DryRun(&buf)))
+	assert.Equal(t, 1, strings.Count(buf.String(), "// xorgen dry run: "))
+}
+
+func TestDryRun_Dir(t *testing.T) {
+	out := t.TempDir()
+	var buf bytes.Buffer
+	require.NoError(t, GenerateDir("testdata/assets", OutputDir(out), PackageName("assets"), WithTest(), DryRun(&buf)))
+	assert.Contains(t, buf.String(), "// xorgen dry run: "+filepath.ToSlash(filepath.Join(out, "assets_fs.go"))+"\n")
+	assert.Contains(t, buf.String(), "// xorgen dry run: "+filepath.ToSlash(filepath.Join(out, "assets_fs_test.go"))+"\n")
+	assert.Contains(t, buf.String(), "func fsAssets() (fs.FS, error)")
+	entries, err := os.ReadDir(out)
+	require.NoError(t, err)
+	assert.Empty(t, entries, "No files should be created with a dry run")
+}
@@ -0,0 +1,79 @@
+package xorgen
+
+import (
+	_ "embed"
+	"errors"
+	"github.com/saylorsolutions/gocryptx/pkg/passlock"
+	"text/template"
+)
+
+var (
+	//go:embed encrypt.go.tmpl
+	encryptText     string
+	encryptTemplate = template.Must(template.New("encrypt").Parse(encryptText))
+)
+
+// Encrypt encrypts the payload with AES-GCM using passlock instead of screening it, so the generated file provides actual confidentiality.
+// The key is derived from a pass phrase read from the PassSource during generation, and the generated decrypt function reads the pass phrase from a PassSource at runtime.
+// For an input called "secret.txt", the generated function is called decryptSecret_txt, and it returns an error if the pass phrase is incorrect, rather than garbage.
+//
+// Keys are derived with passlock.SetShortDelayIterations, since the default iteration count requires too much memory to derive a key at runtime.
+// Encrypted files are always regenerated, since the fingerprint of the input can't be recorded without letting anyone with the generated file check guesses of its content.
+// A nil PassSource disables encryption. This can't be combined with options that rely on a screening key, and can't be used when embedding a directory.
+func Encrypt(src passlock.PassSource) ParamOpt {
+	return func(params *Params) error {
+		params.passSource = src
+		params.encrypted = src != nil
+		return nil
+	}
+}
+
+// checkEncrypt validates that no options are set that conflict with encryption.
+func (params *Params) checkEncrypt() error {
+	switch {
+	case len(params.keyData) > 0:
+		return errors.New("a key can't be given when encrypting, since the key is derived from the pass phrase")
+	case params.scatter > 1:
+		return errors.New("keys can't be scattered when encrypting")
+	case params.verifyChecksum:
+		return errors.New("a checksum can't be embedded when encrypting, since AES-GCM already detects tampering")
+	case params.http:
+		return errors.New("an http.FileSystem accessor can't be generated when encrypting")
+	case params.cached:
+		return errors.New("a cached accessor can't be generated when encrypting")
+	case params.withTest:
+		return errors.New("a companion test can't be generated when encrypting, since it would need the pass phrase")
+	}
+	return nil
+}
+
+// encryptData encrypts the (optionally compressed) payload with a key derived from the pass phrase, and populates the DataString.
+func encryptData(params *Params) error {
+	if err := compressData(params); err != nil {
+		return err
+	}
+	pass, err := params.passSource.Passphrase()
+	if err != nil {
+		return err
+	}
+	defer clear(pass)
+	gen, err := passlock.NewKeyGenerator(passlock.SetShortDelayIterations())
+	if err != nil {
+		return err
+	}
+	key, salt, err := gen.GenerateKey(pass)
+	if err != nil {
+		return err
+	}
+	defer clear(key)
+	l, err := gen.NewLocker(key, salt)
+	if err != nil {
+		return err
+	}
+	encrypted, err := l.Seal(params.fileData)
+	if err != nil {
+		return err
+	}
+	params.dataString = params.encodeData(encrypted)
+	return nil
+}
@@ -0,0 +1,96 @@
+package xorgen
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/saylorsolutions/gocryptx/pkg/passlock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go/ast"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func testPass(pass string) passlock.PassSource {
+	return passlock.PassSourceFunc(func() (passlock.Passphrase, error) {
+		return passlock.Passphrase(pass), nil
+	})
+}
+
+func TestEncrypt(t *testing.T) {
+	fset := token.NewFileSet()
+	conf := types.Config{Importer: importer.ForCompiler(fset, "source", nil)}
+	for _, encoding := range []DataEncoding{EncodeBytes, EncodeString, EncodeBase64} {
+		for _, compressed := range []bool{false, true} {
+			t.Run(fmt.Sprintf("%s compressed=%v", encoding, compressed), func(t *testing.T) {
+				params, err := buildParams("test.txt", Encrypt(testPass("pass")), EncodeData(encoding), CompressData(compressed))
+				require.NoError(t, err)
+				assert.Empty(t, params.keyString, "No key should be embedded when encrypting")
+				var buf bytes.Buffer
+				require.NoError(t, renderFile(params, &buf))
+				assertValidSource(t, buf.Bytes())
+				assert.NotContains(t, buf.String(), testMessage)
+				assert.Contains(t, buf.String(), "func decryptTest_txt(src passlock.PassSource) ([]byte, error)")
+
+				f, err := parser.ParseFile(fset, "generated.go", buf.Bytes(), 0)
+				require.NoError(t, err)
+				_, err = conf.Check(params.packageName, fset, []*ast.File{f}, nil)
+				assert.NoError(t, err, "Generated source should type check:\n%s", buf.Bytes())
+			})
+		}
+	}
+}
+
+func TestEncrypt_Neg(t *testing.T) {
+	tests := map[string][]ParamOpt{
+		"Fixed key":    {UseKeyOffset([]byte{1, 2, 3}, 0)},
+		"Scatter key":  {ScatterKey(3)},
+		"Checksum":     {EmbedChecksum()},
+		"HTTP":         {HTTPFileSystem()},
+		"Cached":       {CacheData()},
+		"With test":    {WithTest()},
+		"Pass failure": {Encrypt(passlock.PassFromEnv("XORGEN_UNSET_TEST_PASS"))},
+	}
+	for name, opts := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := buildParams("test.txt", append([]ParamOpt{Encrypt(testPass("pass"))}, opts...)...)
+			assert.Error(t, err)
+		})
+	}
+	_, err := buildDirParams("testdata/assets", Encrypt(testPass("pass")))
+	assert.Error(t, err, "Directories can't be encrypted")
+}
+
+func TestEncrypt_UpToDate(t *testing.T) {
+	first, err := buildParams("test.txt", Encrypt(testPass("pass")))
+	require.NoError(t, err)
+	second, err := buildParams("test.txt", Encrypt(testPass("pass")))
+	require.NoError(t, err)
+	assert.NotEqual(t, first.dataString, second.dataString, "Encryption should use a new salt and nonce each time")
+	assert.NotEqual(t, first.inputHash, second.inputHash, "The fingerprint should depend on the encrypted output")
+
+	guess, err := buildParams("test.txt", Encrypt(testPass("wrong pass")))
+	require.NoError(t, err)
+	guess.dataString = first.dataString
+	assert.Equal(t, first.inputHash, guess.fingerprint(), "The fingerprint should only depend on the options and the encrypted output")
+	data, err := os.ReadFile("test.txt")
+	require.NoError(t, err)
+	guess.fileData = append(data, "changed"...)
+	assert.Equal(t, first.inputHash, guess.fingerprint(), "The input shouldn't be part of the fingerprint")
+
+	dir := t.TempDir()
+	input := filepath.Join(dir, "secret.txt")
+	require.NoError(t, os.WriteFile(input, []byte(testMessage), 0600))
+	var statuses []string
+	for i := 0; i < 2; i++ {
+		require.NoError(t, GenerateFile(input, OutputDir(dir), PackageName("assets"), Encrypt(testPass("pass")), Report(func(r Result) {
+			statuses = append(statuses, r.Status)
+		})))
+	}
+	assert.Equal(t, []string{StatusGenerated, StatusGenerated}, statuses, "Encrypted files should always be regenerated")
+}
@@ -0,0 +1,557 @@
+package xorgen
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"github.com/saylorsolutions/gocryptx/pkg/xor"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+var (
+	ErrNotExtractable = errors.New("embedded data can't be extracted")
+)
+
+// maxExtractDepth limits how deeply declarations may refer to each other, so a cycle can't recurse forever.
+const maxExtractDepth = 64
+
+// ExtractedFile is the original content of a file embedded by xorgen, which is recovered with ExtractFile.
+type ExtractedFile struct {
+	// Name is the name of the input, or the slash separated path of the file within the directory embedded with GenerateDir.
+	Name string
+	// Data is the original content, after it's unscreened and decompressed.
+	Data []byte
+}
+
+// ExtractFile recovers the original content embedded in a Go file generated by xorgen, by evaluating the key, offset, and data declared in it.
+// This is useful to audit what was actually shipped, or to recover an input that was lost.
+// A file generated with GenerateDir returns every file it embeds, and files generated with SharedKey or GoEmbed read the shared key file or screened file next to them.
+// The key file for a file generated with KeyFile must be given with KeyFile, and other options are ignored.
+//
+// Embedded checksums are verified, so an error is returned if the data doesn't match the content it was generated from.
+// ErrNotGenerated is returned for files that weren't generated by xorgen.
+// An error wrapping ErrNotExtractable is returned for encrypted files, which can only be decrypted with their pass phrase, and for files that don't declare their data as generated.
+func ExtractFile(path string, opts ...ParamOpt) ([]ExtractedFile, error) {
+	params := new(Params)
+	for _, opt := range opts {
+		if err := opt(params); err != nil {
+			return nil, err
+		}
+	}
+	src, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if _, _, err := readStamp(src); err != nil {
+		return nil, err
+	}
+	ex := &extractor{
+		fset:   token.NewFileSet(),
+		dir:    filepath.Dir(path),
+		vars:   map[string]ast.Expr{},
+		embeds: map[string]string{},
+		funcs:  map[string]*ast.FuncDecl{},
+	}
+	f, err := parser.ParseFile(ex.fset, path, src, parser.ParseComments)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrNotExtractable, err)
+	}
+	ex.declare(f)
+	for _, spec := range f.Imports {
+		if strings.HasSuffix(spec.Path.Value, `/passlock"`) {
+			return nil, fmt.Errorf("%w: '%s' is encrypted, so it can only be decrypted with its pass phrase", ErrNotExtractable, path)
+		}
+	}
+	// Files generated with a shared key refer to the key declared in the shared key file of the same package.
+	keysPath := filepath.Join(ex.dir, SharedKeyFileName)
+	if filepath.Base(path) != SharedKeyFileName && exists(keysPath) {
+		keys, err := parser.ParseFile(ex.fset, keysPath, nil, 0)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidSharedKey, err)
+		}
+		ex.declare(keys)
+	}
+	if params.externalKey {
+		kf, err := xor.LoadKeyFile(params.keyFile)
+		if err != nil {
+			return nil, err
+		}
+		ex.keyFile = &kf
+	}
+
+	files, err := ex.embeddedFiles(f)
+	if err != nil {
+		return nil, err
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("%w: '%s' doesn't embed any screened data", ErrNotExtractable, path)
+	}
+	fsys, err := xor.NewEmbeddedFS(files...)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrNotExtractable, err)
+	}
+	extracted := make([]ExtractedFile, len(files))
+	for i, file := range files {
+		data, err := fs.ReadFile(fsys, file.Name)
+		if err != nil {
+			return nil, fmt.Errorf("failed to extract '%s': %w", file.Name, err)
+		}
+		extracted[i] = ExtractedFile{Name: file.Name, Data: data}
+	}
+	return extracted, nil
+}
+
+// binding is a local variable assigned in a generated function, which is the value at index of a tuple when it's assigned from a function call.
+type binding struct {
+	expr  ast.Expr
+	index int
+}
+
+// extractor evaluates the declarations in a generated file that are used to construct its xor.EmbeddedFile values.
+// Only the expressions generated by xorgen's templates are supported.
+type extractor struct {
+	fset    *token.FileSet
+	dir     string
+	vars    map[string]ast.Expr
+	embeds  map[string]string
+	funcs   map[string]*ast.FuncDecl
+	locals  map[string]binding
+	keyFile *xor.KeyFile
+	depth   int
+}
+
+// declare records the package level variables and functions declared in f.
+// Variables declared with a go:embed directive are recorded with the path they embed.
+func (ex *extractor) declare(f *ast.File) {
+	for _, decl := range f.Decls {
+		switch decl := decl.(type) {
+		case *ast.FuncDecl:
+			if decl.Recv == nil {
+				ex.funcs[decl.Name.Name] = decl
+			}
+		case *ast.GenDecl:
+			if decl.Tok != token.VAR {
+				continue
+			}
+			for _, spec := range decl.Specs {
+				spec := spec.(*ast.ValueSpec)
+				for i, name := range spec.Names {
+					if i < len(spec.Values) {
+						ex.vars[name.Name] = spec.Values[i]
+						continue
+					}
+					doc := spec.Doc
+					if doc == nil {
+						doc = decl.Doc
+					}
+					if path := embedDirective(doc); len(path) > 0 {
+						ex.embeds[name.Name] = path
+					}
+				}
+			}
+		}
+	}
+}
+
+// embedDirective returns the path given in a go:embed directive in doc, or an empty string if there isn't one.
+func embedDirective(doc *ast.CommentGroup) string {
+	if doc == nil {
+		return ""
+	}
+	for _, c := range doc.List {
+		path, ok := strings.CutPrefix(c.Text, "//go:embed ")
+		if !ok {
+			continue
+		}
+		path = strings.TrimSpace(path)
+		if unquoted, err := strconv.Unquote(path); err == nil {
+			path = unquoted
+		}
+		return path
+	}
+	return ""
+}
+
+// embeddedFiles evaluates every xor.EmbeddedFile literal in f.
+// Literals in a function are evaluated with the local variables assigned in it.
+func (ex *extractor) embeddedFiles(f *ast.File) ([]xor.EmbeddedFile, error) {
+	var files []xor.EmbeddedFile
+	for _, decl := range f.Decls {
+		ex.locals = nil
+		if fn, ok := decl.(*ast.FuncDecl); ok && fn.Body != nil {
+			ex.locals = localBindings(fn.Body)
+		}
+		var err error
+		ast.Inspect(decl, func(node ast.Node) bool {
+			if err != nil {
+				return false
+			}
+			lit, ok := node.(*ast.CompositeLit)
+			if !ok {
+				return true
+			}
+			switch {
+			case isEmbeddedFileType(lit.Type):
+				var file xor.EmbeddedFile
+				file, err = ex.embeddedFile(lit)
+				files = append(files, file)
+			case isEmbeddedFileSlice(lit.Type):
+				for _, elt := range lit.Elts {
+					fileLit, ok := elt.(*ast.CompositeLit)
+					if !ok {
+						err = ex.unsupported(elt)
+						return false
+					}
+					var file xor.EmbeddedFile
+					if file, err = ex.embeddedFile(fileLit); err != nil {
+						return false
+					}
+					files = append(files, file)
+				}
+			default:
+				return true
+			}
+			return false
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return files, nil
+}
+
+// localBindings returns the local variables assigned in body.
+func localBindings(body *ast.BlockStmt) map[string]binding {
+	locals := map[string]binding{}
+	ast.Inspect(body, func(node ast.Node) bool {
+		assign, ok := node.(*ast.AssignStmt)
+		if !ok {
+			return true
+		}
+		for i, lhs := range assign.Lhs {
+			ident, ok := lhs.(*ast.Ident)
+			if !ok || ident.Name == "_" {
+				continue
+			}
+			switch {
+			case len(assign.Rhs) == len(assign.Lhs):
+				locals[ident.Name] = binding{expr: assign.Rhs[i]}
+			case len(assign.Rhs) == 1:
+				locals[ident.Name] = binding{expr: assign.Rhs[0], index: i}
+			}
+		}
+		return true
+	})
+	return locals
+}
+
+func isEmbeddedFileType(expr ast.Expr) bool {
+	return expr != nil && types.ExprString(expr) == "xor.EmbeddedFile"
+}
+
+func isEmbeddedFileSlice(expr ast.Expr) bool {
+	arr, ok := expr.(*ast.ArrayType)
+	return ok && isEmbeddedFileType(arr.Elt)
+}
+
+// embeddedFile evaluates the fields of an xor.EmbeddedFile literal that are needed to read its content.
+func (ex *extractor) embeddedFile(lit *ast.CompositeLit) (xor.EmbeddedFile, error) {
+	var (
+		file xor.EmbeddedFile
+		err  error
+	)
+	for _, elt := range lit.Elts {
+		kv, ok := elt.(*ast.KeyValueExpr)
+		if !ok {
+			return file, ex.unsupported(elt)
+		}
+		key, ok := kv.Key.(*ast.Ident)
+		if !ok {
+			return file, ex.unsupported(kv.Key)
+		}
+		var buf []byte
+		switch key.Name {
+		case "Name":
+			buf, err = ex.bytes(kv.Value)
+			file.Name = string(buf)
+		case "Key":
+			file.Key, err = ex.bytes(kv.Value)
+		case "Offset":
+			file.Offset, err = ex.int(kv.Value)
+		case "Data":
+			buf, err = ex.bytes(kv.Value)
+			file.Data = string(buf)
+		case "Size":
+			var size int
+			size, err = ex.int(kv.Value)
+			file.Size = int64(size)
+		case "Compressed":
+			file.Compressed, err = ex.bool(kv.Value)
+		case "Checksum":
+			file.Checksum, err = ex.bytes(kv.Value)
+		}
+		if err != nil {
+			return file, err
+		}
+	}
+	return file, nil
+}
+
+// unsupported returns an error for an expression that isn't generated by xorgen.
+func (ex *extractor) unsupported(expr ast.Expr) error {
+	return fmt.Errorf("%w: unsupported expression '%s' at %s", ErrNotExtractable, types.ExprString(expr), ex.fset.Position(expr.Pos()))
+}
+
+// resolve returns the expression that an identifier refers to, and the index of its value if it's assigned from a function call.
+func (ex *extractor) resolve(ident *ast.Ident) (binding, error) {
+	if b, ok := ex.locals[ident.Name]; ok {
+		return b, nil
+	}
+	if expr, ok := ex.vars[ident.Name]; ok {
+		return binding{expr: expr}, nil
+	}
+	return binding{}, fmt.Errorf("%w: '%s' isn't declared in the generated file", ErrNotExtractable, ident.Name)
+}
+
+// enter guards against declarations that refer to each other in a cycle.
+func (ex *extractor) enter(expr ast.Expr) error {
+	ex.depth++
+	if ex.depth > maxExtractDepth {
+		return fmt.Errorf("%w: '%s' at %s refers to too many declarations", ErrNotExtractable, types.ExprString(expr), ex.fset.Position(expr.Pos()))
+	}
+	return nil
+}
+
+// bytes evaluates an expression that results in a string or byte slice.
+func (ex *extractor) bytes(expr ast.Expr) ([]byte, error) {
+	if err := ex.enter(expr); err != nil {
+		return nil, err
+	}
+	defer func() {
+		ex.depth--
+	}()
+	switch e := expr.(type) {
+	case *ast.ParenExpr:
+		return ex.bytes(e.X)
+	case *ast.BasicLit:
+		if e.Kind != token.STRING {
+			break
+		}
+		s, err := strconv.Unquote(e.Value)
+		if err != nil {
+			return nil, ex.unsupported(e)
+		}
+		return []byte(s), nil
+	case *ast.CompositeLit:
+		buf := make([]byte, 0, len(e.Elts))
+		for _, elt := range e.Elts {
+			n, err := ex.int(elt)
+			if err != nil {
+				return nil, err
+			}
+			if n < 0 || n > 0xff {
+				return nil, ex.unsupported(elt)
+			}
+			buf = append(buf, byte(n))
+		}
+		return buf, nil
+	case *ast.SliceExpr:
+		if e.Low == nil && e.High == nil && e.Max == nil {
+			return ex.bytes(e.X)
+		}
+	case *ast.Ident:
+		if path, ok := ex.embeds[e.Name]; ok {
+			return os.ReadFile(filepath.Join(ex.dir, filepath.FromSlash(path)))
+		}
+		b, err := ex.resolve(e)
+		if err != nil {
+			return nil, err
+		}
+		if call, ok := ex.keyCall(b.expr); ok {
+			if b.index != 0 {
+				return nil, ex.unsupported(e)
+			}
+			kf, err := ex.loadKey(call)
+			if err != nil {
+				return nil, err
+			}
+			return kf.Key, nil
+		}
+		if b.index != 0 {
+			return nil, ex.unsupported(e)
+		}
+		return ex.bytes(b.expr)
+	case *ast.CallExpr:
+		return ex.callBytes(e)
+	}
+	return nil, ex.unsupported(expr)
+}
+
+// callBytes evaluates the function calls and conversions that generated files use to declare data.
+func (ex *extractor) callBytes(call *ast.CallExpr) ([]byte, error) {
+	switch types.ExprString(call.Fun) {
+	case "string", "[]byte":
+		if len(call.Args) == 1 {
+			return ex.bytes(call.Args[0])
+		}
+	case "base64.StdEncoding.DecodeString":
+		if len(call.Args) != 1 {
+			break
+		}
+		encoded, err := ex.bytes(call.Args[0])
+		if err != nil {
+			return nil, err
+		}
+		buf, err := base64.StdEncoding.DecodeString(string(encoded))
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrNotExtractable, err)
+		}
+		return buf, nil
+	case "append":
+		if len(call.Args) == 0 {
+			break
+		}
+		buf, err := ex.bytes(call.Args[0])
+		if err != nil {
+			return nil, err
+		}
+		buf = bytes.Clone(buf)
+		for i, arg := range call.Args[1:] {
+			if call.Ellipsis.IsValid() && i == len(call.Args)-2 {
+				more, err := ex.bytes(arg)
+				if err != nil {
+					return nil, err
+				}
+				buf = append(buf, more...)
+				continue
+			}
+			n, err := ex.int(arg)
+			if err != nil {
+				return nil, err
+			}
+			if n < 0 || n > 0xff {
+				return nil, ex.unsupported(arg)
+			}
+			buf = append(buf, byte(n))
+		}
+		return buf, nil
+	}
+	return nil, ex.unsupported(call)
+}
+
+// int evaluates an integer expression.
+func (ex *extractor) int(expr ast.Expr) (int, error) {
+	if err := ex.enter(expr); err != nil {
+		return 0, err
+	}
+	defer func() {
+		ex.depth--
+	}()
+	switch e := expr.(type) {
+	case *ast.ParenExpr:
+		return ex.int(e.X)
+	case *ast.BasicLit:
+		switch e.Kind {
+		case token.INT:
+			n, err := strconv.ParseInt(e.Value, 0, 64)
+			if err != nil {
+				return 0, ex.unsupported(e)
+			}
+			return int(n), nil
+		case token.CHAR:
+			r, _, _, err := strconv.UnquoteChar(e.Value[1:len(e.Value)-1], '\'')
+			if err != nil {
+				return 0, ex.unsupported(e)
+			}
+			return int(r), nil
+		}
+	case *ast.Ident:
+		b, err := ex.resolve(e)
+		if err != nil {
+			return 0, err
+		}
+		if call, ok := ex.keyCall(b.expr); ok {
+			if b.index != 1 {
+				return 0, ex.unsupported(e)
+			}
+			kf, err := ex.loadKey(call)
+			if err != nil {
+				return 0, err
+			}
+			return kf.Offset, nil
+		}
+		if b.index != 0 {
+			return 0, ex.unsupported(e)
+		}
+		return ex.int(b.expr)
+	}
+	return 0, ex.unsupported(expr)
+}
+
+// bool evaluates a boolean constant.
+func (ex *extractor) bool(expr ast.Expr) (bool, error) {
+	if ident, ok := expr.(*ast.Ident); ok {
+		switch ident.Name {
+		case "true":
+			return true, nil
+		case "false":
+			return false, nil
+		}
+	}
+	return false, ex.unsupported(expr)
+}
+
+// keyCall reports whether expr calls a function declared in the generated file, which is how a key is loaded from a key file at runtime.
+func (ex *extractor) keyCall(expr ast.Expr) (*ast.CallExpr, bool) {
+	call, ok := expr.(*ast.CallExpr)
+	if !ok {
+		return nil, false
+	}
+	ident, ok := call.Fun.(*ast.Ident)
+	if !ok {
+		return nil, false
+	}
+	_, ok = ex.funcs[ident.Name]
+	return call, ok
+}
+
+// loadKey returns the key file given to ExtractFile in place of the key loaded by call, after verifying it against the key hash checked by the loading function.
+func (ex *extractor) loadKey(call *ast.CallExpr) (*xor.KeyFile, error) {
+	if ex.keyFile == nil {
+		return nil, fmt.Errorf("%w: the key is loaded from a key file at runtime, which must be given to extract the data", ErrNotExtractable)
+	}
+	fn := ex.funcs[call.Fun.(*ast.Ident).Name]
+	var hash ast.Expr
+	ast.Inspect(fn, func(node ast.Node) bool {
+		cmp, ok := node.(*ast.BinaryExpr)
+		if !ok || cmp.Op != token.NEQ {
+			return true
+		}
+		if sum, ok := cmp.X.(*ast.CallExpr); ok && types.ExprString(sum.Fun) == "sha256.Sum256" {
+			hash = cmp.Y
+			return false
+		}
+		return true
+	})
+	if hash == nil {
+		return nil, ex.unsupported(call)
+	}
+	want, err := ex.bytes(hash)
+	if err != nil {
+		return nil, err
+	}
+	if sum := sha256.Sum256(ex.keyFile.Key); !bytes.Equal(sum[:], want) {
+		return nil, errors.New("key file doesn't match the embedded data")
+	}
+	return ex.keyFile, nil
+}
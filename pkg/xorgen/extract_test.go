@@ -0,0 +1,49 @@
+package xorgen
+
+import (
+	"github.com/saylorsolutions/gocryptx/pkg/xor"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestExtractFile_SharedKey(t *testing.T) {
+	dir := t.TempDir()
+	input := filepath.Join(dir, "secret.txt")
+	require.NoError(t, os.WriteFile(input, []byte(testMessage), 0600))
+	require.NoError(t, GenerateFile(input, OutputDir(dir), PackageName("assets"), SharedKey(), CompressData(), EmbedChecksum()))
+
+	files, err := ExtractFile(filepath.Join(dir, "secret_txt.go"))
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+	assert.Equal(t, testMessage, string(files[0].Data))
+
+	_, err = ExtractFile(filepath.Join(dir, SharedKeyFileName))
+	assert.ErrorIs(t, err, ErrNotExtractable, "The shared key file doesn't embed any data")
+}
+
+func TestExtractFile_Neg(t *testing.T) {
+	_, err := ExtractFile("extract_test.go")
+	assert.ErrorIs(t, err, ErrNotGenerated)
+
+	dir := t.TempDir()
+	input := filepath.Join(dir, "secret.txt")
+	require.NoError(t, os.WriteFile(input, []byte(testMessage), 0600))
+	require.NoError(t, GenerateFile(input, OutputDir(dir), PackageName("assets"), UseKeyOffset([]byte{0xaa, 0xbb, 0xcc}, 0), EncodeData(EncodeString), EmbedChecksum()))
+	target := filepath.Join(dir, "secret_txt.go")
+	src, err := os.ReadFile(target)
+	require.NoError(t, err)
+	keyLiteral := `[]byte("\xaa\xbb\xcc")`
+	require.Contains(t, string(src), keyLiteral)
+
+	require.NoError(t, os.WriteFile(target, []byte(strings.Replace(string(src), keyLiteral, `[]byte("\xab\xbb\xcc")`, 1)), 0600))
+	_, err = ExtractFile(target)
+	assert.ErrorIs(t, err, xor.ErrChecksumMismatch, "The embedded checksum should be verified")
+
+	require.NoError(t, os.WriteFile(target, []byte(strings.Replace(string(src), keyLiteral, "otherKey", 1)), 0600))
+	_, err = ExtractFile(target)
+	assert.ErrorIs(t, err, ErrNotExtractable, "Only declarations in the generated file can be evaluated")
+}
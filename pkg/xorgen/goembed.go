@@ -0,0 +1,224 @@
+package xorgen
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"github.com/saylorsolutions/gocryptx/pkg/xor"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	// ScreenedExt is the extension added to a file screened with ScreenFile, which is removed from its name to name the functions generated with GoEmbed.
+	ScreenedExt = ".xor"
+	// ScreenedKeyExt is the extension added to the path of a screened file to name the key file written next to it by ScreenFile.
+	ScreenedKeyExt = ".xkey"
+)
+
+// ScreenFile screens the input file and writes it to output, so it can be embedded with a go:embed directive in a file generated with GoEmbed.
+// An empty output is the input path with ScreenedExt added, and the key and offset are written to a key file at the output path with ScreenedKeyExt added.
+// A random key is generated with the selected key strategy unless one is given with UseKeyOffset or UseKeyRandomOffset, and the input is compressed first with CompressData.
+// If the output can already be unscreened to the input with its key file, then it's left alone unless ForceRegenerate is used, so the key doesn't churn between builds.
+//
+// Other options that only affect generated code are ignored, and the screened file can't be written with DryRun.
+func ScreenFile(input, output string, opts ...ParamOpt) error {
+	params := &Params{
+		compressLevel: gzip.BestCompression,
+	}
+	for _, opt := range opts {
+		if err := opt(params); err != nil {
+			return err
+		}
+	}
+	if params.dryRun != nil {
+		return errors.New("a screened file can't be written to a dry run, since it's binary")
+	}
+	if len(output) == 0 {
+		output = input + ScreenedExt
+	}
+	keyPath := output + ScreenedKeyExt
+	data, err := os.ReadFile(input)
+	if err != nil {
+		return err
+	}
+	params.fileData = data
+
+	if !params.force && len(params.keyData) == 0 {
+		current, err := screenedUpToDate(output, keyPath, data, params.compressed)
+		if err != nil {
+			return err
+		}
+		if current {
+			params.reportScreened(output, keyPath, StatusUpToDate)
+			return nil
+		}
+	}
+	if len(params.keyData) == 0 {
+		key, offset, err := params.generateKey(data)
+		if err != nil {
+			return err
+		}
+		params.keyData = key
+		params.offset = offset
+	}
+	if err := compressData(params); err != nil {
+		return err
+	}
+	screened, err := xor.TransformBytes(params.fileData, params.keyData, params.offset)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(output, screened, 0644); err != nil {
+		return err
+	}
+	err = xor.SaveKeyFile(keyPath, xor.KeyFile{
+		Key:    params.keyData,
+		Offset: params.offset,
+		Label:  "xorgen " + filepath.Base(input),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to write key file: %w", err)
+	}
+	params.reportScreened(output, keyPath, StatusGenerated)
+	return nil
+}
+
+// screenedUpToDate reports whether the screened file unscreens to data with the key in its key file.
+// A missing screened file or key file is not up-to-date.
+func screenedUpToDate(output, keyPath string, data []byte, compressed bool) (bool, error) {
+	if !exists(output) || !exists(keyPath) {
+		return false, nil
+	}
+	content, _, err := readScreened(output, keyPath, compressed)
+	if err != nil {
+		// The existing file can't be unscreened with the current options, so it's replaced.
+		return false, nil
+	}
+	return bytes.Equal(content, data), nil
+}
+
+// readScreened unscreens a file written by ScreenFile with its key file, and decompresses it if it's compressed.
+func readScreened(path, keyPath string, compressed bool) ([]byte, xor.KeyFile, error) {
+	kf, err := xor.LoadKeyFile(keyPath)
+	if err != nil {
+		return nil, kf, err
+	}
+	screened, err := os.ReadFile(path)
+	if err != nil {
+		return nil, kf, err
+	}
+	content, err := xor.TransformBytes(screened, kf.Key, kf.Offset)
+	if err != nil {
+		return nil, kf, err
+	}
+	if !compressed {
+		return content, kf, nil
+	}
+	r, err := gzip.NewReader(bytes.NewReader(content))
+	if err != nil {
+		return nil, kf, fmt.Errorf("'%s' isn't compressed, or doesn't match its key file: %w", path, err)
+	}
+	defer func() {
+		_ = r.Close()
+	}()
+	content, err = io.ReadAll(r)
+	if err != nil {
+		return nil, kf, fmt.Errorf("'%s' isn't compressed, or doesn't match its key file: %w", path, err)
+	}
+	return content, kf, nil
+}
+
+// reportScreened passes the result for a screened file to the Report function, if there is one.
+func (params *Params) reportScreened(path, keyPath, status string) {
+	if params.report == nil {
+		return
+	}
+	result := Result{
+		Path:    path,
+		Status:  status,
+		KeyFile: keyPath,
+	}
+	if status != StatusUpToDate {
+		result.KeyFingerprint = checksum(params.keyData)
+	}
+	params.report(result)
+}
+
+// GoEmbed indicates that the input is a file screened with ScreenFile, which is embedded with a go:embed directive instead of a literal.
+// The key is read from the key file next to the input when the file is generated, and embedded as usual, so the generated functions are the same.
+// ScreenedExt is removed from the input name to name generated functions, so "secret.txt.xor" results in a function called unscreenSecret_txt.
+//
+// This decouples screening from code generation, and keeps large payloads out of Go source, so they don't slow down compilation.
+// The screened file must be in the output directory or a subdirectory, like any file embedded with go:embed, and CompressData must match how it was screened.
+// Data is embedded as a string, or a []byte with EncodeBytes.
+// This can't be combined with KeyFile, SharedKey, Encrypt, or a key given with UseKeyOffset, and can't be used with Generate.
+func GoEmbed(val ...bool) ParamOpt {
+	return func(params *Params) error {
+		if len(val) > 0 {
+			params.goEmbed = val[0]
+			return nil
+		}
+		params.goEmbed = true
+		return nil
+	}
+}
+
+// inputPath records the path of the input file, which is needed to embed it with GoEmbed.
+func inputPath(path string) ParamOpt {
+	return func(params *Params) error {
+		params.inputPath = path
+		return nil
+	}
+}
+
+// checkGoEmbed validates that no options are set that conflict with embedding a screened file with go:embed.
+func (params *Params) checkGoEmbed() error {
+	switch {
+	case len(params.inputPath) == 0:
+		return errors.New("a screened file must be read from disk to embed it with go:embed")
+	case len(params.keyData) > 0:
+		return errors.New("a key can't be given when embedding a screened file, since it's read from the key file")
+	case params.externalKey:
+		return errors.New("a key file can't be used when embedding a screened file with go:embed")
+	case params.sharedKey:
+		return errors.New("a shared key can't be used when embedding a screened file, since it has its own key")
+	case params.encrypted:
+		return errors.New("an encrypted file can't be embedded with go:embed, only a screened file")
+	}
+	return nil
+}
+
+// loadEmbedded unscreens the input with the key in its key file, and replaces the payload with the original content.
+// The path of the input relative to the output directory is used in the go:embed directive.
+func (params *Params) loadEmbedded() error {
+	if err := params.checkGoEmbed(); err != nil {
+		return err
+	}
+	embedPath := sourcePath(params.outputDir, params.inputPath)
+	if len(embedPath) == 0 || strings.HasPrefix(embedPath, "../") || !fs.ValidPath(embedPath) {
+		return fmt.Errorf("'%s' must be in the output directory or a subdirectory to embed it with go:embed", params.inputPath)
+	}
+	content, kf, err := readScreened(params.inputPath, params.inputPath+ScreenedKeyExt, params.compressed)
+	if err != nil {
+		return fmt.Errorf("failed to unscreen '%s': %w", params.inputPath, err)
+	}
+	if err := populateFileData(params, strings.TrimSuffix(params.inputName, ScreenedExt), content); err != nil {
+		return err
+	}
+	if !params.compressed && bytes.HasPrefix(content, []byte{0x1f, 0x8b}) {
+		params.warnings = append(params.warnings, fmt.Sprintf("'%s' looks gzip compressed, so it may need to be generated with compression to match how it was screened", params.inputPath))
+	}
+	params.embedPath = embedPath
+	params.keyData = kf.Key
+	params.offset = kf.Offset
+	params.randomOffset = false
+	if params.encoding != EncodeBytes {
+		params.encoding = EncodeString
+	}
+	return nil
+}
@@ -0,0 +1,115 @@
+package xorgen
+
+import (
+	"bytes"
+	"github.com/saylorsolutions/gocryptx/pkg/xor"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestScreenFile(t *testing.T) {
+	dir := t.TempDir()
+	input := filepath.Join(dir, "secret.txt")
+	require.NoError(t, os.WriteFile(input, []byte(testMessage), 0600))
+	output := input + ScreenedExt
+	keyPath := output + ScreenedKeyExt
+	var results []Result
+	screen := func(opts ...ParamOpt) []byte {
+		t.Helper()
+		require.NoError(t, ScreenFile(input, "", append(opts, Report(func(r Result) {
+			results = append(results, r)
+		}))...))
+		screened, err := os.ReadFile(output)
+		require.NoError(t, err)
+		return screened
+	}
+
+	first := screen()
+	assert.NotEqual(t, testMessage, string(first))
+	kf, err := xor.LoadKeyFile(keyPath)
+	require.NoError(t, err)
+	unscreened, err := xor.TransformBytes(first, kf.Key, kf.Offset)
+	require.NoError(t, err)
+	assert.Equal(t, testMessage, string(unscreened))
+	require.Len(t, results, 1)
+	assert.Equal(t, StatusGenerated, results[0].Status)
+	assert.Equal(t, keyPath, results[0].KeyFile)
+
+	assert.Equal(t, first, screen(), "An unchanged input should not be screened again")
+	assert.Equal(t, StatusUpToDate, results[1].Status)
+	assert.NotEqual(t, first, screen(ForceRegenerate()), "A forced screen should use a new key")
+	compressed := screen(CompressData())
+	content, _, err := readScreened(output, keyPath, true)
+	require.NoError(t, err)
+	assert.Equal(t, testMessage, string(content))
+	assert.Equal(t, compressed, screen(CompressData()))
+
+	key := []byte{0x01, 0x02, 0x03}
+	require.NoError(t, ScreenFile(input, filepath.Join(dir, "fixed.bin"), UseKeyOffset(key, 1)))
+	kf, err = xor.LoadKeyFile(filepath.Join(dir, "fixed.bin"+ScreenedKeyExt))
+	require.NoError(t, err)
+	assert.Equal(t, key, kf.Key)
+	assert.Equal(t, 1, kf.Offset)
+
+	assert.Error(t, ScreenFile(input, "", DryRun(io.Discard)))
+}
+
+func TestGoEmbed(t *testing.T) {
+	dir := t.TempDir()
+	input := filepath.Join(dir, "assets", "secret.txt")
+	require.NoError(t, os.Mkdir(filepath.Dir(input), 0700))
+	require.NoError(t, os.WriteFile(input, []byte(testMessage), 0600))
+	require.NoError(t, ScreenFile(input, "", CompressData()))
+
+	for _, encoding := range []DataEncoding{EncodeBytes, EncodeString, EncodeBase64} {
+		t.Run(encoding, func(t *testing.T) {
+			require.NoError(t, GenerateFile(input+ScreenedExt, OutputDir(dir), PackageName("assets"), GoEmbed(), CompressData(), EncodeData(encoding), VaryShape(), ForceRegenerate()))
+			src, err := os.ReadFile(filepath.Join(dir, "secret_txt.go"))
+			require.NoError(t, err)
+			assertValidSource(t, src)
+			assert.Contains(t, string(src), "//go:embed assets/secret.txt.xor\n")
+			assert.Contains(t, string(src), "func unscreenSecret_txt()")
+			assertPackageChecks(t, dir)
+		})
+	}
+
+	params, err := buildParams(input+ScreenedExt, OutputDir(dir), PackageName("assets"), GoEmbed(), CompressData(), EmbedChecksum())
+	require.NoError(t, err)
+	assert.Equal(t, testMessage, string(params.fileData), "The payload should be the original content")
+	assert.Equal(t, "secret.txt", params.inputName)
+	assert.Equal(t, checksum([]byte(testMessage)), params.checksum)
+	assert.Empty(t, params.dataString, "Data shouldn't be embedded as a literal")
+
+	_, err = buildParams(input+ScreenedExt, OutputDir(dir), PackageName("assets"), GoEmbed())
+	require.NoError(t, err, "Compressed data without CompressData is embedded as is")
+}
+
+func TestGoEmbed_Neg(t *testing.T) {
+	dir := t.TempDir()
+	input := filepath.Join(dir, "secret.txt")
+	require.NoError(t, os.WriteFile(input, []byte(testMessage), 0600))
+	require.NoError(t, ScreenFile(input, ""))
+
+	tests := map[string][]ParamOpt{
+		"Key":        {UseKeyOffset([]byte{1, 2, 3}, 0)},
+		"Key file":   {KeyFile(filepath.Join(dir, "test.xkey"))},
+		"Shared key": {SharedKey()},
+		"Encrypted":  {Encrypt(testPass("pass"))},
+		"Compressed": {CompressData()},
+		"Outside":    {OutputDir(filepath.Join(dir, "sub"))},
+	}
+	require.NoError(t, os.Mkdir(filepath.Join(dir, "sub"), 0700))
+	for name, opts := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := buildParams(input+ScreenedExt, append([]ParamOpt{OutputDir(dir), PackageName("assets"), GoEmbed()}, opts...)...)
+			assert.Error(t, err)
+		})
+	}
+
+	err := Generate("secret.txt.xor", bytes.NewReader(nil), io.Discard, GoEmbed())
+	assert.Error(t, err, "A screened file must be read from disk")
+}
@@ -0,0 +1,16 @@
+package xorgen
+
+// HTTPFileSystem generates an additional function that returns the embedded data as an http.FileSystem, ready to pass to http.FileServer.
+// For a single file, the file system contains the input file by its base name, and for a directory it serves the same files as the generated fs.FS.
+// The modification times of input files are recorded, so the file server can send Last-Modified headers.
+// This means that changing only the modification time of an input will regenerate the file.
+func HTTPFileSystem(val ...bool) ParamOpt {
+	return func(params *Params) error {
+		if len(val) > 0 {
+			params.http = val[0]
+			return nil
+		}
+		params.http = true
+		return nil
+	}
+}
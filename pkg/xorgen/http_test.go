@@ -0,0 +1,88 @@
+package xorgen
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go/ast"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestHTTPFileSystem(t *testing.T) {
+	fset := token.NewFileSet()
+	conf := types.Config{Importer: importer.ForCompiler(fset, "source", nil)}
+	for _, decode := range []string{decodeReader, decodeLoopMod} {
+		for _, encoding := range []DataEncoding{EncodeBytes, EncodeString, EncodeBase64} {
+			for _, checksum := range []bool{false, true} {
+				t.Run(fmt.Sprintf("%s %s checksum=%v", decode, encoding, checksum), func(t *testing.T) {
+					params, err := buildParams("test.txt", EncodeData(encoding), CompressData(checksum), EmbedChecksum(checksum), HTTPFileSystem(), VaryShape())
+					require.NoError(t, err)
+					assert.Equal(t, "test.txt", params.inputName)
+					assert.NotZero(t, params.modTime, "Modification time should be recorded")
+					params.shape.Decode = decode
+					params.importPaths = params.imports()
+					var buf bytes.Buffer
+					require.NoError(t, renderFile(params, &buf))
+					assertValidSource(t, buf.Bytes())
+					assert.Contains(t, buf.String(), "func httpTest_txt() (http.FileSystem, error)")
+
+					f, err := parser.ParseFile(fset, "generated.go", buf.Bytes(), 0)
+					require.NoError(t, err)
+					_, err = conf.Check(params.packageName, fset, []*ast.File{f}, nil)
+					assert.NoError(t, err, "Generated source should type check:\n%s", buf.Bytes())
+				})
+			}
+		}
+	}
+}
+
+func TestHTTPFileSystem_Dir(t *testing.T) {
+	params, err := buildDirParams("testdata/assets", HTTPFileSystem(), ExposeFunctions())
+	require.NoError(t, err)
+	for _, f := range params.Files {
+		assert.NotZero(t, f.ModTime, "Modification time should be recorded for '%s'", f.Name)
+	}
+	var buf bytes.Buffer
+	require.NoError(t, renderDir(params, &buf))
+	assertValidSource(t, buf.Bytes())
+	assert.Contains(t, buf.String(), "func HTTPAssets() (http.FileSystem, error)")
+
+	fset := token.NewFileSet()
+	conf := types.Config{Importer: importer.ForCompiler(fset, "source", nil)}
+	f, err := parser.ParseFile(fset, "generated.go", buf.Bytes(), 0)
+	require.NoError(t, err)
+	_, err = conf.Check(params.Package, fset, []*ast.File{f}, nil)
+	assert.NoError(t, err, "Generated source should type check:\n%s", buf.Bytes())
+}
+
+func TestHTTPFileSystem_ModTime(t *testing.T) {
+	dir := t.TempDir()
+	input := filepath.Join(dir, "index.html")
+	require.NoError(t, os.WriteFile(input, []byte("<html></html>"), 0600))
+	modTime := time.Date(2024, time.March, 1, 12, 0, 0, 0, time.UTC)
+	require.NoError(t, os.Chtimes(input, modTime, modTime))
+
+	params, err := buildParams(input, HTTPFileSystem())
+	require.NoError(t, err)
+	assert.Equal(t, modTime.Unix(), params.modTime)
+	withoutHTTP, err := buildParams(input)
+	require.NoError(t, err)
+	assert.Zero(t, withoutHTTP.modTime, "Modification time should only be recorded when it's used")
+
+	later := modTime.Add(time.Hour)
+	require.NoError(t, os.Chtimes(input, later, later))
+	touched, err := buildParams(input, HTTPFileSystem())
+	require.NoError(t, err)
+	assert.NotEqual(t, params.inputHash, touched.inputHash, "A changed modification time should cause regeneration")
+
+	_, err = buildDataParams(".", []byte("data"), time.Time{}, HTTPFileSystem())
+	assert.Error(t, err, "Names that aren't valid in an fs.FS should be rejected")
+}
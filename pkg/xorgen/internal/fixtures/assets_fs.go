@@ -0,0 +1,42 @@
+// Code generated by xorgen, DO NOT EDIT.
+// xorgen:hash 41d109fc4b54f4fc7c466899118cc4fe9a59a88851168da5dd3379765c343ddc
+// xorgen:sum 865923b4df5c2c5f6871ecc2a282a5b1aaf070ed8dbe54a4064e54440e35399a
+// xorgen:source 51e5a024dc25a1d62d1bf0893950fd5a26a37d5983c1bfb4fb265579b3d480e3 "testdata/assets"
+package fixtures
+
+import (
+	"github.com/saylorsolutions/gocryptx/pkg/xor"
+	"io/fs"
+)
+
+var filesAssets = []xor.EmbeddedFile{
+	{
+		Name:       "css/site.css",
+		Key:        []byte("dd\xfe\x87\xee\t\xe8L\x8ai\x1f\xb2\xe9\x1b\x93؝\xd3 \x86\xa0"),
+		Offset:     11,
+		Data:       "\xadb\x13\x93؝\xd3 \x84_.\xae\xb1.\xba\xa1\xbe\x04D\xa6֝[I\xbb\x92\xd0bv.Efh\xfe\r\x8f\xee@Y\x8ai\x1f",
+		Size:       21,
+		Compressed: true,
+	},
+	{
+		Name:       "empty.txt",
+		Key:        []byte("\xaa"),
+		Offset:     0,
+		Data:       "\xb5!\xa2\xaa\xaa\xaa\xaa\xaa\xa8U\xa9\xaa\xaa\xaa\xaa\xaa\xaa\xaa\xaa\xaa",
+		Size:       0,
+		Compressed: true,
+	},
+	{
+		Name:       "message.txt",
+		Key:        []byte("J\x1b\x8e`H\x81\xcc\xf1\xc8|\x9d\xb8T\xec\x03\x8fa\xb1ж\x0e\xfati5K\xc0>ig\xd1D,\x8a/Àg"),
+		Offset:     15,
+		Data:       "\x90\xea\xb9ж\x0e\xfatk\xca9\x94\x16 J\xff\x15\xe4\xc7\x02\xed\xce+\x05N\xa6\xa9\x00\xad\x9d\xd9\x06\xb4\xb2u\x1d\xbdK\xc54\x99\x9e\x98D\xb7\xb9\"xJ\xcc>\xc2r\xca\xce\n\x8a/\xc3",
+		Size:       38,
+		Compressed: true,
+	},
+}
+
+// FSAssets returns an fs.FS of the files embedded from "testdata/assets", which unscreens each file as it's opened.
+func FSAssets() (fs.FS, error) {
+	return xor.NewEmbeddedFS(filesAssets...)
+}
@@ -0,0 +1,33 @@
+// Code generated by xorgen, DO NOT EDIT.
+package fixtures
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io/fs"
+	"testing"
+)
+
+func TestXorgenFSAssets(t *testing.T) {
+	expected := map[string]string{
+		"css/site.css": "9767e91e9d4b0334e59a1d389e9801bc6a2c5c4a5500a3c2c7915687965b2c16",
+		"empty.txt":    "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855",
+		"message.txt":  "74091445899fe31b2784fd23f08a41dd94e870f638da5bfdca1b2c1fe078de53",
+	}
+
+	fsys, err := FSAssets()
+	if err != nil {
+		t.Fatalf("Failed to create fs.FS: %v", err)
+	}
+	for name, want := range expected {
+		data, err := fs.ReadFile(fsys, name)
+		if err != nil {
+			t.Errorf("Failed to read '%s': %v", name, err)
+			continue
+		}
+		sum := sha256.Sum256(data)
+		if got := hex.EncodeToString(sum[:]); got != want {
+			t.Errorf("Checksum of '%s' is %s, expected %s", name, got, want)
+		}
+	}
+}
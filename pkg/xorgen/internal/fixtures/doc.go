@@ -0,0 +1,13 @@
+//go:generate xorgen -Ec -p fixtures --encoding bytes --with-test test.txt
+//go:generate xorgen -E -p fixtures --encoding string --checksum test_string.txt
+//go:generate xorgen -Ec -p fixtures --encoding base64 --cached test_base64.txt
+//go:generate xorgen -Ec -p fixtures --with-test --dir testdata/assets
+//go:generate xorgen -E -p fixtures --scatter-key 3 --obfuscate-names test_scatter.txt
+//go:generate xorgen -Ec -p fixtures --encrypt file:testdata/pass.txt test_encrypt.txt
+//go:generate xorgen -E -p fixtures --keyfile testdata/test_keyfile.xkey test_keyfile.txt
+//go:generate xorgen screen -c testdata/test_embed.txt
+//go:generate xorgen -Ec -p fixtures --checksum --go-embed testdata/test_embed.txt.xor
+
+// Package fixtures holds files generated by xorgen with the options that the xorgen tests exercise.
+// It's internal so the generated functions don't become part of the xorgen API, and it's regenerated with 'go generate'.
+package fixtures
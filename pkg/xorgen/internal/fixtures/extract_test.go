@@ -0,0 +1,80 @@
+package fixtures
+
+import (
+	"github.com/saylorsolutions/gocryptx/pkg/xor"
+	"github.com/saylorsolutions/gocryptx/pkg/xorgen"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestExtractFile(t *testing.T) {
+	tests := map[string]struct {
+		source string
+		opts   []xorgen.ParamOpt
+	}{
+		"test_txt.go":         {source: "test.txt"},
+		"test_string_txt.go":  {source: "test_string.txt"},
+		"test_base64_txt.go":  {source: "test_base64.txt"},
+		"test_scatter_txt.go": {source: "test_scatter.txt"},
+		"test_embed_txt.go":   {source: "testdata/test_embed.txt"},
+		"test_keyfile_txt.go": {source: "test_keyfile.txt", opts: []xorgen.ParamOpt{xorgen.KeyFile(testKeyFile)}},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			want, err := os.ReadFile(tc.source)
+			require.NoError(t, err)
+			files, err := xorgen.ExtractFile(name, tc.opts...)
+			require.NoError(t, err)
+			require.Len(t, files, 1)
+			assert.Equal(t, filepath.Base(tc.source), files[0].Name)
+			assert.Equal(t, string(want), string(files[0].Data))
+		})
+	}
+
+	files, err := xorgen.ExtractFile("assets_fs.go")
+	require.NoError(t, err)
+	var names []string
+	for _, file := range files {
+		names = append(names, file.Name)
+		want, err := os.ReadFile(filepath.Join("testdata/assets", filepath.FromSlash(file.Name)))
+		require.NoError(t, err)
+		assert.Equal(t, string(want), string(file.Data))
+	}
+	assert.Len(t, names, 3)
+}
+
+func TestExtractFile_Neg(t *testing.T) {
+	_, err := xorgen.ExtractFile("test_encrypt_txt.go")
+	assert.ErrorIs(t, err, xorgen.ErrNotExtractable, "Encrypted files can't be extracted without the pass phrase")
+	_, err = xorgen.ExtractFile("test_keyfile_txt.go")
+	assert.ErrorIs(t, err, xorgen.ErrNotExtractable, "A key file is needed when the key isn't embedded")
+
+	wrongKey := filepath.Join(t.TempDir(), "wrong.xkey")
+	require.NoError(t, xor.SaveKeyFile(wrongKey, xor.KeyFile{Key: []byte{0x01, 0x02, 0x03}}))
+	_, err = xorgen.ExtractFile("test_keyfile_txt.go", xorgen.KeyFile(wrongKey))
+	assert.Error(t, err, "A key file that doesn't match shouldn't be used")
+}
+
+func TestVerifyFile(t *testing.T) {
+	for _, name := range []string{"test_txt.go", "test_string_txt.go", "test_base64_txt.go", "test_scatter_txt.go", "assets_fs.go"} {
+		t.Run(name, func(t *testing.T) {
+			result, err := xorgen.VerifyFile(name)
+			require.NoError(t, err)
+			assert.True(t, result.SourceChecked, "Source should be found and checked")
+		})
+	}
+	for _, name := range []string{"test_encrypt_txt.go", "test_keyfile_txt.go"} {
+		t.Run(name, func(t *testing.T) {
+			result, err := xorgen.VerifyFile(name)
+			require.NoError(t, err)
+			assert.Empty(t, result.Source, "No source digest should be recorded when the key isn't embedded")
+		})
+	}
+	_, err := xorgen.VerifyFile("test_txt_test.go")
+	assert.ErrorIs(t, err, xorgen.ErrNotGenerated, "Companion tests aren't stamped")
+	_, err = xorgen.VerifyFile("extract_test.go")
+	assert.ErrorIs(t, err, xorgen.ErrNotGenerated)
+}
@@ -0,0 +1,228 @@
+package fixtures
+
+import (
+	"bytes"
+	"github.com/saylorsolutions/gocryptx/pkg/passlock"
+	"github.com/saylorsolutions/gocryptx/pkg/xor"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io"
+	"io/fs"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+const (
+	testMessage = "A test message that should be screened"
+	testKeyFile = "testdata/test_keyfile.xkey"
+)
+
+func TestUnscreenTest_txt(t *testing.T) {
+	data, err := UnscreenTest_txt()
+	assert.NoError(t, err)
+	assert.Equal(t, testMessage, string(data))
+}
+
+func TestStreamTest_txt(t *testing.T) {
+	r, err := StreamTest_txt()
+	assert.NoError(t, err)
+	var buf strings.Builder
+	_, err = io.Copy(&buf, r)
+	assert.NoError(t, err)
+	assert.Equal(t, testMessage, buf.String())
+}
+
+func TestUnscreenTest_string_txt(t *testing.T) {
+	data, err := UnscreenTest_string_txt()
+	assert.NoError(t, err)
+	assert.Equal(t, testMessage, string(data))
+
+	r, err := StreamTest_string_txt()
+	assert.NoError(t, err)
+	data, err = io.ReadAll(r)
+	assert.NoError(t, err)
+	assert.Equal(t, testMessage, string(data))
+
+	original := offsetTest_string_txt
+	offsetTest_string_txt = (original + 1) % len(keyTest_string_txt)
+	_, err = UnscreenTest_string_txt()
+	offsetTest_string_txt = original
+	assert.ErrorContains(t, err, "checksum", "Key drift should be detected by the embedded checksum")
+}
+
+func TestUnscreenTest_scatter_txt(t *testing.T) {
+	data, err := UnscreenTest_scatter_txt()
+	assert.NoError(t, err)
+	assert.Equal(t, testMessage, string(data))
+}
+
+func TestUnscreenTest_base64_txt(t *testing.T) {
+	data, err := UnscreenTest_base64_txt()
+	assert.NoError(t, err)
+	assert.Equal(t, testMessage, string(data))
+
+	r, err := StreamTest_base64_txt()
+	assert.NoError(t, err)
+	data, err = io.ReadAll(r)
+	assert.NoError(t, err)
+	assert.Equal(t, testMessage, string(data))
+}
+
+func TestUnscreenTest_txt_to(t *testing.T) {
+	tests := map[string]func(w io.Writer) error{
+		"Compressed bytes": UnscreenTest_txt_to,
+		"String checksum":  UnscreenTest_string_txt_to,
+		"Base64":           UnscreenTest_base64_txt_to,
+		"Scattered":        UnscreenTest_scatter_txt_to,
+		"Key file": func(w io.Writer) error {
+			return UnscreenTest_keyfile_txt_to(w, testKeyFile)
+		},
+	}
+	for name, unscreenTo := range tests {
+		t.Run(name, func(t *testing.T) {
+			var buf strings.Builder
+			require.NoError(t, unscreenTo(&buf))
+			assert.Equal(t, testMessage, buf.String())
+		})
+	}
+
+	original := offsetTest_string_txt
+	offsetTest_string_txt = (original + 1) % len(keyTest_string_txt)
+	err := UnscreenTest_string_txt_to(io.Discard)
+	offsetTest_string_txt = original
+	assert.ErrorContains(t, err, "checksum", "Key drift should be detected after writing")
+}
+
+func TestOpenTest_txt(t *testing.T) {
+	tests := map[string]func() (fs.File, error){
+		"Compressed bytes": OpenTest_txt,
+		"String checksum":  OpenTest_string_txt,
+		"Base64":           OpenTest_base64_txt,
+		"Scattered":        OpenTest_scatter_txt,
+		"Key file": func() (fs.File, error) {
+			return OpenTest_keyfile_txt(testKeyFile)
+		},
+	}
+	for name, open := range tests {
+		t.Run(name, func(t *testing.T) {
+			f, err := open()
+			require.NoError(t, err)
+			defer func() {
+				assert.NoError(t, f.Close())
+			}()
+			info, err := f.Stat()
+			require.NoError(t, err)
+			assert.True(t, strings.HasPrefix(info.Name(), "test"), "The file should be named after the input, got '%s'", info.Name())
+			assert.Equal(t, int64(len(testMessage)), info.Size())
+			assert.False(t, info.IsDir())
+			data, err := io.ReadAll(f)
+			require.NoError(t, err)
+			assert.Equal(t, testMessage, string(data))
+
+			seeker, ok := f.(io.Seeker)
+			require.True(t, ok, "Opened files should support io.Seeker")
+			_, err = seeker.Seek(2, io.SeekStart)
+			require.NoError(t, err)
+			data, err = io.ReadAll(f)
+			require.NoError(t, err)
+			assert.Equal(t, testMessage[2:], string(data))
+		})
+	}
+
+	original := offsetTest_string_txt
+	offsetTest_string_txt = (original + 1) % len(keyTest_string_txt)
+	_, err := OpenTest_string_txt()
+	offsetTest_string_txt = original
+	assert.ErrorContains(t, err, "checksum", "Key drift should be detected when the file is opened")
+}
+
+func TestCachedTest_base64_txt(t *testing.T) {
+	data, err := CachedTest_base64_txt()
+	require.NoError(t, err)
+	assert.Equal(t, testMessage, string(data))
+	again, err := CachedTest_base64_txt()
+	require.NoError(t, err)
+	assert.Same(t, &data[0], &again[0], "The cached buffer should be returned on later calls")
+
+	WipeTest_base64_txt()
+	assert.Equal(t, make([]byte, len(testMessage)), data, "The cached buffer should be zeroed")
+	_, err = CachedTest_base64_txt()
+	assert.Error(t, err, "The cached accessor should fail after the data is wiped")
+
+	data, err = UnscreenTest_base64_txt()
+	require.NoError(t, err)
+	assert.Equal(t, testMessage, string(data), "Wiping shouldn't affect the embedded data")
+}
+
+func TestDecryptTest_encrypt_txt(t *testing.T) {
+	data, err := DecryptTest_encrypt_txt(passlock.PassFromFile("testdata/pass.txt"))
+	require.NoError(t, err)
+	assert.Equal(t, testMessage, string(data))
+
+	r, err := StreamTest_encrypt_txt(passlock.PassFromFile("testdata/pass.txt"))
+	require.NoError(t, err)
+	data, err = io.ReadAll(r)
+	require.NoError(t, err)
+	assert.Equal(t, testMessage, string(data))
+
+	_, err = DecryptTest_encrypt_txt(passlock.PassSourceFunc(func() (passlock.Passphrase, error) {
+		return passlock.Passphrase("wrong pass phrase"), nil
+	}))
+	assert.Error(t, err, "An incorrect pass phrase should return an error instead of garbage")
+}
+
+func TestUnscreenTest_keyfile_txt(t *testing.T) {
+	data, err := UnscreenTest_keyfile_txt(testKeyFile)
+	require.NoError(t, err)
+	assert.Equal(t, testMessage, string(data))
+
+	r, err := StreamTest_keyfile_txt(testKeyFile)
+	require.NoError(t, err)
+	data, err = io.ReadAll(r)
+	require.NoError(t, err)
+	assert.Equal(t, testMessage, string(data))
+
+	_, err = UnscreenTest_keyfile_txt(filepath.Join(t.TempDir(), "missing.xkey"))
+	assert.Error(t, err)
+
+	other := filepath.Join(t.TempDir(), "other.xkey")
+	require.NoError(t, xor.SaveKeyFile(other, xor.KeyFile{Key: []byte{1, 2, 3}}))
+	_, err = UnscreenTest_keyfile_txt(other)
+	assert.ErrorContains(t, err, "doesn't match", "A different key should return an error instead of garbage")
+}
+
+func TestUnscreenTest_embed_txt(t *testing.T) {
+	data, err := UnscreenTest_embed_txt()
+	require.NoError(t, err)
+	assert.Equal(t, testMessage, string(data))
+
+	var buf bytes.Buffer
+	require.NoError(t, UnscreenTest_embed_txt_to(&buf))
+	assert.Equal(t, testMessage, buf.String())
+
+	f, err := OpenTest_embed_txt()
+	require.NoError(t, err)
+	data, err = io.ReadAll(f)
+	require.NoError(t, err)
+	assert.Equal(t, testMessage, string(data))
+	info, err := f.Stat()
+	require.NoError(t, err)
+	assert.Equal(t, "test_embed.txt", info.Name())
+}
+
+func TestFSAssets(t *testing.T) {
+	fsys, err := FSAssets()
+	require.NoError(t, err)
+	require.NoError(t, fstest.TestFS(fsys, "message.txt", "empty.txt", "css/site.css"))
+
+	data, err := fs.ReadFile(fsys, "message.txt")
+	require.NoError(t, err)
+	assert.Equal(t, testMessage, string(data))
+
+	_, err = fs.Stat(fsys, "_skip/hidden.txt")
+	assert.ErrorIs(t, err, fs.ErrNotExist, "Names beginning with '_' should be skipped")
+	_, err = fs.Stat(fsys, ".hidden")
+	assert.ErrorIs(t, err, fs.ErrNotExist, "Names beginning with '.' should be skipped")
+}
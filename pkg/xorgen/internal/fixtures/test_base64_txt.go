@@ -0,0 +1,119 @@
+// Code generated by xorgen, DO NOT EDIT.
+// xorgen:hash 2c7bf118c15c068d8926754e1d0e14ed6501b2680fdc2c109aeae1325b520001
+// xorgen:sum a8e43b7c351acb587a27913c21159780ce3d744b69819b9f66b51aa477a5ccb7
+// xorgen:source 74091445899fe31b2784fd23f08a41dd94e870f638da5bfdca1b2c1fe078de53 "test_base64.txt"
+package fixtures
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"errors"
+	"github.com/saylorsolutions/gocryptx/pkg/xor"
+	"io"
+	"io/fs"
+	"strings"
+	"sync"
+)
+
+var (
+	keyTest_base64_txt    = []byte("c\xbb\xa0\f\xdd2\xb5ߘ\xcc\x1b=\x14\x13Wp\f\v\x03\abhlC\xe8\xf8%3\xb3\xf7\xb2\x02\xec\xdc\bB\xae\xcc")
+	dataTest_base64_txt   = "b4cDAwdiaGxBF4pxG/ranFMkkSVs4IAs7ojFlR7k91YENPBdQh86WSNNKSgloQil+SkzGOKpiMrcCEI="
+	offsetTest_base64_txt = 15
+)
+
+func UnscreenTest_base64_txt() ([]byte, error) {
+	r, err := xor.NewReader(base64.NewDecoder(base64.StdEncoding, strings.NewReader(dataTest_base64_txt)), keyTest_base64_txt, offsetTest_base64_txt)
+	if err != nil {
+		return nil, err
+	}
+	uncompress, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, err
+	}
+	var out bytes.Buffer
+	_, err = io.Copy(&out, uncompress)
+	if err != nil {
+		return nil, err
+	}
+	uncompress.Close()
+	return out.Bytes(), nil
+}
+
+func StreamTest_base64_txt() (io.Reader, error) {
+	r, err := xor.NewReader(base64.NewDecoder(base64.StdEncoding, strings.NewReader(dataTest_base64_txt)), keyTest_base64_txt, offsetTest_base64_txt)
+	if err != nil {
+		return nil, err
+	}
+	return gzip.NewReader(r)
+}
+
+// UnscreenTest_base64_txt_to writes the embedded data to w as it's unscreened and decompressed, so it's never held in memory all at once.
+func UnscreenTest_base64_txt_to(w io.Writer) error {
+	r, err := xor.NewReader(base64.NewDecoder(base64.StdEncoding, strings.NewReader(dataTest_base64_txt)), keyTest_base64_txt, offsetTest_base64_txt)
+	if err != nil {
+		return err
+	}
+	uncompress, err := gzip.NewReader(r)
+	if err != nil {
+		return err
+	}
+	defer uncompress.Close()
+	_, err = io.Copy(w, uncompress)
+	return err
+}
+
+// OpenTest_base64_txt returns a read-only fs.File of the embedded data named "test_base64.txt", which also supports io.Seeker and io.ReaderAt.
+func OpenTest_base64_txt() (fs.File, error) {
+	fsys, err := fsTest_base64_txt()
+	if err != nil {
+		return nil, err
+	}
+	return fsys.Open("test_base64.txt")
+}
+
+// fsTest_base64_txt returns an fs.FS containing only the embedded data, which is unscreened when it's opened.
+func fsTest_base64_txt() (fs.FS, error) {
+	screened, err := base64.StdEncoding.DecodeString(dataTest_base64_txt)
+	if err != nil {
+		return nil, err
+	}
+	return xor.NewEmbeddedFS(xor.EmbeddedFile{
+		Name:       "test_base64.txt",
+		Key:        keyTest_base64_txt,
+		Offset:     offsetTest_base64_txt,
+		Data:       string(screened),
+		Size:       38,
+		Compressed: true,
+	})
+}
+
+var (
+	cacheOnceTest_base64_txt sync.Once
+	cacheMuTest_base64_txt   sync.Mutex
+	cacheTest_base64_txt     []byte
+	cacheErrTest_base64_txt  error
+)
+
+// CachedTest_base64_txt unscreens the embedded data the first time it's called, and returns the same buffer until WipeTest_base64_txt is called.
+// The returned buffer is shared, so it shouldn't be modified or retained after it's wiped.
+func CachedTest_base64_txt() ([]byte, error) {
+	cacheOnceTest_base64_txt.Do(func() {
+		cacheTest_base64_txt, cacheErrTest_base64_txt = UnscreenTest_base64_txt()
+	})
+	cacheMuTest_base64_txt.Lock()
+	defer cacheMuTest_base64_txt.Unlock()
+	return cacheTest_base64_txt, cacheErrTest_base64_txt
+}
+
+// WipeTest_base64_txt zeroes the cached data so it's no longer resident in memory, and CachedTest_base64_txt returns an error after it's called.
+func WipeTest_base64_txt() {
+	cacheOnceTest_base64_txt.Do(func() {})
+	cacheMuTest_base64_txt.Lock()
+	defer cacheMuTest_base64_txt.Unlock()
+	for i := range cacheTest_base64_txt {
+		cacheTest_base64_txt[i] = 0
+	}
+	cacheTest_base64_txt = nil
+	cacheErrTest_base64_txt = errors.New("cached data has been wiped")
+}
@@ -0,0 +1,99 @@
+// Code generated by xorgen, DO NOT EDIT.
+// xorgen:hash ebb021278fa3f31c285bbb8c0a5903b89506dbdb7cdaf449e811925bfce10df6
+// xorgen:sum 5cae31a7997712bb5957564a5c4b9daf8883a82c0dfaf9ccdce9dea7ba1a0af0
+// xorgen:source 0bfd62c4ef07d4baf8109bd99787569327df6f60c859fb0dcecb9c5639cf2f79 "testdata/test_embed.txt.xor"
+package fixtures
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	_ "embed"
+	"errors"
+	"github.com/saylorsolutions/gocryptx/pkg/xor"
+	"io"
+	"io/fs"
+	"strings"
+)
+
+var keyTest_embed_txt = []byte("\x87C\xe0jԋx\xd9NExx\xf0\xa3O \x9a\x11\u03829\xcc\x06\xfc\xdd\xf1\xd2\xc0ܮ\xf2:\x95@\x01\x97,\xcd")
+
+//go:embed testdata/test_embed.txt.xor
+var dataTest_embed_txt string
+
+var offsetTest_embed_txt = 2
+
+func UnscreenTest_embed_txt() ([]byte, error) {
+	r, err := xor.NewReader(strings.NewReader(dataTest_embed_txt), keyTest_embed_txt, offsetTest_embed_txt)
+	if err != nil {
+		return nil, err
+	}
+	uncompress, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, err
+	}
+	var out bytes.Buffer
+	_, err = io.Copy(&out, uncompress)
+	if err != nil {
+		return nil, err
+	}
+	uncompress.Close()
+	if sha256.Sum256(out.Bytes()) != checksumTest_embed_txt {
+		return nil, errors.New("unscreened data doesn't match its checksum")
+	}
+	return out.Bytes(), nil
+}
+
+func StreamTest_embed_txt() (io.Reader, error) {
+	buf, err := UnscreenTest_embed_txt()
+	if err != nil {
+		return nil, err
+	}
+	return bytes.NewReader(buf), nil
+}
+
+// UnscreenTest_embed_txt_to writes the embedded data to w as it's unscreened and decompressed, so it's never held in memory all at once.
+// The checksum is verified after all data is written, so w may have received corrupted data when an error is returned.
+func UnscreenTest_embed_txt_to(w io.Writer) error {
+	r, err := xor.NewReader(strings.NewReader(dataTest_embed_txt), keyTest_embed_txt, offsetTest_embed_txt)
+	if err != nil {
+		return err
+	}
+	uncompress, err := gzip.NewReader(r)
+	if err != nil {
+		return err
+	}
+	defer uncompress.Close()
+	h := sha256.New()
+	if _, err := io.Copy(io.MultiWriter(w, h), uncompress); err != nil {
+		return err
+	}
+	if !bytes.Equal(h.Sum(nil), checksumTest_embed_txt[:]) {
+		return errors.New("unscreened data doesn't match its checksum")
+	}
+	return nil
+}
+
+// OpenTest_embed_txt returns a read-only fs.File of the embedded data named "test_embed.txt", which also supports io.Seeker and io.ReaderAt.
+func OpenTest_embed_txt() (fs.File, error) {
+	fsys, err := fsTest_embed_txt()
+	if err != nil {
+		return nil, err
+	}
+	return fsys.Open("test_embed.txt")
+}
+
+// fsTest_embed_txt returns an fs.FS containing only the embedded data, which is unscreened when it's opened.
+func fsTest_embed_txt() (fs.FS, error) {
+	return xor.NewEmbeddedFS(xor.EmbeddedFile{
+		Name:       "test_embed.txt",
+		Key:        keyTest_embed_txt,
+		Offset:     offsetTest_embed_txt,
+		Data:       dataTest_embed_txt,
+		Size:       38,
+		Compressed: true,
+		Checksum:   checksumTest_embed_txt[:],
+	})
+}
+
+var checksumTest_embed_txt = [32]uint8{0x74, 0x9, 0x14, 0x45, 0x89, 0x9f, 0xe3, 0x1b, 0x27, 0x84, 0xfd, 0x23, 0xf0, 0x8a, 0x41, 0xdd, 0x94, 0xe8, 0x70, 0xf6, 0x38, 0xda, 0x5b, 0xfd, 0xca, 0x1b, 0x2c, 0x1f, 0xe0, 0x78, 0xde, 0x53}
@@ -0,0 +1,61 @@
+// Code generated by xorgen, DO NOT EDIT.
+// xorgen:hash 20a5048f162d8e6064ffb023d60ad03d6b396539fb1124774f1f193643211698
+// xorgen:sum 69072f15a159feb1051ce949029859b51e853a7100ad45a1d61f8842df5475b4
+package fixtures
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"github.com/saylorsolutions/gocryptx/pkg/passlock"
+	"io"
+)
+
+var dataTest_encrypt_txt = "PxRCfAFnEvVxh96+72IlQZg5Rd9xp4gV4ORnq9ZLsY80HUidadsuGjBHr71/JHGJEy2de9YTCqupnYF7ZMBipTW0g48qJBYASyfI12t0x6AQuGjAASx0jWk24G/LFoD2TOg8HbS+5qDK03+VzjCFqytO3SqN5pE="
+
+// DecryptTest_encrypt_txt decrypts the embedded data with a pass phrase read from the PassSource, like passlock.PassFromEnv, passlock.PassFromFile, or passlock.PassFromPrompt.
+// An error is returned if the pass phrase is incorrect, or the embedded data has been tampered with.
+func DecryptTest_encrypt_txt(src passlock.PassSource) ([]byte, error) {
+	data, err := base64.StdEncoding.DecodeString(dataTest_encrypt_txt)
+	if err != nil {
+		return nil, err
+	}
+	pass, err := src.Passphrase()
+	if err != nil {
+		return nil, err
+	}
+	defer clear(pass)
+	gen, err := passlock.NewKeyGenerator(passlock.SetShortDelayIterations())
+	if err != nil {
+		return nil, err
+	}
+	key, err := gen.DeriveKey(pass, data)
+	if err != nil {
+		return nil, err
+	}
+	defer clear(key)
+	l, err := gen.NewLocker(key, nil)
+	if err != nil {
+		return nil, err
+	}
+	compressed, err := l.Open(data)
+	if err != nil {
+		return nil, err
+	}
+	defer clear(compressed)
+	uncompress, err := gzip.NewReader(bytes.NewReader(compressed))
+	if err != nil {
+		return nil, err
+	}
+	defer uncompress.Close()
+	return io.ReadAll(uncompress)
+}
+
+// StreamTest_encrypt_txt returns a reader of the decrypted data, see DecryptTest_encrypt_txt.
+func StreamTest_encrypt_txt(src passlock.PassSource) (io.Reader, error) {
+	buf, err := DecryptTest_encrypt_txt(src)
+	if err != nil {
+		return nil, err
+	}
+	return bytes.NewReader(buf), nil
+}
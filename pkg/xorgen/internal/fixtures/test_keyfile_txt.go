@@ -0,0 +1,93 @@
+// Code generated by xorgen, DO NOT EDIT.
+// xorgen:hash 785c7cb38017a9b96a77a434d925cb66879a0ba76e9b3ff92b7959f552073e6d
+// xorgen:sum da10486c266791442458cdb3ab669ae29783c9849e380fc0d47faa55cc1a11aa
+package fixtures
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"github.com/saylorsolutions/gocryptx/pkg/xor"
+	"io"
+	"io/fs"
+	"strings"
+)
+
+var dataTest_keyfile_txt = "jEff5n1Zje7LEpKIdbnxVJrb/8JsVYZNIN78TCmPxcK4uIcHPX4="
+
+func UnscreenTest_keyfile_txt(keyFile string) ([]byte, error) {
+	keyTest_keyfile_txt, offsetTest_keyfile_txt, err := loadKeyTest_keyfile_txt(keyFile)
+	if err != nil {
+		return nil, err
+	}
+	r, err := xor.NewReader(base64.NewDecoder(base64.StdEncoding, strings.NewReader(dataTest_keyfile_txt)), keyTest_keyfile_txt, offsetTest_keyfile_txt)
+	if err != nil {
+		return nil, err
+	}
+	return io.ReadAll(r)
+}
+
+func StreamTest_keyfile_txt(keyFile string) (io.Reader, error) {
+	keyTest_keyfile_txt, offsetTest_keyfile_txt, err := loadKeyTest_keyfile_txt(keyFile)
+	if err != nil {
+		return nil, err
+	}
+	return xor.NewReader(base64.NewDecoder(base64.StdEncoding, strings.NewReader(dataTest_keyfile_txt)), keyTest_keyfile_txt, offsetTest_keyfile_txt)
+}
+
+// UnscreenTest_keyfile_txt_to writes the embedded data to w as it's unscreened, so it's never held in memory all at once.
+func UnscreenTest_keyfile_txt_to(w io.Writer, keyFile string) error {
+	keyTest_keyfile_txt, offsetTest_keyfile_txt, err := loadKeyTest_keyfile_txt(keyFile)
+	if err != nil {
+		return err
+	}
+	r, err := xor.NewReader(base64.NewDecoder(base64.StdEncoding, strings.NewReader(dataTest_keyfile_txt)), keyTest_keyfile_txt, offsetTest_keyfile_txt)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(w, r)
+	return err
+}
+
+// OpenTest_keyfile_txt returns a read-only fs.File of the embedded data named "test_keyfile.txt", which also supports io.Seeker and io.ReaderAt.
+func OpenTest_keyfile_txt(keyFile string) (fs.File, error) {
+	fsys, err := fsTest_keyfile_txt(keyFile)
+	if err != nil {
+		return nil, err
+	}
+	return fsys.Open("test_keyfile.txt")
+}
+
+// fsTest_keyfile_txt returns an fs.FS containing only the embedded data, which is unscreened when it's opened.
+func fsTest_keyfile_txt(keyFile string) (fs.FS, error) {
+	keyTest_keyfile_txt, offsetTest_keyfile_txt, err := loadKeyTest_keyfile_txt(keyFile)
+	if err != nil {
+		return nil, err
+	}
+	screened, err := base64.StdEncoding.DecodeString(dataTest_keyfile_txt)
+	if err != nil {
+		return nil, err
+	}
+	return xor.NewEmbeddedFS(xor.EmbeddedFile{
+		Name:       "test_keyfile.txt",
+		Key:        keyTest_keyfile_txt,
+		Offset:     offsetTest_keyfile_txt,
+		Data:       string(screened),
+		Size:       38,
+		Compressed: false,
+	})
+}
+
+// loadKeyTest_keyfile_txt loads the key for the embedded data from a key file, and verifies that it's the key the data was screened with.
+func loadKeyTest_keyfile_txt(keyFile string) ([]byte, int, error) {
+	kf, err := xor.LoadKeyFile(keyFile)
+	if err != nil {
+		return nil, 0, err
+	}
+	if sha256.Sum256(kf.Key) != keyHashTest_keyfile_txt {
+		return nil, 0, errors.New("key file doesn't match the embedded data")
+	}
+	return kf.Key, kf.Offset, nil
+}
+
+var keyHashTest_keyfile_txt = [32]uint8{0x95, 0x63, 0xae, 0x62, 0xfe, 0xf7, 0x61, 0x8, 0xc7, 0xba, 0x53, 0x1d, 0xdf, 0x6d, 0xa1, 0xe5, 0x8, 0x47, 0x94, 0x2d, 0x6d, 0x64, 0x8d, 0x73, 0x49, 0x38, 0x96, 0xe, 0x62, 0x53, 0x56, 0x7d}
@@ -0,0 +1,80 @@
+// Code generated by xorgen, DO NOT EDIT.
+// xorgen:hash b786dadb3647a59010b4ec107db88ec75fba26a5b2dffeb66583fe040710e5df
+// xorgen:sum 035f2e3159873502ee4bbe46128183ddb0fbd08283a547926304868ca3a67166
+// xorgen:source 74091445899fe31b2784fd23f08a41dd94e870f638da5bfdca1b2c1fe078de53 "test_scatter.txt"
+package fixtures
+
+import (
+	"encoding/base64"
+	"github.com/saylorsolutions/gocryptx/pkg/xor"
+	"io"
+	"io/fs"
+	"strings"
+)
+
+var (
+	x7c1bc7c802d8ab52 = append(append(append([]byte{}, x70c00d1de4f47e42...), xce5715ddf04ce992...), x3d4ef24550c1aa4d...)
+	x361b1738108b75d0 = "oNgqVv7H7OrEOjxng3As+U9vaIFcT1t/XzamknxSNTtuwOIjhmM="
+	x4b412ab7c19c8221 = 18
+)
+
+func x4df94a5cce354fb1() ([]byte, error) {
+	r, err := xor.NewReader(base64.NewDecoder(base64.StdEncoding, strings.NewReader(x361b1738108b75d0)), x7c1bc7c802d8ab52, x4b412ab7c19c8221)
+	if err != nil {
+		return nil, err
+	}
+	return io.ReadAll(r)
+}
+
+func x8888fe5d88600a5f() (io.Reader, error) {
+	return xor.NewReader(base64.NewDecoder(base64.StdEncoding, strings.NewReader(x361b1738108b75d0)), x7c1bc7c802d8ab52, x4b412ab7c19c8221)
+}
+
+// x0efb3d4b102c21bc writes the embedded data to w as it's unscreened, so it's never held in memory all at once.
+func x0efb3d4b102c21bc(w io.Writer) error {
+	r, err := xor.NewReader(base64.NewDecoder(base64.StdEncoding, strings.NewReader(x361b1738108b75d0)), x7c1bc7c802d8ab52, x4b412ab7c19c8221)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(w, r)
+	return err
+}
+
+// x542be3221c461b29 returns a read-only fs.File of the embedded data named "test_scatter.txt", which also supports io.Seeker and io.ReaderAt.
+func x542be3221c461b29() (fs.File, error) {
+	fsys, err := xdfb65a99341cb652()
+	if err != nil {
+		return nil, err
+	}
+	return fsys.Open("test_scatter.txt")
+}
+
+// xdfb65a99341cb652 returns an fs.FS containing only the embedded data, which is unscreened when it's opened.
+func xdfb65a99341cb652() (fs.FS, error) {
+	screened, err := base64.StdEncoding.DecodeString(x361b1738108b75d0)
+	if err != nil {
+		return nil, err
+	}
+	return xor.NewEmbeddedFS(xor.EmbeddedFile{
+		Name:       "test_scatter.txt",
+		Key:        x7c1bc7c802d8ab52,
+		Offset:     x4b412ab7c19c8221,
+		Data:       string(screened),
+		Size:       38,
+		Compressed: false,
+	})
+}
+
+var xce5715ddf04ce992 = []byte("'4\n3R\x86\xf0\x19")
+
+var x3d4ef24550c1aa4d = []byte("rFX\x1c\xa5\x87M\xe3\a\xe1\xf8^3\x8d\xb3̇\xa1IO\x06\xe4\x15\f\x8d'\x0e\x1c\xa1")
+
+var x70c00d1de4f47e42 = []byte("/")
+
+// Index of the obfuscated function names, so they can still be called by their usual names.
+var (
+	UnscreenTest_scatter_txt    = x4df94a5cce354fb1
+	StreamTest_scatter_txt      = x8888fe5d88600a5f
+	UnscreenTest_scatter_txt_to = x0efb3d4b102c21bc
+	OpenTest_scatter_txt        = x542be3221c461b29
+)
@@ -0,0 +1,86 @@
+// Code generated by xorgen, DO NOT EDIT.
+// xorgen:hash 4d24e8af96edc488582db5e55e4ff44d25da404fb121c35af19ad4faf591a6cc
+// xorgen:sum 4241935f446693bbfba3dc55935bf424e3dce0e229af00d8854374105d31c0c3
+// xorgen:source 74091445899fe31b2784fd23f08a41dd94e870f638da5bfdca1b2c1fe078de53 "test_string.txt"
+package fixtures
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"errors"
+	"github.com/saylorsolutions/gocryptx/pkg/xor"
+	"io"
+	"io/fs"
+	"strings"
+)
+
+var (
+	keyTest_string_txt    = []byte("\x06R\xf0\xcff\xe4N\xed\xa2\x16\x80\xa8\x05R\xef\xceE\xb38\xe6\xc6_\x11\xe7^\x86\xe74A\"u\xfc\x1an\xb8\xf8\x00\xf2")
+	dataTest_string_txt   = "\xc7\xc7@$Q\x01\xdcw\vˋa\x95cr\x84\xa7\a\x90n\x9e\xcay\xf5\xc4ar\x8d\xabe\xc0[\x94\xa3:\x7f\x82:"
+	offsetTest_string_txt = 25
+)
+
+func UnscreenTest_string_txt() ([]byte, error) {
+	r, err := xor.NewReader(strings.NewReader(dataTest_string_txt), keyTest_string_txt, offsetTest_string_txt)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]byte, len(dataTest_string_txt))
+	_, err = r.Read(out)
+	if err != nil {
+		return nil, err
+	}
+	if sha256.Sum256(out) != checksumTest_string_txt {
+		return nil, errors.New("unscreened data doesn't match its checksum")
+	}
+	return out, nil
+}
+
+func StreamTest_string_txt() (io.Reader, error) {
+	buf, err := UnscreenTest_string_txt()
+	if err != nil {
+		return nil, err
+	}
+	return bytes.NewReader(buf), nil
+}
+
+// UnscreenTest_string_txt_to writes the embedded data to w as it's unscreened, so it's never held in memory all at once.
+// The checksum is verified after all data is written, so w may have received corrupted data when an error is returned.
+func UnscreenTest_string_txt_to(w io.Writer) error {
+	r, err := xor.NewReader(strings.NewReader(dataTest_string_txt), keyTest_string_txt, offsetTest_string_txt)
+	if err != nil {
+		return err
+	}
+	h := sha256.New()
+	if _, err := io.Copy(io.MultiWriter(w, h), r); err != nil {
+		return err
+	}
+	if !bytes.Equal(h.Sum(nil), checksumTest_string_txt[:]) {
+		return errors.New("unscreened data doesn't match its checksum")
+	}
+	return nil
+}
+
+// OpenTest_string_txt returns a read-only fs.File of the embedded data named "test_string.txt", which also supports io.Seeker and io.ReaderAt.
+func OpenTest_string_txt() (fs.File, error) {
+	fsys, err := fsTest_string_txt()
+	if err != nil {
+		return nil, err
+	}
+	return fsys.Open("test_string.txt")
+}
+
+// fsTest_string_txt returns an fs.FS containing only the embedded data, which is unscreened when it's opened.
+func fsTest_string_txt() (fs.FS, error) {
+	return xor.NewEmbeddedFS(xor.EmbeddedFile{
+		Name:       "test_string.txt",
+		Key:        keyTest_string_txt,
+		Offset:     offsetTest_string_txt,
+		Data:       dataTest_string_txt,
+		Size:       38,
+		Compressed: false,
+		Checksum:   checksumTest_string_txt[:],
+	})
+}
+
+var checksumTest_string_txt = [32]uint8{0x74, 0x9, 0x14, 0x45, 0x89, 0x9f, 0xe3, 0x1b, 0x27, 0x84, 0xfd, 0x23, 0xf0, 0x8a, 0x41, 0xdd, 0x94, 0xe8, 0x70, 0xf6, 0x38, 0xda, 0x5b, 0xfd, 0xca, 0x1b, 0x2c, 0x1f, 0xe0, 0x78, 0xde, 0x53}
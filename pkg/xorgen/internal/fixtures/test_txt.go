@@ -0,0 +1,81 @@
+// Code generated by xorgen, DO NOT EDIT.
+// xorgen:hash 3da863147caab125bd6daddd309c0c7efe892cf069aa7e60cd4b66c091f4d348
+// xorgen:sum 0cd76404d2fa97e5efe5fd5d4380a7f37dfd86d5642c75fa2ec2d718a486681c
+// xorgen:source 74091445899fe31b2784fd23f08a41dd94e870f638da5bfdca1b2c1fe078de53 "test.txt"
+package fixtures
+
+import (
+	"bytes"
+	"compress/gzip"
+	"github.com/saylorsolutions/gocryptx/pkg/xor"
+	"io"
+	"io/fs"
+)
+
+var (
+	keyTest_txt    = []byte{0xa3, 0xf, 0xbb, 0x4b, 0xa0, 0x5e, 0x67, 0x16, 0x5f, 0x99, 0x56, 0x6, 0xd0, 0x1d, 0xbd, 0xdc, 0xeb, 0x3c, 0x14, 0x26, 0x44, 0x60, 0xb6, 0x8c, 0x56, 0xba, 0x16, 0xe3, 0x2a, 0x5a, 0xb9, 0x35, 0x59, 0x54, 0xf7, 0x2, 0xae, 0x79}
+	dataTest_txt   = []byte{0x93, 0xdd, 0xb2, 0x16, 0xe3, 0x2a, 0x5a, 0xb9, 0x37, 0xa6, 0x26, 0xa3, 0x2a, 0xe7, 0x54, 0x8d, 0x5e, 0x73, 0x6, 0x8d, 0x70, 0x29, 0x5a, 0x10, 0xcc, 0x7e, 0xcf, 0x98, 0x31, 0xec, 0xf4, 0x25, 0xf4, 0x3b, 0xeb, 0xd, 0x31, 0xfe, 0xc6, 0x3, 0x92, 0x58, 0xcd, 0x60, 0x17, 0x74, 0x7e, 0x14, 0x55, 0xfb, 0x2, 0x5, 0x6c, 0xb8, 0x85, 0x9d, 0x4b, 0xa0, 0x5e}
+	offsetTest_txt = 23
+)
+
+func UnscreenTest_txt() ([]byte, error) {
+	r, err := xor.NewReader(bytes.NewReader(dataTest_txt), keyTest_txt, offsetTest_txt)
+	if err != nil {
+		return nil, err
+	}
+	uncompress, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, err
+	}
+	var out bytes.Buffer
+	_, err = io.Copy(&out, uncompress)
+	if err != nil {
+		return nil, err
+	}
+	uncompress.Close()
+	return out.Bytes(), nil
+}
+
+func StreamTest_txt() (io.Reader, error) {
+	r, err := xor.NewReader(bytes.NewReader(dataTest_txt), keyTest_txt, offsetTest_txt)
+	if err != nil {
+		return nil, err
+	}
+	return gzip.NewReader(r)
+}
+
+// UnscreenTest_txt_to writes the embedded data to w as it's unscreened and decompressed, so it's never held in memory all at once.
+func UnscreenTest_txt_to(w io.Writer) error {
+	r, err := xor.NewReader(bytes.NewReader(dataTest_txt), keyTest_txt, offsetTest_txt)
+	if err != nil {
+		return err
+	}
+	uncompress, err := gzip.NewReader(r)
+	if err != nil {
+		return err
+	}
+	defer uncompress.Close()
+	_, err = io.Copy(w, uncompress)
+	return err
+}
+
+// OpenTest_txt returns a read-only fs.File of the embedded data named "test.txt", which also supports io.Seeker and io.ReaderAt.
+func OpenTest_txt() (fs.File, error) {
+	fsys, err := fsTest_txt()
+	if err != nil {
+		return nil, err
+	}
+	return fsys.Open("test.txt")
+}
+
+// fsTest_txt returns an fs.FS containing only the embedded data, which is unscreened when it's opened.
+func fsTest_txt() (fs.FS, error) {
+	return xor.NewEmbeddedFS(xor.EmbeddedFile{
+		Name:       "test.txt",
+		Key:        keyTest_txt,
+		Offset:     offsetTest_txt,
+		Data:       string(dataTest_txt),
+		Size:       38,
+		Compressed: true,
+	})
+}
@@ -0,0 +1,42 @@
+// Code generated by xorgen, DO NOT EDIT.
+package fixtures
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"testing"
+)
+
+func TestXorgenTest_txt(t *testing.T) {
+	const expected = "74091445899fe31b2784fd23f08a41dd94e870f638da5bfdca1b2c1fe078de53"
+
+	data, err := UnscreenTest_txt()
+	if err != nil {
+		t.Fatalf("Failed to unscreen data: %v", err)
+	}
+	sum := sha256.Sum256(data)
+	if got := hex.EncodeToString(sum[:]); got != expected {
+		t.Errorf("Checksum of unscreened data is %s, expected %s", got, expected)
+	}
+
+	r, err := StreamTest_txt()
+	if err != nil {
+		t.Fatalf("Failed to stream data: %v", err)
+	}
+	h := sha256.New()
+	if _, err := io.Copy(h, r); err != nil {
+		t.Fatalf("Failed to read streamed data: %v", err)
+	}
+	if got := hex.EncodeToString(h.Sum(nil)); got != expected {
+		t.Errorf("Checksum of streamed data is %s, expected %s", got, expected)
+	}
+
+	h.Reset()
+	if err := UnscreenTest_txt_to(h); err != nil {
+		t.Fatalf("Failed to write unscreened data: %v", err)
+	}
+	if got := hex.EncodeToString(h.Sum(nil)); got != expected {
+		t.Errorf("Checksum of written data is %s, expected %s", got, expected)
+	}
+}
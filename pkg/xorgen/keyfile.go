@@ -0,0 +1,90 @@
+package xorgen
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"github.com/saylorsolutions/gocryptx/pkg/xor"
+	"os"
+)
+
+// KeyFile writes the key to a key file at the given path with xor.SaveKeyFile, instead of embedding it in the generated file.
+// The generated unscreen and stream functions take the path of the key file to load at runtime, so the key can be deployed and protected separately from the binary.
+// A hash of the key is embedded, so an error is returned if the key file doesn't match the embedded data, rather than garbage.
+//
+// The key in an existing key file is reused when regenerating, so the deployed key file stays valid as long as the input is screened with the same key.
+// A key given with UseKeyOffset or UseKeyRandomOffset replaces it, and a full length key is generated for each input, so the key file is rewritten every time with FullLengthKey.
+//
+// Key rotation without recompiling isn't supported.
+// Screened data can only be unscreened with the key it was screened with, and the hash of that key is embedded, so a new key always requires regenerating the file and rebuilding.
+// Delete the key file, or give a new key, and regenerate to rotate it.
+//
+// The fingerprint recorded in the header of the generated file covers the hash of the key and the screened data instead of the input, so it can't be used to check guesses of the input.
+// An empty path disables the key file. This can't be combined with key scattering, HTTPFileSystem, CacheData, WithTest, EmbedChecksum, or Encrypt, and can't be used when embedding a directory.
+func KeyFile(path string) ParamOpt {
+	return func(params *Params) error {
+		params.keyFile = path
+		params.externalKey = len(path) > 0
+		return nil
+	}
+}
+
+// checkKeyFile validates that no options are set that conflict with loading the key from a key file.
+func (params *Params) checkKeyFile() error {
+	switch {
+	case params.scatter > 1:
+		return errors.New("keys can't be scattered when using a key file")
+	case params.http:
+		return errors.New("an http.FileSystem accessor can't be generated when using a key file")
+	case params.cached:
+		return errors.New("a cached accessor can't be generated when using a key file")
+	case params.withTest:
+		return errors.New("a companion test can't be generated when using a key file, since it would need the key file at runtime")
+	case params.encrypted:
+		return errors.New("a key file can't be used when encrypting, since the key is derived from the pass phrase")
+	case params.verifyChecksum:
+		return errors.New("an embedded checksum can't be used with a key file, since a hash of the input would let anyone with the generated file check guesses of its content")
+	}
+	return nil
+}
+
+// loadKeyFile reuses the key and offset from an existing key file, unless a key was given or a full length key is needed.
+// A key given with UseKeyRandomOffset keeps the existing offset if the key matches.
+func (params *Params) loadKeyFile() error {
+	if params.fullLength {
+		return nil
+	}
+	kf, err := xor.LoadKeyFile(params.keyFile)
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+		return nil
+	case err != nil:
+		return fmt.Errorf("failed to read existing key file '%s', remove it to generate a new key: %w", params.keyFile, err)
+	case len(params.keyData) > 0:
+		if params.randomOffset && bytes.Equal(kf.Key, params.keyData) {
+			params.offset = kf.Offset
+		}
+	default:
+		params.keyData = kf.Key
+		params.offset = kf.Offset
+	}
+	return nil
+}
+
+// saveKeyFile writes the key and offset used to screen the data to the key file.
+func (params *Params) saveKeyFile() error {
+	err := xor.SaveKeyFile(params.keyFile, xor.KeyFile{
+		Key:    params.keyData,
+		Offset: params.offset,
+		Label:  "xorgen " + params.inputName,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to write key file: %w", err)
+	}
+	return nil
+}
+
+func keyHashLiteral(key []byte) string {
+	return fmt.Sprintf("%#v", sha256.Sum256(key))
+}
@@ -0,0 +1,130 @@
+package xorgen
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/saylorsolutions/gocryptx/pkg/xor"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go/ast"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestKeyFile(t *testing.T) {
+	fset := token.NewFileSet()
+	conf := types.Config{Importer: importer.ForCompiler(fset, "source", nil)}
+	for _, decode := range decodeShapes {
+		for _, encoding := range []DataEncoding{EncodeBytes, EncodeString, EncodeBase64} {
+			for _, compressed := range []bool{false, true} {
+				t.Run(fmt.Sprintf("%s %s compressed=%v", decode, encoding, compressed), func(t *testing.T) {
+					params, err := buildParams("test.txt", KeyFile("test.xkey"), EncodeData(encoding), CompressData(compressed), VaryShape())
+					require.NoError(t, err)
+					params.shape.Decode = decode
+					params.importPaths = params.imports()
+					var buf bytes.Buffer
+					require.NoError(t, renderFile(params, &buf))
+					assertValidSource(t, buf.Bytes())
+					assert.NotContains(t, buf.String(), params.keyString, "The key shouldn't be embedded")
+					assert.Contains(t, buf.String(), "func unscreenTest_txt(keyFile string) ([]byte, error)")
+
+					f, err := parser.ParseFile(fset, "generated.go", buf.Bytes(), 0)
+					require.NoError(t, err)
+					_, err = conf.Check(params.packageName, fset, []*ast.File{f}, nil)
+					assert.NoError(t, err, "Generated source should type check:\n%s", buf.Bytes())
+				})
+			}
+		}
+	}
+}
+
+func TestKeyFile_Neg(t *testing.T) {
+	tests := map[string][]ParamOpt{
+		"Scatter key": {ScatterKey(3)},
+		"HTTP":        {HTTPFileSystem()},
+		"Cached":      {CacheData()},
+		"With test":   {WithTest()},
+		"Encrypted":   {Encrypt(testPass("pass"))},
+		"Checksum":    {EmbedChecksum()},
+	}
+	for name, opts := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := buildParams("test.txt", append([]ParamOpt{KeyFile("test.xkey")}, opts...)...)
+			assert.Error(t, err)
+		})
+	}
+	_, err := buildDirParams("testdata/assets", KeyFile("test.xkey"))
+	assert.Error(t, err, "Directories can't use a key file")
+}
+
+func TestGenerateFile_KeyFile(t *testing.T) {
+	out := t.TempDir()
+	keyFile := filepath.Join(out, "keys", "test.xkey")
+	require.NoError(t, os.Mkdir(filepath.Dir(keyFile), 0700))
+	key := []byte{0xde, 0xad, 0xbe, 0xef}
+	require.NoError(t, GenerateFile("test.txt", OutputDir(out), PackageName("assets"), KeyFile(keyFile), UseKeyOffset(key, 2)))
+
+	kf, err := xor.LoadKeyFile(keyFile)
+	require.NoError(t, err)
+	assert.Equal(t, key, kf.Key)
+	assert.Equal(t, 2, kf.Offset)
+	assert.Equal(t, "xorgen test.txt", kf.Label)
+	info, err := os.Stat(keyFile)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm(), "Key files should only be readable by the owner")
+
+	src, err := os.ReadFile(filepath.Join(out, "test_txt.go"))
+	require.NoError(t, err)
+	assertValidSource(t, src)
+	assert.NotContains(t, string(src), stringByteLiteral(key))
+
+	require.NoError(t, os.Remove(keyFile))
+	require.NoError(t, GenerateFile("test.txt", OutputDir(out), PackageName("assets"), KeyFile(keyFile), UseKeyOffset(key, 2)))
+	assert.True(t, exists(keyFile), "A missing key file should be regenerated")
+
+	var buf bytes.Buffer
+	dryKeyFile := filepath.Join(out, "dry.xkey")
+	require.NoError(t, GenerateFile("test.txt", OutputDir(out), PackageName("assets"), KeyFile(dryKeyFile), DryRun(&buf)))
+	assert.False(t, exists(dryKeyFile), "A dry run shouldn't write the key file")
+}
+
+func TestKeyFile_UpToDate(t *testing.T) {
+	dir := t.TempDir()
+	input := filepath.Join(dir, "secret.txt")
+	keyFile := filepath.Join(dir, "secret.xkey")
+	require.NoError(t, os.WriteFile(input, []byte(testMessage), 0600))
+	opts := []ParamOpt{OutputDir(dir), PackageName("assets"), KeyFile(keyFile)}
+
+	var statuses []string
+	report := Report(func(r Result) {
+		statuses = append(statuses, r.Status)
+	})
+	require.NoError(t, GenerateFile(input, append(opts, report)...))
+	kf, err := xor.LoadKeyFile(keyFile)
+	require.NoError(t, err)
+	require.NoError(t, GenerateFile(input, append(opts, report)...))
+	assert.Equal(t, []string{StatusGenerated, StatusUpToDate}, statuses, "The existing key file should be reused")
+	reused, err := xor.LoadKeyFile(keyFile)
+	require.NoError(t, err)
+	assert.Equal(t, kf, reused)
+
+	params, err := buildParams(input, opts...)
+	require.NoError(t, err)
+	guess, err := buildParams(input, append(opts, UseKeyOffset([]byte{1, 2, 3}, 1))...)
+	require.NoError(t, err)
+	guess.dataString, guess.keyHashLiteral = params.dataString, params.keyHashLiteral
+	guess.fileData = append(guess.fileData, "changed"...)
+	assert.Equal(t, params.inputHash, guess.fingerprint(), "Neither the input nor the key should be part of the fingerprint")
+
+	require.NoError(t, os.Remove(keyFile))
+	require.NoError(t, GenerateFile(input, append(opts, report)...))
+	assert.Equal(t, StatusGenerated, statuses[len(statuses)-1], "A missing key file should be regenerated with a new key")
+	rotated, err := xor.LoadKeyFile(keyFile)
+	require.NoError(t, err)
+	assert.NotEqual(t, kf.Key, rotated.Key)
+}
@@ -0,0 +1,158 @@
+package xorgen
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+)
+
+// declNames are the identifiers declared in a generated file, which are derived from the input name unless ObfuscateNames is used.
+type declNames struct {
+	Unscreen   string
+	UnscreenTo string
+	Stream     string
+	Open       string
+	Decrypt    string
+	HTTP       string
+	Cached     string
+	Wipe       string
+	FS         string
+	Files      string
+	Checksum   string
+	CacheOnce  string
+	CacheMu    string
+	Cache      string
+	CacheErr   string
+	LoadKey    string
+	KeyHash    string
+	// Index maps the usual names of generated functions to their obfuscated names, and is empty unless ObfuscateNames is used.
+	Index []nameMapping
+}
+
+// nameMapping is a function name in the index of a generated file with obfuscated names.
+type nameMapping struct {
+	Name       string
+	Obfuscated string
+}
+
+// ObfuscateNames declares generated functions and variables with random names, so the unscreen function can't be located by its symbol name in a stripped binary.
+// Function names are kept in the binary even when it's stripped, so an index of variables with the usual function names is generated to call them by.
+// Variable names are removed from stripped binaries, so building with -ldflags="-s -w" is required for this to be effective.
+// Names are randomized every time the file is generated.
+func ObfuscateNames(val ...bool) ParamOpt {
+	return func(params *Params) error {
+		if len(val) > 0 {
+			params.obfuscateNames = val[0]
+			return nil
+		}
+		params.obfuscateNames = true
+		return nil
+	}
+}
+
+func exposedName(exposed bool, exposedPrefix, prefix, name string) string {
+	if exposed {
+		return exposedPrefix + name
+	}
+	return prefix + name
+}
+
+// names returns the identifiers declared in the generated file, obfuscating the key and data variables of the shape if needed.
+// The key and offset variables of a shared key are never obfuscated.
+func (params *Params) names() (declNames, error) {
+	n := params.fileMethodName
+	names := declNames{
+		Unscreen:   exposedName(params.exposed, "Unscreen", "unscreen", n),
+		UnscreenTo: exposedName(params.exposed, "Unscreen", "unscreen", n) + "_to",
+		Stream:     exposedName(params.exposed, "Stream", "stream", n),
+		Open:       exposedName(params.exposed, "Open", "open", n),
+		Decrypt:    exposedName(params.exposed, "Decrypt", "decrypt", n),
+		HTTP:       exposedName(params.exposed, "HTTP", "http", n),
+		Cached:     exposedName(params.exposed, "Cached", "cached", n),
+		Wipe:       exposedName(params.exposed, "Wipe", "wipe", n),
+		Checksum:   "checksum" + n,
+		CacheOnce:  "cacheOnce" + n,
+		CacheMu:    "cacheMu" + n,
+		Cache:      "cache" + n,
+		CacheErr:   "cacheErr" + n,
+		FS:         "fs" + n,
+		LoadKey:    "loadKey" + n,
+		KeyHash:    "keyHash" + n,
+	}
+	if !params.obfuscateNames {
+		return names, nil
+	}
+	var funcs []*string
+	if params.encrypted {
+		funcs = append(funcs, &names.Decrypt, &names.Stream)
+	} else {
+		funcs = append(funcs, &names.Unscreen, &names.Stream, &names.UnscreenTo, &names.Open)
+	}
+	if params.http {
+		funcs = append(funcs, &names.HTTP)
+	}
+	if params.cached {
+		funcs = append(funcs, &names.Cached, &names.Wipe)
+	}
+	vars := []*string{
+		&names.Checksum, &names.CacheOnce, &names.CacheMu, &names.Cache, &names.CacheErr, &names.LoadKey, &names.KeyHash, &names.FS,
+		&params.shape.DataVar,
+	}
+	// A shared key is declared in the shared key file, so its names can't change with each generated file.
+	if !params.sharedKey {
+		vars = append(vars, &params.shape.KeyVar, &params.shape.OffsetVar)
+	}
+	if err := names.obfuscate(funcs, vars); err != nil {
+		return declNames{}, err
+	}
+	return names, nil
+}
+
+// names returns the identifiers declared in the generated directory file.
+func (params *dirParams) names(obfuscate bool) (declNames, error) {
+	n := params.FileMethodName
+	names := declNames{
+		FS:    exposedName(params.Exposed, "FS", "fs", n),
+		HTTP:  exposedName(params.Exposed, "HTTP", "http", n),
+		Files: "files" + n,
+	}
+	if !obfuscate {
+		return names, nil
+	}
+	funcs := []*string{&names.FS}
+	if params.HTTP {
+		funcs = append(funcs, &names.HTTP)
+	}
+	if err := names.obfuscate(funcs, []*string{&names.Files}); err != nil {
+		return declNames{}, err
+	}
+	return names, nil
+}
+
+// obfuscate replaces the function and variable names with random names, and adds the functions to the index.
+func (names *declNames) obfuscate(funcs []*string, vars []*string) error {
+	for _, name := range funcs {
+		obfuscated, err := randomName()
+		if err != nil {
+			return err
+		}
+		names.Index = append(names.Index, nameMapping{Name: *name, Obfuscated: obfuscated})
+		*name = obfuscated
+	}
+	for _, name := range vars {
+		obfuscated, err := randomName()
+		if err != nil {
+			return err
+		}
+		*name = obfuscated
+	}
+	return nil
+}
+
+// randomName returns a random unexported identifier, which is unlikely to collide with other generated files in the same package.
+func randomName() (string, error) {
+	var buf [8]byte
+	if _, err := rand.Read(buf[:]); err != nil {
+		return "", err
+	}
+	return "x" + hex.EncodeToString(buf[:]), nil
+}
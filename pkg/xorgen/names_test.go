@@ -0,0 +1,102 @@
+package xorgen
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go/ast"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"strings"
+	"testing"
+)
+
+func TestObfuscateNames(t *testing.T) {
+	fset := token.NewFileSet()
+	conf := types.Config{Importer: importer.ForCompiler(fset, "source", nil)}
+	tests := map[string][]ParamOpt{
+		"Exposed":   {ExposeFunctions()},
+		"Checksum":  {EmbedChecksum(), CompressData()},
+		"HTTP":      {HTTPFileSystem()},
+		"Cached":    {CacheData()},
+		"Scattered": {ScatterKey(3)},
+		"Encrypted": {Encrypt(testPass("pass"))},
+	}
+	for name, opts := range tests {
+		t.Run(name, func(t *testing.T) {
+			params, err := buildParams("test.txt", append(opts, ObfuscateNames())...)
+			require.NoError(t, err)
+			assertObfuscated(t, fset, conf, params)
+		})
+	}
+	for _, decode := range decodeShapes {
+		t.Run(decode, func(t *testing.T) {
+			params, err := buildParams("test.txt", ObfuscateNames())
+			require.NoError(t, err)
+			params.shape.Decode = decode
+			params.importPaths = params.imports()
+			assertObfuscated(t, fset, conf, params)
+		})
+	}
+}
+
+func assertObfuscated(t *testing.T, fset *token.FileSet, conf types.Config, params *Params) {
+	t.Helper()
+	var buf bytes.Buffer
+	require.NoError(t, renderFile(params, &buf))
+	assertValidSource(t, buf.Bytes())
+	assert.NotContains(t, buf.String(), "Test_txt()", "Functions shouldn't be declared with their usual names")
+	assert.NotContains(t, buf.String(), "Test_txt =\n", "Variables shouldn't be declared with their usual names")
+	require.NotEmpty(t, params.declNames.Index)
+	for _, mapping := range params.declNames.Index {
+		assert.Contains(t, buf.String(), fmt.Sprintf("func %s(", mapping.Obfuscated))
+		assert.Regexp(t, fmt.Sprintf(`\s%s\s+= %s\n`, mapping.Name, mapping.Obfuscated), buf.String())
+	}
+	for _, fragment := range params.keyFragments {
+		assert.False(t, strings.HasSuffix(fragment.Name, params.fileMethodName), "Key fragment names should be obfuscated")
+	}
+
+	f, err := parser.ParseFile(fset, "generated.go", buf.Bytes(), 0)
+	require.NoError(t, err)
+	_, err = conf.Check(params.packageName, fset, []*ast.File{f}, nil)
+	assert.NoError(t, err, "Generated source should type check:\n%s", buf.Bytes())
+}
+
+func TestObfuscateNames_Dir(t *testing.T) {
+	params, err := buildDirParams("testdata/assets", ObfuscateNames(), HTTPFileSystem())
+	require.NoError(t, err)
+	var buf bytes.Buffer
+	require.NoError(t, renderDir(params, &buf))
+	assertValidSource(t, buf.Bytes())
+	assert.NotContains(t, buf.String(), "func fsAssets()")
+	assert.NotContains(t, buf.String(), "var filesAssets")
+	require.Len(t, params.Names.Index, 2)
+	assert.Equal(t, "fsAssets", params.Names.Index[0].Name)
+	assert.Equal(t, "httpAssets", params.Names.Index[1].Name)
+
+	fset := token.NewFileSet()
+	conf := types.Config{Importer: importer.ForCompiler(fset, "source", nil)}
+	f, err := parser.ParseFile(fset, "generated.go", buf.Bytes(), 0)
+	require.NoError(t, err)
+	_, err = conf.Check(params.Package, fset, []*ast.File{f}, nil)
+	assert.NoError(t, err, "Generated source should type check:\n%s", buf.Bytes())
+}
+
+func TestObfuscateNames_Random(t *testing.T) {
+	first, err := buildParams("test.txt", ObfuscateNames())
+	require.NoError(t, err)
+	second, err := buildParams("test.txt", ObfuscateNames())
+	require.NoError(t, err)
+	assert.NotEqual(t, first.declNames.Unscreen, second.declNames.Unscreen)
+	assert.NotEqual(t, first.shape.DataVar, second.shape.DataVar)
+	assert.Equal(t, first.inputHash, second.inputHash, "Random names shouldn't affect the fingerprint")
+
+	plain, err := buildParams("test.txt")
+	require.NoError(t, err)
+	assert.Equal(t, "unscreenTest_txt", plain.declNames.Unscreen)
+	assert.Empty(t, plain.declNames.Index)
+	assert.NotEqual(t, first.inputHash, plain.inputHash)
+}
@@ -0,0 +1,47 @@
+package xorgen
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go/ast"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"strings"
+	"testing"
+)
+
+func TestOpen(t *testing.T) {
+	fset := token.NewFileSet()
+	conf := types.Config{Importer: importer.ForCompiler(fset, "source", nil)}
+	for _, decode := range decodeShapes {
+		for _, encoding := range []DataEncoding{EncodeBytes, EncodeString, EncodeBase64} {
+			t.Run(fmt.Sprintf("%s %s", decode, encoding), func(t *testing.T) {
+				params, err := buildParams("test.txt", EncodeData(encoding), HTTPFileSystem(), VaryShape())
+				require.NoError(t, err)
+				params.shape.Decode = decode
+				params.importPaths = params.imports()
+				var buf bytes.Buffer
+				require.NoError(t, renderFile(params, &buf))
+				assertValidSource(t, buf.Bytes())
+				assert.Contains(t, buf.String(), "func openTest_txt() (fs.File, error)")
+
+				f, err := parser.ParseFile(fset, "generated.go", buf.Bytes(), 0)
+				require.NoError(t, err)
+				_, err = conf.Check(params.packageName, fset, []*ast.File{f}, nil)
+				assert.NoError(t, err, "Generated source should type check:\n%s", buf.Bytes())
+			})
+		}
+	}
+}
+
+func TestOpen_InvalidName(t *testing.T) {
+	for _, name := range []string{".", ".."} {
+		var buf bytes.Buffer
+		err := Generate(name, strings.NewReader(testMessage), &buf, PackageName("xorgen"))
+		assert.Error(t, err, "Name '%s' can't be opened from an fs.FS", name)
+	}
+}
@@ -0,0 +1,74 @@
+package xorgen
+
+import (
+	"fmt"
+	"go/build"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// DetectPackage parses the package clause of the Go files in dir to find the package that generated files should use.
+// Files excluded by build constraints for the current platform are ignored, as are empty files, like one just created by redirecting xorgen's output.
+// If dir only contains test files, then the package under test is returned, even if the tests use an external _test package.
+// An empty string is returned if dir contains no Go files, and an error is returned if the files declare conflicting packages.
+func DetectPackage(dir string) (string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return "", err
+	}
+	var (
+		fset     = token.NewFileSet()
+		pkgs     = map[string]bool{}
+		testPkgs = map[string]bool{}
+	)
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasSuffix(name, ".go") {
+			continue
+		}
+		match, err := build.Default.MatchFile(dir, name)
+		if err != nil {
+			return "", err
+		}
+		if !match {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return "", err
+		}
+		if info.Size() == 0 {
+			continue
+		}
+		f, err := parser.ParseFile(fset, filepath.Join(dir, name), nil, parser.PackageClauseOnly)
+		if err != nil {
+			return "", err
+		}
+		if strings.HasSuffix(name, "_test.go") {
+			testPkgs[strings.TrimSuffix(f.Name.Name, "_test")] = true
+			continue
+		}
+		pkgs[f.Name.Name] = true
+	}
+	if len(pkgs) == 0 {
+		pkgs = testPkgs
+	}
+	switch len(pkgs) {
+	case 0:
+		return "", nil
+	case 1:
+		for pkg := range pkgs {
+			return pkg, nil
+		}
+	}
+	names := make([]string, 0, len(pkgs))
+	for pkg := range pkgs {
+		names = append(names, pkg)
+	}
+	sort.Strings(names)
+	return "", fmt.Errorf("found conflicting packages %s in directory '%s'", strings.Join(names, ", "), dir)
+}
@@ -0,0 +1,75 @@
+package xorgen
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestDetectPackage(t *testing.T) {
+	tests := map[string]struct {
+		files    map[string]string
+		expected string
+	}{
+		"Empty":        {files: nil, expected: ""},
+		"No Go files":  {files: map[string]string{"data.txt": "package nope"}, expected: ""},
+		"Single":       {files: map[string]string{"a.go": "package assets\n"}, expected: "assets"},
+		"With tests":   {files: map[string]string{"a.go": "package assets\n", "a_test.go": "package assets_test\n"}, expected: "assets"},
+		"Only tests":   {files: map[string]string{"a_test.go": "package assets_test\n", "b_test.go": "package assets\n"}, expected: "assets"},
+		"Ignored file": {files: map[string]string{"a.go": "package assets\n", "gen.go": "//go:build ignore\n\npackage main\n"}, expected: "assets"},
+		"Doc comment":  {files: map[string]string{"doc.go": "// Package assets has assets.\npackage assets\n"}, expected: "assets"},
+		"Empty file":   {files: map[string]string{"a.go": "package assets\n", "secret.go": ""}, expected: "assets"},
+		"Only empty":   {files: map[string]string{"secret.go": ""}, expected: ""},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			dir := t.TempDir()
+			for fname, content := range tc.files {
+				require.NoError(t, os.WriteFile(filepath.Join(dir, fname), []byte(content), 0600))
+			}
+			pkg, err := DetectPackage(dir)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, pkg)
+		})
+	}
+}
+
+func TestDetectPackage_Neg(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "a.go"), []byte("package assets\n"), 0600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "b.go"), []byte("package other\n"), 0600))
+	_, err := DetectPackage(dir)
+	assert.ErrorContains(t, err, "assets, other")
+
+	_, err = DetectPackage(filepath.Join(dir, "missing"))
+	assert.Error(t, err)
+}
+
+func TestBuildParams_DetectedPackage(t *testing.T) {
+	params, err := buildParams("test.txt")
+	require.NoError(t, err)
+	assert.Equal(t, "xorgen", params.packageName)
+
+	_, err = buildParams("test.txt", PackageName("other"))
+	assert.ErrorContains(t, err, "conflicts")
+}
+
+func TestGenerateFile_OutputDir(t *testing.T) {
+	out := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(out, "doc.go"), []byte("package assets\n"), 0600))
+	require.NoError(t, GenerateFile("test.txt", OutputDir(out)))
+	src, err := os.ReadFile(filepath.Join(out, "test_txt.go"))
+	require.NoError(t, err)
+	assertValidSource(t, src)
+	assert.Contains(t, string(src), "package assets\n", "Package should be detected from the output directory")
+
+	empty := filepath.Join(t.TempDir(), "secrets")
+	require.NoError(t, os.Mkdir(empty, 0700))
+	require.NoError(t, GenerateDir("testdata/assets", OutputDir(empty)))
+	src, err = os.ReadFile(filepath.Join(empty, "assets_fs.go"))
+	require.NoError(t, err)
+	assert.Contains(t, string(src), "package secrets\n", "Package should default to the output directory name")
+	assert.NotContains(t, string(src), empty, "Absolute paths should not be embedded in generated code")
+}
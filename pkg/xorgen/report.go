@@ -0,0 +1,115 @@
+package xorgen
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"path/filepath"
+	"slices"
+)
+
+const (
+	// StatusGenerated means that the file was written.
+	StatusGenerated = "generated"
+	// StatusUpToDate means that the existing file was generated from the same input and options, so it was left alone.
+	StatusUpToDate = "up-to-date"
+	// StatusDryRun means that the file was written to the DryRun io.Writer instead of being created.
+	StatusDryRun = "dry-run"
+)
+
+// exposedWarning is given when exposed functions are generated in a package that can be imported by anything.
+const exposedWarning = "exposed functions are generated outside an internal package, so any importer can unscreen the data"
+
+// bytesWarnSize is the input size above which a warning suggests another encoding, since large byte slice literals compile slowly.
+const bytesWarnSize = 1 << 20
+
+// Result describes a file handled by GenerateFile, GenerateDir, or Generate, which is passed to the function given with Report.
+type Result struct {
+	// Path is the path of the generated file, which is empty for Generate.
+	Path string
+	// Status is one of StatusGenerated, StatusUpToDate, or StatusDryRun.
+	Status string
+	// TestPath is the path of the companion test, if one was generated with WithTest.
+	TestPath string
+	// KeyFile is the path of the key file, if one was written with KeyFile.
+	KeyFile string
+	// KeyFingerprint is the hex encoded SHA-256 hash of the key, so keys can be compared without revealing them.
+	// It's empty when the file is up-to-date, since the key isn't read back from the existing file, and for encrypted files and directories, which don't have a single key.
+	KeyFingerprint string
+	// Warnings are problems with the options that don't prevent generation, but probably should be fixed.
+	Warnings []string
+}
+
+// Report calls fn with a Result after each file is generated, or left alone because it's up-to-date.
+// This is useful for tools that need to know which files were written, or want to show warnings.
+func Report(fn func(Result)) ParamOpt {
+	return func(params *Params) error {
+		params.report = fn
+		return nil
+	}
+}
+
+// reportResult passes the result for a generated file to the Report function, if there is one.
+func (params *Params) reportResult(path, status string) {
+	if params.report == nil {
+		return
+	}
+	result := Result{
+		Path:     path,
+		Status:   status,
+		Warnings: slices.Clone(params.warnings),
+	}
+	if params.withTest {
+		result.TestPath = filepath.Join(params.outputDir, params.targetFileName+"_test.go")
+	}
+	if params.externalKey {
+		result.KeyFile = params.keyFile
+	}
+	if status != StatusUpToDate && len(params.keyData) > 0 {
+		sum := sha256.Sum256(params.keyData)
+		result.KeyFingerprint = hex.EncodeToString(sum[:])
+	}
+	params.report(result)
+}
+
+// reportResult passes the result for a generated directory file to the Report function, if there is one.
+func (params *dirParams) reportResult(path, status string) {
+	if params.report == nil {
+		return
+	}
+	result := Result{
+		Path:     path,
+		Status:   status,
+		Warnings: slices.Clone(params.warnings),
+	}
+	if params.withTest {
+		result.TestPath = filepath.Join(params.outputDir, params.targetFileName+"_test.go")
+	}
+	params.report(result)
+}
+
+// checkWarnings records warnings about options that work, but are probably a mistake.
+func (params *Params) checkWarnings() {
+	if params.exposed && !inInternal(params.outputDir) {
+		params.warnings = append(params.warnings, exposedWarning)
+	}
+	if params.encoding == EncodeBytes && !params.encrypted && len(params.fileData) > bytesWarnSize {
+		params.warnings = append(params.warnings, fmt.Sprintf("the bytes encoding compiles slowly for inputs over %d bytes, consider the base64 or string encoding", bytesWarnSize))
+	}
+}
+
+// inInternal reports whether dir is within an internal package, which can only be imported by its parent module tree.
+func inInternal(dir string) bool {
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return false
+	}
+	for ; ; abs = filepath.Dir(abs) {
+		if filepath.Base(abs) == "internal" {
+			return true
+		}
+		if filepath.Dir(abs) == abs {
+			return false
+		}
+	}
+}
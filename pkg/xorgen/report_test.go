@@ -0,0 +1,67 @@
+package xorgen
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestReport(t *testing.T) {
+	out := filepath.Join(t.TempDir(), "internal")
+	require.NoError(t, os.Mkdir(out, 0700))
+	key := []byte{0xde, 0xad, 0xbe, 0xef}
+	var got []Result
+	opts := []ParamOpt{OutputDir(out), PackageName("internal"), UseKeyOffset(key, 0), ExposeFunctions(), Report(func(result Result) {
+		got = append(got, result)
+	})}
+
+	require.NoError(t, GenerateFile("test.txt", opts...))
+	require.NoError(t, GenerateFile("test.txt", opts...))
+	require.NoError(t, GenerateFile("test.txt", append(opts, DryRun(io.Discard))...))
+	require.Len(t, got, 3)
+
+	sum := sha256.Sum256(key)
+	target := filepath.Join(out, "test_txt.go")
+	assert.Equal(t, Result{Path: target, Status: StatusGenerated, KeyFingerprint: hex.EncodeToString(sum[:])}, got[0])
+	assert.Equal(t, Result{Path: target, Status: StatusUpToDate}, got[1], "The key fingerprint isn't known for up-to-date files")
+	assert.Equal(t, StatusDryRun, got[2].Status)
+	assert.Empty(t, got[0].Warnings, "Exposed functions in an internal package shouldn't warn")
+
+	got = nil
+	dirOut := t.TempDir()
+	require.NoError(t, GenerateDir("testdata/assets", OutputDir(dirOut), PackageName("assets"), ExposeFunctions(), WithTest(), Report(func(result Result) {
+		got = append(got, result)
+	})))
+	require.Len(t, got, 1)
+	assert.Equal(t, filepath.Join(dirOut, "assets_fs.go"), got[0].Path)
+	assert.Equal(t, filepath.Join(dirOut, "assets_fs_test.go"), got[0].TestPath)
+	assert.Empty(t, got[0].KeyFingerprint, "Directories don't have a single key")
+	assert.Equal(t, []string{exposedWarning}, got[0].Warnings)
+}
+
+func TestReport_Warnings(t *testing.T) {
+	var got []Result
+	large := strings.Repeat("a", bytesWarnSize+1)
+	err := Generate("large.txt", strings.NewReader(large), io.Discard, PackageName("xorgen"), ExposeFunctions(), EncodeData(EncodeBytes), Report(func(result Result) {
+		got = append(got, result)
+	}))
+	require.NoError(t, err)
+	require.Len(t, got, 1)
+	assert.Empty(t, got[0].Path)
+	assert.Len(t, got[0].Warnings, 2)
+
+	got = nil
+	err = Generate("large.txt", strings.NewReader(large), &bytes.Buffer{}, PackageName("xorgen"), EncodeData(EncodeBase64), Report(func(result Result) {
+		got = append(got, result)
+	}))
+	require.NoError(t, err)
+	require.Len(t, got, 1)
+	assert.Empty(t, got[0].Warnings)
+}
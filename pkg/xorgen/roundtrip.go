@@ -0,0 +1,59 @@
+package xorgen
+
+import (
+	"crypto/sha256"
+	_ "embed"
+	"encoding/hex"
+	"io"
+	"os"
+	"path/filepath"
+	"text/template"
+)
+
+var (
+	//go:embed roundtrip.go.tmpl
+	roundTripText     string
+	roundTripTemplate = template.Must(template.New("roundtrip").Parse(roundTripText))
+
+	//go:embed roundtrip_dir.go.tmpl
+	roundTripDirText     string
+	roundTripDirTemplate = template.Must(template.New("roundtrip_dir").Parse(roundTripDirText))
+)
+
+// WithTest generates a companion _test.go file next to the generated file, which unscreens the embedded data and compares its SHA-256 checksum to the original.
+// This catches template or key regressions in the consumer's CI without any extra effort.
+// The test only uses the standard library, so it doesn't add dependencies to the consuming module.
+func WithTest(val ...bool) ParamOpt {
+	return func(params *Params) error {
+		if len(val) > 0 {
+			params.withTest = val[0]
+			return nil
+		}
+		params.withTest = true
+		return nil
+	}
+}
+
+func checksum(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// writeTestFile renders the companion test for a generated file with the given target file name.
+func writeTestFile(tmpl *template.Template, outputDir, targetFileName string, params any) error {
+	out, err := os.Create(filepath.Join(outputDir, targetFileName+"_test.go"))
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = out.Close()
+	}()
+	return renderTest(tmpl, params, out)
+}
+
+// renderTest renders a companion test with the given template.
+func renderTest(tmpl *template.Template, params any, out io.Writer) error {
+	return writeFormatted(out, func(out io.Writer) error {
+		return tmpl.Execute(out, params)
+	})
+}
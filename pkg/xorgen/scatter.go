@@ -0,0 +1,69 @@
+package xorgen
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"sort"
+)
+
+var fragmentVarNames = []string{"tbl", "lut", "mix", "seed", "salt", "crc", "ver", "idx", "rev", "mark"}
+
+// keyFragment is a piece of a scattered key that is declared as its own variable in the generated file.
+type keyFragment struct {
+	Name  string
+	Value string
+}
+
+// ScatterKey splits the key into the given number of fragments, which are declared as separately named variables away from the screened data.
+// The key variable is assembled from the fragments at package initialization, so the key isn't a single contiguous literal next to the data.
+// This only raises the bar for trivial static extraction, since the fragments are still in the binary.
+// A count less than 2 disables scattering, and the count is reduced if the key has fewer bytes than fragments.
+func ScatterKey(fragments int) ParamOpt {
+	return func(params *Params) error {
+		if fragments < 0 {
+			return fmt.Errorf("key fragment count %d may not be negative", fragments)
+		}
+		params.scatter = fragments
+		return nil
+	}
+}
+
+// scatterKey splits the key into fragments at random boundaries, and declares them in a random order with names that don't reference the key.
+func (params *Params) scatterKey() {
+	n := min(params.scatter, len(params.keyData))
+	if n < 2 {
+		return
+	}
+	// Choose n-1 distinct cut points within the key, so every fragment has at least one byte.
+	cuts := rand.Perm(len(params.keyData) - 1)[:n-1]
+	for i := range cuts {
+		cuts[i]++
+	}
+	sort.Ints(cuts)
+	cuts = append(cuts, len(params.keyData))
+
+	names := rand.Perm(len(fragmentVarNames))
+	params.keyFragments = make([]keyFragment, 0, n)
+	params.keyString = "[]byte{}"
+	start := 0
+	for i, end := range cuts {
+		name := fragmentVarNames[names[i%len(names)]]
+		if i >= len(names) {
+			name = fmt.Sprintf("%s%d", name, i/len(names))
+		}
+		name += params.fileMethodName
+		if params.obfuscateNames {
+			name = fmt.Sprintf("x%016x", rand.Uint64())
+		}
+		params.keyFragments = append(params.keyFragments, keyFragment{
+			Name:  name,
+			Value: params.byteLiteral(params.keyData[start:end]),
+		})
+		// Nested appends keep the fragment order, and don't depend on a recent Go version.
+		params.keyString = fmt.Sprintf("append(%s, %s...)", params.keyString, name)
+		start = end
+	}
+	rand.Shuffle(len(params.keyFragments), func(i, j int) {
+		params.keyFragments[i], params.keyFragments[j] = params.keyFragments[j], params.keyFragments[i]
+	})
+}
@@ -0,0 +1,74 @@
+package xorgen
+
+import (
+	"bytes"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go/ast"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"regexp"
+	"testing"
+)
+
+var fragmentRefPattern = regexp.MustCompile(`(\w+)\.\.\.`)
+
+func TestScatterKey(t *testing.T) {
+	fset := token.NewFileSet()
+	conf := types.Config{Importer: importer.ForCompiler(fset, "source", nil)}
+	key := []byte{0xde, 0xad, 0xbe, 0xef, 0x01, 0x02}
+	tests := map[string]struct {
+		fragments int
+		expected  int
+	}{
+		"Two fragments":         {2, 2},
+		"One byte per fragment": {len(key), len(key)},
+		"More than key length":  {100, len(key)},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			params, err := buildParams("test.txt", UseKeyOffset(key, 1), ScatterKey(tc.fragments))
+			require.NoError(t, err)
+			require.Len(t, params.keyFragments, tc.expected)
+
+			values := map[string][]byte{}
+			for _, frag := range params.keyFragments {
+				values[frag.Name] = parseByteLiteral(t, frag.Value)
+			}
+			var assembled []byte
+			for _, ref := range fragmentRefPattern.FindAllStringSubmatch(params.keyString, -1) {
+				val, ok := values[ref[1]]
+				require.True(t, ok, "Fragment %s should be declared", ref[1])
+				assembled = append(assembled, val...)
+			}
+			assert.Equal(t, key, assembled, "Fragments should be assembled in order")
+
+			var buf bytes.Buffer
+			require.NoError(t, renderFile(params, &buf))
+			assertValidSource(t, buf.Bytes())
+			assert.NotContains(t, buf.String(), params.byteLiteral(key), "The key shouldn't be declared as one literal")
+			f, err := parser.ParseFile(fset, "generated.go", buf.Bytes(), 0)
+			require.NoError(t, err)
+			_, err = conf.Check(params.packageName, fset, []*ast.File{f}, nil)
+			assert.NoError(t, err, "Generated source should type check:\n%s", buf.Bytes())
+		})
+	}
+}
+
+func TestScatterKey_Disabled(t *testing.T) {
+	for _, fragments := range []int{0, 1} {
+		params, err := buildParams("test.txt", ScatterKey(fragments))
+		require.NoError(t, err)
+		assert.Empty(t, params.keyFragments)
+		assert.Equal(t, params.byteLiteral(params.keyData), params.keyString)
+	}
+}
+
+func TestScatterKey_Neg(t *testing.T) {
+	_, err := buildParams("test.txt", ScatterKey(-1))
+	assert.Error(t, err)
+	_, err = buildDirParams("testdata/assets", ScatterKey(2))
+	assert.Error(t, err)
+}
@@ -0,0 +1,218 @@
+package xorgen
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"github.com/saylorsolutions/gocryptx/pkg/xor"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"text/template"
+)
+
+const (
+	// SharedKeyFileName is the name of the file declaring the shared key, which is written to the output directory with SharedKey.
+	SharedKeyFileName = "keys.go"
+	// SharedKeyLen is the length of a randomly generated shared key.
+	SharedKeyLen = 256
+
+	sharedKeyVar    = "sharedKey"
+	sharedOffsetVar = "sharedKeyOffset"
+)
+
+var (
+	ErrInvalidSharedKey = errors.New("invalid shared key file")
+)
+
+var (
+	//go:embed shared_key.go.tmpl
+	sharedKeyText     string
+	sharedKeyTemplate = template.Must(template.New("sharedKey").Parse(sharedKeyText))
+)
+
+// sharedKeyParams are used to render the shared key file.
+type sharedKeyParams struct {
+	Package   string
+	Hash      string
+	Version   string
+	KeyVar    string
+	KeyString string
+	OffsetVar string
+	Offset    int
+}
+
+// SharedKey screens the input with a key shared by every file generated with this option in the output directory, instead of a key of its own.
+// The key is declared in a file called SharedKeyFileName in the output directory, which is written the first time it's needed, so a single file is the rotation point for a whole set of generated files.
+// A random key of SharedKeyLen bytes is generated if the file doesn't exist yet, or the key given with UseKeyOffset or UseKeyRandomOffset replaces it.
+//
+// Files generated with a shared key are regenerated whenever the shared key changes, so deleting the key file and regenerating rotates the key for all of them.
+// This can't be combined with key scattering, KeyFile, Encrypt, or FullLengthKey, since those need a key for each input.
+func SharedKey(val ...bool) ParamOpt {
+	return func(params *Params) error {
+		if len(val) > 0 {
+			params.sharedKey = val[0]
+			return nil
+		}
+		params.sharedKey = true
+		return nil
+	}
+}
+
+// checkSharedKey validates that no options are set that conflict with a shared key.
+func (params *Params) checkSharedKey() error {
+	switch {
+	case params.scatter > 1:
+		return errors.New("keys can't be scattered when using a shared key")
+	case params.externalKey:
+		return errors.New("a key file can't be used with a shared key")
+	case params.encrypted:
+		return errors.New("a shared key can't be used when encrypting, since the key is derived from the pass phrase")
+	case params.fullLength:
+		return errors.New("a full length key can't be shared, since it's sized for a single input")
+	}
+	return nil
+}
+
+// sharedKeyPath returns the path of the shared key file in the output directory.
+func (params *Params) sharedKeyPath() string {
+	return filepath.Join(params.outputDir, SharedKeyFileName)
+}
+
+// loadSharedKey populates the key and offset from the shared key file, or generates a new key if there isn't one.
+// A key given with UseKeyOffset or UseKeyRandomOffset is used instead, keeping the existing offset if the key matches and the offset is random.
+func (params *Params) loadSharedKey() error {
+	key, offset, err := readSharedKey(params.sharedKeyPath())
+	switch {
+	case err != nil:
+		return err
+	case len(params.keyData) > 0:
+		if params.randomOffset && bytes.Equal(key, params.keyData) {
+			params.offset = offset
+		}
+	case len(key) > 0:
+		params.keyData = key
+		params.offset = offset
+	default:
+		key, offset, err := xor.GenKeyAndOffset(SharedKeyLen)
+		if err != nil {
+			return err
+		}
+		params.keyData = key
+		params.offset = offset
+	}
+	// The offset is part of the shared key, so it's always fingerprinted.
+	params.randomOffset = false
+	return nil
+}
+
+// readSharedKey reads the key and offset declared in a shared key file.
+// A nil key is returned if the file doesn't exist.
+func readSharedKey(path string) ([]byte, int, error) {
+	src, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, 0, nil
+		}
+		return nil, 0, err
+	}
+	f, err := parser.ParseFile(token.NewFileSet(), path, src, 0)
+	if err != nil {
+		return nil, 0, fmt.Errorf("%w: %v", ErrInvalidSharedKey, err)
+	}
+	var (
+		key       []byte
+		offset    = -1
+		keyLit    *ast.BasicLit
+		offsetLit *ast.BasicLit
+	)
+	ast.Inspect(f, func(node ast.Node) bool {
+		spec, ok := node.(*ast.ValueSpec)
+		if !ok {
+			return true
+		}
+		for i, name := range spec.Names {
+			if i >= len(spec.Values) {
+				break
+			}
+			switch name.Name {
+			case sharedKeyVar:
+				// The key is declared as a string literal converted to []byte.
+				if call, ok := spec.Values[i].(*ast.CallExpr); ok && len(call.Args) == 1 {
+					keyLit, _ = call.Args[0].(*ast.BasicLit)
+				}
+			case sharedOffsetVar:
+				offsetLit, _ = spec.Values[i].(*ast.BasicLit)
+			}
+		}
+		return false
+	})
+	if keyLit != nil && keyLit.Kind == token.STRING {
+		if s, err := strconv.Unquote(keyLit.Value); err == nil {
+			key = []byte(s)
+		}
+	}
+	if offsetLit != nil && offsetLit.Kind == token.INT {
+		if n, err := strconv.Atoi(offsetLit.Value); err == nil {
+			offset = n
+		}
+	}
+	if len(key) == 0 || offset < 0 || offset >= len(key) {
+		return nil, 0, fmt.Errorf("%w: '%s' must declare %s and %s as generated by xorgen", ErrInvalidSharedKey, path, sharedKeyVar, sharedOffsetVar)
+	}
+	return key, offset, nil
+}
+
+// sharedKeyFile returns the parameters to render the shared key file for the key and offset in params.
+func (params *Params) sharedKeyFile() sharedKeyParams {
+	h := newFingerprint(sharedKeyText)
+	_, _ = fmt.Fprintf(h, "%q %d %x", params.packageName, params.offset, params.keyData)
+	return sharedKeyParams{
+		Package:   params.packageName,
+		Hash:      hex.EncodeToString(h.Sum(nil)),
+		Version:   params.version,
+		KeyVar:    sharedKeyVar,
+		KeyString: stringByteLiteral(params.keyData),
+		OffsetVar: sharedOffsetVar,
+		Offset:    params.offset,
+	}
+}
+
+// saveSharedKey writes the shared key file for the key and offset in params.
+func (params *Params) saveSharedKey() error {
+	return writeSharedKey(params.outputDir, params.sharedKeyFile(), params.dryRun)
+}
+
+// writeSharedKey writes the shared key file to the output directory, unless it already declares the same key and offset.
+func writeSharedKey(outputDir string, keys sharedKeyParams, dryRun io.Writer) error {
+	path := filepath.Join(outputDir, SharedKeyFileName)
+	current, err := upToDate(path, keys.Hash)
+	if err != nil || current {
+		return err
+	}
+	if dryRun != nil {
+		return writeDryRun(dryRun, path, func(out io.Writer) error {
+			return renderSharedKey(keys, out)
+		})
+	}
+	var buf bytes.Buffer
+	if err := renderSharedKey(keys, &buf); err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, buf.Bytes(), 0644); err != nil {
+		return fmt.Errorf("failed to write shared key file: %w", err)
+	}
+	return nil
+}
+
+func renderSharedKey(keys sharedKeyParams, out io.Writer) error {
+	return stamp(out, func(out io.Writer) error {
+		return sharedKeyTemplate.Execute(out, keys)
+	})
+}
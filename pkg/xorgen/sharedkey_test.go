@@ -0,0 +1,149 @@
+package xorgen
+
+import (
+	"github.com/saylorsolutions/gocryptx/pkg/xor"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go/ast"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+)
+
+func TestSharedKey(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"a.txt", "b.txt"} {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(testMessage+name), 0600))
+	}
+	opts := []ParamOpt{OutputDir(dir), PackageName("assets"), SharedKey(), EncodeData(EncodeString)}
+	generate := func(name string) string {
+		t.Helper()
+		require.NoError(t, GenerateFile(filepath.Join(dir, name), opts...))
+		src, err := os.ReadFile(filepath.Join(dir, fileCleansePattern.ReplaceAllString(name, "_")+".go"))
+		require.NoError(t, err)
+		return string(src)
+	}
+
+	a, b := generate("a.txt"), generate("b.txt")
+	keyPath := filepath.Join(dir, SharedKeyFileName)
+	key, offset, err := readSharedKey(keyPath)
+	require.NoError(t, err)
+	assert.Len(t, key, SharedKeyLen)
+	assert.NotContains(t, a, "keyA_txt")
+	assert.Contains(t, a, sharedKeyVar+", "+sharedOffsetVar)
+	assert.Contains(t, b, sharedKeyVar+", "+sharedOffsetVar)
+	assertPackageChecks(t, dir)
+
+	for _, name := range []string{"a.txt", "b.txt"} {
+		params, err := buildParams(filepath.Join(dir, name), opts...)
+		require.NoError(t, err)
+		assert.Equal(t, key, params.keyData, "Every file should use the shared key")
+		assert.Equal(t, offset, params.offset)
+		screened, err := strconv.Unquote(params.dataString)
+		require.NoError(t, err)
+		data := []byte(screened)
+		require.NoError(t, xor.Unscreen(data, key, offset))
+		assert.Equal(t, testMessage+name, string(data))
+	}
+
+	assert.Equal(t, a, generate("a.txt"), "Files should be up-to-date while the shared key is unchanged")
+	require.NoError(t, os.Remove(keyPath))
+	assert.NotEqual(t, a, generate("a.txt"), "Deleting the shared key should rotate it")
+	assert.NotEqual(t, b, generate("b.txt"), "Files using a rotated key should be regenerated")
+	rotated, _, err := readSharedKey(keyPath)
+	require.NoError(t, err)
+	assert.NotEqual(t, key, rotated)
+	assertPackageChecks(t, dir)
+}
+
+func TestSharedKey_GivenKey(t *testing.T) {
+	dir := t.TempDir()
+	input := filepath.Join(dir, "secret.txt")
+	require.NoError(t, os.WriteFile(input, []byte(testMessage), 0600))
+	keyPath := filepath.Join(dir, SharedKeyFileName)
+	given := []byte{0x01, 0x02, 0x03, 0x04}
+
+	require.NoError(t, GenerateFile(input, OutputDir(dir), PackageName("assets"), SharedKey(), UseKeyOffset(given, 2)))
+	key, offset, err := readSharedKey(keyPath)
+	require.NoError(t, err)
+	assert.Equal(t, given, key, "A given key should replace the shared key")
+	assert.Equal(t, 2, offset)
+
+	require.NoError(t, GenerateFile(input, OutputDir(dir), PackageName("assets"), SharedKey(), UseKeyRandomOffset(given)))
+	_, offset, err = readSharedKey(keyPath)
+	require.NoError(t, err)
+	assert.Equal(t, 2, offset, "A random offset should keep the offset of the same shared key")
+}
+
+func TestSharedKey_Dir(t *testing.T) {
+	out := t.TempDir()
+	input := filepath.Join(out, "secret.txt")
+	require.NoError(t, os.WriteFile(input, []byte(testMessage), 0600))
+	require.NoError(t, GenerateFile(input, OutputDir(out), PackageName("assets"), SharedKey()))
+	require.NoError(t, GenerateDir("testdata/assets", OutputDir(out), PackageName("assets"), SharedKey()))
+
+	src, err := os.ReadFile(filepath.Join(out, "assets_fs.go"))
+	require.NoError(t, err)
+	assert.Contains(t, string(src), "Key:        "+sharedKeyVar+",")
+	assert.Contains(t, string(src), "Offset:     "+sharedOffsetVar+",")
+	assertPackageChecks(t, out)
+}
+
+func TestSharedKey_ObfuscateNames(t *testing.T) {
+	dir := t.TempDir()
+	input := filepath.Join(dir, "secret.txt")
+	require.NoError(t, os.WriteFile(input, []byte(testMessage), 0600))
+	require.NoError(t, GenerateFile(input, OutputDir(dir), PackageName("assets"), SharedKey(), ObfuscateNames(), VaryShape()))
+
+	src, err := os.ReadFile(filepath.Join(dir, "secret_txt.go"))
+	require.NoError(t, err)
+	assert.Contains(t, string(src), sharedKeyVar, "Shared key names shouldn't be obfuscated")
+	assertPackageChecks(t, dir)
+
+	files, err := ExtractFile(filepath.Join(dir, "secret_txt.go"))
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+	assert.Equal(t, testMessage, string(files[0].Data))
+}
+
+func TestSharedKey_Neg(t *testing.T) {
+	tests := map[string][]ParamOpt{
+		"Scatter key": {ScatterKey(3)},
+		"Key file":    {KeyFile("test.xkey")},
+		"Encrypted":   {Encrypt(testPass("pass"))},
+		"Full length": {FullLengthKey()},
+	}
+	for name, opts := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := buildParams("test.txt", append([]ParamOpt{SharedKey(), OutputDir(t.TempDir()), PackageName("assets")}, opts...)...)
+			assert.Error(t, err)
+		})
+	}
+
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, SharedKeyFileName), []byte("package assets\n\nvar sharedKey = 1\n"), 0600))
+	_, err := buildParams("test.txt", SharedKey(), OutputDir(dir), PackageName("assets"))
+	assert.ErrorIs(t, err, ErrInvalidSharedKey)
+}
+
+// assertPackageChecks type checks every Go file in dir as a single package.
+func assertPackageChecks(t *testing.T, dir string) {
+	t.Helper()
+	fset := token.NewFileSet()
+	conf := types.Config{Importer: importer.ForCompiler(fset, "source", nil)}
+	paths, err := filepath.Glob(filepath.Join(dir, "*.go"))
+	require.NoError(t, err)
+	var files []*ast.File
+	for _, path := range paths {
+		f, err := parser.ParseFile(fset, path, nil, 0)
+		require.NoError(t, err)
+		files = append(files, f)
+	}
+	_, err = conf.Check("assets", fset, files, nil)
+	assert.NoError(t, err, "Generated files should type check together")
+}
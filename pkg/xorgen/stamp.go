@@ -0,0 +1,120 @@
+package xorgen
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"go/format"
+	"hash"
+	"io"
+	"path/filepath"
+)
+
+const (
+	// versionHeaderPrefix starts the comment line in a generated file that records the version of xorgen that generated it.
+	versionHeaderPrefix = "// xorgen:version "
+	// sourceHeaderPrefix starts the comment line in a generated file that records a digest and the relative path of its source.
+	sourceHeaderPrefix = "// xorgen:source "
+	// sumHeaderPrefix starts the comment line in a generated file that records a checksum of the rest of the file.
+	sumHeaderPrefix = "// xorgen:sum "
+)
+
+// GeneratorVersion records the version of xorgen in the header of generated files, so it's clear which release generated them.
+// The version isn't part of the fingerprint, so upgrading xorgen doesn't regenerate files unless the generated code changes.
+func GeneratorVersion(version string) ParamOpt {
+	return func(params *Params) error {
+		params.version = version
+		return nil
+	}
+}
+
+// stamp renders a generated file and writes it to out with a checksum of its content inserted after the fingerprint, so VerifyFile can detect edits.
+// The file is formatted like gofmt, so generated files pass formatting checks without another step.
+func stamp(out io.Writer, render func(out io.Writer) error) error {
+	src, err := renderFormatted(render)
+	if err != nil {
+		return err
+	}
+	sum, err := contentSum(src)
+	if err != nil {
+		return err
+	}
+	i := bytes.Index(src, []byte(hashHeaderPrefix))
+	if i < 0 {
+		return fmt.Errorf("generated file is missing the '%s' header", hashHeaderPrefix)
+	}
+	end := i + bytes.IndexByte(src[i:], '\n') + 1
+	if _, err := out.Write(src[:end]); err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintf(out, "%s%s\n", sumHeaderPrefix, sum); err != nil {
+		return err
+	}
+	_, err = out.Write(src[end:])
+	return err
+}
+
+// renderFormatted renders a generated file and formats it with go/format.
+func renderFormatted(render func(out io.Writer) error) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := render(&buf); err != nil {
+		return nil, err
+	}
+	src, err := format.Source(buf.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("failed to format generated file: %w", err)
+	}
+	return src, nil
+}
+
+// writeFormatted renders a generated file that isn't stamped, like a companion test, and writes it to out formatted with go/format.
+func writeFormatted(out io.Writer, render func(out io.Writer) error) error {
+	src, err := renderFormatted(render)
+	if err != nil {
+		return err
+	}
+	_, err = out.Write(src)
+	return err
+}
+
+// contentSum returns the checksum of a generated file without its sum header.
+// The file is formatted first, so running gofmt on a generated file isn't considered an edit.
+func contentSum(src []byte) (string, error) {
+	formatted, err := format.Source(src)
+	if err != nil {
+		return "", fmt.Errorf("failed to format generated file: %w", err)
+	}
+	sum := sha256.Sum256(formatted)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// sourcePath returns the path of the source relative to the output directory, since that's where VerifyFile finds it from.
+// An empty string is returned if there's no relative path between them.
+func sourcePath(outputDir, source string) string {
+	outAbs, err := filepath.Abs(outputDir)
+	if err != nil {
+		return ""
+	}
+	abs, err := filepath.Abs(source)
+	if err != nil {
+		return ""
+	}
+	rel, err := filepath.Rel(outAbs, abs)
+	if err != nil {
+		return ""
+	}
+	return filepath.ToSlash(rel)
+}
+
+// fileDigest returns the digest of a single source file recorded in the source header.
+func fileDigest(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// writeDirDigest adds a file within a source directory to the digest recorded in the source header.
+func writeDirDigest(h hash.Hash, rel string, data []byte) {
+	_, _ = fmt.Fprintf(h, "%q %d\x00", rel, len(data))
+	h.Write(data)
+}
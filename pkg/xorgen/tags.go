@@ -0,0 +1,32 @@
+package xorgen
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+var (
+	buildTagPattern = regexp.MustCompile(`^!?[A-Za-z0-9_.]+$`)
+)
+
+// BuildTags adds a //go:build constraint to the generated file, so embedded data is only included in builds that satisfy every tag.
+// Tags are separated by commas and may be negated with '!', so "release,!debug" results in "//go:build release && !debug".
+// An empty string doesn't add a constraint.
+func BuildTags(tags string) ParamOpt {
+	return func(params *Params) error {
+		var terms []string
+		for _, tag := range strings.Split(tags, ",") {
+			tag = strings.TrimSpace(tag)
+			if len(tag) == 0 {
+				continue
+			}
+			if !buildTagPattern.MatchString(tag) {
+				return fmt.Errorf("invalid build tag '%s', tags may only contain the characters A-Z, a-z, 0-9, '_', or '.', and may start with '!'", tag)
+			}
+			terms = append(terms, tag)
+		}
+		params.buildConstraint = strings.Join(terms, " && ")
+		return nil
+	}
+}
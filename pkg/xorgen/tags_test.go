@@ -0,0 +1,53 @@
+package xorgen
+
+import (
+	"bytes"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go/build/constraint"
+	"testing"
+)
+
+func TestBuildTags(t *testing.T) {
+	tests := map[string]string{
+		"":                  "",
+		"release":           "release",
+		"release,!debug":    "release && !debug",
+		" linux , go1.21 ,": "linux && go1.21",
+	}
+	for tags, expected := range tests {
+		t.Run(tags, func(t *testing.T) {
+			params, err := buildParams("test.txt", BuildTags(tags))
+			require.NoError(t, err)
+			assert.Equal(t, expected, params.buildConstraint)
+
+			var buf bytes.Buffer
+			require.NoError(t, renderFile(params, &buf))
+			assertValidSource(t, buf.Bytes())
+			if len(expected) == 0 {
+				assert.NotContains(t, buf.String(), "//go:build")
+				return
+			}
+			line := "//go:build " + expected
+			assert.Contains(t, buf.String(), "\n\n"+line+"\n\npackage xorgen\n", "Constraint should be separated from the package clause")
+			_, err = constraint.Parse(line)
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestBuildTags_Dir(t *testing.T) {
+	params, err := buildDirParams("testdata/assets", BuildTags("release"))
+	require.NoError(t, err)
+	var buf bytes.Buffer
+	require.NoError(t, renderDir(params, &buf))
+	assertValidSource(t, buf.Bytes())
+	assert.Contains(t, buf.String(), "\n\n//go:build release\n\npackage xorgen\n")
+}
+
+func TestBuildTags_Neg(t *testing.T) {
+	for _, tags := range []string{"!", "a b", "a||b", "a,(b)", "!!a"} {
+		_, err := buildParams("test.txt", BuildTags(tags))
+		assert.Error(t, err, "Tags '%s' should be rejected", tags)
+	}
+}
@@ -0,0 +1,720 @@
+package xorgen
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/rand"
+	"crypto/sha256"
+	_ "embed"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"github.com/saylorsolutions/gocryptx/pkg/passlock"
+	"github.com/saylorsolutions/gocryptx/pkg/xor"
+	"io"
+	"io/fs"
+	"math/big"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+	"unicode"
+)
+
+const (
+	// DefaultMaxInputSize is the default limit for the size of an input file.
+	// Much larger inputs generate Go source files that are very slow to compile, or can't be compiled at all.
+	DefaultMaxInputSize int64 = 16 << 20
+)
+
+var (
+	ErrInputTooLarge = errors.New("input file is too large to embed")
+)
+
+var (
+	//go:embed screen_embed.go.tmpl
+	tmplText     string
+	tmplTemplate = template.Must(template.New("template").Parse(tmplText))
+)
+
+// DataEncoding specifies how the screened payload is represented in generated code.
+type DataEncoding = string
+
+const (
+	// EncodeBytes embeds the payload as a []byte composite literal.
+	// This is very slow to compile for large payloads, taking more than 10 times as long as the other encodings for a multi-MB payload, and uses far more memory in gopls.
+	// It only makes sense for small payloads that need to be in a []byte variable, since the fs.FS accessor has to copy it to a string.
+	EncodeBytes DataEncoding = "bytes"
+	// EncodeString embeds the payload as an escaped string literal, which is read without a copy at runtime.
+	// This compiles about as quickly as EncodeBase64, but generated files are larger since non-printable bytes are escaped.
+	EncodeString DataEncoding = "string"
+	// EncodeBase64 embeds the payload as a base64 string literal, which is decoded at runtime.
+	// This produces the smallest generated files for large payloads, and is the default.
+	EncodeBase64 DataEncoding = "base64"
+
+	// DefaultEncoding is the encoding used if none is specified.
+	DefaultEncoding = EncodeBase64
+)
+
+// Params are used to render a file that embeds a single input.
+// They're populated from the input and ParamOpt values during generation.
+type Params struct {
+	packageName     string
+	buildConstraint string
+	exposed         bool
+	compressed      bool
+	encoding        DataEncoding
+	fileMethodName  string
+	keyString       string
+	dataString      string
+	offset          int
+	shape           shape
+	importPaths     []string
+	keyFragments    []keyFragment
+	checksum        string
+	verifyChecksum  bool
+	checksumLiteral string
+	inputHash       string
+	http            bool
+	inputName       string
+	size            int
+	modTime         int64
+	cached          bool
+	encrypted       bool
+	declNames       declNames
+	externalKey     bool
+	keyHashLiteral  string
+	sharedKey       bool
+	embedPath       string
+	version         string
+	source          string
+	sourceDigest    string
+
+	keyData         []byte
+	fullLength      bool
+	randomOffset    bool
+	compressLevel   int
+	scatter         int
+	fileData        []byte
+	maxInputSize    int64
+	varyShape       bool
+	withTest        bool
+	force           bool
+	targetFileName  string
+	outputDir       string
+	detectedPackage string
+	passSource      passlock.PassSource
+	obfuscateNames  bool
+	dryRun          io.Writer
+	keyFile         string
+	goEmbed         bool
+	inputPath       string
+	report          func(Result)
+	warnings        []string
+}
+
+// ParamOpt operates on Params in a standard and predictable way, and is used in GenerateFile, GenerateDir, and Generate.
+// If any ParamOpt returns an error, then file generation ceases and the error is returned.
+type ParamOpt = func(params *Params) error
+
+// CompressData indicates that data should be compressed.
+func CompressData(val ...bool) ParamOpt {
+	return func(params *Params) error {
+		if len(val) > 0 {
+			params.compressed = val[0]
+			return nil
+		}
+		params.compressed = true
+		return nil
+	}
+}
+
+// CompressLevel sets the gzip compression level used when data is compressed, which is gzip.BestCompression by default.
+// Lower levels trade compression ratio for speed, which can matter for build times with large inputs.
+// The level must be between gzip.HuffmanOnly and gzip.BestCompression.
+func CompressLevel(level int) ParamOpt {
+	return func(params *Params) error {
+		if level < gzip.HuffmanOnly || level > gzip.BestCompression {
+			return fmt.Errorf("invalid compression level %d, must be between %d and %d", level, gzip.HuffmanOnly, gzip.BestCompression)
+		}
+		params.compressLevel = level
+		return nil
+	}
+}
+
+// ExposeFunctions indicates that generated functions should be exposed.
+func ExposeFunctions(val ...bool) ParamOpt {
+	return func(params *Params) error {
+		if len(val) > 0 {
+			params.exposed = val[0]
+			return nil
+		}
+		params.exposed = true
+		return nil
+	}
+}
+
+// UseKeyOffset sets a key to be used instead of generating one randomly.
+func UseKeyOffset(key []byte, offset int) ParamOpt {
+	return func(params *Params) error {
+		params.keyData = key
+		params.offset = offset
+		params.randomOffset = false
+		return nil
+	}
+}
+
+// UseKeyRandomOffset sets a key to be used instead of generating one randomly, with a securely generated random offset within the key.
+// This allows externally managed keys to still benefit from offset randomization.
+func UseKeyRandomOffset(key []byte) ParamOpt {
+	return func(params *Params) error {
+		if len(key) == 0 {
+			return errors.New("cannot use empty key")
+		}
+		offset, err := rand.Int(rand.Reader, big.NewInt(int64(len(key))))
+		if err != nil {
+			return err
+		}
+		params.keyData = key
+		params.offset = int(offset.Int64())
+		params.randomOffset = true
+		return nil
+	}
+}
+
+// EncodeData specifies how the screened payload should be represented in the generated file.
+// An empty encoding will use DefaultEncoding.
+// With any encoding other than EncodeBytes, the key is also embedded as a string literal, since composite literals are slow to compile.
+func EncodeData(encoding DataEncoding) ParamOpt {
+	encoding = strings.TrimSpace(encoding)
+	return func(params *Params) error {
+		switch encoding {
+		case "":
+			params.encoding = DefaultEncoding
+		case EncodeBytes, EncodeString, EncodeBase64:
+			params.encoding = encoding
+		default:
+			return fmt.Errorf("unknown data encoding '%s', must be one of %s, %s, or %s", encoding, EncodeBytes, EncodeString, EncodeBase64)
+		}
+		return nil
+	}
+}
+
+// EmbedChecksum embeds a SHA-256 checksum of the input, which the generated unscreen function verifies before returning data.
+// This detects tampering or key drift even when compression is disabled, and gzip's CRC-32 isn't available.
+// The generated stream function has to buffer and verify the whole payload before returning a reader.
+func EmbedChecksum(val ...bool) ParamOpt {
+	return func(params *Params) error {
+		if len(val) > 0 {
+			params.verifyChecksum = val[0]
+			return nil
+		}
+		params.verifyChecksum = true
+		return nil
+	}
+}
+
+// RandomKey generates a random key and offset based on the payload size, which is the default.
+func RandomKey() ParamOpt {
+	return func(params *Params) error {
+		params.fullLength = false
+		return nil
+	}
+}
+
+// FullLengthKey generates a random key that is as long as the payload, with a random offset.
+// This prevents repeating the key over the payload, at the cost of a generated file that is about twice as large.
+func FullLengthKey() ParamOpt {
+	return func(params *Params) error {
+		params.fullLength = true
+		return nil
+	}
+}
+
+// PackageName specifies the package name of the generated file.
+// By default, the package is detected from existing Go files in the output directory, falling back to the directory name if there are none.
+// This is useful for cases where the directory is empty and the expected package name doesn't match the name of the directory.
+// The name must match the detected package if there is one.
+func PackageName(name string) ParamOpt {
+	name = strings.TrimSpace(name)
+	return func(params *Params) error {
+		if len(name) == 0 {
+			return nil
+		}
+		params.packageName = name
+		return nil
+	}
+}
+
+// OutputDir sets the directory where the generated file is written, which is the current working directory by default.
+// The package of the generated file is detected from this directory.
+func OutputDir(dir string) ParamOpt {
+	return func(params *Params) error {
+		params.outputDir = dir
+		return nil
+	}
+}
+
+// MaxInputSize sets the maximum size of the input file in bytes, which is DefaultMaxInputSize by default.
+// Generation fails with ErrInputTooLarge if the input is larger than this, rather than producing a file that may not compile.
+// A size of 0 disables the limit.
+func MaxInputSize(size int64) ParamOpt {
+	return func(params *Params) error {
+		if size < 0 {
+			return fmt.Errorf("max input size %d may not be negative", size)
+		}
+		params.maxInputSize = size
+		return nil
+	}
+}
+
+// GenerateFile will generate a file embedding the input file with XOR screening.
+// Various generation options may be passed as zero or more ParamOpt.
+// If the existing generated file was created from the same input and options, then it's left alone unless ForceRegenerate is used.
+func GenerateFile(input string, opts ...ParamOpt) error {
+	params, err := buildParams(input, opts...)
+	if err != nil {
+		return err
+	}
+
+	target := filepath.Join(params.outputDir, params.targetFileName+".go")
+	if params.dryRun != nil {
+		if params.sharedKey {
+			if err := params.saveSharedKey(); err != nil {
+				return err
+			}
+		}
+		err := writeDryRun(params.dryRun, target, func(out io.Writer) error {
+			return renderFile(params, out)
+		})
+		if err == nil && params.withTest {
+			testTarget := filepath.Join(params.outputDir, params.targetFileName+"_test.go")
+			err = writeDryRun(params.dryRun, testTarget, func(out io.Writer) error {
+				return renderTest(roundTripTemplate, params.view(), out)
+			})
+		}
+		if err != nil {
+			return err
+		}
+		params.reportResult(target, StatusDryRun)
+		return nil
+	}
+	if !params.force && !params.encrypted {
+		current, err := upToDate(target, params.inputHash)
+		if err != nil {
+			return err
+		}
+		if current && (!params.withTest || exists(filepath.Join(params.outputDir, params.targetFileName+"_test.go"))) && (!params.externalKey || exists(params.keyFile)) && (!params.sharedKey || exists(params.sharedKeyPath())) {
+			params.reportResult(target, StatusUpToDate)
+			return nil
+		}
+	}
+	if params.externalKey {
+		if err := params.saveKeyFile(); err != nil {
+			return err
+		}
+	}
+	if params.sharedKey {
+		if err := params.saveSharedKey(); err != nil {
+			return err
+		}
+	}
+	out, err := os.Create(target)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = out.Close()
+	}()
+
+	if err := renderFile(params, out); err != nil {
+		return err
+	}
+	if params.withTest {
+		if err := writeTestFile(roundTripTemplate, params.outputDir, params.targetFileName, params.view()); err != nil {
+			return err
+		}
+	}
+	params.reportResult(target, StatusGenerated)
+	return nil
+}
+
+// Generate reads the input from the io.Reader and writes the generated file to the io.Writer, so the payload never needs to be written to disk.
+// The name is used in place of an input file name to name generated functions, so "secret.bin" results in a function called unscreenSecret_bin.
+// The package is still detected from the output directory, which is the current working directory unless OutputDir is given.
+func Generate(name string, in io.Reader, out io.Writer, opts ...ParamOpt) error {
+	data, err := io.ReadAll(in)
+	if err != nil {
+		return err
+	}
+	params, err := buildDataParams(name, data, time.Time{}, opts...)
+	if err != nil {
+		return err
+	}
+	if params.withTest {
+		return errors.New("a companion test can't be generated when writing to an io.Writer")
+	}
+	if params.externalKey {
+		if err := params.saveKeyFile(); err != nil {
+			return err
+		}
+	}
+	if params.sharedKey {
+		if err := params.saveSharedKey(); err != nil {
+			return err
+		}
+	}
+	if err := renderFile(params, out); err != nil {
+		return err
+	}
+	params.reportResult("", StatusGenerated)
+	return nil
+}
+
+func buildParams(input string, opts ...ParamOpt) (*Params, error) {
+	data, err := os.ReadFile(input)
+	if err != nil {
+		return nil, err
+	}
+	info, err := os.Stat(input)
+	if err != nil {
+		return nil, err
+	}
+	params, err := buildDataParams(filepath.Base(input), data, info.ModTime(), append([]ParamOpt{inputPath(input)}, opts...)...)
+	if err != nil {
+		return nil, err
+	}
+	// A digest of the input would let anyone with the generated file check guesses of its content, when it isn't embedded with its key.
+	if !params.encrypted && !params.externalKey {
+		params.source = sourcePath(params.outputDir, input)
+		params.sourceDigest = fileDigest(data)
+	}
+	return params, nil
+}
+
+func buildDataParams(name string, data []byte, modTime time.Time, opts ...ParamOpt) (*Params, error) {
+	params := &Params{
+		encoding:      DefaultEncoding,
+		compressLevel: gzip.BestCompression,
+		maxInputSize:  DefaultMaxInputSize,
+	}
+	if err := populateFileData(params, name, data); err != nil {
+		return nil, err
+	}
+	for _, opt := range opts {
+		if err := opt(params); err != nil {
+			return nil, err
+		}
+	}
+	if err := populateContextData(params); err != nil {
+		return nil, err
+	}
+	if params.goEmbed {
+		if err := params.loadEmbedded(); err != nil {
+			return nil, err
+		}
+	}
+	// Data embedded with go:embed isn't a literal, so it doesn't slow down compilation.
+	if params.maxInputSize > 0 && !params.goEmbed && int64(len(params.fileData)) > params.maxInputSize {
+		return nil, fmt.Errorf("%w: %d bytes exceeds the maximum of %d bytes. Consider embedding a screened file with go:embed and unscreening it at runtime with xor.NewReader instead", ErrInputTooLarge, len(params.fileData), params.maxInputSize)
+	}
+	if len(params.detectedPackage) > 0 && params.packageName != params.detectedPackage {
+		return nil, fmt.Errorf("package name '%s' conflicts with package '%s' declared by existing files in the output directory", params.packageName, params.detectedPackage)
+	}
+
+	if params.encrypted {
+		if err := params.checkEncrypt(); err != nil {
+			return nil, err
+		}
+	}
+	if params.externalKey {
+		if err := params.checkKeyFile(); err != nil {
+			return nil, err
+		}
+		if err := params.loadKeyFile(); err != nil {
+			return nil, err
+		}
+	}
+	if params.sharedKey {
+		if err := params.checkSharedKey(); err != nil {
+			return nil, err
+		}
+		if err := params.loadSharedKey(); err != nil {
+			return nil, err
+		}
+	}
+	if !params.encrypted && (!fs.ValidPath(params.inputName) || params.inputName == ".") {
+		return nil, fmt.Errorf("name '%s' can't be used as a file name in an fs.FS", params.inputName)
+	}
+	if params.http {
+		if !modTime.IsZero() {
+			params.modTime = modTime.Unix()
+		}
+	}
+	params.shape = defaultShape(params.fileMethodName)
+	if params.varyShape {
+		// The shape of an encrypted file is selected by its name, so it reveals nothing about the input.
+		content := params.fileData
+		if params.encrypted {
+			content = []byte(params.fileMethodName)
+		}
+		params.shape = hashedShape(params.fileMethodName, content)
+	}
+	if params.sharedKey {
+		params.shape.KeyVar = sharedKeyVar
+		params.shape.OffsetVar = sharedOffsetVar
+	}
+	params.importPaths = params.imports()
+	names, err := params.names()
+	if err != nil {
+		return nil, err
+	}
+	params.declNames = names
+	if params.verifyChecksum {
+		params.checksumLiteral = fmt.Sprintf("%#v", sha256.Sum256(params.fileData))
+	}
+	params.checkWarnings()
+
+	if params.encrypted {
+		if err := encryptData(params); err != nil {
+			return nil, err
+		}
+		params.inputHash = params.fingerprint()
+		return params, nil
+	}
+	if !params.externalKey {
+		params.inputHash = params.fingerprint()
+	}
+	if len(params.keyData) == 0 {
+		key, offset, err := params.generateKey(params.fileData)
+		if err != nil {
+			return nil, err
+		}
+		params.keyData = key
+		params.offset = offset
+	}
+	if params.goEmbed {
+		// The data is already screened, and embedded from the screened file.
+		params.keyString = params.byteLiteral(params.keyData)
+	} else if err := screenData(params); err != nil {
+		return nil, err
+	}
+	if params.externalKey {
+		params.keyHashLiteral = keyHashLiteral(params.keyData)
+		params.inputHash = params.fingerprint()
+	}
+	params.scatterKey()
+	return params, nil
+}
+
+// fileView is the data that a single input's templates are rendered with.
+type fileView struct {
+	Package         string
+	BuildConstraint string
+	Exposed         bool
+	Compressed      bool
+	Encoding        DataEncoding
+	FileMethodName  string
+	KeyString       string
+	DataString      string
+	Offset          int
+	Shape           shape
+	Imports         []string
+	KeyFragments    []keyFragment
+	Checksum        string
+	VerifyChecksum  bool
+	ChecksumLiteral string
+	InputHash       string
+	HTTP            bool
+	InputName       string
+	Size            int
+	ModTime         int64
+	Cached          bool
+	Names           declNames
+	ExternalKey     bool
+	KeyHashLiteral  string
+	SharedKey       bool
+	EmbedPath       string
+	Version         string
+	Source          string
+	SourceDigest    string
+}
+
+// view returns the fields of params that the templates reference.
+func (params *Params) view() fileView {
+	return fileView{
+		Package:         params.packageName,
+		BuildConstraint: params.buildConstraint,
+		Exposed:         params.exposed,
+		Compressed:      params.compressed,
+		Encoding:        params.encoding,
+		FileMethodName:  params.fileMethodName,
+		KeyString:       params.keyString,
+		DataString:      params.dataString,
+		Offset:          params.offset,
+		Shape:           params.shape,
+		Imports:         params.importPaths,
+		KeyFragments:    params.keyFragments,
+		Checksum:        params.checksum,
+		VerifyChecksum:  params.verifyChecksum,
+		ChecksumLiteral: params.checksumLiteral,
+		InputHash:       params.inputHash,
+		HTTP:            params.http,
+		InputName:       params.inputName,
+		Size:            params.size,
+		ModTime:         params.modTime,
+		Cached:          params.cached,
+		Names:           params.declNames,
+		ExternalKey:     params.externalKey,
+		KeyHashLiteral:  params.keyHashLiteral,
+		SharedKey:       params.sharedKey,
+		EmbedPath:       params.embedPath,
+		Version:         params.version,
+		Source:          params.source,
+		SourceDigest:    params.sourceDigest,
+	}
+}
+
+func renderFile(params *Params, out io.Writer) error {
+	return stamp(out, func(out io.Writer) error {
+		if params.encrypted {
+			return encryptTemplate.Execute(out, params.view())
+		}
+		return tmplTemplate.Execute(out, params.view())
+	})
+}
+
+// populateContextData detects the package of the output directory, which is used as the package name unless one was given with PackageName.
+func populateContextData(params *Params) error {
+	dir, err := filepath.Abs(params.outputDir)
+	if err != nil {
+		return err
+	}
+	pkg, err := DetectPackage(dir)
+	if err != nil {
+		return err
+	}
+	params.detectedPackage = pkg
+	if len(params.packageName) > 0 {
+		return nil
+	}
+	if len(pkg) > 0 {
+		params.packageName = pkg
+		return nil
+	}
+	params.packageName = filepath.Base(dir)
+	return nil
+}
+
+var (
+	fileCleansePattern = regexp.MustCompile(`[^a-zA-Z0-9_]`)
+)
+
+func populateFileData(params *Params, fname string, data []byte) error {
+	if len(strings.TrimSpace(fname)) == 0 {
+		return errors.New("a name is required to name generated functions")
+	}
+	params.fileData = data
+	params.inputName = fname
+	params.size = len(data)
+	params.checksum = checksum(data)
+	params.fileMethodName = fileCleansePattern.ReplaceAllString(unicap(fname), "_")
+	params.targetFileName = fileCleansePattern.ReplaceAllString(fname, "_")
+	return nil
+}
+
+// generateKey generates a random key and offset for the payload with the selected key strategy.
+func (params *Params) generateKey(data []byte) ([]byte, int, error) {
+	if params.fullLength {
+		return fullLengthKey(data)
+	}
+	return matchedKey(data)
+}
+
+func matchedKey(data []byte) ([]byte, int, error) {
+	return xor.GenKeyMatched(data)
+}
+
+func fullLengthKey(data []byte) ([]byte, int, error) {
+	return xor.GenKeyAndOffset(len(data))
+}
+
+func screenData(params *Params) error {
+	if err := compressData(params); err != nil {
+		return err
+	}
+	var buf bytes.Buffer
+	w, err := xor.NewWriter(&buf, params.keyData, params.offset)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(params.fileData)
+	if err != nil {
+		return err
+	}
+	params.keyString = params.byteLiteral(params.keyData)
+	params.dataString = params.encodeData(buf.Bytes())
+	return nil
+}
+
+// compressData replaces the payload with its gzip compressed form if compression is enabled.
+func compressData(params *Params) error {
+	if !params.compressed {
+		return nil
+	}
+	var buf bytes.Buffer
+	w, err := gzip.NewWriterLevel(&buf, params.compressLevel)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(params.fileData)
+	if err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	params.fileData = buf.Bytes()
+	return nil
+}
+
+// encodeData formats the processed payload as a Go literal with the selected encoding.
+func (params *Params) encodeData(data []byte) string {
+	switch params.encoding {
+	case EncodeString:
+		return strconv.Quote(string(data))
+	case EncodeBase64:
+		return strconv.Quote(base64.StdEncoding.EncodeToString(data))
+	default:
+		return fmt.Sprintf("%#v", data)
+	}
+}
+
+// byteLiteral formats data as a Go expression of type []byte that matches the selected encoding.
+func (params *Params) byteLiteral(data []byte) string {
+	if params.encoding == EncodeBytes {
+		return fmt.Sprintf("%#v", data)
+	}
+	return stringByteLiteral(data)
+}
+
+// stringByteLiteral formats data as a string literal converted to []byte.
+// This compiles much faster than a composite literal when data is large.
+func stringByteLiteral(data []byte) string {
+	return "[]byte(" + strconv.Quote(string(data)) + ")"
+}
+
+func unicap(s string) string {
+	runes := []rune(s)
+	switch len(runes) {
+	case 0:
+		return ""
+	case 1:
+		return string(unicode.ToUpper(runes[0]))
+	default:
+		return string(append([]rune{unicode.ToUpper(runes[0])}, runes[1:]...))
+	}
+}
@@ -0,0 +1,309 @@
+package xorgen
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"fmt"
+	"github.com/saylorsolutions/gocryptx/pkg/xor"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go/ast"
+	"go/format"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testMessage = "A test message that should be screened"
+
+func TestRenderFile_Encodings(t *testing.T) {
+	for _, encoding := range []DataEncoding{EncodeBytes, EncodeString, EncodeBase64} {
+		for _, compressed := range []bool{false, true} {
+			t.Run(fmt.Sprintf("%s compressed=%v", encoding, compressed), func(t *testing.T) {
+				params, err := buildParams("test.txt", EncodeData(encoding), CompressData(compressed))
+				require.NoError(t, err)
+				var buf bytes.Buffer
+				require.NoError(t, renderFile(params, &buf))
+				assertValidSource(t, buf.Bytes())
+
+				assert.Equal(t, testMessage, string(unscreenParams(t, params)))
+				assert.Equal(t, params.keyData, parseByteLiteral(t, params.keyString))
+				if encoding != EncodeBytes {
+					assert.True(t, strings.HasPrefix(params.keyString, "[]byte(\""), "Compact encodings should embed the key as a string literal")
+				}
+			})
+		}
+	}
+}
+
+func TestEncodeData_Default(t *testing.T) {
+	params, err := buildParams("test.txt")
+	require.NoError(t, err)
+	assert.Equal(t, DefaultEncoding, params.encoding)
+	params, err = buildParams("test.txt", EncodeData(EncodeBytes), EncodeData(""))
+	require.NoError(t, err)
+	assert.Equal(t, DefaultEncoding, params.encoding)
+}
+
+func TestEncodeData_Neg(t *testing.T) {
+	_, err := buildParams("test.txt", EncodeData("hex"))
+	assert.Error(t, err)
+}
+
+// assertValidSource parses the generated source and ensures that every import is used.
+func assertValidSource(t *testing.T, src []byte) {
+	t.Helper()
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "generated.go", src, parser.ParseComments)
+	require.NoError(t, err, "Generated source should be valid Go:\n%s", src)
+
+	used := map[string]bool{}
+	ast.Inspect(f, func(n ast.Node) bool {
+		if sel, ok := n.(*ast.SelectorExpr); ok {
+			if ident, ok := sel.X.(*ast.Ident); ok {
+				used[ident.Name] = true
+			}
+		}
+		return true
+	})
+	for _, imp := range f.Imports {
+		if imp.Name != nil && imp.Name.Name == "_" {
+			continue
+		}
+		path, err := strconv.Unquote(imp.Path.Value)
+		require.NoError(t, err)
+		name := path[strings.LastIndex(path, "/")+1:]
+		assert.True(t, used[name], "Import %s should be used in generated source:\n%s", path, src)
+	}
+}
+
+func decodeDataString(t *testing.T, params *Params) []byte {
+	t.Helper()
+	switch params.encoding {
+	case EncodeString:
+		data, err := strconv.Unquote(params.dataString)
+		require.NoError(t, err)
+		return []byte(data)
+	case EncodeBase64:
+		encoded, err := strconv.Unquote(params.dataString)
+		require.NoError(t, err)
+		data, err := base64.StdEncoding.DecodeString(encoded)
+		require.NoError(t, err)
+		return data
+	default:
+		return parseByteLiteral(t, params.dataString)
+	}
+}
+
+// parseByteLiteral parses a []byte composite literal formatted with %#v, or a string literal converted to []byte.
+func parseByteLiteral(t *testing.T, literal string) []byte {
+	t.Helper()
+	if quoted, ok := strings.CutPrefix(literal, "[]byte(\""); ok {
+		s, err := strconv.Unquote("\"" + strings.TrimSuffix(quoted, ")"))
+		require.NoError(t, err)
+		return []byte(s)
+	}
+	var data []byte
+	literal = strings.TrimSuffix(strings.TrimPrefix(literal, "[]byte{"), "}")
+	for _, b := range strings.Split(literal, ", ") {
+		val, err := strconv.ParseUint(b, 0, 8)
+		require.NoError(t, err)
+		data = append(data, byte(val))
+	}
+	return data
+}
+
+func TestMaxInputSize(t *testing.T) {
+	_, err := buildParams("test.txt", MaxInputSize(int64(len(testMessage))))
+	assert.NoError(t, err)
+	_, err = buildParams("test.txt", MaxInputSize(int64(len(testMessage)-1)))
+	assert.ErrorIs(t, err, ErrInputTooLarge)
+	_, err = buildParams("test.txt", MaxInputSize(int64(len(testMessage)-1)), MaxInputSize(0))
+	assert.NoError(t, err, "A max size of 0 should disable the limit")
+	_, err = buildParams("test.txt", MaxInputSize(-1))
+	assert.Error(t, err)
+}
+
+func TestVaryShape(t *testing.T) {
+	params, err := buildParams("test.txt")
+	require.NoError(t, err)
+	assert.Equal(t, defaultShape(params.fileMethodName), params.shape, "The default shape should be used unless VaryShape is given")
+
+	params, err = buildParams("test.txt", VaryShape())
+	require.NoError(t, err)
+	again, err := buildParams("test.txt", VaryShape())
+	require.NoError(t, err)
+	assert.Equal(t, params.shape, again.shape, "The same input should always produce the same shape")
+
+	seen := map[string]bool{}
+	for i := 0; i < 100; i++ {
+		shape := hashedShape("Test", []byte(fmt.Sprintf("content %d", i)))
+		seen[shape.Decode] = true
+	}
+	assert.Len(t, seen, len(decodeShapes), "Every decode shape should be selected for some inputs")
+}
+
+func TestRenderFile_Shapes(t *testing.T) {
+	fset := token.NewFileSet()
+	conf := types.Config{Importer: importer.ForCompiler(fset, "source", nil)}
+	for _, decode := range decodeShapes {
+		for _, encoding := range []DataEncoding{EncodeBytes, EncodeString, EncodeBase64} {
+			for _, compressed := range []bool{false, true} {
+				for _, checksum := range []bool{false, true} {
+					t.Run(fmt.Sprintf("%s %s compressed=%v checksum=%v", decode, encoding, compressed, checksum), func(t *testing.T) {
+						params, err := buildParams("test.txt", EncodeData(encoding), CompressData(compressed), EmbedChecksum(checksum), VaryShape())
+						require.NoError(t, err)
+						params.shape.Decode = decode
+						params.shape.SeparateVars = compressed
+						params.importPaths = params.imports()
+						var buf bytes.Buffer
+						require.NoError(t, renderFile(params, &buf))
+						assertValidSource(t, buf.Bytes())
+
+						f, err := parser.ParseFile(fset, "generated.go", buf.Bytes(), 0)
+						require.NoError(t, err)
+						_, err = conf.Check(params.packageName, fset, []*ast.File{f}, nil)
+						assert.NoError(t, err, "Generated source should type check:\n%s", buf.Bytes())
+						assert.Equal(t, testMessage, string(unscreenParams(t, params)))
+					})
+				}
+			}
+		}
+	}
+}
+
+func TestCompressLevel(t *testing.T) {
+	for _, level := range []int{gzip.HuffmanOnly, gzip.NoCompression, gzip.BestSpeed, gzip.BestCompression} {
+		params, err := buildParams("test.txt", CompressData(), CompressLevel(level))
+		require.NoError(t, err)
+		assert.Equal(t, testMessage, string(unscreenParams(t, params)))
+	}
+	params, err := buildParams("test.txt", CompressData())
+	require.NoError(t, err)
+	assert.Equal(t, gzip.BestCompression, params.compressLevel, "Best compression should be the default")
+
+	_, err = buildParams("test.txt", CompressLevel(gzip.BestCompression+1))
+	assert.Error(t, err)
+	_, err = buildParams("test.txt", CompressLevel(gzip.HuffmanOnly-1))
+	assert.Error(t, err)
+}
+
+func TestUseKeyRandomOffset(t *testing.T) {
+	key := []byte{0xde, 0xad, 0xbe, 0xef}
+	seen := map[int]bool{}
+	for i := 0; i < 100; i++ {
+		params, err := buildParams("test.txt", UseKeyRandomOffset(key))
+		require.NoError(t, err)
+		assert.Equal(t, key, params.keyData)
+		seen[params.offset] = true
+		assert.Equal(t, testMessage, string(unscreenParams(t, params)))
+	}
+	assert.Len(t, seen, len(key), "Every offset should be selected for some runs")
+
+	_, err := buildParams("test.txt", UseKeyRandomOffset(nil))
+	assert.Error(t, err)
+}
+
+func TestFullLengthKey(t *testing.T) {
+	params, err := buildParams("test.txt", FullLengthKey())
+	require.NoError(t, err)
+	assert.Len(t, params.keyData, len(testMessage))
+	assert.Equal(t, testMessage, string(unscreenParams(t, params)))
+}
+
+func TestGenerate(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, Generate("secret.bin", strings.NewReader(testMessage), &buf, CompressData()))
+	assertValidSource(t, buf.Bytes())
+	assert.Contains(t, buf.String(), "func unscreenSecret_bin()")
+	assert.NotContains(t, buf.String(), testMessage)
+
+	params, err := buildDataParams("secret.bin", []byte(testMessage), time.Time{}, CompressData())
+	require.NoError(t, err)
+	assert.Equal(t, testMessage, string(unscreenParams(t, params)))
+
+	err = Generate("", strings.NewReader(testMessage), &buf)
+	assert.Error(t, err, "A name should be required")
+	err = Generate("secret.bin", strings.NewReader(testMessage), &buf, MaxInputSize(1))
+	assert.ErrorIs(t, err, ErrInputTooLarge)
+}
+
+func TestWithTest(t *testing.T) {
+	out := t.TempDir()
+	require.NoError(t, GenerateFile("test.txt", OutputDir(out), PackageName("assets"), BuildTags("release"), WithTest()))
+	src, err := os.ReadFile(filepath.Join(out, "test_txt_test.go"))
+	require.NoError(t, err)
+	assertValidSource(t, src)
+	assert.Contains(t, string(src), "//go:build release\n", "Companion test should share the build constraint")
+	assert.Contains(t, string(src), checksum([]byte(testMessage)))
+
+	out = t.TempDir()
+	require.NoError(t, GenerateFile("test.txt", OutputDir(out), PackageName("assets"), WithTest(false)))
+	_, err = os.Stat(filepath.Join(out, "test_txt_test.go"))
+	assert.ErrorIs(t, err, os.ErrNotExist)
+
+	err = Generate("secret.bin", strings.NewReader(testMessage), io.Discard, WithTest())
+	assert.Error(t, err, "Companion tests can't be written to an io.Writer")
+}
+
+func TestGenerated_Formatted(t *testing.T) {
+	tests := map[string][]ParamOpt{
+		"Default":    {WithTest()},
+		"Bytes":      {EncodeData(EncodeBytes), CompressData(), EmbedChecksum(), WithTest()},
+		"Varied":     {VaryShape(), BuildTags("release"), HTTPFileSystem(), CacheData()},
+		"Scattered":  {ScatterKey(3), ObfuscateNames()},
+		"Encrypted":  {Encrypt(testPass("pass"))},
+		"Key file":   {KeyFile(filepath.Join(t.TempDir(), "test.xkey"))},
+		"Shared key": {SharedKey(), WithTest()},
+	}
+	for name, opts := range tests {
+		t.Run(name, func(t *testing.T) {
+			out := t.TempDir()
+			require.NoError(t, GenerateFile("test.txt", append([]ParamOpt{OutputDir(out), PackageName("assets")}, opts...)...))
+			assertFormatted(t, out)
+		})
+	}
+	t.Run("Dir", func(t *testing.T) {
+		out := t.TempDir()
+		require.NoError(t, GenerateDir("testdata/assets", OutputDir(out), PackageName("assets"), WithTest(), HTTPFileSystem(), ObfuscateNames()))
+		assertFormatted(t, out)
+	})
+}
+
+// assertFormatted ensures that every Go file in dir is formatted like gofmt would format it.
+func assertFormatted(t *testing.T, dir string) {
+	t.Helper()
+	paths, err := filepath.Glob(filepath.Join(dir, "*.go"))
+	require.NoError(t, err)
+	require.NotEmpty(t, paths)
+	for _, path := range paths {
+		src, err := os.ReadFile(path)
+		require.NoError(t, err)
+		formatted, err := format.Source(src)
+		require.NoError(t, err)
+		assert.Equal(t, string(formatted), string(src), "%s should be formatted", filepath.Base(path))
+	}
+}
+
+func unscreenParams(t *testing.T, params *Params) []byte {
+	t.Helper()
+	r, err := xor.NewReader(bytes.NewReader(decodeDataString(t, params)), params.keyData, params.offset)
+	require.NoError(t, err)
+	var unscreened io.Reader = r
+	if params.compressed {
+		unscreened, err = gzip.NewReader(r)
+		require.NoError(t, err)
+	}
+	got, err := io.ReadAll(unscreened)
+	require.NoError(t, err)
+	return got
+}
@@ -0,0 +1,123 @@
+package xorgen
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"os"
+	"strings"
+)
+
+// hashHeaderPrefix starts the comment line in a generated file that records the fingerprint of its inputs.
+const hashHeaderPrefix = "// xorgen:hash "
+
+// ForceRegenerate generates a file even if the existing file was generated from the same input and options.
+// By default, an up-to-date file is left alone, so repeated go:generate runs don't churn keys and create noisy diffs.
+func ForceRegenerate(val ...bool) ParamOpt {
+	return func(params *Params) error {
+		if len(val) > 0 {
+			params.force = val[0]
+			return nil
+		}
+		params.force = true
+		return nil
+	}
+}
+
+// newFingerprint starts a hash of everything that affects a generated file, beginning with the template used to render it.
+// The hash is recorded in the generated file instead of the input itself, and a new version of xorgen with a different template will always regenerate.
+func newFingerprint(templateText string) hash.Hash {
+	h := sha256.New()
+	_, _ = fmt.Fprintf(h, "xorgen\x00%d\x00%s", len(templateText), templateText)
+	return h
+}
+
+// fingerprint returns the hash of the input and every option that affects the generated file.
+// When encrypting, the encrypted data is hashed instead of the input, since an unsalted hash of the input would let anyone with the generated file check guesses without the pass phrase.
+// The encrypted data is different every time, so encrypted files are always regenerated.
+// With a key file, the hash of the key and the screened data are hashed instead of the input and key, since they're already in the generated file.
+func (params *Params) fingerprint() string {
+	text := tmplText
+	if params.encrypted {
+		text = encryptText
+	}
+	if params.withTest {
+		text += roundTripText
+	}
+	key := params.keyData
+	if params.externalKey {
+		key = nil
+	}
+	h := newFingerprint(text)
+	_, _ = fmt.Fprintf(h, "%q %q %q %v %v %d %q %v %d %v %v %v %x",
+		params.fileMethodName, params.packageName, params.buildConstraint,
+		params.exposed, params.compressed, params.compressLevel, params.encoding, params.varyShape,
+		params.scatter, params.verifyChecksum, params.withTest, params.fullLength, key,
+	)
+	if params.http {
+		_, _ = fmt.Fprintf(h, " http %q %d", params.inputName, params.modTime)
+	}
+	if params.cached {
+		_, _ = fmt.Fprint(h, " cached")
+	}
+	if params.encrypted {
+		_, _ = fmt.Fprint(h, " encrypted")
+	}
+	if params.obfuscateNames {
+		_, _ = fmt.Fprint(h, " obfuscated")
+	}
+	if params.externalKey {
+		_, _ = fmt.Fprintf(h, " keyfile %q", params.keyFile)
+	}
+	if params.sharedKey {
+		_, _ = fmt.Fprint(h, " shared")
+	}
+	if params.goEmbed {
+		_, _ = fmt.Fprintf(h, " embed %q", params.embedPath)
+	}
+	if len(key) > 0 && !params.randomOffset {
+		_, _ = fmt.Fprintf(h, " %d", params.offset)
+	}
+	if params.externalKey {
+		_, _ = fmt.Fprintf(h, " %s", params.keyHashLiteral)
+	}
+	if params.encrypted || params.externalKey {
+		_, _ = fmt.Fprintf(h, " %d\x00%s", len(params.dataString), params.dataString)
+		return hex.EncodeToString(h.Sum(nil))
+	}
+	_, _ = fmt.Fprintf(h, " %d\x00", len(params.fileData))
+	h.Write(params.fileData)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// upToDate reports whether the generated file at path records the given fingerprint in its header.
+// A missing file, or one generated before fingerprints were recorded, is not up-to-date.
+func upToDate(path, fingerprint string) (bool, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return false, nil
+		}
+		return false, err
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+	scanner := bufio.NewScanner(f)
+	// The fingerprint is recorded right after the "Code generated" line, so there's no need to read further.
+	for i := 0; i < 2 && scanner.Scan(); i++ {
+		if line, ok := strings.CutPrefix(scanner.Text(), hashHeaderPrefix); ok {
+			return line == fingerprint, nil
+		}
+	}
+	return false, scanner.Err()
+}
+
+// exists reports whether there is a file at path.
+func exists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
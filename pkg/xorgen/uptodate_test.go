@@ -0,0 +1,80 @@
+package xorgen
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestGenerateFile_UpToDate(t *testing.T) {
+	dir := t.TempDir()
+	input := filepath.Join(dir, "secret.txt")
+	target := filepath.Join(dir, "secret_txt.go")
+	require.NoError(t, os.WriteFile(input, []byte(testMessage), 0600))
+
+	generate := func(opts ...ParamOpt) string {
+		t.Helper()
+		require.NoError(t, GenerateFile(input, append([]ParamOpt{OutputDir(dir), PackageName("assets")}, opts...)...))
+		src, err := os.ReadFile(target)
+		require.NoError(t, err)
+		return string(src)
+	}
+
+	first := generate()
+	assert.Contains(t, first, "\n"+hashHeaderPrefix)
+	assert.Equal(t, first, generate(), "An unchanged input should not be regenerated")
+	assert.Equal(t, first, generate(RandomKey()), "Default options should not cause regeneration")
+	assert.NotEqual(t, first, generate(ForceRegenerate()), "A forced generation should create new keys")
+
+	forced := generate()
+	compressed := generate(CompressData())
+	assert.NotEqual(t, forced, compressed, "Changed options should cause regeneration")
+
+	require.NoError(t, os.WriteFile(input, []byte(testMessage+"!"), 0600))
+	assert.NotEqual(t, compressed, generate(CompressData()), "A changed input should cause regeneration")
+
+	key := []byte{0x01, 0x02, 0x03}
+	fixed := generate(UseKeyOffset(key, 1))
+	assert.Equal(t, fixed, generate(UseKeyOffset(key, 1)))
+	assert.NotEqual(t, fixed, generate(UseKeyOffset(key, 2)), "A changed offset should cause regeneration")
+	random := generate(UseKeyRandomOffset(key))
+	assert.Equal(t, random, generate(UseKeyRandomOffset(key)), "A random offset should not cause regeneration")
+
+	withTest := generate(WithTest())
+	testFile := filepath.Join(dir, "secret_txt_test.go")
+	require.NoError(t, os.Remove(testFile))
+	assert.NotEqual(t, withTest, generate(WithTest()), "A missing companion test should cause regeneration")
+	assert.FileExists(t, testFile)
+}
+
+func TestGenerateDir_UpToDate(t *testing.T) {
+	out := t.TempDir()
+	target := filepath.Join(out, "assets_fs.go")
+	generate := func(opts ...ParamOpt) string {
+		t.Helper()
+		require.NoError(t, GenerateDir("testdata/assets", append([]ParamOpt{OutputDir(out), PackageName("assets")}, opts...)...))
+		src, err := os.ReadFile(target)
+		require.NoError(t, err)
+		return string(src)
+	}
+
+	first := generate()
+	assert.Equal(t, first, generate(), "An unchanged directory should not be regenerated")
+	assert.NotEqual(t, first, generate(ForceRegenerate()))
+	assert.NotEqual(t, first, generate(CompressData()))
+}
+
+func TestUpToDate(t *testing.T) {
+	dir := t.TempDir()
+	current, err := upToDate(filepath.Join(dir, "missing.go"), "abc")
+	assert.NoError(t, err)
+	assert.False(t, current)
+
+	old := filepath.Join(dir, "old.go")
+	require.NoError(t, os.WriteFile(old, []byte("// Code generated by xorgen, DO NOT EDIT.\npackage assets\n"), 0600))
+	current, err = upToDate(old, "abc")
+	assert.NoError(t, err)
+	assert.False(t, current, "Files without a recorded fingerprint should be regenerated")
+}
@@ -0,0 +1,160 @@
+package xorgen
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+var (
+	ErrNotGenerated = errors.New("not a file generated by xorgen")
+	ErrUnstamped    = errors.New("generated file has no checksum, regenerate it with --force to add one")
+	ErrModified     = errors.New("generated file has been modified since it was generated")
+	ErrStale        = errors.New("generated file is stale, its source has changed since it was generated")
+)
+
+// Stamp is the information recorded in the header of a generated file.
+type Stamp struct {
+	// Fingerprint is the hash of the input and options used to generate the file.
+	Fingerprint string
+	// Version is the version of xorgen that generated the file, which is empty if it wasn't known.
+	Version string
+	// Source is the path of the input file or directory relative to the generated file.
+	// It's empty if the input was read from an io.Reader, or if the file is encrypted or uses a key file, since the digest would reveal the input.
+	Source string
+	// SourceDigest is the digest of the source when the file was generated.
+	SourceDigest string
+	// Sum is the checksum of the generated file, without the line that records it.
+	Sum string
+}
+
+// Verification is the result of verifying a generated file with VerifyFile.
+type Verification struct {
+	Stamp
+	// SourceChecked is true if the source was found and matched its digest.
+	SourceChecked bool
+}
+
+// VerifyFile checks that a file generated by xorgen hasn't been edited since it was generated, and that its source hasn't changed if it can be found.
+// A source that can't be found isn't an error, since sources of secrets are often kept out of version control, so SourceChecked will be false.
+// ErrNotGenerated is returned for Go files that weren't generated by xorgen, including companion tests.
+// Otherwise, an error wrapping ErrUnstamped, ErrModified, or ErrStale is returned if the file fails verification.
+func VerifyFile(path string) (Verification, error) {
+	src, err := os.ReadFile(path)
+	if err != nil {
+		return Verification{}, err
+	}
+	stamp, rest, err := readStamp(src)
+	if err != nil {
+		return Verification{}, err
+	}
+	result := Verification{Stamp: stamp}
+	if len(stamp.Sum) == 0 {
+		return result, ErrUnstamped
+	}
+	sum, err := contentSum(rest)
+	if err != nil {
+		return result, fmt.Errorf("%w: %v", ErrModified, err)
+	}
+	if sum != stamp.Sum {
+		return result, ErrModified
+	}
+	if len(stamp.Source) == 0 {
+		return result, nil
+	}
+	source := filepath.Join(filepath.Dir(path), filepath.FromSlash(stamp.Source))
+	info, err := os.Stat(source)
+	if errors.Is(err, fs.ErrNotExist) {
+		return result, nil
+	}
+	if err != nil {
+		return result, err
+	}
+	var digest string
+	if info.IsDir() {
+		digest, err = dirDigest(source)
+	} else {
+		var data []byte
+		data, err = os.ReadFile(source)
+		digest = fileDigest(data)
+	}
+	if err != nil {
+		return result, err
+	}
+	result.SourceChecked = true
+	if digest != stamp.SourceDigest {
+		return result, fmt.Errorf("%w: '%s'", ErrStale, stamp.Source)
+	}
+	return result, nil
+}
+
+// readStamp parses the header of a generated file, and returns the source without the sum line so its checksum can be computed.
+func readStamp(src []byte) (Stamp, []byte, error) {
+	var (
+		stamp Stamp
+		rest  bytes.Buffer
+	)
+	scanner := bufio.NewScanner(bytes.NewReader(src))
+	scanner.Buffer(nil, len(src)+1)
+	header := true
+	for i := 0; scanner.Scan(); i++ {
+		line := scanner.Text()
+		if i == 0 && line != "// Code generated by xorgen, DO NOT EDIT." {
+			return Stamp{}, nil, ErrNotGenerated
+		}
+		if header && strings.HasPrefix(line, "// ") {
+			if val, ok := strings.CutPrefix(line, sumHeaderPrefix); ok {
+				stamp.Sum = val
+				continue
+			}
+			if val, ok := strings.CutPrefix(line, hashHeaderPrefix); ok {
+				stamp.Fingerprint = val
+			} else if val, ok := strings.CutPrefix(line, versionHeaderPrefix); ok {
+				stamp.Version = val
+			} else if val, ok := strings.CutPrefix(line, sourceHeaderPrefix); ok {
+				digest, quoted, _ := strings.Cut(val, " ")
+				source, err := strconv.Unquote(quoted)
+				if err != nil {
+					return Stamp{}, nil, fmt.Errorf("invalid source header '%s': %w", line, err)
+				}
+				stamp.Source, stamp.SourceDigest = source, digest
+			}
+		} else {
+			header = false
+		}
+		rest.WriteString(line)
+		rest.WriteByte('\n')
+	}
+	if err := scanner.Err(); err != nil {
+		return Stamp{}, nil, err
+	}
+	if len(stamp.Fingerprint) == 0 {
+		return Stamp{}, nil, ErrNotGenerated
+	}
+	return stamp, rest.Bytes(), nil
+}
+
+// dirDigest returns the digest of the files in a source directory that would be embedded by GenerateDir.
+func dirDigest(dir string) (string, error) {
+	h := sha256.New()
+	err := walkDir(dir, func(path, rel string, _ fs.DirEntry) error {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		writeDirDigest(h, rel, data)
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
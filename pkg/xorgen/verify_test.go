@@ -0,0 +1,115 @@
+package xorgen
+
+import (
+	"bytes"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go/format"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestVerifyFile(t *testing.T) {
+	dir := t.TempDir()
+	input := filepath.Join(dir, "secret.txt")
+	require.NoError(t, os.WriteFile(input, []byte(testMessage), 0600))
+	require.NoError(t, GenerateFile(input, OutputDir(dir), PackageName("assets"), GeneratorVersion("v1.2.3")))
+	target := filepath.Join(dir, "secret_txt.go")
+
+	result, err := VerifyFile(target)
+	require.NoError(t, err)
+	assert.Equal(t, "v1.2.3", result.Version)
+	assert.Equal(t, "secret.txt", result.Source)
+	assert.NotEmpty(t, result.Fingerprint)
+	assert.True(t, result.SourceChecked)
+
+	src, err := os.ReadFile(target)
+	require.NoError(t, err)
+	formatted, err := format.Source(src)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(target, formatted, 0600))
+	_, err = VerifyFile(target)
+	assert.NoError(t, err, "Formatting a generated file isn't an edit")
+
+	edited := bytes.Replace(formatted, []byte("func unscreen"), []byte("func Unscreen"), 1)
+	require.NoError(t, os.WriteFile(target, edited, 0600))
+	_, err = VerifyFile(target)
+	assert.ErrorIs(t, err, ErrModified)
+
+	var unstamped []string
+	for _, line := range strings.Split(string(formatted), "\n") {
+		if !strings.HasPrefix(line, sumHeaderPrefix) {
+			unstamped = append(unstamped, line)
+		}
+	}
+	require.NoError(t, os.WriteFile(target, []byte(strings.Join(unstamped, "\n")), 0600))
+	_, err = VerifyFile(target)
+	assert.ErrorIs(t, err, ErrUnstamped)
+
+	require.NoError(t, os.WriteFile(target, formatted, 0600))
+	require.NoError(t, os.WriteFile(input, []byte("changed"), 0600))
+	_, err = VerifyFile(target)
+	assert.ErrorIs(t, err, ErrStale)
+
+	require.NoError(t, os.Remove(input))
+	result, err = VerifyFile(target)
+	assert.NoError(t, err, "A missing source isn't an error")
+	assert.False(t, result.SourceChecked)
+}
+
+func TestVerifyFile_NoInputDigest(t *testing.T) {
+	dir := t.TempDir()
+	input := filepath.Join(dir, "secret.txt")
+	require.NoError(t, os.WriteFile(input, []byte(testMessage), 0600))
+	tests := map[string]struct {
+		target string
+		opts   []ParamOpt
+	}{
+		"Encrypted": {target: "secret_txt.go", opts: []ParamOpt{Encrypt(testPass("pass"))}},
+		"Key file":  {target: "secret_txt.go", opts: []ParamOpt{KeyFile(filepath.Join(dir, "secret.xkey"))}},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			require.NoError(t, GenerateFile(input, append([]ParamOpt{OutputDir(dir), PackageName("assets"), ForceRegenerate()}, tc.opts...)...))
+			src, err := os.ReadFile(filepath.Join(dir, tc.target))
+			require.NoError(t, err)
+			assert.NotContains(t, string(src), sourceHeaderPrefix)
+			assert.NotContains(t, string(src), fileDigest([]byte(testMessage)), "No hash of the input should be recorded")
+		})
+	}
+}
+
+func TestVerifyFile_Dir(t *testing.T) {
+	dir := t.TempDir()
+	assets := filepath.Join(dir, "assets")
+	require.NoError(t, os.MkdirAll(filepath.Join(assets, "sub"), 0700))
+	require.NoError(t, os.WriteFile(filepath.Join(assets, "a.txt"), []byte("a"), 0600))
+	require.NoError(t, os.WriteFile(filepath.Join(assets, "sub", "b.txt"), []byte("b"), 0600))
+	out := filepath.Join(dir, "internal")
+	require.NoError(t, os.Mkdir(out, 0700))
+	require.NoError(t, GenerateDir(assets, OutputDir(out)))
+	target := filepath.Join(out, "assets_fs.go")
+
+	result, err := VerifyFile(target)
+	require.NoError(t, err)
+	assert.Equal(t, "../assets", result.Source)
+	assert.True(t, result.SourceChecked)
+
+	require.NoError(t, os.WriteFile(filepath.Join(assets, "_ignored.txt"), []byte("ignored"), 0600))
+	_, err = VerifyFile(target)
+	assert.NoError(t, err, "Files that aren't embedded shouldn't affect the digest")
+
+	require.NoError(t, os.WriteFile(filepath.Join(assets, "sub", "c.txt"), []byte("c"), 0600))
+	_, err = VerifyFile(target)
+	assert.ErrorIs(t, err, ErrStale)
+}
+
+func TestGenerate_Stamp(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, Generate("secret.bin", strings.NewReader(testMessage), &buf, PackageName("xorgen")))
+	assert.Contains(t, buf.String(), sumHeaderPrefix)
+	assert.NotContains(t, buf.String(), sourceHeaderPrefix, "There's no source path when reading from an io.Reader")
+	assert.NotContains(t, buf.String(), versionHeaderPrefix, "The version should only be stamped if it's known")
+}